@@ -2,11 +2,11 @@ package analytics
 
 import (
 	"concert-manager/domain"
-	"concert-manager/util"
 	"fmt"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Count struct {
@@ -22,6 +22,13 @@ type Summary struct {
 	TopArtists  []Count `json:"topArtists"`
 	TopVenues   []Count `json:"topVenues"`
 	TopGenres   []Count `json:"topGenres"`
+
+	AverageRating   float64  `json:"averageRating"`
+	TopRatedVenues  []Rating `json:"topRatedVenues"`
+	TopRatedArtists []Rating `json:"topRatedArtists"`
+	TopCompanions   []Count  `json:"topCompanions"`
+
+	TotalSpentCents int `json:"totalSpentCents"`
 }
 
 type EventsResponse struct {
@@ -36,11 +43,12 @@ var monthNames = []string{
 	"July", "August", "September", "October", "November", "December",
 }
 
-// PastEvents returns events whose date is before today.
+// PastEvents returns events that are over in the time zone of their venue.
 func PastEvents(events []domain.Event) []domain.Event {
+	now := time.Now()
 	out := []domain.Event{}
 	for _, e := range events {
-		if util.PastDate(e.Date) {
+		if e.Passed(now) {
 			out = append(out, e)
 		}
 	}
@@ -61,20 +69,9 @@ func analyticsGenres(a domain.Artist) []string {
 	return a.Genres.LastFm
 }
 
-func parseDate(date string) (year, month int, ok bool) {
-	parts := strings.Split(date, "/")
-	if len(parts) != 3 {
-		return 0, 0, false
-	}
-	m, err := strconv.Atoi(parts[0])
-	if err != nil {
-		return 0, 0, false
-	}
-	y, err := strconv.Atoi(parts[2])
-	if err != nil {
-		return 0, 0, false
-	}
-	return y, m, true
+// dateParts is the year and month of the date, ok is false when the date isn't set
+func dateParts(date domain.Date) (year, month int, ok bool) {
+	return date.Year, int(date.Month), !date.IsZero()
 }
 
 type aggregator struct {
@@ -125,10 +122,17 @@ func top(counts []Count, n int) []Count {
 	return counts[:n]
 }
 
+func topRatings(ratings []Rating, n int) []Rating {
+	if len(ratings) <= n {
+		return ratings
+	}
+	return ratings[:n]
+}
+
 func CountByYear(events []domain.Event) []Count {
 	agg := newAggregator()
 	for _, e := range events {
-		y, _, ok := parseDate(e.Date)
+		y, _, ok := dateParts(e.Date)
 		if !ok {
 			continue
 		}
@@ -141,7 +145,7 @@ func CountByYear(events []domain.Event) []Count {
 func CountByMonth(events []domain.Event) []Count {
 	agg := newAggregator()
 	for _, e := range events {
-		y, m, ok := parseDate(e.Date)
+		y, m, ok := dateParts(e.Date)
 		if !ok || m < 1 || m > 12 {
 			continue
 		}
@@ -152,10 +156,12 @@ func CountByMonth(events []domain.Event) []Count {
 	return agg.toCounts()
 }
 
+// CountByArtist groups past events by the artists seen at them. At a festival only the
+// artists of the sets we saw are counted, and each of them once for the whole festival.
 func CountByArtist(events []domain.Event) []Count {
 	agg := newAggregator()
 	for _, e := range events {
-		for _, a := range e.Artists() {
+		for _, a := range e.SeenArtists() {
 			if a.ID.Primary == "" {
 				continue
 			}
@@ -177,12 +183,12 @@ func CountByVenue(events []domain.Event) []Count {
 }
 
 // CountByGenre groups past events by genre. Each genre is counted at most
-// once per event, even if multiple artists in the lineup share it.
+// once per event, even if multiple artists seen at it share it.
 func CountByGenre(events []domain.Event) []Count {
 	agg := newAggregator()
 	for _, e := range events {
 		seen := map[string]bool{}
-		for _, artist := range e.Artists() {
+		for _, artist := range e.SeenArtists() {
 			for _, genre := range analyticsGenres(artist) {
 				key := strings.ToLower(strings.TrimSpace(genre))
 				if key == "" || seen[key] {
@@ -204,13 +210,20 @@ func BuildSummary(events []domain.Event) Summary {
 		TopArtists:  top(CountByArtist(events), TopN),
 		TopVenues:   top(CountByVenue(events), TopN),
 		TopGenres:   top(CountByGenre(events), TopN),
+
+		AverageRating:   AverageRating(events),
+		TopRatedVenues:  topRatings(RatingByVenue(events), TopN),
+		TopRatedArtists: topRatings(RatingByArtist(events), TopN),
+		TopCompanions:   top(CountByCompanion(events), TopN),
+
+		TotalSpentCents: BuildSpendingSummary(events).TotalCents,
 	}
 }
 
 func FilterEventsByYear(events []domain.Event, yearKey string) []domain.Event {
 	out := []domain.Event{}
 	for _, e := range events {
-		y, _, ok := parseDate(e.Date)
+		y, _, ok := dateParts(e.Date)
 		if !ok {
 			continue
 		}
@@ -236,7 +249,7 @@ func FilterEventsByMonth(events []domain.Event, monthKey string) []domain.Event
 	}
 	out := []domain.Event{}
 	for _, e := range events {
-		ey, em, ok := parseDate(e.Date)
+		ey, em, ok := dateParts(e.Date)
 		if !ok {
 			continue
 		}
@@ -250,7 +263,7 @@ func FilterEventsByMonth(events []domain.Event, monthKey string) []domain.Event
 func FilterEventsByArtist(events []domain.Event, artistID string) []domain.Event {
 	out := []domain.Event{}
 	for _, e := range events {
-		for _, a := range e.Artists() {
+		for _, a := range e.SeenArtists() {
 			if a.ID.Primary == artistID {
 				out = append(out, e)
 				break
@@ -277,7 +290,7 @@ func FilterEventsByGenre(events []domain.Event, genreKey string) []domain.Event
 	out := []domain.Event{}
 	for _, e := range events {
 		matched := false
-		for _, artist := range e.Artists() {
+		for _, artist := range e.SeenArtists() {
 			for _, genre := range analyticsGenres(artist) {
 				if strings.ToLower(strings.TrimSpace(genre)) == key {
 					matched = true
@@ -28,7 +28,7 @@ func event(date string, v domain.Venue, main domain.Artist, openers ...domain.Ar
 		MainAct: &m,
 		Openers: openers,
 		Venue:   v,
-		Date:    date,
+		Date:    domain.MustParseDate(date),
 		ID:      domain.ID{Primary: date + "|" + v.Name + "|" + main.Name},
 	}
 }
@@ -101,6 +101,37 @@ func TestCountByArtistIncludesOpeners(t *testing.T) {
 	}
 }
 
+func TestCountByArtistOnlyCountsSeenFestivalSets(t *testing.T) {
+	v := venue("v1", "Festival Grounds")
+	headliner := artist("h1", "Headliner", nil, nil, nil)
+	seen := artist("s1", "Seen", nil, nil, nil)
+	missed := artist("m1", "Missed", nil, nil, nil)
+	festival := event("7/12/2024", v, headliner, seen, missed)
+	festival.Festival = &domain.Festival{
+		Name:    "Fest",
+		EndDate: domain.MustParseDate("7/14/2024"),
+		Days: []domain.FestivalDay{
+			{Date: domain.MustParseDate("7/12/2024"), Sets: []domain.Set{{ArtistID: "h1", Seen: true}, {ArtistID: "m1"}}},
+			{Date: domain.MustParseDate("7/13/2024"), Sets: []domain.Set{{ArtistID: "s1", Seen: true}}},
+			{Date: domain.MustParseDate("7/14/2024"), Sets: []domain.Set{{ArtistID: "s1", Seen: true}}},
+		},
+	}
+	// a festival without any sets recorded counts the whole lineup
+	noSets := event("8/1/2024", v, headliner, missed)
+	noSets.Festival = &domain.Festival{Name: "Other Fest", EndDate: domain.MustParseDate("8/2/2024")}
+
+	got := CountByArtist([]domain.Event{festival, noSets})
+	want := map[string]int{"h1": 2, "s1": 1, "m1": 1}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d artist buckets, got %+v", len(want), got)
+	}
+	for _, c := range got {
+		if want[c.Key] != c.Count {
+			t.Errorf("expected %s to be counted %d times, got %d", c.Key, want[c.Key], c.Count)
+		}
+	}
+}
+
 func TestCountByVenue(t *testing.T) {
 	a := artist("a1", "Artist 1", nil, nil, nil)
 	v1 := venue("v1", "Bravo")
@@ -262,3 +293,145 @@ func TestFilterEventsByMonth(t *testing.T) {
 		t.Errorf("expected 0 for malformed key, got %d", len(got))
 	}
 }
+
+func rated(e domain.Event, rating int, performances ...domain.Performance) domain.Event {
+	e.Rating = rating
+	e.Performances = performances
+	return e
+}
+
+func TestRatingByVenue(t *testing.T) {
+	v1 := venue("v1", "Venue 1")
+	v2 := venue("v2", "Venue 2")
+	a := artist("a1", "Artist 1", nil, nil, nil)
+	events := []domain.Event{
+		rated(event("1/1/2024", v1, a), 3),
+		rated(event("2/1/2024", v1, a), 4),
+		rated(event("3/1/2024", v2, a), 5),
+		rated(event("4/1/2024", v2, a), 0),
+	}
+	got := RatingByVenue(events)
+	if !reflect.DeepEqual(got, []Rating{
+		{Key: "v2", Name: "Venue 2", Average: 5, Count: 1},
+		{Key: "v1", Name: "Venue 1", Average: 3.5, Count: 2},
+	}) {
+		t.Errorf("unexpected venue ratings %+v", got)
+	}
+	if avg := AverageRating(events); avg != 4 {
+		t.Errorf("expected unrated events to be ignored in the average, got %v", avg)
+	}
+}
+
+func TestRatingByArtistUsesPerformances(t *testing.T) {
+	v := venue("v1", "Venue 1")
+	main := artist("a1", "Main", nil, nil, nil)
+	opener := artist("a2", "Opener", nil, nil, nil)
+	events := []domain.Event{
+		rated(event("1/1/2024", v, main, opener), 5, domain.Performance{ArtistID: "a1", Rating: 4}),
+		rated(event("2/1/2024", v, opener), 0, domain.Performance{ArtistID: "a2", Rating: 5}),
+	}
+	got := RatingByArtist(events)
+	if !reflect.DeepEqual(got, []Rating{
+		{Key: "a2", Name: "Opener", Average: 5, Count: 1},
+		{Key: "a1", Name: "Main", Average: 4, Count: 1},
+	}) {
+		t.Errorf("unexpected artist ratings %+v", got)
+	}
+}
+
+func TestCountByCompanionIgnoresCase(t *testing.T) {
+	v := venue("v1", "Venue 1")
+	a := artist("a1", "Artist 1", nil, nil, nil)
+	first := event("1/1/2024", v, a)
+	first.Companions = []string{"Sam", "sam ", "Jo"}
+	second := event("2/1/2024", v, a)
+	second.Companions = []string{"SAM"}
+	events := []domain.Event{first, second}
+
+	got := CountByCompanion(events)
+	if len(got) != 2 || got[0].Key != "sam" || got[0].Count != 2 || got[1].Key != "jo" {
+		t.Errorf("unexpected companion counts %+v", got)
+	}
+	if filtered := FilterEventsByCompanion(events, "Sam"); len(filtered) != 2 {
+		t.Errorf("expected both events with Sam, got %d", len(filtered))
+	}
+}
+
+func paid(e domain.Event, priceCents, quantity, feesCents int) domain.Event {
+	e.Ticket = domain.Ticket{PriceCents: priceCents, Quantity: quantity, FeesCents: feesCents}
+	return e
+}
+
+func TestBuildSpendingSummary(t *testing.T) {
+	v1 := venue("v1", "Venue 1")
+	v2 := venue("v2", "Venue 2")
+	rock := artist("a1", "Rock Band", []string{"rock"}, nil, nil)
+	punk := artist("a2", "Punk Band", []string{"punk", "rock"}, nil, nil)
+	events := []domain.Event{
+		paid(event("1/1/2024", v1, rock, punk), 5000, 2, 1000),
+		paid(event("2/1/2024", v2, punk), 2500, 1, 0),
+		event("3/1/2025", v2, rock),
+	}
+
+	got := BuildSpendingSummary(events)
+	if got.TotalCents != 13500 || got.Events != 2 || got.Tickets != 3 {
+		t.Errorf("unexpected totals %+v", got)
+	}
+	if got.AveragePerEventCents != 6750 || got.AveragePerTicketCents != 4500 {
+		t.Errorf("unexpected averages %+v", got)
+	}
+	if got.ArtistsSeen != 3 || got.CostPerArtistCents != 4500 {
+		t.Errorf("unexpected cost per artist %+v", got)
+	}
+	if len(got.ByYear) != 1 || got.ByYear[0].Key != "2024" || got.ByYear[0].TotalCents != 13500 {
+		t.Errorf("expected unpaid events to be left out of the years, got %+v", got.ByYear)
+	}
+	if !reflect.DeepEqual(keysOf(got.ByVenue), []string{"v1", "v2"}) || got.ByVenue[0].AverageCents != 11000 {
+		t.Errorf("unexpected venue spending %+v", got.ByVenue)
+	}
+	// every event counts in full towards each genre in its lineup
+	if !reflect.DeepEqual(keysOf(got.ByGenre), []string{"punk", "rock"}) || got.ByGenre[1].TotalCents != 13500 {
+		t.Errorf("unexpected genre spending %+v", got.ByGenre)
+	}
+	if !reflect.DeepEqual(keysOf(got.ByArtist), []string{"a2", "a1"}) || got.ByArtist[0].TotalCents != 8000 {
+		t.Errorf("unexpected artist spending %+v", got.ByArtist)
+	}
+}
+
+func keysOf(spending []Spending) []string {
+	out := make([]string, len(spending))
+	for i, s := range spending {
+		out[i] = s.Key
+	}
+	return out
+}
+
+func TestBuildAlbumSummary(t *testing.T) {
+	v := venue("v1", "Venue 1")
+	a1 := artist("a1", "Artist 1", nil, nil, nil)
+	a2 := artist("a2", "Artist 2", nil, nil, nil)
+	first := event("5/1/2024", v, a1, a2)
+	second := event("6/2/2025", v, a1)
+	albums := []domain.Album{
+		{ID: "al1", Artists: []domain.Artist{a1}, Signed: true, EventID: first.ID.Primary},
+		{ID: "al2", Artists: []domain.Artist{a1}, EventID: second.ID.Primary},
+		{ID: "al3", Artists: []domain.Artist{a2}, Signed: true},
+		{ID: "al4", Artists: []domain.Artist{a2}, Wishlisted: true, EventID: first.ID.Primary},
+		{ID: "al5", Artists: []domain.Artist{a1}, Signed: true, EventID: "deleted"},
+	}
+
+	got := BuildAlbumSummary([]domain.Event{first, second}, albums)
+	if got.Albums != 4 || got.AcquiredAtShows != 2 || got.SignedAtShows != 1 {
+		t.Errorf("unexpected totals %+v", got)
+	}
+	if !reflect.DeepEqual(keys(got.ByYear), []string{"2024", "2025"}) || got.ByVenue[0].Count != 2 {
+		t.Errorf("unexpected album counts %+v %+v", got.ByYear, got.ByVenue)
+	}
+	want := []SignedAlbums{
+		{Key: "a1", Name: "Artist 1", Seen: 2, Signed: 2, SignedAtShows: 1, PerShow: 1},
+		{Key: "a2", Name: "Artist 2", Seen: 1, Signed: 1, PerShow: 1},
+	}
+	if !reflect.DeepEqual(got.SignedByArtist, want) {
+		t.Errorf("expected signed albums %+v, got %+v", want, got.SignedByArtist)
+	}
+}
@@ -0,0 +1,192 @@
+package analytics
+
+import (
+	"concert-manager/domain"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// SignedAlbums compares how many signed albums we have by an artist to how many times
+// we've seen them, signed albums bought elsewhere still count towards the artist
+type SignedAlbums struct {
+	Key           string  `json:"key"`
+	Name          string  `json:"name"`
+	Seen          int     `json:"seen"`
+	Signed        int     `json:"signed"`
+	SignedAtShows int     `json:"signedAtShows"`
+	PerShow       float64 `json:"perShow"`
+}
+
+// AlbumSummary covers the owned albums, wishlisted albums are left out. Only albums linked
+// to one of the events count as acquired at a show.
+type AlbumSummary struct {
+	Albums          int            `json:"albums"`
+	AcquiredAtShows int            `json:"acquiredAtShows"`
+	SignedAtShows   int            `json:"signedAtShows"`
+	ByEvent         []Count        `json:"byEvent"`
+	ByVenue         []Count        `json:"byVenue"`
+	ByYear          []Count        `json:"byYear"`
+	SignedByArtist  []SignedAlbums `json:"signedByArtist"`
+}
+
+type AlbumsResponse struct {
+	Count  int            `json:"count"`
+	Albums []domain.Album `json:"albums"`
+}
+
+func ownedAlbums(albums []domain.Album) []domain.Album {
+	out := []domain.Album{}
+	for _, album := range albums {
+		if !album.Wishlisted {
+			out = append(out, album)
+		}
+	}
+	return out
+}
+
+func eventsByID(events []domain.Event) map[string]domain.Event {
+	byID := map[string]domain.Event{}
+	for _, e := range events {
+		byID[e.ID.Primary] = e
+	}
+	return byID
+}
+
+// AlbumsAcquiredAtShows returns the owned albums linked to one of the events
+func AlbumsAcquiredAtShows(events []domain.Event, albums []domain.Album) []domain.Album {
+	byID := eventsByID(events)
+	out := []domain.Album{}
+	for _, album := range ownedAlbums(albums) {
+		if _, ok := byID[album.EventID]; ok && album.EventID != "" {
+			out = append(out, album)
+		}
+	}
+	return out
+}
+
+func eventName(e domain.Event) string {
+	name := e.Venue.Name
+	if e.IsFestival() && e.Festival.Name != "" {
+		name = e.Festival.Name
+	} else if e.MainAct != nil && e.MainAct.Name != "" {
+		name = fmt.Sprintf("%s at %s", e.MainAct.Name, e.Venue.Name)
+	}
+	return fmt.Sprintf("%s, %s", name, e.Date)
+}
+
+func AlbumsByEvent(events []domain.Event, albums []domain.Album) []Count {
+	byID := eventsByID(events)
+	agg := newAggregator()
+	for _, album := range AlbumsAcquiredAtShows(events, albums) {
+		agg.add(album.EventID, eventName(byID[album.EventID]))
+	}
+	return agg.toCounts()
+}
+
+func AlbumsByVenue(events []domain.Event, albums []domain.Album) []Count {
+	byID := eventsByID(events)
+	agg := newAggregator()
+	for _, album := range AlbumsAcquiredAtShows(events, albums) {
+		venue := byID[album.EventID].Venue
+		if venue.ID.Primary == "" {
+			continue
+		}
+		agg.add(venue.ID.Primary, venue.Name)
+	}
+	return agg.toCounts()
+}
+
+func AlbumsByYear(events []domain.Event, albums []domain.Album) []Count {
+	byID := eventsByID(events)
+	agg := newAggregator()
+	for _, album := range AlbumsAcquiredAtShows(events, albums) {
+		y, _, ok := dateParts(byID[album.EventID].Date)
+		if !ok {
+			continue
+		}
+		key := strconv.Itoa(y)
+		agg.add(key, key)
+	}
+	return agg.toCounts()
+}
+
+// SignedAlbumsByArtist covers every artist seen at the events, sorted by signed albums.
+// An album with several artists counts as signed by each of them.
+func SignedAlbumsByArtist(events []domain.Event, albums []domain.Album) []SignedAlbums {
+	byArtist := map[string]*SignedAlbums{}
+	for _, e := range events {
+		for _, a := range lineup(e) {
+			signed, ok := byArtist[a.ID.Primary]
+			if !ok {
+				signed = &SignedAlbums{Key: a.ID.Primary, Name: a.Name}
+				byArtist[a.ID.Primary] = signed
+			}
+			signed.Seen++
+		}
+	}
+
+	byID := eventsByID(events)
+	for _, album := range ownedAlbums(albums) {
+		if !album.Signed {
+			continue
+		}
+		_, atShow := byID[album.EventID]
+		atShow = atShow && album.EventID != ""
+		for _, a := range album.Artists {
+			signed, ok := byArtist[a.ID.Primary]
+			if !ok {
+				continue
+			}
+			signed.Signed++
+			if atShow {
+				signed.SignedAtShows++
+			}
+		}
+	}
+
+	out := make([]SignedAlbums, 0, len(byArtist))
+	for _, signed := range byArtist {
+		signed.PerShow = math.Round(float64(signed.Signed)/float64(signed.Seen)*100) / 100
+		out = append(out, *signed)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Signed != out[j].Signed {
+			return out[i].Signed > out[j].Signed
+		}
+		if out[i].Seen != out[j].Seen {
+			return out[i].Seen > out[j].Seen
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+func BuildAlbumSummary(events []domain.Event, albums []domain.Album) AlbumSummary {
+	summary := AlbumSummary{
+		Albums:         len(ownedAlbums(albums)),
+		ByEvent:        AlbumsByEvent(events, albums),
+		ByVenue:        AlbumsByVenue(events, albums),
+		ByYear:         AlbumsByYear(events, albums),
+		SignedByArtist: SignedAlbumsByArtist(events, albums),
+	}
+	for _, album := range AlbumsAcquiredAtShows(events, albums) {
+		summary.AcquiredAtShows++
+		if album.Signed {
+			summary.SignedAtShows++
+		}
+	}
+	return summary
+}
+
+// FilterAlbumsByEvent returns the owned albums acquired at the event
+func FilterAlbumsByEvent(albums []domain.Album, eventID string) []domain.Album {
+	out := []domain.Album{}
+	for _, album := range ownedAlbums(albums) {
+		if album.EventID == eventID {
+			out = append(out, album)
+		}
+	}
+	return out
+}
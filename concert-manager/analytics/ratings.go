@@ -0,0 +1,177 @@
+package analytics
+
+import (
+	"concert-manager/domain"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Rating is the average star rating of the rated events or performances for a key.
+// Unrated events and performances are left out of both the average and the count.
+type Rating struct {
+	Key     string  `json:"key"`
+	Name    string  `json:"name"`
+	Average float64 `json:"average"`
+	Count   int     `json:"count"`
+}
+
+type ratingAggregator struct {
+	totals       map[string]int
+	counts       map[string]int
+	displayNames map[string]string
+}
+
+func newRatingAggregator() *ratingAggregator {
+	return &ratingAggregator{
+		totals:       map[string]int{},
+		counts:       map[string]int{},
+		displayNames: map[string]string{},
+	}
+}
+
+func (a *ratingAggregator) add(key, name string, rating int) {
+	if rating <= 0 {
+		return
+	}
+	a.totals[key] += rating
+	a.counts[key]++
+	if _, ok := a.displayNames[key]; !ok {
+		a.displayNames[key] = name
+	}
+}
+
+func (a *ratingAggregator) toRatings() []Rating {
+	ratings := make([]Rating, 0, len(a.counts))
+	for key, count := range a.counts {
+		ratings = append(ratings, Rating{
+			Key:     key,
+			Name:    a.displayNames[key],
+			Average: roundRating(float64(a.totals[key]) / float64(count)),
+			Count:   count,
+		})
+	}
+	sortRatings(ratings)
+	return ratings
+}
+
+// highest average first, then the most rated, then by name
+func sortRatings(ratings []Rating) {
+	sort.SliceStable(ratings, func(i, j int) bool {
+		if ratings[i].Average != ratings[j].Average {
+			return ratings[i].Average > ratings[j].Average
+		}
+		if ratings[i].Count != ratings[j].Count {
+			return ratings[i].Count > ratings[j].Count
+		}
+		return ratings[i].Name < ratings[j].Name
+	})
+}
+
+func roundRating(rating float64) float64 {
+	return math.Round(rating*100) / 100
+}
+
+// AverageRating is the average rating of the rated events, 0 if none are rated
+func AverageRating(events []domain.Event) float64 {
+	total, count := 0, 0
+	for _, e := range events {
+		if e.Rating > 0 {
+			total += e.Rating
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return roundRating(float64(total) / float64(count))
+}
+
+// RatingByVenue averages the event ratings at each venue
+func RatingByVenue(events []domain.Event) []Rating {
+	agg := newRatingAggregator()
+	for _, e := range events {
+		if e.Venue.ID.Primary == "" {
+			continue
+		}
+		agg.add(e.Venue.ID.Primary, e.Venue.Name, e.Rating)
+	}
+	return agg.toRatings()
+}
+
+// RatingByArtist averages the performance ratings of each artist
+func RatingByArtist(events []domain.Event) []Rating {
+	agg := newRatingAggregator()
+	for _, e := range events {
+		for _, a := range e.Artists() {
+			if a.ID.Primary == "" {
+				continue
+			}
+			if performance, ok := e.Performance(a.ID.Primary); ok {
+				agg.add(a.ID.Primary, a.Name, performance.Rating)
+			}
+		}
+	}
+	return agg.toRatings()
+}
+
+// RatingByYear averages the event ratings in each year
+func RatingByYear(events []domain.Event) []Rating {
+	agg := newRatingAggregator()
+	for _, e := range events {
+		y, _, ok := dateParts(e.Date)
+		if !ok {
+			continue
+		}
+		key := strconv.Itoa(y)
+		agg.add(key, key, e.Rating)
+	}
+	return agg.toRatings()
+}
+
+// CountByCompanion groups past events by the people who came along, ignoring case
+func CountByCompanion(events []domain.Event) []Count {
+	agg := newAggregator()
+	for _, e := range events {
+		seen := map[string]bool{}
+		for _, companion := range e.Companions {
+			key := companionKey(companion)
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			agg.add(key, strings.TrimSpace(companion))
+		}
+	}
+	return agg.toCounts()
+}
+
+func FilterEventsByCompanion(events []domain.Event, companion string) []domain.Event {
+	key := companionKey(companion)
+	out := []domain.Event{}
+	for _, e := range events {
+		for _, c := range e.Companions {
+			if companionKey(c) == key {
+				out = append(out, e)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// FilterEventsByMinRating keeps the events rated at least the given number of stars
+func FilterEventsByMinRating(events []domain.Event, minRating int) []domain.Event {
+	out := []domain.Event{}
+	for _, e := range events {
+		if e.Rating > 0 && e.Rating >= minRating {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func companionKey(companion string) string {
+	return strings.ToLower(strings.TrimSpace(companion))
+}
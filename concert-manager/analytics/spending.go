@@ -0,0 +1,189 @@
+package analytics
+
+import (
+	"concert-manager/domain"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Spending totals the ticket costs of the events for a key. Amounts are in cents and
+// events without a ticket cost are left out.
+type Spending struct {
+	Key          string `json:"key"`
+	Name         string `json:"name"`
+	Events       int    `json:"events"`
+	Tickets      int    `json:"tickets"`
+	TotalCents   int    `json:"totalCents"`
+	AverageCents int    `json:"averageCents"`
+}
+
+type SpendingSummary struct {
+	TotalCents            int `json:"totalCents"`
+	Events                int `json:"events"`
+	Tickets               int `json:"tickets"`
+	AveragePerEventCents  int `json:"averagePerEventCents"`
+	AveragePerTicketCents int `json:"averagePerTicketCents"`
+	// every artist in the lineup of an event counts as one artist seen, at festivals only the sets we saw count
+	ArtistsSeen        int        `json:"artistsSeen"`
+	CostPerArtistCents int        `json:"costPerArtistCents"`
+	ByYear             []Spending `json:"byYear"`
+	ByMonth            []Spending `json:"byMonth"`
+	ByVenue            []Spending `json:"byVenue"`
+	ByGenre            []Spending `json:"byGenre"`
+	ByArtist           []Spending `json:"byArtist"`
+}
+
+type spendingAggregator struct {
+	spending map[string]*Spending
+}
+
+func newSpendingAggregator() *spendingAggregator {
+	return &spendingAggregator{spending: map[string]*Spending{}}
+}
+
+func (a *spendingAggregator) add(key, name string, tickets, cents int) {
+	spending, ok := a.spending[key]
+	if !ok {
+		spending = &Spending{Key: key, Name: name}
+		a.spending[key] = spending
+	}
+	spending.Events++
+	spending.Tickets += tickets
+	spending.TotalCents += cents
+}
+
+func (a *spendingAggregator) toSpending() []Spending {
+	out := make([]Spending, 0, len(a.spending))
+	for _, spending := range a.spending {
+		spending.AverageCents = averageCents(spending.TotalCents, spending.Events)
+		out = append(out, *spending)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].TotalCents != out[j].TotalCents {
+			return out[i].TotalCents > out[j].TotalCents
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+func averageCents(totalCents, count int) int {
+	if count == 0 {
+		return 0
+	}
+	return int(math.Round(float64(totalCents) / float64(count)))
+}
+
+// PaidEvents returns the events with a ticket cost recorded
+func PaidEvents(events []domain.Event) []domain.Event {
+	out := []domain.Event{}
+	for _, e := range events {
+		if e.Ticket.Populated() {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func SpendingByYear(events []domain.Event) []Spending {
+	agg := newSpendingAggregator()
+	for _, e := range PaidEvents(events) {
+		y, _, ok := dateParts(e.Date)
+		if !ok {
+			continue
+		}
+		key := strconv.Itoa(y)
+		agg.add(key, key, e.Ticket.Quantity, e.Ticket.TotalCents())
+	}
+	return agg.toSpending()
+}
+
+func SpendingByMonth(events []domain.Event) []Spending {
+	agg := newSpendingAggregator()
+	for _, e := range PaidEvents(events) {
+		y, m, ok := dateParts(e.Date)
+		if !ok || m < 1 || m > 12 {
+			continue
+		}
+		key := fmt.Sprintf("%04d-%02d", y, m)
+		name := fmt.Sprintf("%s %d", monthNames[m-1], y)
+		agg.add(key, name, e.Ticket.Quantity, e.Ticket.TotalCents())
+	}
+	return agg.toSpending()
+}
+
+func SpendingByVenue(events []domain.Event) []Spending {
+	agg := newSpendingAggregator()
+	for _, e := range PaidEvents(events) {
+		if e.Venue.ID.Primary == "" {
+			continue
+		}
+		agg.add(e.Venue.ID.Primary, e.Venue.Name, e.Ticket.Quantity, e.Ticket.TotalCents())
+	}
+	return agg.toSpending()
+}
+
+// SpendingByGenre counts the full cost of an event towards each genre in its lineup,
+// so the genre totals add up to more than the overall total for mixed lineups
+func SpendingByGenre(events []domain.Event) []Spending {
+	agg := newSpendingAggregator()
+	for _, e := range PaidEvents(events) {
+		seen := map[string]bool{}
+		for _, artist := range e.Artists() {
+			for _, genre := range analyticsGenres(artist) {
+				key := strings.ToLower(strings.TrimSpace(genre))
+				if key == "" || seen[key] {
+					continue
+				}
+				seen[key] = true
+				agg.add(key, genre, e.Ticket.Quantity, e.Ticket.TotalCents())
+			}
+		}
+	}
+	return agg.toSpending()
+}
+
+// SpendingByArtist splits the cost of each event evenly between the artists seen at it
+func SpendingByArtist(events []domain.Event) []Spending {
+	agg := newSpendingAggregator()
+	for _, e := range PaidEvents(events) {
+		artists := lineup(e)
+		for _, a := range artists {
+			agg.add(a.ID.Primary, a.Name, e.Ticket.Quantity, averageCents(e.Ticket.TotalCents(), len(artists)))
+		}
+	}
+	return agg.toSpending()
+}
+
+func lineup(e domain.Event) []domain.Artist {
+	artists := []domain.Artist{}
+	for _, a := range e.SeenArtists() {
+		if a.ID.Primary != "" {
+			artists = append(artists, a)
+		}
+	}
+	return artists
+}
+
+func BuildSpendingSummary(events []domain.Event) SpendingSummary {
+	summary := SpendingSummary{
+		ByYear:   SpendingByYear(events),
+		ByMonth:  SpendingByMonth(events),
+		ByVenue:  SpendingByVenue(events),
+		ByGenre:  SpendingByGenre(events),
+		ByArtist: SpendingByArtist(events),
+	}
+	for _, e := range PaidEvents(events) {
+		summary.TotalCents += e.Ticket.TotalCents()
+		summary.Events++
+		summary.Tickets += e.Ticket.Quantity
+		summary.ArtistsSeen += len(lineup(e))
+	}
+	summary.AveragePerEventCents = averageCents(summary.TotalCents, summary.Events)
+	summary.AveragePerTicketCents = averageCents(summary.TotalCents, summary.Tickets)
+	summary.CostPerArtistCents = averageCents(summary.TotalCents, summary.ArtistsSeen)
+	return summary
+}
@@ -0,0 +1,268 @@
+package backup
+
+import (
+	"concert-manager/db"
+	"concert-manager/domain"
+	"concert-manager/log"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Version of the archive format, bump when the archive layout changes
+const Version = "1.0"
+
+// Archive is a portable snapshot of all saved data. Events and albums embed the artists and
+// venues they reference, which are matched back up by primary ID when restoring.
+type Archive struct {
+	Version   string          `json:"version"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Venues    []domain.Venue  `json:"venues"`
+	Artists   []domain.Artist `json:"artists"`
+	Events    []domain.Event  `json:"events"`
+	Albums    []domain.Album  `json:"albums"`
+}
+
+type Mode string
+
+const (
+	// ReplaceMode restores the archive and then deletes the existing data it doesn't contain
+	ReplaceMode Mode = "replace"
+	// MergeMode keeps existing data, overwriting records that share an ID with the archive
+	MergeMode Mode = "merge"
+)
+
+func ParseMode(mode string) (Mode, error) {
+	switch Mode(mode) {
+	case ReplaceMode, MergeMode:
+		return Mode(mode), nil
+	case "":
+		return MergeMode, nil
+	}
+	errMsg := fmt.Sprintf("invalid restore mode: %s. Expected {replace, merge}", mode)
+	return "", errors.New(errMsg)
+}
+
+// Summary counts the records written by a restore
+type Summary struct {
+	Venues  int `json:"venues"`
+	Artists int `json:"artists"`
+	Events  int `json:"events"`
+	Albums  int `json:"albums"`
+}
+
+type Service struct {
+	Repo *db.EventRepository
+}
+
+func (s *Service) Export(ctx context.Context) (*Archive, error) {
+	log.Info("Exporting backup archive")
+	venues, err := s.Repo.ListVenues(ctx)
+	if err != nil {
+		return nil, err
+	}
+	artists, err := s.Repo.ListArtists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.Repo.ListEvents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	albums, err := s.Repo.ListAlbums(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	archive := &Archive{
+		Version:   Version,
+		CreatedAt: time.Now().UTC(),
+		Venues:    venues,
+		Artists:   artists,
+		Events:    events,
+		Albums:    albums,
+	}
+	log.Infof("Exported %d venues, %d artists, %d events and %d albums",
+		len(venues), len(artists), len(events), len(albums))
+	return archive, nil
+}
+
+// Restore writes the archive over the existing data. A replace only deletes what isn't in the
+// archive once all of it is written, so a restore that fails part way through leaves the old data
+// in place alongside what was written rather than a partial copy of the archive.
+func (s *Service) Restore(ctx context.Context, archive Archive, mode Mode) (*Summary, error) {
+	log.Infof("Restoring backup archive from %v in %s mode", archive.CreatedAt, mode)
+	if archive.Version != Version {
+		message := fmt.Sprintf("unsupported archive version %s, expected %s", archive.Version, Version)
+		return nil, domain.InvalidFields(domain.FieldError{Field: "version", Message: message})
+	}
+	if err := validate(archive); err != nil {
+		return nil, err
+	}
+
+	existingVenues, err := s.Repo.ListVenues(ctx)
+	if err != nil {
+		return nil, err
+	}
+	existingArtists, err := s.Repo.ListArtists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	existingEvents, err := s.Repo.ListEvents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	existingAlbums, err := s.Repo.ListAlbums(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// referenced entities have to be written before the events and albums pointing at them
+	summary := &Summary{}
+	for _, venue := range archive.Venues {
+		if slices.ContainsFunc(existingVenues, func(v domain.Venue) bool { return v.ID.Primary == venue.ID.Primary }) {
+			_, err = s.Repo.UpdateVenue(ctx, venue)
+		} else {
+			_, err = s.Repo.AddVenue(ctx, venue)
+		}
+		if err != nil {
+			return summary, err
+		}
+		summary.Venues++
+	}
+	for _, artist := range archive.Artists {
+		if slices.ContainsFunc(existingArtists, artist.Equals) {
+			_, err = s.Repo.UpdateArtist(ctx, artist)
+		} else {
+			_, err = s.Repo.AddArtist(ctx, artist)
+		}
+		if err != nil {
+			return summary, err
+		}
+		summary.Artists++
+	}
+	for _, event := range archive.Events {
+		if slices.ContainsFunc(existingEvents, event.Equals) {
+			_, err = s.Repo.UpdateEvent(ctx, event)
+		} else {
+			_, err = s.Repo.AddEvent(ctx, event)
+		}
+		if err != nil {
+			return summary, err
+		}
+		summary.Events++
+	}
+	for _, album := range archive.Albums {
+		if slices.ContainsFunc(existingAlbums, album.Equals) {
+			_, err = s.Repo.UpdateAlbum(ctx, album)
+		} else {
+			_, err = s.Repo.AddAlbum(ctx, album)
+		}
+		if err != nil {
+			return summary, err
+		}
+		summary.Albums++
+	}
+
+	if mode == ReplaceMode {
+		if err := s.deleteMissing(ctx, archive); err != nil {
+			return summary, fmt.Errorf("restored the archive but failed to delete the data it doesn't contain: %w", err)
+		}
+	}
+	log.Infof("Restored backup archive %+v", *summary)
+	return summary, nil
+}
+
+// deletes the records that aren't in the archive, events and albums first so the artists and
+// venues are no longer referenced
+func (s *Service) deleteMissing(ctx context.Context, archive Archive) error {
+	log.Info("Deleting data missing from the restored backup archive")
+	err := deleteMissing(ctx, s.Repo.ListEvents, s.Repo.DeleteEvent, archive.Events, func(e domain.Event) string { return e.ID.Primary })
+	if err != nil {
+		return err
+	}
+	err = deleteMissing(ctx, s.Repo.ListAlbums, s.Repo.DeleteAlbum, archive.Albums, func(a domain.Album) string { return a.ID })
+	if err != nil {
+		return err
+	}
+	err = deleteMissing(ctx, s.Repo.ListArtists, s.Repo.DeleteArtist, archive.Artists, func(a domain.Artist) string { return a.ID.Primary })
+	if err != nil {
+		return err
+	}
+	return deleteMissing(ctx, s.Repo.ListVenues, s.Repo.DeleteVenue, archive.Venues, func(v domain.Venue) string { return v.ID.Primary })
+}
+
+func deleteMissing[T any](ctx context.Context, list func(context.Context) ([]T, error),
+	remove func(context.Context, string) error, keep []T, id func(T) string) error {
+	keepIDs := map[string]bool{}
+	for _, item := range keep {
+		keepIDs[id(item)] = true
+	}
+	existing, err := list(ctx)
+	if err != nil {
+		return err
+	}
+	for _, item := range existing {
+		if keepIDs[id(item)] {
+			continue
+		}
+		if err := remove(ctx, id(item)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checks that every record has an ID and every reference points at a record in the archive,
+// so a bad archive is rejected before anything is written. The invalid field names the record
+// by its position in the archive, like events[2].venue.
+func validate(archive Archive) error {
+	venueIDs := map[string]bool{}
+	for i, venue := range archive.Venues {
+		if venue.ID.Primary == "" {
+			return invalidRecord(fmt.Sprintf("venues[%d].id", i), "archive contains a venue without an ID")
+		}
+		venueIDs[venue.ID.Primary] = true
+	}
+	artistIDs := map[string]bool{}
+	for i, artist := range archive.Artists {
+		if artist.ID.Primary == "" {
+			return invalidRecord(fmt.Sprintf("artists[%d].id", i), "archive contains an artist without an ID")
+		}
+		artistIDs[artist.ID.Primary] = true
+	}
+
+	for i, event := range archive.Events {
+		if event.ID.Primary == "" {
+			return invalidRecord(fmt.Sprintf("events[%d].id", i), "archive contains an event without an ID")
+		}
+		if !venueIDs[event.Venue.ID.Primary] {
+			message := fmt.Sprintf("event %s references unknown venue %s", event.ID.Primary, event.Venue.ID.Primary)
+			return invalidRecord(fmt.Sprintf("events[%d].venue", i), message)
+		}
+		for _, artist := range event.Artists() {
+			if artist.Populated() && !artistIDs[artist.ID.Primary] {
+				message := fmt.Sprintf("event %s references unknown artist %s", event.ID.Primary, artist.ID.Primary)
+				return invalidRecord(fmt.Sprintf("events[%d].artists", i), message)
+			}
+		}
+	}
+	for i, album := range archive.Albums {
+		if album.ID == "" {
+			return invalidRecord(fmt.Sprintf("albums[%d].id", i), "archive contains an album without an ID")
+		}
+		for _, artist := range album.Artists {
+			if !artistIDs[artist.ID.Primary] {
+				message := fmt.Sprintf("album %s references unknown artist %s", album.ID, artist.ID.Primary)
+				return invalidRecord(fmt.Sprintf("albums[%d].artists", i), message)
+			}
+		}
+	}
+	return nil
+}
+
+func invalidRecord(field, message string) error {
+	return domain.InvalidFields(domain.FieldError{Field: field, Message: message})
+}
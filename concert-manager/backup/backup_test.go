@@ -0,0 +1,122 @@
+package backup
+
+import (
+	"concert-manager/db"
+	"concert-manager/db/memory"
+	"concert-manager/domain"
+	"context"
+	"errors"
+	"testing"
+)
+
+func seed(t *testing.T, repo *db.EventRepository) {
+	ctx := context.Background()
+	venue, _ := repo.AddVenue(ctx, domain.Venue{Name: "The Earl", City: "Atlanta", State: "GA"})
+	mainAct, _ := repo.AddArtist(ctx, domain.Artist{Name: "Main"})
+	opener, _ := repo.AddArtist(ctx, domain.Artist{Name: "Opener"})
+	_, err := repo.AddEvent(ctx, domain.Event{MainAct: &mainAct, Openers: []domain.Artist{opener}, Venue: venue, Date: domain.MustParseDate("3/14/2024")})
+	if err != nil {
+		t.Fatalf("failed to seed event: %v", err)
+	}
+	if _, err := repo.AddAlbum(ctx, domain.Album{Name: "Album", Artists: []domain.Artist{mainAct}}); err != nil {
+		t.Fatalf("failed to seed album: %v", err)
+	}
+}
+
+func TestExportAndRestore(t *testing.T) {
+	ctx := context.Background()
+	source := &Service{Repo: db.NewMemoryRepository()}
+	seed(t, source.Repo)
+	archive, err := source.Export(ctx)
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+
+	target := &Service{Repo: db.NewMemoryRepository()}
+	target.Repo.AddVenue(ctx, domain.Venue{Name: "Other", City: "Athens", State: "GA"})
+	summary, err := target.Restore(ctx, *archive, ReplaceMode)
+	if err != nil {
+		t.Fatalf("failed to restore: %v", err)
+	}
+	if summary.Venues != 1 || summary.Artists != 2 || summary.Events != 1 || summary.Albums != 1 {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+
+	restored, _ := target.Export(ctx)
+	if len(restored.Venues) != 1 || restored.Venues[0].ID.Primary != archive.Venues[0].ID.Primary {
+		t.Errorf("expected existing venues to be replaced and IDs kept, got %+v", restored.Venues)
+	}
+	event := restored.Events[0]
+	if event.ID.Primary != archive.Events[0].ID.Primary || event.MainAct.ID.Primary != archive.Events[0].MainAct.ID.Primary {
+		t.Errorf("expected event IDs and references to be kept, got %+v", event)
+	}
+	if restored.Albums[0].ID != archive.Albums[0].ID || len(restored.Albums[0].Artists) != 1 {
+		t.Errorf("expected album to be restored, got %+v", restored.Albums)
+	}
+
+	if _, err := target.Restore(ctx, *archive, MergeMode); err != nil {
+		t.Fatalf("failed to merge the same archive again: %v", err)
+	}
+	merged, _ := target.Export(ctx)
+	if len(merged.Events) != 1 || len(merged.Artists) != 2 {
+		t.Errorf("expected merge to overwrite records with the same IDs, got %+v", merged)
+	}
+}
+
+func TestRestoreInvalidArchive(t *testing.T) {
+	ctx := context.Background()
+	service := &Service{Repo: db.NewMemoryRepository()}
+	seed(t, service.Repo)
+	archive, _ := service.Export(ctx)
+
+	badVersion := *archive
+	badVersion.Version = "0.1"
+	if _, err := service.Restore(ctx, badVersion, ReplaceMode); err == nil {
+		t.Error("expected error restoring an unsupported version")
+	}
+
+	missingArtist := *archive
+	missingArtist.Artists = missingArtist.Artists[:1]
+	if _, err := service.Restore(ctx, missingArtist, ReplaceMode); err == nil {
+		t.Error("expected error restoring an archive with a dangling reference")
+	}
+	if events, _ := service.Repo.ListEvents(ctx); len(events) != 1 {
+		t.Errorf("expected invalid archive to leave data untouched, got %d events", len(events))
+	}
+}
+
+// fails to add any event, like a database going away part way through a restore
+type failingEventClient struct {
+	*memory.EventClient
+}
+
+func (failingEventClient) Add(context.Context, domain.Event) (string, error) {
+	return "", errors.New("database unavailable")
+}
+
+func TestFailedReplaceKeepsExistingData(t *testing.T) {
+	ctx := context.Background()
+	source := &Service{Repo: db.NewMemoryRepository()}
+	seed(t, source.Repo)
+	archive, _ := source.Export(ctx)
+
+	target := &Service{Repo: db.NewMemoryRepository()}
+	seed(t, target.Repo)
+	before, _ := target.Export(ctx)
+	target.Repo.EventRepo = failingEventClient{target.Repo.EventRepo.(*memory.EventClient)}
+
+	if _, err := target.Restore(ctx, *archive, ReplaceMode); err == nil {
+		t.Fatal("expected the restore to fail")
+	}
+	after, _ := target.Export(ctx)
+	if len(after.Events) != 1 || after.Events[0].ID.Primary != before.Events[0].ID.Primary {
+		t.Errorf("expected the existing event to be kept, got %+v", after.Events)
+	}
+	if len(after.Albums) != 1 || after.Albums[0].ID != before.Albums[0].ID {
+		t.Errorf("expected the existing album to be kept, got %+v", after.Albums)
+	}
+	// the archive's venue and artists were written before the failure, next to the existing ones
+	if len(after.Venues) != 2 || len(after.Artists) != 4 {
+		t.Errorf("expected the old and restored venues and artists, got %+v and %+v", after.Venues, after.Artists)
+	}
+}
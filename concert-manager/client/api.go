@@ -0,0 +1,503 @@
+package client
+
+import (
+	"concert-manager/analytics"
+	"concert-manager/domain"
+	"concert-manager/jobs"
+	"concert-manager/loader"
+	"concert-manager/scheduler"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (c *Client) UploadEvents(ctx context.Context, filename string, file io.Reader) (string, error) {
+	var message string
+	err := c.upload(ctx, "/v1/upload", nil, "file", filename, "text/csv", file, &message)
+	return message, err
+}
+
+func (c *Client) GetUpcomingEvents(ctx context.Context) ([]domain.EventDetails, error) {
+	var events []domain.EventDetails
+	err := c.do(ctx, http.MethodGet, "/v1/events/upcoming", nil, nil, &events)
+	return events, err
+}
+
+// RefreshUpcomingEvents starts finding the upcoming events again, see WaitForJob
+func (c *Client) RefreshUpcomingEvents(ctx context.Context) (*jobs.Job, error) {
+	return c.jobRequest(ctx, http.MethodPost, "/v1/events/upcoming/refresh", nil)
+}
+
+// GetRecommendedEvents lists upcoming events recommended at least at the threshold: low, medium or high
+func (c *Client) GetRecommendedEvents(ctx context.Context, threshold string) ([]domain.EventDetails, error) {
+	var events []domain.EventDetails
+	err := c.do(ctx, http.MethodGet, "/v1/events/recommended", url.Values{"threshold": {threshold}}, nil, &events)
+	return events, err
+}
+
+func (c *Client) GetSavedEvents(ctx context.Context) ([]SavedEvent, error) {
+	var events []SavedEvent
+	err := c.do(ctx, http.MethodGet, "/v1/events/saved", nil, nil, &events)
+	return events, err
+}
+
+// GetSavedEvent is the saved event with the primary ID, an APIError with http.StatusNotFound
+// when there isn't one
+func (c *Client) GetSavedEvent(ctx context.Context, id string) (*SavedEvent, error) {
+	var events []SavedEvent
+	if err := c.do(ctx, http.MethodGet, "/v1/events/saved", url.Values{"id": {id}}, nil, &events); err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, &APIError{StatusCode: http.StatusNotFound, Message: "event with ID " + id + " not found"}
+	}
+	return &events[0], nil
+}
+
+// ListSavedEvents is a page of the saved events matching the filter, by date unless sorted otherwise
+func (c *Client) ListSavedEvents(ctx context.Context, options ListOptions, filter EventFilter) (*Page[SavedEvent], error) {
+	query := options.values("date")
+	filter.apply(query)
+	var page Page[SavedEvent]
+	if err := c.do(ctx, http.MethodGet, "/v1/events/saved", query, nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (c *Client) AddSavedEvent(ctx context.Context, event domain.Event) (*domain.Event, error) {
+	var saved domain.Event
+	if err := c.do(ctx, http.MethodPost, "/v1/events/saved", nil, event, &saved); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+func (c *Client) UpdateSavedEvent(ctx context.Context, id string, event domain.Event) error {
+	return c.do(ctx, http.MethodPut, path("/v1/events/saved", id), nil, event, nil)
+}
+
+func (c *Client) DeleteSavedEvent(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, path("/v1/events/saved", id), nil, nil, nil)
+}
+
+func (c *Client) RefreshSavedEvents(ctx context.Context) error {
+	return c.do(ctx, http.MethodPost, "/v1/events/saved/refresh", nil, nil, nil)
+}
+
+func (c *Client) GetVenues(ctx context.Context) ([]domain.Venue, error) {
+	var venues []domain.Venue
+	err := c.do(ctx, http.MethodGet, "/v1/venues", nil, nil, &venues)
+	return venues, err
+}
+
+// ListVenues is a page of the venues, by name unless sorted otherwise
+func (c *Client) ListVenues(ctx context.Context, options ListOptions) (*Page[domain.Venue], error) {
+	var page Page[domain.Venue]
+	if err := c.do(ctx, http.MethodGet, "/v1/venues", options.values("name"), nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (c *Client) AddVenue(ctx context.Context, venue domain.Venue) (*domain.Venue, error) {
+	var saved domain.Venue
+	if err := c.do(ctx, http.MethodPost, "/v1/venues", nil, venue, &saved); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+func (c *Client) UpdateVenue(ctx context.Context, id string, venue domain.Venue) error {
+	return c.do(ctx, http.MethodPut, path("/v1/venues", id), nil, venue, nil)
+}
+
+func (c *Client) DeleteVenue(ctx context.Context, id string, options DeleteOptions) error {
+	return c.do(ctx, http.MethodDelete, path("/v1/venues", id), options.values(), nil, nil)
+}
+
+// MergeVenues merges the source venue into the venue with the ID, returning the merged venue
+func (c *Client) MergeVenues(ctx context.Context, id, sourceID string) (*domain.Venue, error) {
+	var merged domain.Venue
+	if err := c.do(ctx, http.MethodPost, path("/v1/venues", id, "merge"), nil, mergeRequest{SourceID: sourceID}, &merged); err != nil {
+		return nil, err
+	}
+	return &merged, nil
+}
+
+func (c *Client) RefreshVenues(ctx context.Context) error {
+	return c.do(ctx, http.MethodPost, "/v1/venues/refresh", nil, nil, nil)
+}
+
+func (c *Client) GetAlbums(ctx context.Context) ([]domain.Album, error) {
+	var albums []domain.Album
+	err := c.do(ctx, http.MethodGet, "/v1/albums", nil, nil, &albums)
+	return albums, err
+}
+
+// ListAlbums is a page of the albums matching the filter, by name unless sorted otherwise
+func (c *Client) ListAlbums(ctx context.Context, options ListOptions, filter AlbumFilter) (*Page[domain.Album], error) {
+	query := options.values("name")
+	filter.apply(query)
+	var page Page[domain.Album]
+	if err := c.do(ctx, http.MethodGet, "/v1/albums", query, nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (c *Client) AddAlbum(ctx context.Context, album domain.Album) (*domain.Album, error) {
+	var saved domain.Album
+	if err := c.do(ctx, http.MethodPost, "/v1/albums", nil, album, &saved); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+func (c *Client) UpdateAlbum(ctx context.Context, id string, album domain.Album) error {
+	return c.do(ctx, http.MethodPut, path("/v1/albums", id), nil, album, nil)
+}
+
+func (c *Client) DeleteAlbum(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, path("/v1/albums", id), nil, nil, nil)
+}
+
+func (c *Client) RefreshAlbums(ctx context.Context) (*jobs.Job, error) {
+	return c.jobRequest(ctx, http.MethodPost, "/v1/albums/refresh", nil)
+}
+
+// UploadAlbumImage uploads a cover image, returning the url to set as the album's CoverImageUrl
+func (c *Client) UploadAlbumImage(ctx context.Context, filename, contentType string, image io.Reader) (string, error) {
+	var response struct {
+		URL string `json:"url"`
+	}
+	err := c.upload(ctx, "/v1/albums/images", nil, "image", filename, contentType, image, &response)
+	return response.URL, err
+}
+
+// ImportAlbums adds the albums in a CSV file. The format is detected from the header when it's
+// empty, and a dry run only reports what would be imported.
+func (c *Client) ImportAlbums(ctx context.Context, filename string, file io.Reader, format loader.AlbumFormat, dryRun bool) (*loader.AlbumImportReport, error) {
+	query := url.Values{}
+	setOptional(query, "format", string(format))
+	if dryRun {
+		query.Set("dryRun", "true")
+	}
+	var report loader.AlbumImportReport
+	if err := c.upload(ctx, "/v1/albums/import", query, "file", filename, "text/csv", file, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (c *Client) GetArtists(ctx context.Context) ([]domain.Artist, error) {
+	var artists []domain.Artist
+	err := c.do(ctx, http.MethodGet, "/v1/artists", nil, nil, &artists)
+	return artists, err
+}
+
+// ListArtists is a page of the artists by name
+func (c *Client) ListArtists(ctx context.Context, options ListOptions) (*Page[domain.Artist], error) {
+	var page Page[domain.Artist]
+	if err := c.do(ctx, http.MethodGet, "/v1/artists", options.values("name"), nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (c *Client) AddArtist(ctx context.Context, artist domain.Artist) (*domain.Artist, error) {
+	var saved domain.Artist
+	if err := c.do(ctx, http.MethodPost, "/v1/artists", nil, artist, &saved); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+func (c *Client) UpdateArtist(ctx context.Context, id string, artist domain.Artist) error {
+	return c.do(ctx, http.MethodPut, path("/v1/artists", id), nil, artist, nil)
+}
+
+func (c *Client) DeleteArtist(ctx context.Context, id string, options DeleteOptions) error {
+	return c.do(ctx, http.MethodDelete, path("/v1/artists", id), options.values(), nil, nil)
+}
+
+// MergeArtists merges the source artist into the artist with the ID, returning the merged artist
+func (c *Client) MergeArtists(ctx context.Context, id, sourceID string) (*domain.Artist, error) {
+	var merged domain.Artist
+	if err := c.do(ctx, http.MethodPost, path("/v1/artists", id, "merge"), nil, mergeRequest{SourceID: sourceID}, &merged); err != nil {
+		return nil, err
+	}
+	return &merged, nil
+}
+
+func (c *Client) RefreshArtists(ctx context.Context) error {
+	return c.do(ctx, http.MethodPost, "/v1/artists/refresh", nil, nil, nil)
+}
+
+// RefreshRanks starts recalculating the ranks of upcoming events
+func (c *Client) RefreshRanks(ctx context.Context) (*jobs.Job, error) {
+	return c.jobRequest(ctx, http.MethodPost, "/v1/ranks/refresh", nil)
+}
+
+func (c *Client) GetGenres(ctx context.Context) (*domain.GenreResponse, error) {
+	var genres domain.GenreResponse
+	if err := c.do(ctx, http.MethodGet, "/v1/genres", nil, nil, &genres); err != nil {
+		return nil, err
+	}
+	return &genres, nil
+}
+
+// ReloadGenres starts looking up the genres of the artists by name again, or of every artist when none are given
+func (c *Client) ReloadGenres(ctx context.Context, artists ...string) (*jobs.Job, error) {
+	return c.jobRequest(ctx, http.MethodGet, "/v1/genres/refresh", url.Values{"artists": artists})
+}
+
+func (c *Client) Search(ctx context.Context, query string, options SearchOptions) (*SearchResponse, error) {
+	params := url.Values{"q": {query}}
+	setOptional(params, "tolerance", options.Tolerance)
+	if len(options.Types) > 0 {
+		types := []string{}
+		for _, resultType := range options.Types {
+			types = append(types, string(resultType))
+		}
+		params.Set("types", strings.Join(types, ","))
+	}
+	if options.Limit > 0 {
+		params.Set("limit", strconv.Itoa(options.Limit))
+	}
+	var response SearchResponse
+	if err := c.do(ctx, http.MethodGet, "/v1/search", params, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Sync lists the saved data changed since the token of a previous sync, or everything with
+// Reset set when the token is empty or the server can no longer answer for it
+func (c *Client) Sync(ctx context.Context, since string) (*domain.Changes, error) {
+	var params url.Values
+	if since != "" {
+		params = url.Values{"since": {since}}
+	}
+	var changes domain.Changes
+	if err := c.do(ctx, http.MethodGet, "/v1/sync", params, nil, &changes); err != nil {
+		return nil, err
+	}
+	return &changes, nil
+}
+
+// jobRequest sends a request answered with a job
+func (c *Client) jobRequest(ctx context.Context, method, path string, query url.Values) (*jobs.Job, error) {
+	var job jobs.Job
+	if err := c.do(ctx, method, path, query, nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetJobs lists the running and recent jobs, newest first
+func (c *Client) GetJobs(ctx context.Context, filter JobFilter) ([]jobs.Job, error) {
+	params := url.Values{}
+	setOptional(params, "kind", filter.Kind)
+	setOptional(params, "status", string(filter.Status))
+	var list []jobs.Job
+	err := c.do(ctx, http.MethodGet, "/v1/jobs", params, nil, &list)
+	return list, err
+}
+
+func (c *Client) GetJob(ctx context.Context, id string) (*jobs.Job, error) {
+	return c.jobRequest(ctx, http.MethodGet, path("/v1/jobs", id), nil)
+}
+
+// CancelJob asks a running job to stop, it is cancelled once the server notices
+func (c *Client) CancelJob(ctx context.Context, id string) (*jobs.Job, error) {
+	return c.jobRequest(ctx, http.MethodPost, path("/v1/jobs", id, "cancel"), nil)
+}
+
+// WaitForJob checks on a job every interval until it finishes or the context is done
+func (c *Client) WaitForJob(ctx context.Context, id string, interval time.Duration) (*jobs.Job, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		job, err := c.GetJob(ctx, id)
+		if err != nil || job.Finished() {
+			return job, err
+		}
+		select {
+		case <-ctx.Done():
+			return job, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// GetSchedules lists the scheduled refreshes with their next and last runs
+func (c *Client) GetSchedules(ctx context.Context) ([]scheduler.TaskStatus, error) {
+	var schedules []scheduler.TaskStatus
+	err := c.do(ctx, http.MethodGet, "/v1/schedules", nil, nil, &schedules)
+	return schedules, err
+}
+
+func (c *Client) GetSchedule(ctx context.Context, kind string) (*scheduler.TaskStatus, error) {
+	var schedule scheduler.TaskStatus
+	if err := c.do(ctx, http.MethodGet, path("/v1/schedules", kind), nil, nil, &schedule); err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// GetOpenAPI is the OpenAPI document of the server
+func (c *Client) GetOpenAPI(ctx context.Context) (map[string]any, error) {
+	var document map[string]any
+	err := c.do(ctx, http.MethodGet, "/v1/openapi.json", nil, nil, &document)
+	return document, err
+}
+
+func (c *Client) GetAnalyticsSummary(ctx context.Context) (*analytics.Summary, error) {
+	var summary analytics.Summary
+	if err := c.do(ctx, http.MethodGet, "/v1/analytics/summary", nil, nil, &summary); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+func (c *Client) GetSpending(ctx context.Context) (*analytics.SpendingSummary, error) {
+	var spending analytics.SpendingSummary
+	if err := c.do(ctx, http.MethodGet, "/v1/analytics/spending", nil, nil, &spending); err != nil {
+		return nil, err
+	}
+	return &spending, nil
+}
+
+// GetRatedEvents lists the past events rated at least minRating stars
+func (c *Client) GetRatedEvents(ctx context.Context, minRating int) (*analytics.EventsResponse, error) {
+	return c.analyticsEvents(ctx, "/v1/analytics/ratings", url.Values{"min": {strconv.Itoa(minRating)}})
+}
+
+// GetRatings lists the average ratings by venues, artists or years, highest first
+func (c *Client) GetRatings(ctx context.Context, dimension string) ([]analytics.Rating, error) {
+	var ratings []analytics.Rating
+	err := c.do(ctx, http.MethodGet, path("/v1/analytics/ratings", dimension), nil, nil, &ratings)
+	return ratings, err
+}
+
+func (c *Client) GetAlbumSummary(ctx context.Context) (*analytics.AlbumSummary, error) {
+	var summary analytics.AlbumSummary
+	if err := c.do(ctx, http.MethodGet, "/v1/analytics/albums", nil, nil, &summary); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+func (c *Client) GetAlbumsByEvent(ctx context.Context, eventID string) (*analytics.AlbumsResponse, error) {
+	var albums analytics.AlbumsResponse
+	if err := c.do(ctx, http.MethodGet, path("/v1/analytics/albums", eventID), nil, nil, &albums); err != nil {
+		return nil, err
+	}
+	return &albums, nil
+}
+
+func (c *Client) GetYearCounts(ctx context.Context) ([]analytics.Count, error) {
+	return c.analyticsCounts(ctx, "years")
+}
+
+// GetEventsByYear lists the past events of a year like "2024"
+func (c *Client) GetEventsByYear(ctx context.Context, year string) (*analytics.EventsResponse, error) {
+	return c.analyticsEvents(ctx, path("/v1/analytics/years", year), nil)
+}
+
+func (c *Client) GetMonthCounts(ctx context.Context) ([]analytics.Count, error) {
+	return c.analyticsCounts(ctx, "months")
+}
+
+// GetEventsByMonth lists the past events of a month like "2024-03"
+func (c *Client) GetEventsByMonth(ctx context.Context, month string) (*analytics.EventsResponse, error) {
+	return c.analyticsEvents(ctx, path("/v1/analytics/months", month), nil)
+}
+
+func (c *Client) GetArtistCounts(ctx context.Context) ([]analytics.Count, error) {
+	return c.analyticsCounts(ctx, "artists")
+}
+
+func (c *Client) GetEventsByArtist(ctx context.Context, artistID string) (*analytics.EventsResponse, error) {
+	return c.analyticsEvents(ctx, path("/v1/analytics/artists", artistID), nil)
+}
+
+func (c *Client) GetVenueCounts(ctx context.Context) ([]analytics.Count, error) {
+	return c.analyticsCounts(ctx, "venues")
+}
+
+func (c *Client) GetEventsByVenue(ctx context.Context, venueID string) (*analytics.EventsResponse, error) {
+	return c.analyticsEvents(ctx, path("/v1/analytics/venues", venueID), nil)
+}
+
+func (c *Client) GetGenreCounts(ctx context.Context) ([]analytics.Count, error) {
+	return c.analyticsCounts(ctx, "genres")
+}
+
+func (c *Client) GetEventsByGenre(ctx context.Context, genre string) (*analytics.EventsResponse, error) {
+	return c.analyticsEvents(ctx, path("/v1/analytics/genres", genre), nil)
+}
+
+func (c *Client) GetCompanionCounts(ctx context.Context) ([]analytics.Count, error) {
+	return c.analyticsCounts(ctx, "companions")
+}
+
+func (c *Client) GetEventsByCompanion(ctx context.Context, companion string) (*analytics.EventsResponse, error) {
+	return c.analyticsEvents(ctx, path("/v1/analytics/companions", companion), nil)
+}
+
+func (c *Client) analyticsCounts(ctx context.Context, dim string) ([]analytics.Count, error) {
+	var counts []analytics.Count
+	err := c.do(ctx, http.MethodGet, "/v1/analytics/"+dim, nil, nil, &counts)
+	return counts, err
+}
+
+func (c *Client) analyticsEvents(ctx context.Context, route string, query url.Values) (*analytics.EventsResponse, error) {
+	var events analytics.EventsResponse
+	if err := c.do(ctx, http.MethodGet, route, query, nil, &events); err != nil {
+		return nil, err
+	}
+	return &events, nil
+}
+
+func (c *Client) GetBackup(ctx context.Context) (*BackupArchive, error) {
+	var archive BackupArchive
+	if err := c.do(ctx, http.MethodGet, "/v1/backup", nil, nil, &archive); err != nil {
+		return nil, err
+	}
+	return &archive, nil
+}
+
+// RestoreBackup restores an archive from GetBackup. Mode "replace" deletes the existing data first,
+// "merge" or an empty mode keeps it and overwrites records with the same ID.
+func (c *Client) RestoreBackup(ctx context.Context, archive BackupArchive, mode string) (*BackupSummary, error) {
+	query := url.Values{}
+	setOptional(query, "mode", mode)
+	var summary BackupSummary
+	if err := c.do(ctx, http.MethodPost, "/v1/restore", query, archive, &summary); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// StartSpotifyAuth returns the url to open to authorize Spotify
+func (c *Client) StartSpotifyAuth(ctx context.Context) (string, error) {
+	var response struct {
+		AuthUrl string `json:"authUrl"`
+	}
+	err := c.do(ctx, http.MethodGet, "/v1/spotify/auth/start", nil, nil, &response)
+	return response.AuthUrl, err
+}
+
+func (c *Client) GetSpotifyAuthStatus(ctx context.Context) (*SpotifyAuthStatus, error) {
+	var status SpotifyAuthStatus
+	if err := c.do(ctx, http.MethodGet, "/v1/spotify/auth/status", nil, nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
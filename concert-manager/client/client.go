@@ -0,0 +1,323 @@
+// Package client is a typed client for the /v1 API of the server, see /v1/openapi.json for
+// the document it follows. Every method is named after the operationId it calls.
+package client
+
+import (
+	"bytes"
+	"concert-manager/domain"
+	"concert-manager/jobs"
+	"concert-manager/search"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Client struct {
+	// like https://example.com, without /v1
+	BaseURL string
+	// API key of the user, sent as a bearer token
+	APIKey     string
+	HTTPClient *http.Client
+}
+
+func New(baseURL, apiKey string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		APIKey:     apiKey,
+		HTTPClient: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// APIError is a response with an error status, decoded from the error envelope of the server.
+// Code is stable, like not_found or conflict, while Message is meant for people.
+type APIError struct {
+	StatusCode int
+	Code       string              `json:"code"`
+	Message    string              `json:"message"`
+	RequestID  string              `json:"requestId"`
+	Fields     []domain.FieldError `json:"fields"`
+	References *domain.References  `json:"references"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("request %s failed with status %d (%s): %s", e.RequestID, e.StatusCode, e.Code, e.Message)
+}
+
+// IsStatus is whether err is an APIError with the status, e.g. http.StatusConflict for a delete
+// of an artist or venue that is still referenced
+func IsStatus(err error, status int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
+
+// IsCode is whether err is an APIError with the code, e.g. "invalid_request"
+func IsCode(err error, code string) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
+
+// SavedEvent is a saved event with the albums bought or signed at it
+type SavedEvent struct {
+	domain.Event
+	Albums []domain.Album `json:"albums"`
+}
+
+// Page is a page of a list, pass NextCursor as ListOptions.Cursor to get the next one.
+// NextCursor is empty on the last page.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	Count      int    `json:"count"`
+	Total      int    `json:"total"`
+	NextCursor string `json:"nextCursor"`
+}
+
+// ListOptions sorts and pages a list. Sort is a field name, descending when prefixed with "-",
+// and the list endpoint's default when empty. Limit 0 returns every item.
+type ListOptions struct {
+	Sort   string
+	Limit  int
+	Cursor string
+}
+
+func (o ListOptions) values(defaultSort string) url.Values {
+	query := url.Values{}
+	// setting sort always gets a Page back, plain requests return the whole list
+	query.Set("sort", defaultSort)
+	if o.Sort != "" {
+		query.Set("sort", o.Sort)
+	}
+	if o.Limit > 0 {
+		query.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Cursor != "" {
+		query.Set("cursor", o.Cursor)
+	}
+	return query
+}
+
+// EventFilter limits ListSavedEvents, zero fields are ignored
+type EventFilter struct {
+	From      domain.Date
+	To        domain.Date
+	Purchased *bool
+	VenueID   string
+	ArtistID  string
+	Genre     string
+}
+
+func (f EventFilter) apply(query url.Values) {
+	setOptional(query, "from", f.From.String())
+	setOptional(query, "to", f.To.String())
+	setOptionalBool(query, "purchased", f.Purchased)
+	setOptional(query, "venueId", f.VenueID)
+	setOptional(query, "artistId", f.ArtistID)
+	setOptional(query, "genre", f.Genre)
+}
+
+// AlbumFilter limits ListAlbums, zero fields are ignored
+type AlbumFilter struct {
+	Format     string
+	Wishlisted *bool
+	Signed     *bool
+}
+
+func (f AlbumFilter) apply(query url.Values) {
+	setOptional(query, "format", f.Format)
+	setOptionalBool(query, "wishlisted", f.Wishlisted)
+	setOptionalBool(query, "signed", f.Signed)
+}
+
+// JobFilter limits GetJobs, zero fields are ignored
+type JobFilter struct {
+	// like upcoming-events-refresh or genres-reload
+	Kind   string
+	Status jobs.Status
+}
+
+// DeleteOptions are for deleting an artist or venue that events still reference. Without them
+// the delete fails with http.StatusConflict.
+type DeleteOptions struct {
+	// also delete the events
+	Cascade bool
+	// move the events to this artist or venue
+	ReassignTo string
+}
+
+func (o DeleteOptions) values() url.Values {
+	query := url.Values{}
+	if o.Cascade {
+		query.Set("cascade", "true")
+	}
+	setOptional(query, "reassignTo", o.ReassignTo)
+	return query
+}
+
+// SearchOptions tune Search, zero fields use the server defaults
+type SearchOptions struct {
+	// exact, strict, moderate, lenient or a share of each word between 0 and 1
+	Tolerance string
+	Types     []search.ResultType
+	Limit     int
+}
+
+type SearchResponse struct {
+	Query   string          `json:"query"`
+	Count   int             `json:"count"`
+	Results []search.Result `json:"results"`
+}
+
+// BackupArchive is everything saved for a user, as exported by GetBackup
+type BackupArchive struct {
+	Version   string          `json:"version"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Venues    []domain.Venue  `json:"venues"`
+	Artists   []domain.Artist `json:"artists"`
+	Events    []domain.Event  `json:"events"`
+	Albums    []domain.Album  `json:"albums"`
+}
+
+// BackupSummary counts what a restore wrote
+type BackupSummary struct {
+	Venues  int `json:"venues"`
+	Artists int `json:"artists"`
+	Events  int `json:"events"`
+	Albums  int `json:"albums"`
+}
+
+type SpotifyAuthStatus struct {
+	Authenticated bool      `json:"authenticated"`
+	ExpireTs      time.Time `json:"expireTs"`
+}
+
+type mergeRequest struct {
+	SourceID string `json:"sourceId"`
+}
+
+func setOptional(query url.Values, name, value string) {
+	if value != "" {
+		query.Set(name, value)
+	}
+}
+
+func setOptionalBool(query url.Values, name string, value *bool) {
+	if value != nil {
+		query.Set(name, strconv.FormatBool(*value))
+	}
+}
+
+// do sends a JSON request and decodes the response into out, body and out can be nil
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request body: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := c.newRequest(ctx, method, path, query, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.send(req, out)
+}
+
+// upload sends a file in a multipart form field
+func (c *Client) upload(ctx context.Context, path string, query url.Values, field, filename, contentType string, file io.Reader, out any) error {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q; filename=%q`, field, filename))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	header.Set("Content-Type", contentType)
+	part, err := form.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	if err := form.Close(); err != nil {
+		return err
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, path, query, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return c.send(req, out)
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, query url.Values, body io.Reader) (*http.Request, error) {
+	target := c.BaseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	req.Header.Set("Accept", "application/json")
+	return req, nil
+}
+
+func (c *Client) send(req *http.Request, out any) error {
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %w", req.Method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return decodeError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response of %s %s: %w", req.Method, req.URL.Path, err)
+	}
+	return nil
+}
+
+// decodeError reads the error envelope of a response, falling back to the body as the message
+// for responses that didn't come from the server, like those of a proxy
+func decodeError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	var envelope struct {
+		Error *APIError `json:"error"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil || envelope.Error == nil {
+		return &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(body))}
+	}
+	envelope.Error.StatusCode = resp.StatusCode
+	return envelope.Error
+}
+
+// path joins escaped segments onto a route, like path("/v1/venues", id, "merge")
+func path(route string, segments ...string) string {
+	for _, segment := range segments {
+		route += "/" + url.PathEscape(segment)
+	}
+	return route
+}
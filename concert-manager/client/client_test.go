@@ -0,0 +1,170 @@
+package client
+
+import (
+	"concert-manager/db"
+	"concert-manager/domain"
+	"concert-manager/finder"
+	"concert-manager/jobs"
+	"concert-manager/ranker"
+	"concert-manager/server"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type stubAuthenticator map[string]string
+
+func (a stubAuthenticator) Authenticate(apiKey string) (string, bool) {
+	id, ok := a[apiKey]
+	return id, ok
+}
+
+type noopSync struct{}
+
+func (noopSync) SyncArtistAdd(string) error           { return nil }
+func (noopSync) SyncArtistUpdate(string) error        { return nil }
+func (noopSync) SyncArtistDelete(string)              {}
+func (noopSync) SyncArtistMerge(string, string) error { return nil }
+func (noopSync) SyncVenueAdd(string) error            { return nil }
+func (noopSync) SyncVenueUpdate(string) error         { return nil }
+func (noopSync) SyncVenueDelete(string)               {}
+func (noopSync) SyncVenueMerge(string, string) error  { return nil }
+func (noopSync) SyncEventAdd(string) error            { return nil }
+func (noopSync) SyncEventUpdate(string) error         { return nil }
+func (noopSync) SyncEventDelete(string)               {}
+
+type noUpcomingEvents struct{}
+
+func (noUpcomingEvents) GetUpcomingEvents() []domain.EventDetails    { return []domain.EventDetails{} }
+func (noUpcomingEvents) CachedUpcomingEvents() []domain.EventDetails { return nil }
+func (noUpcomingEvents) ChangeLocation(string, string)               {}
+func (noUpcomingEvents) GetLocation() finder.Location                { return finder.Location{} }
+func (noUpcomingEvents) RefreshUpcomingEvents(context.Context) error { return nil }
+func (noUpcomingEvents) GetRecommendedEvents(ranker.RecLevel) []domain.EventDetails {
+	return []domain.EventDetails{}
+}
+
+func newTestClient(t *testing.T, apiKey string) *Client {
+	cache := &db.Cache{Database: db.NewMemoryRepository()}
+	cache.LoadCaches()
+	router := server.Router{
+		Authenticator: stubAuthenticator{"key": ""},
+		Servers: map[string]*server.Server{"": {
+			SavedEventCache:     cache,
+			ArtistCache:         cache,
+			VenueCache:          cache,
+			AlbumCache:          cache,
+			UpcomingEventsCache: noUpcomingEvents{},
+			SyncService:         noopSync{},
+			ChangeTracker:       cache,
+			Jobs:                &jobs.Manager{},
+		}},
+	}
+	ts := httptest.NewServer(router.Handler())
+	t.Cleanup(ts.Close)
+	return New(ts.URL, apiKey)
+}
+
+func TestClientCoversOperations(t *testing.T) {
+	c := newTestClient(t, "key")
+	document, err := c.GetOpenAPI(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get OpenAPI document: %v", err)
+	}
+	clientType := reflect.TypeOf(c)
+	paths := document["paths"].(map[string]any)
+	for path, methods := range paths {
+		for method, op := range methods.(map[string]any) {
+			op := op.(map[string]any)
+			// public operations are for redirects rather than clients
+			if security, ok := op["security"].([]any); ok && len(security) == 0 {
+				continue
+			}
+			id := op["operationId"].(string)
+			if _, ok := clientType.MethodByName(id); !ok {
+				t.Errorf("no client method for %s %s (%s)", method, path, id)
+			}
+		}
+	}
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, "key")
+
+	fox, err := c.AddVenue(ctx, domain.Venue{Name: "Fox Theatre", City: "Atlanta", State: "GA"})
+	if err != nil {
+		t.Fatalf("failed to add venue: %v", err)
+	}
+	if _, err := c.AddVenue(ctx, domain.Venue{Name: "Tabernacle", City: "Atlanta", State: "GA"}); err != nil {
+		t.Fatalf("failed to add venue: %v", err)
+	}
+	event, err := c.AddSavedEvent(ctx, domain.Event{
+		MainAct: &domain.Artist{Name: "Radiohead"},
+		Venue:   *fox,
+		Date:    domain.MustParseDate("3/14/2024"),
+	})
+	if err != nil {
+		t.Fatalf("failed to add event: %v", err)
+	}
+
+	page, err := c.ListVenues(ctx, ListOptions{Limit: 1})
+	if err != nil {
+		t.Fatalf("failed to list venues: %v", err)
+	}
+	if page.Total != 2 || len(page.Items) != 1 || page.Items[0].Name != "Fox Theatre" || page.NextCursor == "" {
+		t.Errorf("unexpected first page %+v", page)
+	}
+	page, err = c.ListVenues(ctx, ListOptions{Limit: 1, Cursor: page.NextCursor})
+	if err != nil || len(page.Items) != 1 || page.Items[0].Name != "Tabernacle" || page.NextCursor != "" {
+		t.Errorf("unexpected last page %+v, %v", page, err)
+	}
+
+	saved, err := c.GetSavedEvent(ctx, event.ID.Primary)
+	if err != nil || saved.Venue.Name != "Fox Theatre" || saved.Albums == nil {
+		t.Errorf("unexpected saved event %+v, %v", saved, err)
+	}
+	results, err := c.Search(ctx, "radio", SearchOptions{})
+	if err != nil || results.Count == 0 {
+		t.Errorf("expected search results, got %+v, %v", results, err)
+	}
+
+	changes, err := c.Sync(ctx, "")
+	if err != nil || !changes.Reset || len(changes.Venues.Created) != 2 {
+		t.Errorf("expected a full sync, got %+v, %v", changes, err)
+	}
+
+	job, err := c.RefreshAlbums(ctx)
+	if err != nil {
+		t.Fatalf("failed to start album refresh: %v", err)
+	}
+	job, err = c.WaitForJob(ctx, job.ID, 10*time.Millisecond)
+	if err != nil || job.Status != jobs.Succeeded {
+		t.Errorf("expected the album refresh to succeed, got %+v, %v", job, err)
+	}
+
+	err = c.DeleteVenue(ctx, fox.ID.Primary, DeleteOptions{})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict || apiErr.Code != "conflict" || apiErr.References == nil || apiErr.RequestID == "" {
+		t.Errorf("expected conflict deleting a referenced venue, got %+v", err)
+	}
+	if err := c.DeleteVenue(ctx, fox.ID.Primary, DeleteOptions{Cascade: true}); err != nil {
+		t.Errorf("failed to delete venue: %v", err)
+	}
+	if _, err := c.GetSavedEvent(ctx, event.ID.Primary); !IsStatus(err, http.StatusNotFound) {
+		t.Errorf("expected the event to be deleted with its venue, got %v", err)
+	}
+	changes, err = c.Sync(ctx, changes.Token)
+	if err != nil || changes.Reset || len(changes.Venues.Deleted) != 1 || len(changes.Events.Deleted) != 1 {
+		t.Errorf("expected the venue and event deletes, got %+v, %v", changes, err)
+	}
+
+	_, err = newTestClient(t, "wrong").GetVenues(ctx)
+	if !IsStatus(err, http.StatusUnauthorized) {
+		t.Errorf("expected unauthorized, got %v", err)
+	}
+}
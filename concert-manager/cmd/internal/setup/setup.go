@@ -0,0 +1,110 @@
+// Package setup wires up what the server and TUI executables share, the database backend selected
+// by the environment and the real-time sync of changes made by other clients.
+package setup
+
+import (
+	"concert-manager/db"
+	"concert-manager/db/firestore"
+	"concert-manager/db/sqlite"
+	"concert-manager/log"
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+)
+
+const dbBackendEnv = "CM_DB_BACKEND"
+
+// the Firestore client is shared by every user, each user's data lives under their own document
+var (
+	firestoreConnection *firestore.Firestore
+	firestoreMutex      sync.Mutex
+)
+
+// Database connects to the backend set by CM_DB_BACKEND, or an empty in-memory database with
+// --memory, returning a repository that only sees the user's data
+func Database(userID string) (*db.EventRepository, error) {
+	if slices.Contains(os.Args, "--memory") {
+		log.Info("Starting with in-memory database, no changes will be persisted")
+		return db.NewMemoryRepository(), nil
+	}
+
+	switch backend := os.Getenv(dbBackendEnv); backend {
+	case "", "firestore":
+		connection, err := sharedFirestore()
+		if err != nil {
+			return nil, err
+		}
+		dbConnection := connection.ForUser(userID)
+		venueClient := &firestore.VenueClient{Connection: dbConnection}
+		artistClient := &firestore.ArtistClient{Connection: dbConnection}
+		eventClient := &firestore.EventClient{
+			Connection:   dbConnection,
+			VenueClient:  venueClient,
+			ArtistClient: artistClient,
+		}
+		albumClient := &firestore.AlbumClient{Connection: dbConnection, ArtistClient: artistClient}
+		return &db.EventRepository{
+			VenueRepo:     venueClient,
+			ArtistRepo:    artistClient,
+			EventRepo:     eventClient,
+			AlbumRepo:     albumClient,
+			BatchRepo:     &firestore.BatchClient{Connection: dbConnection},
+			MigrationRepo: &firestore.MigrationClient{Connection: dbConnection},
+		}, nil
+	case "sqlite":
+		dbConnection, err := sqlite.Setup(userID)
+		if err != nil {
+			return nil, err
+		}
+		return &db.EventRepository{
+			VenueRepo:     &sqlite.VenueClient{Connection: dbConnection},
+			ArtistRepo:    &sqlite.ArtistClient{Connection: dbConnection},
+			EventRepo:     &sqlite.EventClient{Connection: dbConnection},
+			AlbumRepo:     &sqlite.AlbumClient{Connection: dbConnection},
+			BatchRepo:     &sqlite.BatchClient{Connection: dbConnection},
+			MigrationRepo: &sqlite.MigrationClient{Connection: dbConnection},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported %s value %q, expected firestore or sqlite", dbBackendEnv, backend)
+	}
+}
+
+func sharedFirestore() (*firestore.Firestore, error) {
+	firestoreMutex.Lock()
+	defer firestoreMutex.Unlock()
+	if firestoreConnection == nil {
+		connection, err := firestore.Setup()
+		if err != nil {
+			return nil, err
+		}
+		firestoreConnection = connection
+	}
+	return firestoreConnection, nil
+}
+
+// ArgValue returns the value following the flag in the command line arguments, e.g. --user <id>
+func ArgValue(flag string) string {
+	idx := slices.Index(os.Args, flag)
+	if idx < 0 || idx+1 >= len(os.Args) {
+		return ""
+	}
+	return os.Args[idx+1]
+}
+
+// StartRealtimeSync keeps the saved data cache in sync with changes made by other clients, which
+// only Firestore supports
+func StartRealtimeSync(interactor *db.EventRepository, savedCache *db.Cache, syncService db.SyncHooks) {
+	eventClient, ok := interactor.EventRepo.(*firestore.EventClient)
+	if !ok {
+		log.Fatal("Real-time sync is only supported with the Firestore backend")
+	}
+	savedCache.SyncService = syncService
+	listener := &firestore.Listener{Connection: eventClient.Connection}
+	go func() {
+		if err := savedCache.Listen(context.Background(), listener); err != nil {
+			log.Alert("Stopped listening for database changes", err)
+		}
+	}()
+}
@@ -1,19 +1,30 @@
 package main
 
 import (
+	"concert-manager/backup"
+	"concert-manager/cmd/internal/setup"
 	"concert-manager/db"
-	"concert-manager/db/firestore"
 	"concert-manager/external/gcs"
 	"concert-manager/external/lastfm"
 	"concert-manager/external/spotify"
 	"concert-manager/external/ticketmaster"
+	"concert-manager/file"
 	"concert-manager/finder"
+	"concert-manager/jobs"
 	"concert-manager/loader"
 	"concert-manager/log"
+	"concert-manager/migrate"
 	"concert-manager/ranker"
+	"concert-manager/scheduler"
 	"concert-manager/server"
+	"concert-manager/user"
+	"context"
+	"fmt"
 	"os"
 	"slices"
+	"time"
+	// venue time zones are looked up by name, which needs the zone database on hosts without one
+	_ "time/tzdata"
 )
 
 func main() {
@@ -21,19 +32,25 @@ func main() {
 		log.Fatal("Failed to set up logger:", err)
 	}
 
-	apiKey := os.Getenv("CM_API_KEY")
-	if apiKey == "" {
-		log.Fatal("CM_API_KEY env var must be set")
+	users, err := user.Load()
+	if err != nil {
+		log.Fatal("Failed to load users:", err)
+	}
+	if id := setup.ArgValue("--add-user"); id != "" {
+		addUser(users, id)
+		return
 	}
 
-	dbConnection, err := firestore.Setup()
-	if err != nil {
-		log.Fatal("Failed to set up database:", err)
+	if slices.Contains(os.Args, "--migrate") || setup.ArgValue("--backup") != "" || setup.ArgValue("--restore") != "" ||
+		setup.ArgValue("--import-albums") != "" {
+		runCommand(setup.ArgValue("--user"))
+		return
 	}
 
-	gcsClient, err := gcs.Setup()
-	if err != nil {
-		log.Fatal("Failed to set up GCS client:", err)
+	users.LegacyApiKey = os.Getenv("CM_API_KEY")
+	userIDs := users.IDs()
+	if len(userIDs) == 0 {
+		log.Fatal("CM_API_KEY env var must be set, or add a user with --add-user")
 	}
 
 	if slices.Contains(os.Args, "--test") {
@@ -41,36 +58,72 @@ func main() {
 		spotify.TEST_MODE = true
 	}
 
-	venueClient := &firestore.VenueClient{Connection: dbConnection}
-	artistClient := &firestore.ArtistClient{Connection: dbConnection}
-	eventClient := &firestore.EventClient{
-		Connection:   dbConnection,
-		VenueClient:  venueClient,
-		ArtistClient: artistClient,
+	shared := sharedServices{
+		imageUploader: setupImageUploader(),
+		ticketmaster:  ticketmaster.Ticketmaster{},
+		lastFm:        lastfm.NewClient(),
+		schedules:     loadSchedules(),
+	}
+	router := server.Router{Authenticator: users, Servers: map[string]*server.Server{}, DefaultUserID: user.DefaultID}
+	for _, userID := range userIDs {
+		router.Servers[userID] = setupUserServer(userID, shared)
+	}
+	router.StartServer()
+}
+
+// services that don't hold any user data, so every user can share them
+type sharedServices struct {
+	imageUploader *gcs.GCS
+	ticketmaster  ticketmaster.Ticketmaster
+	lastFm        *lastfm.Client
+	schedules     scheduleConfig
+}
+
+// album images are uploaded to GCS, the server runs without uploads when no bucket is set or
+// with --memory, where nothing should reach the cloud
+func setupImageUploader() *gcs.GCS {
+	if slices.Contains(os.Args, "--memory") {
+		log.Info("Album image uploads are disabled with the in-memory database")
+		return nil
+	}
+	if !gcs.Configured() {
+		log.Info("CM_ALBUM_IMAGE_BUCKET is not set, album image uploads are disabled")
+		return nil
 	}
-	albumClient := &firestore.AlbumClient{Connection: dbConnection, ArtistClient: artistClient}
-	interactor := &db.EventRepository{
-		VenueRepo:  venueClient,
-		ArtistRepo: artistClient,
-		EventRepo:  eventClient,
-		AlbumRepo:  albumClient,
+	gcsClient, err := gcs.Setup()
+	if err != nil {
+		log.Fatal("Failed to set up GCS client:", err)
+	}
+	return gcsClient
+}
+
+// builds the caches and server for a single user, which only ever see that user's data
+func setupUserServer(userID string, shared sharedServices) *server.Server {
+	log.Infof("Setting up server for user %q", userID)
+	interactor, err := setup.Database(userID)
+	if err != nil {
+		log.Fatal("Failed to set up database:", err)
+	}
+	if _, err := migrate.NewMigrator(interactor).Run(context.Background(), false); err != nil {
+		log.Fatal("Failed to run migrations:", err)
 	}
 
 	savedCache := &db.Cache{}
 	savedCache.Database = interactor
 	savedCache.LoadCaches()
 
-	ticketmaster := ticketmaster.Ticketmaster{}
 	eventFinder := finder.NewEventFinder()
-	eventFinder.Ticketmaster = ticketmaster
+	eventFinder.Ticketmaster = shared.ticketmaster
 
-	spotifyAuth := spotify.NewAuthentication()
+	spotifyAuth := spotify.NewAuthentication(userID)
 	spotifyClient := spotify.NewClient(spotifyAuth)
-	lastFmClient := lastfm.NewClient()
 
 	artistRanksCache := &ranker.ArtistRankCache{
+		UserID:         userID,
 		MusicSvc:       spotifyClient,
-		ArtistProvider: lastFmClient,
+		ArtistProvider: shared.lastFm,
+		// ranks and upcoming events are refreshed ahead of time when they are scheduled
+		ScheduledRefresh: shared.schedules.scheduled(server.RanksJob),
 	}
 	err = artistRanksCache.InitializeFromFile()
 	if err != nil {
@@ -83,24 +136,41 @@ func main() {
 
 	artistInfoFinder := finder.MetadataFinder{
 		Spotify: spotifyClient,
-		LastFm:  lastFmClient,
+		LastFm:  shared.lastFm,
 	}
 
 	upcomingCache := finder.NewUpcomingEventCache()
+	upcomingCache.UserID = userID
 	upcomingCache.Finder = eventFinder
 	upcomingCache.Ranker = eventRanker
 	upcomingCache.SavedDataCache = savedCache
 	upcomingCache.MetadataFinder = artistInfoFinder
+	upcomingCache.ScheduledRefresh = shared.schedules.scheduled(server.UpcomingEventsJob)
 	err = upcomingCache.InitializeFromFile()
 	if err != nil {
 		log.Fatal("Failed to initialize upcoming events cache:", err)
 	}
 
+	if slices.Contains(os.Args, "--realtime") {
+		log.Info("Starting with real-time sync of database changes")
+		setup.StartRealtimeSync(interactor, savedCache, upcomingCache)
+	}
+
+	jobsPath, err := file.GetCacheFilePath(file.UserFileName(jobHistoryFile, userID))
+	if err != nil {
+		log.Fatal("Failed to get job history file path:", err)
+	}
+	jobManager := &jobs.Manager{FilePath: jobsPath}
+	if err := jobManager.Load(); err != nil {
+		log.Error("Failed to load job history, starting without it:", err)
+	}
+
 	eventLoader := &loader.EventLoader{Cache: savedCache}
 	genreLoader := &loader.GenreLoader{Cache: savedCache, MetadataProvider: artistInfoFinder}
 
-	server := server.Server{}
+	server := &server.Server{}
 	server.EventLoader = eventLoader
+	server.AlbumLoader = &loader.AlbumLoader{Cache: savedCache}
 	server.ArtistInfoLoader = genreLoader
 	server.SavedEventCache = savedCache
 	server.ArtistCache = savedCache
@@ -109,9 +179,195 @@ func main() {
 	server.UpcomingEventsCache = upcomingCache
 	server.RanksCache = artistRanksCache
 	server.SyncService = upcomingCache
-	server.ImageUploader = gcsClient
+	if shared.imageUploader != nil {
+		server.ImageUploader = shared.imageUploader
+	}
 	server.SpotifyAuthHandler = spotifyAuth
-	server.ApiKey = apiKey
+	server.BackupService = &backup.Service{Repo: interactor}
+	server.ChangeTracker = savedCache
+	server.Jobs = jobManager
+
+	refreshScheduler := &scheduler.Scheduler{Jobs: jobManager, Jitter: shared.schedules.jitter}
+	for _, task := range server.RefreshTasks(shared.schedules.specs) {
+		if err := refreshScheduler.Add(task); err != nil {
+			log.Fatal("Failed to schedule refreshes:", err)
+		}
+	}
+	refreshScheduler.Start(context.Background())
+	server.Scheduler = refreshScheduler
+	return server
+}
+
+// runs a one-off migrate, backup, restore or album import against the data of the user selected with --user
+func runCommand(userID string) {
+	interactor, err := setup.Database(userID)
+	if err != nil {
+		log.Fatal("Failed to set up database:", err)
+	}
+
+	if slices.Contains(os.Args, "--migrate") {
+		runMigrations(migrate.NewMigrator(interactor), slices.Contains(os.Args, "--dry-run"))
+		return
+	}
+	if _, err := migrate.NewMigrator(interactor).Run(context.Background(), false); err != nil {
+		log.Fatal("Failed to run migrations:", err)
+	}
+
+	if path := setup.ArgValue("--import-albums"); path != "" {
+		importAlbums(interactor, path, loader.AlbumFormat(setup.ArgValue("--format")), slices.Contains(os.Args, "--dry-run"))
+		return
+	}
+
+	backupService := &backup.Service{Repo: interactor}
+	if path := setup.ArgValue("--backup"); path != "" {
+		writeBackup(backupService, path)
+		return
+	}
+	mode := backup.MergeMode
+	if slices.Contains(os.Args, "--replace") {
+		mode = backup.ReplaceMode
+	}
+	restoreBackup(backupService, setup.ArgValue("--restore"), mode)
+}
+
+func addUser(users *user.Store, id string) {
+	apiKey, err := users.IssueApiKey(id)
+	if err != nil {
+		log.Fatal("Failed to add user:", err)
+	}
+	fmt.Printf("API key for user %s (it won't be shown again): %s\n", id, apiKey)
+}
+
+// recent jobs are kept next to the executable like the caches, in one file per user
+const jobHistoryFile = "jobs.json"
+
+// refreshes scheduled for every user by default, each env var can set a cron expression like
+// "30 4 * * *", a descriptor like "@daily" or "@every 6h", or "off"
+var refreshSchedules = []struct {
+	kind, env, spec string
+}{
+	{server.UpcomingEventsJob, "CM_SCHEDULE_UPCOMING_EVENTS", "0 5 * * *"},
+	{server.RanksJob, "CM_SCHEDULE_RANKS", "0 4 * * 1"},
+	{server.GenresJob, "CM_SCHEDULE_GENRES", "0 3 1 * *"},
+	{server.SavedDataJob, "CM_SCHEDULE_SAVED_DATA", "0 */6 * * *"},
+}
+
+const (
+	scheduleJitterEnv     = "CM_SCHEDULE_JITTER"
+	defaultScheduleJitter = 10 * time.Minute
+)
+
+// schedule specs by job kind, and the random delay added to each run
+type scheduleConfig struct {
+	specs  map[string]string
+	jitter time.Duration
+}
 
-	server.StartServer()
+func (c scheduleConfig) scheduled(kind string) bool {
+	return c.specs[kind] != scheduler.Off
+}
+
+// reads the refresh schedules from the environment, so an invalid one stops the server before any
+// user's server is set up
+func loadSchedules() scheduleConfig {
+	config := scheduleConfig{specs: map[string]string{}, jitter: defaultScheduleJitter}
+	for _, schedule := range refreshSchedules {
+		spec := os.Getenv(schedule.env)
+		if spec == "" {
+			spec = schedule.spec
+		}
+		if spec != scheduler.Off {
+			if _, err := scheduler.Parse(spec); err != nil {
+				log.Fatalf("Invalid %s: %v", schedule.env, err)
+			}
+		}
+		config.specs[schedule.kind] = spec
+	}
+	if jitter := os.Getenv(scheduleJitterEnv); jitter != "" {
+		duration, err := time.ParseDuration(jitter)
+		if err != nil || duration < 0 {
+			log.Fatalf("Invalid %s %q, expected a duration like 10m", scheduleJitterEnv, jitter)
+		}
+		config.jitter = duration
+	}
+	return config
+}
+
+// runs pending migrations from the command line, printing what changed or would change with --dry-run
+func runMigrations(migrator *migrate.Migrator, dryRun bool) {
+	results, err := migrator.Run(context.Background(), dryRun)
+	for _, result := range results {
+		fmt.Printf("Migration %d: %s (%d changes)\n", result.Version, result.Name, len(result.Changes))
+		for _, change := range result.Changes {
+			fmt.Println("  " + change)
+		}
+	}
+	if err != nil {
+		log.Fatal("Failed to run migrations:", err)
+	}
+	if len(results) == 0 {
+		fmt.Println("No pending migrations")
+	} else if dryRun {
+		fmt.Println("Dry run, no changes were made")
+	}
+}
+
+func writeBackup(backupService *backup.Service, path string) {
+	archive, err := backupService.Export(context.Background())
+	if err != nil {
+		log.Fatal("Failed to export backup:", err)
+	}
+	if err := file.WriteJSONFile(path, archive); err != nil {
+		log.Fatal("Failed to write backup:", err)
+	}
+	fmt.Printf("Wrote %d venues, %d artists, %d events and %d albums to %s\n",
+		len(archive.Venues), len(archive.Artists), len(archive.Events), len(archive.Albums), path)
+}
+
+func restoreBackup(backupService *backup.Service, path string, mode backup.Mode) {
+	var archive backup.Archive
+	if err := file.ReadJSONFile(path, &archive); err != nil {
+		log.Fatal("Failed to read backup:", err)
+	}
+	summary, err := backupService.Restore(context.Background(), archive, mode)
+	if err != nil {
+		log.Fatal("Failed to restore backup:", err)
+	}
+	fmt.Printf("Restored %d venues, %d artists, %d events and %d albums from %s in %s mode\n",
+		summary.Venues, summary.Artists, summary.Events, summary.Albums, path, mode)
+}
+
+// imports the albums in a generic or Discogs CSV file, printing the outcome of every row
+func importAlbums(interactor *db.EventRepository, path string, format loader.AlbumFormat, dryRun bool) {
+	albumFile, err := os.Open(path)
+	if err != nil {
+		log.Fatal("Failed to open album file:", err)
+	}
+	defer albumFile.Close()
+
+	cache := &db.Cache{Database: interactor}
+	cache.LoadCaches()
+	albumLoader := &loader.AlbumLoader{Cache: cache}
+	report, err := albumLoader.Import(context.Background(), albumFile, format, dryRun)
+	if err != nil {
+		log.Fatal("Failed to import albums:", err)
+	}
+	for _, row := range report.Rows {
+		line := fmt.Sprintf("Row %d: %s", row.Row, row.Status)
+		if row.Album != nil {
+			line += fmt.Sprintf(" %q", row.Album.Name)
+		}
+		if len(row.NewArtists) > 0 {
+			line += fmt.Sprintf(", new artists %v", row.NewArtists)
+		}
+		if row.Error != "" {
+			line += ", " + row.Error
+		}
+		fmt.Println(line)
+	}
+	fmt.Printf("%d created, %d already existed, %d invalid and %d failed from %s (%s format)\n",
+		report.Created, report.Exists, report.Invalid, report.Failed, path, report.Format)
+	if dryRun {
+		fmt.Println("Dry run, no changes were made")
+	}
 }
@@ -1,8 +1,8 @@
 package main
 
 import (
+	"concert-manager/cmd/internal/setup"
 	"concert-manager/db"
-	"concert-manager/db/firestore"
 	"concert-manager/external/lastfm"
 	"concert-manager/external/spotify"
 	"concert-manager/external/ticketmaster"
@@ -19,7 +19,9 @@ func main() {
 		log.Fatal("Failed to set up logger:", err)
 	}
 
-	dbConnection, err := firestore.Setup()
+	// the TUI works with one user's data at a time, the default user unless --user <id> is passed
+	userID := setup.ArgValue("--user")
+	interactor, err := setup.Database(userID)
 	if err != nil {
 		log.Fatal("Failed to set up database:", err)
 	}
@@ -29,19 +31,6 @@ func main() {
 		spotify.TEST_MODE = true
 	}
 
-	venueClient := &firestore.VenueClient{Connection: dbConnection}
-	artistClient := &firestore.ArtistClient{Connection: dbConnection}
-	eventClient := &firestore.EventClient{
-		Connection:   dbConnection,
-		VenueClient:  venueClient,
-		ArtistClient: artistClient,
-	}
-	interactor := &db.EventRepository{
-		VenueRepo:  venueClient,
-		ArtistRepo: artistClient,
-		EventRepo:  eventClient,
-	}
-
 	savedCache := &db.Cache{}
 	savedCache.Database = interactor
 	savedCache.LoadCaches()
@@ -50,11 +39,12 @@ func main() {
 	eventFinder := finder.NewEventFinder()
 	eventFinder.Ticketmaster = ticketmaster
 
-	spotifyAuth := spotify.NewAuthentication()
+	spotifyAuth := spotify.NewAuthentication(userID)
 	spotifyClient := spotify.NewClient(spotifyAuth)
 	lastFmClient := lastfm.NewClient()
 
 	artistRanksCache := &ranker.ArtistRankCache{
+		UserID:         userID,
 		MusicSvc:       spotifyClient,
 		ArtistProvider: lastFmClient,
 	}
@@ -73,6 +63,7 @@ func main() {
 	}
 
 	upcomingCache := finder.NewUpcomingEventCache()
+	upcomingCache.UserID = userID
 	upcomingCache.Finder = eventFinder
 	upcomingCache.Ranker = eventRanker
 	upcomingCache.SavedDataCache = savedCache
@@ -82,5 +73,10 @@ func main() {
 		log.Fatal("Failed to initialize upcoming events cache:", err)
 	}
 
+	if slices.Contains(os.Args, "--realtime") {
+		log.Info("Starting with real-time sync of database changes")
+		setup.StartRealtimeSync(interactor, savedCache, upcomingCache)
+	}
+
 	tui.Start(savedCache, upcomingCache)
 }
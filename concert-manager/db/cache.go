@@ -3,16 +3,19 @@ package db
 import (
 	"concert-manager/domain"
 	"concert-manager/log"
-	"concert-manager/util"
 	"context"
-	"errors"
+	"fmt"
 	"slices"
 	"sort"
+	"sync"
+	"time"
 )
 
 type Database interface {
 	ListEvents(context.Context) ([]domain.Event, error)
 	AddEvent(context.Context, domain.Event) (domain.Event, error)
+	AddEventWithReferences(context.Context, domain.Event) (domain.Event, error)
+	UpdateEvent(context.Context, domain.Event) (domain.Event, error)
 	DeleteEvent(context.Context, string) error
 	ListArtists(context.Context) ([]domain.Artist, error)
 	AddArtist(context.Context, domain.Artist) (domain.Artist, error)
@@ -24,19 +27,29 @@ type Database interface {
 	DeleteVenue(context.Context, string) error
 	ListAlbums(context.Context) ([]domain.Album, error)
 	AddAlbum(context.Context, domain.Album) (domain.Album, error)
+	AddAlbumWithArtists(context.Context, domain.Album) (domain.Album, error)
 	UpdateAlbum(context.Context, domain.Album) (domain.Album, error)
 	DeleteAlbum(context.Context, string) error
+	ApplyBatch(context.Context, domain.Batch) error
 }
 
+// Cache is safe for concurrent use. Writes hold the lock through the database call so the
+// cache never gets out of order with the database, while reads only wait on in-flight writes.
 type Cache struct {
 	Database    Database
+	SyncService SyncHooks
+	mutex       sync.RWMutex
 	savedEvents []domain.Event
 	artists     []domain.Artist
 	venues      []domain.Venue
 	albums      []domain.Album
+	changes     changeLog
 }
 
 func (c *Cache) LoadCaches() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.resetChanges()
 	log.Info("Initializing saved event cache")
 	savedEvents, err := c.Database.ListEvents(context.Background())
 	if err != nil {
@@ -70,53 +83,64 @@ func (c *Cache) LoadCaches() {
 }
 
 func (c *Cache) RefreshSavedEvents() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 	log.Info("Refreshing saved event cache")
 	savedEvents, err := c.Database.ListEvents(context.Background())
 	if err != nil {
 		return err
 	}
+	recordReload(c, "event", c.savedEvents, savedEvents, func(e domain.Event) string { return e.ID.Primary })
 	c.savedEvents = savedEvents
 	log.Info("Successfully refreshed saved events")
 	return nil
 }
 
 func (c *Cache) RefreshArtists() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 	log.Info("Refreshing artists cache")
 	artists, err := c.Database.ListArtists(context.Background())
 	if err != nil {
 		return err
 	}
+	recordReload(c, "artist", c.artists, artists, func(a domain.Artist) string { return a.ID.Primary })
 	c.artists = artists
 	log.Info("Successfully refreshed artists")
 	return nil
 }
 
 func (c *Cache) RefreshVenues() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 	log.Info("Refreshing venues cache")
 	venues, err := c.Database.ListVenues(context.Background())
 	if err != nil {
 		return err
 	}
+	recordReload(c, "venue", c.venues, venues, func(v domain.Venue) string { return v.ID.Primary })
 	c.venues = venues
 	log.Info("Successfully refreshed venues cache")
 	return nil
 }
 
-func (c Cache) GetSavedEvents() []domain.Event {
+func (c *Cache) GetSavedEvents() []domain.Event {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
 	if c.savedEvents == nil {
 		return []domain.Event{}
 	}
 	return domain.CloneEvents(c.savedEvents)
 }
 
-func (c Cache) GetPassedSavedEvents() []domain.Event {
-	if c.savedEvents == nil {
-		return []domain.Event{}
-	}
+func (c *Cache) GetPassedSavedEvents() []domain.Event {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
 
+	now := time.Now()
 	passedEvents := []domain.Event{}
-	for _, event := range c.GetSavedEvents() {
-		if util.PastDate(event.Date) && !event.Purchased {
+	for _, event := range c.savedEvents {
+		if event.Passed(now) && !event.Purchased {
 			passedEvents = append(passedEvents, domain.CloneEvent(event))
 		}
 	}
@@ -124,6 +148,8 @@ func (c Cache) GetPassedSavedEvents() []domain.Event {
 }
 
 func (c *Cache) AddSavedEvent(event domain.Event) (*domain.Event, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 	log.Debug("Adding saved event to cache", event)
 	existingIdx := slices.IndexFunc(c.savedEvents, event.Equals)
 	if existingIdx >= 0 {
@@ -131,83 +157,124 @@ func (c *Cache) AddSavedEvent(event domain.Event) (*domain.Event, error) {
 		existing := domain.CloneEvent(c.savedEvents[existingIdx])
 		return &existing, nil
 	}
+
+	// artists and the venue not in the cache yet are created in the same write as the event
+	event = domain.CloneEvent(event)
 	if event.MainAct != nil && event.MainAct.Populated() {
-		artist, err := c.AddArtist(*event.MainAct)
-		if err != nil {
-			return nil, err
-		}
-		event.MainAct = artist
+		mainAct := c.cachedArtist(*event.MainAct)
+		event.MainAct = &mainAct
 	}
 	for i, opener := range event.Openers {
-		artist, err := c.AddArtist(opener)
-		if err != nil {
-			return nil, err
-		}
-		event.Openers[i] = *artist
+		event.Openers[i] = c.cachedArtist(opener)
 	}
-	venue, err := c.AddVenue(event.Venue)
-	if err != nil {
-		return nil, err
+	if venueIdx := slices.IndexFunc(c.venues, event.Venue.Equals); venueIdx >= 0 {
+		event.Venue = domain.CloneVenue(c.venues[venueIdx])
 	}
-	event.Venue = *venue
 
-	newEvent, err := c.Database.AddEvent(context.Background(), event)
+	newEvent, err := c.Database.AddEventWithReferences(context.Background(), event)
 	if err != nil {
 		return nil, err
 	}
 
+	for _, artist := range newEvent.Artists() {
+		if artist.Populated() {
+			c.cacheArtist(artist)
+		}
+	}
+	if !slices.ContainsFunc(c.venues, newEvent.Venue.Equals) {
+		c.venues = append(c.venues, domain.CloneVenue(newEvent.Venue))
+		c.recordChange("venue", newEvent.Venue.ID.Primary, ChangeAdded)
+	}
 	c.savedEvents = append(c.savedEvents, newEvent)
+	c.recordChange("event", newEvent.ID.Primary, ChangeAdded)
 	log.Debug("Added saved event to cache", newEvent)
 	return &newEvent, nil
 }
 
 func (c *Cache) UpdateSavedEvent(id string, event domain.Event) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.updateSavedEvent(id, event)
+}
+
+// requires the lock to be held
+func (c *Cache) updateSavedEvent(id string, event domain.Event) error {
 	log.Debugf("Updating event in cache, id=%v, %v", id, event)
 	eventIdx := slices.IndexFunc(c.savedEvents, func(e domain.Event) bool {
 		return e.ID.Primary == id
 	})
 	if eventIdx == -1 {
 		log.Errorf("Unable to find event %v when updating cache", id)
-		return errors.New("event is not cached")
+		return domain.NotFound("event is not cached")
 	}
 
-	event.ID.Primary = id
-	if err := c.Database.DeleteEvent(context.Background(), id); err != nil {
+	if event.MainAct != nil && event.MainAct.Populated() {
+		artist, err := c.addArtist(*event.MainAct)
+		if err != nil {
+			return err
+		}
+		event.MainAct = artist
+	}
+	for i, opener := range event.Openers {
+		artist, err := c.addArtist(opener)
+		if err != nil {
+			return err
+		}
+		event.Openers[i] = *artist
+	}
+	venue, err := c.addVenue(event.Venue)
+	if err != nil {
 		return err
 	}
-	updatedEvent, err := c.Database.AddEvent(context.Background(), event)
+	event.Venue = *venue
+	// performances and sets can name artists that were only just created above
+	event.ResolveArtistRefs()
+
+	event.ID.Primary = id
+	updatedEvent, err := c.Database.UpdateEvent(context.Background(), event)
 	if err != nil {
 		return err
 	}
 
 	c.savedEvents = slices.Replace(c.savedEvents, eventIdx, eventIdx+1, updatedEvent)
+	c.recordChange("event", id, ChangeModified)
+	log.Debug("Updated saved event in cache", updatedEvent)
 	return nil
 }
 
 func (c *Cache) DeleteSavedEvent(id string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 	log.Debug("Deleting saved event from cache", id)
-	eventIdx := slices.IndexFunc(c.savedEvents, func(e domain.Event) bool {
-		return e.ID.Primary == id
-	})
-	if eventIdx == -1 {
+	if !slices.ContainsFunc(c.savedEvents, func(e domain.Event) bool { return e.ID.Primary == id }) {
 		log.Errorf("Unable to find event %v when deleting from cache", id)
-		return errors.New("event is not cached")
+		return domain.NotFound("event is not cached")
 	}
 
-	if err := c.Database.DeleteEvent(context.Background(), id); err != nil {
+	// albums bought at the event outlive it, they are unlinked in the same write that deletes it
+	batch := domain.Batch{}
+	c.batchDeleteEvent(&batch, id)
+	if err := c.applyBatch(batch); err != nil {
 		return err
 	}
-
-	c.savedEvents = slices.Delete(c.savedEvents, eventIdx, eventIdx+1)
 	log.Debug("Deleted saved event from cache", id)
 	return nil
 }
 
-func (c Cache) GetArtists() []domain.Artist {
-	return slices.Clone(c.artists)
+func (c *Cache) GetArtists() []domain.Artist {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return domain.CloneArtists(c.artists)
 }
 
 func (c *Cache) AddArtist(artist domain.Artist) (*domain.Artist, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.addArtist(artist)
+}
+
+// requires the lock to be held
+func (c *Cache) addArtist(artist domain.Artist) (*domain.Artist, error) {
 	log.Debug("Adding artist to cache", artist)
 	existingIdx := slices.IndexFunc(c.artists, artist.Equals)
 	if existingIdx >= 0 {
@@ -222,18 +289,42 @@ func (c *Cache) AddArtist(artist domain.Artist) (*domain.Artist, error) {
 	}
 
 	c.artists = append(c.artists, newArtist)
+	c.recordChange("artist", newArtist.ID.Primary, ChangeAdded)
 	log.Debug("Added artist to cache", newArtist)
 	return &newArtist, nil
 }
 
+// returns the cached copy of the artist, or the artist itself if it hasn't been saved yet
+func (c *Cache) cachedArtist(artist domain.Artist) domain.Artist {
+	if artistIdx := slices.IndexFunc(c.artists, artist.Equals); artistIdx >= 0 {
+		return domain.CloneArtist(c.artists[artistIdx])
+	}
+	return artist
+}
+
+// adds a saved artist to the cache unless it is already there
+func (c *Cache) cacheArtist(artist domain.Artist) {
+	if !slices.ContainsFunc(c.artists, artist.Equals) {
+		c.artists = append(c.artists, domain.CloneArtist(artist))
+		c.recordChange("artist", artist.ID.Primary, ChangeAdded)
+	}
+}
+
 func (c *Cache) UpdateArtist(id string, artist domain.Artist) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.updateArtist(id, artist)
+}
+
+// requires the lock to be held
+func (c *Cache) updateArtist(id string, artist domain.Artist) error {
 	log.Debugf("Updating artist in cache, id=%v, %v", id, artist)
 	artistIdx := slices.IndexFunc(c.artists, func(a domain.Artist) bool {
 		return a.ID.Primary == id
 	})
 	if artistIdx == -1 {
 		log.Errorf("Unable to find artist %v when updating cache", id)
-		return errors.New("artist is not cached")
+		return domain.NotFound("artist is not cached")
 	}
 
 	artist.ID.Primary = id
@@ -243,30 +334,52 @@ func (c *Cache) UpdateArtist(id string, artist domain.Artist) error {
 	}
 
 	c.artists = slices.Replace(c.artists, artistIdx, artistIdx+1, updatedArtist)
+	c.recordChange("artist", id, ChangeModified)
+	c.replaceArtistReferences(updatedArtist)
+	log.Debug("Updated artist in cache", updatedArtist)
+	return nil
+}
 
+// requires the lock to be held, points the saved events at the updated artist
+func (c *Cache) replaceArtistReferences(artist domain.Artist) {
 	for i, event := range c.savedEvents {
-		if event.MainAct != nil && event.MainAct.ID.Primary == id {
-			c.savedEvents[i].MainAct = &updatedArtist
+		replaced := false
+		if event.MainAct != nil && event.MainAct.ID.Primary == artist.ID.Primary {
+			mainAct := domain.CloneArtist(artist)
+			c.savedEvents[i].MainAct = &mainAct
+			replaced = true
 		}
 		for j, opener := range event.Openers {
-			if opener.ID.Primary == id {
-				c.savedEvents[i].Openers[j] = updatedArtist
+			if opener.ID.Primary == artist.ID.Primary {
+				c.savedEvents[i].Openers[j] = domain.CloneArtist(artist)
+				replaced = true
 			}
 		}
+		if replaced {
+			c.recordChange("event", event.ID.Primary, ChangeModified)
+		}
 	}
-
-	log.Debug("Updated artist in cache", updatedArtist)
-	return nil
 }
 
 func (c *Cache) DeleteArtist(id string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.deleteArtist(id)
+}
+
+// requires the lock to be held
+func (c *Cache) deleteArtist(id string) error {
 	log.Debug("Deleting artist from cache", id)
 	artistIdx := slices.IndexFunc(c.artists, func(a domain.Artist) bool {
 		return a.ID.Primary == id
 	})
 	if artistIdx == -1 {
 		log.Errorf("Unable to find artist %v when deleting from cache", id)
-		return errors.New("artist is not cached")
+		return domain.NotFound("artist is not cached")
+	}
+	if refs := c.findArtistReferences(id); !refs.Empty() {
+		log.Errorf("Unable to delete artist %v because it is still referenced by %+v", id, refs)
+		return domain.ReferencedError{Kind: "artist", ID: id, References: refs}
 	}
 
 	if err := c.Database.DeleteArtist(context.Background(), id); err != nil {
@@ -274,15 +387,204 @@ func (c *Cache) DeleteArtist(id string) error {
 	}
 
 	c.artists = slices.Delete(c.artists, artistIdx, artistIdx+1)
+	c.recordChange("artist", id, ChangeRemoved)
 	log.Debug("Deleted artist from cache", id)
 	return nil
 }
 
-func (c Cache) GetVenues() []domain.Venue {
+func (c *Cache) FindArtistReferences(id string) domain.References {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.findArtistReferences(id)
+}
+
+// requires the lock to be held
+func (c *Cache) findArtistReferences(id string) domain.References {
+	refs := domain.References{Events: []string{}, Albums: []string{}}
+	for _, event := range c.savedEvents {
+		if slices.ContainsFunc(event.Artists(), func(a domain.Artist) bool { return a.ID.Primary == id }) {
+			refs.Events = append(refs.Events, event.ID.Primary)
+		}
+	}
+	for _, album := range c.albums {
+		if slices.ContainsFunc(album.Artists, func(a domain.Artist) bool { return a.ID.Primary == id }) {
+			refs.Albums = append(refs.Albums, album.ID)
+		}
+	}
+	return refs
+}
+
+// DeleteArtistCascade deletes every saved event the artist headlines and removes the artist from
+// the lineup, performances and festival sets of the other events it plays at. It also removes the
+// artist from its albums, deleting any album left without artists, and then deletes the artist.
+// Everything is written as one batch, so on failure nothing is changed.
+// The returned references are the events and albums that were changed or deleted.
+func (c *Cache) DeleteArtistCascade(id string) (domain.References, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	log.Debug("Cascading delete of artist from cache", id)
+	if !slices.ContainsFunc(c.artists, func(a domain.Artist) bool { return a.ID.Primary == id }) {
+		log.Errorf("Unable to find artist %v when deleting from cache", id)
+		return domain.References{}, domain.NotFound("artist is not cached")
+	}
+
+	refs := c.findArtistReferences(id)
+	batch := domain.Batch{DeleteArtists: []string{id}}
+	for _, eventID := range refs.Events {
+		eventIdx := slices.IndexFunc(c.savedEvents, func(e domain.Event) bool { return e.ID.Primary == eventID })
+		event := domain.CloneEvent(c.savedEvents[eventIdx])
+		if event.MainAct != nil && event.MainAct.ID.Primary == id {
+			c.batchDeleteEvent(&batch, eventID)
+			continue
+		}
+		// the rest of the event, like its rating and ticket, is kept without the opener
+		event.Openers = slices.DeleteFunc(event.Openers, func(a domain.Artist) bool { return a.ID.Primary == id })
+		event.Performances = slices.DeleteFunc(event.Performances, func(p domain.Performance) bool { return p.ArtistID == id })
+		if event.Festival != nil {
+			for i := range event.Festival.Days {
+				day := &event.Festival.Days[i]
+				day.Sets = slices.DeleteFunc(day.Sets, func(s domain.Set) bool { return s.ArtistID == id })
+			}
+		}
+		batch.UpdateEvents = append(batch.UpdateEvents, event)
+	}
+	for _, albumID := range refs.Albums {
+		album := c.batchedAlbum(&batch, albumID)
+		album.Artists = slices.DeleteFunc(album.Artists, func(a domain.Artist) bool { return a.ID.Primary == id })
+		if len(album.Artists) == 0 {
+			batch.UpdateAlbums = slices.DeleteFunc(batch.UpdateAlbums, func(a domain.Album) bool { return a.ID == albumID })
+			batch.DeleteAlbums = append(batch.DeleteAlbums, albumID)
+		}
+	}
+	return refs, c.applyBatch(batch)
+}
+
+// DeleteArtistReassign points every saved event and album using the artist at the
+// replacement artist instead and then deletes the artist, all as one batch.
+// The returned references are the events and albums that were changed.
+func (c *Cache) DeleteArtistReassign(id string, replacementID string) (domain.References, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	log.Debugf("Reassigning artist %v to %v before deleting from cache", id, replacementID)
+	if id == replacementID {
+		return domain.References{}, domain.Invalid("artist cannot be reassigned to itself")
+	}
+	replacementIdx := slices.IndexFunc(c.artists, func(a domain.Artist) bool {
+		return a.ID.Primary == replacementID
+	})
+	if replacementIdx == -1 {
+		log.Errorf("Unable to find replacement artist %v when reassigning %v", replacementID, id)
+		return domain.References{}, domain.NotFound("replacement artist is not cached")
+	}
+	if !slices.ContainsFunc(c.artists, func(a domain.Artist) bool { return a.ID.Primary == id }) {
+		log.Errorf("Unable to find artist %v when deleting from cache", id)
+		return domain.References{}, domain.NotFound("artist is not cached")
+	}
+
+	batch := domain.Batch{DeleteArtists: []string{id}}
+	refs := c.reassignArtist(&batch, id, c.artists[replacementIdx])
+	return refs, c.applyBatch(batch)
+}
+
+// requires the lock to be held, adds the writes pointing every saved event and album
+// using the artist at the replacement to the batch
+func (c *Cache) reassignArtist(batch *domain.Batch, id string, replacement domain.Artist) domain.References {
+	refs := c.findArtistReferences(id)
+	for _, eventID := range refs.Events {
+		eventIdx := slices.IndexFunc(c.savedEvents, func(e domain.Event) bool { return e.ID.Primary == eventID })
+		event := domain.CloneEvent(c.savedEvents[eventIdx])
+		if event.MainAct != nil && event.MainAct.ID.Primary == id {
+			mainAct := domain.CloneArtist(replacement)
+			event.MainAct = &mainAct
+		}
+		openers := []domain.Artist{}
+		for _, opener := range event.Openers {
+			if opener.ID.Primary == id {
+				opener = domain.CloneArtist(replacement)
+			}
+			mainAct := event.MainAct != nil && event.MainAct.Equals(opener)
+			if !mainAct && !slices.ContainsFunc(openers, opener.Equals) {
+				openers = append(openers, opener)
+			}
+		}
+		event.Openers = openers
+		// a performance already rated for the replacement is kept over the reassigned one
+		performances := []domain.Performance{}
+		for _, performance := range event.Performances {
+			if performance.ArtistID == id {
+				performance.ArtistID = replacement.ID.Primary
+			}
+			if !slices.ContainsFunc(performances, func(p domain.Performance) bool { return p.ArtistID == performance.ArtistID }) {
+				performances = append(performances, performance)
+			}
+		}
+		event.Performances = performances
+		if event.Festival != nil {
+			event.Festival.Sets(func(set *domain.Set) {
+				if set.ArtistID == id {
+					set.ArtistID = replacement.ID.Primary
+				}
+			})
+		}
+		batch.UpdateEvents = append(batch.UpdateEvents, event)
+	}
+	for _, albumID := range refs.Albums {
+		album := c.batchedAlbum(batch, albumID)
+		artists := []domain.Artist{}
+		for _, artist := range album.Artists {
+			if artist.ID.Primary == id {
+				artist = domain.CloneArtist(replacement)
+			}
+			if !slices.ContainsFunc(artists, artist.Equals) {
+				artists = append(artists, artist)
+			}
+		}
+		album.Artists = artists
+	}
+	return refs
+}
+
+// MergeArtists folds the duplicate source artist into the survivor by combining their IDs and
+// genres, moving every saved event and album over to the survivor and deleting the source,
+// all as one batch. The returned references are the events and albums that were changed.
+func (c *Cache) MergeArtists(survivorID string, sourceID string) (*domain.Artist, domain.References, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	log.Debugf("Merging artist %v into %v in cache", sourceID, survivorID)
+	if survivorID == sourceID {
+		return nil, domain.References{}, domain.Invalid("artist cannot be merged into itself")
+	}
+	survivorIdx := slices.IndexFunc(c.artists, func(a domain.Artist) bool { return a.ID.Primary == survivorID })
+	sourceIdx := slices.IndexFunc(c.artists, func(a domain.Artist) bool { return a.ID.Primary == sourceID })
+	if survivorIdx == -1 || sourceIdx == -1 {
+		log.Errorf("Unable to find artists %v and %v when merging in cache", survivorID, sourceID)
+		return nil, domain.References{}, domain.NotFound("artist is not cached")
+	}
+
+	merged := domain.MergeArtists(c.artists[survivorIdx], c.artists[sourceIdx])
+	batch := domain.Batch{UpdateArtists: []domain.Artist{merged}, DeleteArtists: []string{sourceID}}
+	refs := c.reassignArtist(&batch, sourceID, merged)
+	if err := c.applyBatch(batch); err != nil {
+		return nil, refs, err
+	}
+	log.Debug("Merged artist in cache", merged)
+	return &merged, refs, nil
+}
+
+func (c *Cache) GetVenues() []domain.Venue {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
 	return domain.CloneVenues(c.venues)
 }
 
 func (c *Cache) AddVenue(venue domain.Venue) (*domain.Venue, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.addVenue(venue)
+}
+
+// requires the lock to be held
+func (c *Cache) addVenue(venue domain.Venue) (*domain.Venue, error) {
 	log.Debug("Adding venue to cache", venue)
 	existingIdx := slices.IndexFunc(c.venues, venue.Equals)
 	if existingIdx >= 0 {
@@ -297,18 +599,26 @@ func (c *Cache) AddVenue(venue domain.Venue) (*domain.Venue, error) {
 	}
 
 	c.venues = append(c.venues, newVenue)
+	c.recordChange("venue", newVenue.ID.Primary, ChangeAdded)
 	log.Debug("Added venue to cache", newVenue)
 	return &newVenue, nil
 }
 
 func (c *Cache) UpdateVenue(id string, venue domain.Venue) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.updateVenue(id, venue)
+}
+
+// requires the lock to be held
+func (c *Cache) updateVenue(id string, venue domain.Venue) error {
 	log.Debugf("Updating venue in cache, id=%v, %v", id, venue)
 	venueIdx := slices.IndexFunc(c.venues, func(a domain.Venue) bool {
 		return a.ID.Primary == id
 	})
 	if venueIdx == -1 {
 		log.Errorf("Unable to find venue %v when updating cache", id)
-		return errors.New("venue is not cached")
+		return domain.NotFound("venue is not cached")
 	}
 
 	venue.ID.Primary = id
@@ -318,25 +628,41 @@ func (c *Cache) UpdateVenue(id string, venue domain.Venue) error {
 	}
 
 	c.venues = slices.Replace(c.venues, venueIdx, venueIdx+1, updatedVenue)
+	c.recordChange("venue", id, ChangeModified)
+	c.replaceVenueReferences(updatedVenue)
+	log.Debug("Updated venue in cache", updatedVenue)
+	return nil
+}
 
+// requires the lock to be held, points the saved events at the updated venue
+func (c *Cache) replaceVenueReferences(venue domain.Venue) {
 	for i, event := range c.savedEvents {
-		if event.Venue.ID.Primary == id {
-			c.savedEvents[i].Venue = updatedVenue
+		if event.Venue.ID.Primary == venue.ID.Primary {
+			c.savedEvents[i].Venue = domain.CloneVenue(venue)
+			c.recordChange("event", event.ID.Primary, ChangeModified)
 		}
 	}
-
-	log.Debug("Updated venue in cache", updatedVenue)
-	return nil
 }
 
 func (c *Cache) DeleteVenue(id string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.deleteVenue(id)
+}
+
+// requires the lock to be held
+func (c *Cache) deleteVenue(id string) error {
 	log.Debug("Deleting venue from cache", id)
 	venueIdx := slices.IndexFunc(c.venues, func(v domain.Venue) bool {
 		return v.ID.Primary == id
 	})
 	if venueIdx == -1 {
 		log.Errorf("Unable to find venue %v when deleting from cache", id)
-		return errors.New("venue is not cached")
+		return domain.NotFound("venue is not cached")
+	}
+	if refs := c.findVenueReferences(id); !refs.Empty() {
+		log.Errorf("Unable to delete venue %v because it is still referenced by %+v", id, refs)
+		return domain.ReferencedError{Kind: "venue", ID: id, References: refs}
 	}
 
 	if err := c.Database.DeleteVenue(context.Background(), id); err != nil {
@@ -344,58 +670,184 @@ func (c *Cache) DeleteVenue(id string) error {
 	}
 
 	c.venues = slices.Delete(c.venues, venueIdx, venueIdx+1)
+	c.recordChange("venue", id, ChangeRemoved)
 	log.Debug("Deleted venue from cache", id)
 	return nil
 }
 
+func (c *Cache) FindVenueReferences(id string) domain.References {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.findVenueReferences(id)
+}
+
+// requires the lock to be held
+func (c *Cache) findVenueReferences(id string) domain.References {
+	refs := domain.References{Events: []string{}, Albums: []string{}}
+	for _, event := range c.savedEvents {
+		if event.Venue.ID.Primary == id {
+			refs.Events = append(refs.Events, event.ID.Primary)
+		}
+	}
+	return refs
+}
+
+// DeleteVenueCascade deletes every saved event at the venue and then deletes the venue,
+// all as one batch. The returned references are the events that were deleted.
+func (c *Cache) DeleteVenueCascade(id string) (domain.References, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	log.Debug("Cascading delete of venue from cache", id)
+	if !slices.ContainsFunc(c.venues, func(v domain.Venue) bool { return v.ID.Primary == id }) {
+		log.Errorf("Unable to find venue %v when deleting from cache", id)
+		return domain.References{}, domain.NotFound("venue is not cached")
+	}
+
+	refs := c.findVenueReferences(id)
+	batch := domain.Batch{DeleteVenues: []string{id}}
+	for _, eventID := range refs.Events {
+		c.batchDeleteEvent(&batch, eventID)
+	}
+	return refs, c.applyBatch(batch)
+}
+
+// DeleteVenueReassign moves every saved event at the venue to the replacement venue and then
+// deletes the venue, all as one batch. The returned references are the events that were changed.
+func (c *Cache) DeleteVenueReassign(id string, replacementID string) (domain.References, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	log.Debugf("Reassigning venue %v to %v before deleting from cache", id, replacementID)
+	if id == replacementID {
+		return domain.References{}, domain.Invalid("venue cannot be reassigned to itself")
+	}
+	replacementIdx := slices.IndexFunc(c.venues, func(v domain.Venue) bool {
+		return v.ID.Primary == replacementID
+	})
+	if replacementIdx == -1 {
+		log.Errorf("Unable to find replacement venue %v when reassigning %v", replacementID, id)
+		return domain.References{}, domain.NotFound("replacement venue is not cached")
+	}
+	if !slices.ContainsFunc(c.venues, func(v domain.Venue) bool { return v.ID.Primary == id }) {
+		log.Errorf("Unable to find venue %v when deleting from cache", id)
+		return domain.References{}, domain.NotFound("venue is not cached")
+	}
+
+	batch := domain.Batch{DeleteVenues: []string{id}}
+	refs := c.reassignVenue(&batch, id, c.venues[replacementIdx])
+	return refs, c.applyBatch(batch)
+}
+
+// requires the lock to be held, adds the writes moving every saved event at the venue
+// to the replacement to the batch
+func (c *Cache) reassignVenue(batch *domain.Batch, id string, replacement domain.Venue) domain.References {
+	refs := c.findVenueReferences(id)
+	for _, eventID := range refs.Events {
+		eventIdx := slices.IndexFunc(c.savedEvents, func(e domain.Event) bool { return e.ID.Primary == eventID })
+		event := domain.CloneEvent(c.savedEvents[eventIdx])
+		event.Venue = domain.CloneVenue(replacement)
+		batch.UpdateEvents = append(batch.UpdateEvents, event)
+	}
+	return refs
+}
+
+// MergeVenues folds the duplicate source venue into the survivor by combining their IDs,
+// moving every saved event over to the survivor and deleting the source, all as one batch.
+// The returned references are the events that were changed.
+func (c *Cache) MergeVenues(survivorID string, sourceID string) (*domain.Venue, domain.References, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	log.Debugf("Merging venue %v into %v in cache", sourceID, survivorID)
+	if survivorID == sourceID {
+		return nil, domain.References{}, domain.Invalid("venue cannot be merged into itself")
+	}
+	survivorIdx := slices.IndexFunc(c.venues, func(v domain.Venue) bool { return v.ID.Primary == survivorID })
+	sourceIdx := slices.IndexFunc(c.venues, func(v domain.Venue) bool { return v.ID.Primary == sourceID })
+	if survivorIdx == -1 || sourceIdx == -1 {
+		log.Errorf("Unable to find venues %v and %v when merging in cache", survivorID, sourceID)
+		return nil, domain.References{}, domain.NotFound("venue is not cached")
+	}
+
+	merged := domain.MergeVenues(c.venues[survivorIdx], c.venues[sourceIdx])
+	batch := domain.Batch{UpdateVenues: []domain.Venue{merged}, DeleteVenues: []string{sourceID}}
+	refs := c.reassignVenue(&batch, sourceID, merged)
+	if err := c.applyBatch(batch); err != nil {
+		return nil, refs, err
+	}
+	log.Debug("Merged venue in cache", merged)
+	return &merged, refs, nil
+}
+
 func (c *Cache) RefreshAlbums() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 	log.Info("Refreshing albums cache")
 	albums, err := c.Database.ListAlbums(context.Background())
 	if err != nil {
 		return err
 	}
+	recordReload(c, "album", c.albums, albums, func(a domain.Album) string { return a.ID })
 	c.albums = albums
 	log.Info("Successfully refreshed albums")
 	return nil
 }
 
-func (c Cache) GetAlbums() []domain.Album {
-	if c.albums == nil {
-		return []domain.Album{}
+func (c *Cache) GetAlbums() []domain.Album {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	albums := []domain.Album{}
+	for _, album := range c.albums {
+		albums = append(albums, domain.CloneAlbum(album))
 	}
-	return slices.Clone(c.albums)
+	return albums
 }
 
 func (c *Cache) AddAlbum(album domain.Album) (*domain.Album, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 	log.Debug("Adding album to cache", album)
+	if err := c.checkAlbumEvent(album); err != nil {
+		return nil, err
+	}
+	// artists not in the cache yet are created in the same write as the album
+	album = domain.CloneAlbum(album)
 	for i, artist := range album.Artists {
-		savedArtist, err := c.AddArtist(artist)
-		if err != nil {
-			return nil, err
-		}
-		album.Artists[i] = *savedArtist
+		album.Artists[i] = c.cachedArtist(artist)
 	}
-	newAlbum, err := c.Database.AddAlbum(context.Background(), album)
+	newAlbum, err := c.Database.AddAlbumWithArtists(context.Background(), album)
 	if err != nil {
 		return nil, err
 	}
+	for _, artist := range newAlbum.Artists {
+		c.cacheArtist(artist)
+	}
 	c.albums = append(c.albums, newAlbum)
+	c.recordChange("album", newAlbum.ID, ChangeAdded)
 	log.Debug("Added album to cache", newAlbum)
 	return &newAlbum, nil
 }
 
 func (c *Cache) UpdateAlbum(id string, album domain.Album) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.updateAlbum(id, album)
+}
+
+// requires the lock to be held
+func (c *Cache) updateAlbum(id string, album domain.Album) error {
 	log.Debugf("Updating album in cache, id=%v, %v", id, album)
 	albumIdx := slices.IndexFunc(c.albums, func(r domain.Album) bool {
 		return r.ID == id
 	})
 	if albumIdx == -1 {
 		log.Errorf("Unable to find album %v when updating cache", id)
-		return errors.New("album is not cached")
+		return domain.NotFound("album is not cached")
+	}
+	if err := c.checkAlbumEvent(album); err != nil {
+		return err
 	}
 
 	for i, artist := range album.Artists {
-		savedArtist, err := c.AddArtist(artist)
+		savedArtist, err := c.addArtist(artist)
 		if err != nil {
 			return err
 		}
@@ -408,18 +860,39 @@ func (c *Cache) UpdateAlbum(id string, album domain.Album) error {
 	}
 
 	c.albums = slices.Replace(c.albums, albumIdx, albumIdx+1, updatedAlbum)
+	c.recordChange("album", id, ChangeModified)
 	log.Debug("Updated album in cache", updatedAlbum)
 	return nil
 }
 
+// requires the lock to be held, an album can only be linked to a saved event
+func (c *Cache) checkAlbumEvent(album domain.Album) error {
+	if album.EventID == "" {
+		return nil
+	}
+	if !slices.ContainsFunc(c.savedEvents, func(e domain.Event) bool { return e.ID.Primary == album.EventID }) {
+		log.Errorf("Unable to find event %v linked to album %v", album.EventID, album.Name)
+		message := fmt.Sprintf("event with ID %s not found", album.EventID)
+		return domain.InvalidFields(domain.FieldError{Field: "eventId", Message: message})
+	}
+	return nil
+}
+
 func (c *Cache) DeleteAlbum(id string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.deleteAlbum(id)
+}
+
+// requires the lock to be held
+func (c *Cache) deleteAlbum(id string) error {
 	log.Debug("Deleting album from cache", id)
 	albumIdx := slices.IndexFunc(c.albums, func(r domain.Album) bool {
 		return r.ID == id
 	})
 	if albumIdx == -1 {
 		log.Errorf("Unable to find album %v when deleting from cache", id)
-		return errors.New("album is not cached")
+		return domain.NotFound("album is not cached")
 	}
 
 	if err := c.Database.DeleteAlbum(context.Background(), id); err != nil {
@@ -427,11 +900,90 @@ func (c *Cache) DeleteAlbum(id string) error {
 	}
 
 	c.albums = slices.Delete(c.albums, albumIdx, albumIdx+1)
+	c.recordChange("album", id, ChangeRemoved)
 	log.Debug("Deleted album from cache", id)
 	return nil
 }
 
-func (c Cache) GetUniqueGenres() domain.GenreResponse {
+// requires the lock to be held, adds deleting the event to the batch along with unlinking
+// the albums bought at it, which outlive the event
+func (c *Cache) batchDeleteEvent(batch *domain.Batch, id string) {
+	batch.DeleteEvents = append(batch.DeleteEvents, id)
+	for _, album := range c.albums {
+		if album.EventID == id {
+			c.batchedAlbum(batch, album.ID).EventID = ""
+		}
+	}
+}
+
+// requires the lock to be held, returns the album as it will be written by the batch, adding
+// the cached album to the batch's updates the first time. The pointer is only valid until the
+// batch is changed again.
+func (c *Cache) batchedAlbum(batch *domain.Batch, id string) *domain.Album {
+	if idx := slices.IndexFunc(batch.UpdateAlbums, func(a domain.Album) bool { return a.ID == id }); idx >= 0 {
+		return &batch.UpdateAlbums[idx]
+	}
+	albumIdx := slices.IndexFunc(c.albums, func(a domain.Album) bool { return a.ID == id })
+	batch.UpdateAlbums = append(batch.UpdateAlbums, domain.CloneAlbum(c.albums[albumIdx]))
+	return &batch.UpdateAlbums[len(batch.UpdateAlbums)-1]
+}
+
+// requires the lock to be held, writes the batch to the database as one atomic write and
+// only then applies it to the cache, so a failed write leaves both unchanged
+func (c *Cache) applyBatch(batch domain.Batch) error {
+	if err := c.Database.ApplyBatch(context.Background(), batch); err != nil {
+		return err
+	}
+
+	for _, venue := range batch.UpdateVenues {
+		if venueIdx := slices.IndexFunc(c.venues, func(v domain.Venue) bool { return v.ID.Primary == venue.ID.Primary }); venueIdx >= 0 {
+			c.venues[venueIdx] = domain.CloneVenue(venue)
+		}
+		c.recordChange("venue", venue.ID.Primary, ChangeModified)
+		c.replaceVenueReferences(venue)
+	}
+	for _, artist := range batch.UpdateArtists {
+		if artistIdx := slices.IndexFunc(c.artists, func(a domain.Artist) bool { return a.ID.Primary == artist.ID.Primary }); artistIdx >= 0 {
+			c.artists[artistIdx] = domain.CloneArtist(artist)
+		}
+		c.recordChange("artist", artist.ID.Primary, ChangeModified)
+		c.replaceArtistReferences(artist)
+	}
+	for _, event := range batch.UpdateEvents {
+		if eventIdx := slices.IndexFunc(c.savedEvents, func(e domain.Event) bool { return e.ID.Primary == event.ID.Primary }); eventIdx >= 0 {
+			c.savedEvents[eventIdx] = domain.CloneEvent(event)
+		}
+		c.recordChange("event", event.ID.Primary, ChangeModified)
+	}
+	for _, album := range batch.UpdateAlbums {
+		if albumIdx := slices.IndexFunc(c.albums, func(a domain.Album) bool { return a.ID == album.ID }); albumIdx >= 0 {
+			c.albums[albumIdx] = domain.CloneAlbum(album)
+		}
+		c.recordChange("album", album.ID, ChangeModified)
+	}
+	for _, id := range batch.DeleteEvents {
+		c.savedEvents = slices.DeleteFunc(c.savedEvents, func(e domain.Event) bool { return e.ID.Primary == id })
+		c.recordChange("event", id, ChangeRemoved)
+	}
+	for _, id := range batch.DeleteAlbums {
+		c.albums = slices.DeleteFunc(c.albums, func(a domain.Album) bool { return a.ID == id })
+		c.recordChange("album", id, ChangeRemoved)
+	}
+	for _, id := range batch.DeleteArtists {
+		c.artists = slices.DeleteFunc(c.artists, func(a domain.Artist) bool { return a.ID.Primary == id })
+		c.recordChange("artist", id, ChangeRemoved)
+	}
+	for _, id := range batch.DeleteVenues {
+		c.venues = slices.DeleteFunc(c.venues, func(v domain.Venue) bool { return v.ID.Primary == id })
+		c.recordChange("venue", id, ChangeRemoved)
+	}
+	log.Debugf("Applied batch to cache %+v", batch)
+	return nil
+}
+
+func (c *Cache) GetUniqueGenres() domain.GenreResponse {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
 	userGenres := make(map[string]bool)
 	spotifyGenres := make(map[string]bool)
 	lastFmGenres := make(map[string]bool)
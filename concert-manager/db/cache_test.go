@@ -0,0 +1,656 @@
+package db
+
+import (
+	"concert-manager/domain"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestCache() *Cache {
+	cache := &Cache{Database: NewMemoryRepository()}
+	cache.LoadCaches()
+	return cache
+}
+
+func testEvent() domain.Event {
+	return domain.Event{
+		MainAct: &domain.Artist{Name: "Main"},
+		Openers: []domain.Artist{{Name: "Opener"}},
+		Venue:   domain.Venue{Name: "The Earl", City: "Atlanta", State: "GA"},
+		Date:    domain.MustParseDate("3/14/2024"),
+	}
+}
+
+func TestAddSavedEvent(t *testing.T) {
+	cache := newTestCache()
+
+	saved, err := cache.AddSavedEvent(testEvent())
+	if err != nil {
+		t.Fatalf("failed to add event: %v", err)
+	}
+	if saved.ID.Primary == "" || saved.MainAct.ID.Primary == "" || saved.Venue.ID.Primary == "" {
+		t.Errorf("expected IDs to be populated, got %+v", saved)
+	}
+	if len(cache.GetArtists()) != 2 || len(cache.GetVenues()) != 1 || len(cache.GetSavedEvents()) != 1 {
+		t.Errorf("unexpected cache sizes: %d artists, %d venues, %d events",
+			len(cache.GetArtists()), len(cache.GetVenues()), len(cache.GetSavedEvents()))
+	}
+
+	if err := cache.RefreshSavedEvents(); err != nil {
+		t.Fatalf("failed to refresh events: %v", err)
+	}
+	events := cache.GetSavedEvents()
+	if len(events) != 1 || events[0].Openers[0].Name != "Opener" {
+		t.Errorf("unexpected events after refresh %+v", events)
+	}
+}
+
+func TestUpdateArtistUpdatesSavedEvents(t *testing.T) {
+	cache := newTestCache()
+	saved, _ := cache.AddSavedEvent(testEvent())
+
+	artist := *saved.MainAct
+	artist.Name = "Renamed"
+	if err := cache.UpdateArtist(artist.ID.Primary, artist); err != nil {
+		t.Fatalf("failed to update artist: %v", err)
+	}
+	if name := cache.GetSavedEvents()[0].MainAct.Name; name != "Renamed" {
+		t.Errorf("expected cached event to use the renamed artist, got %s", name)
+	}
+}
+
+func TestUpdateSavedEventInPlace(t *testing.T) {
+	cache := newTestCache()
+	saved, _ := cache.AddSavedEvent(testEvent())
+
+	update := domain.CloneEvent(*saved)
+	update.Openers = append(update.Openers, domain.Artist{Name: "Second Opener"})
+	update.Venue = domain.Venue{Name: "Terminal West", City: "Atlanta", State: "GA"}
+	update.Date = domain.MustParseDate("3/15/2024")
+	update.Purchased = true
+	if err := cache.UpdateSavedEvent(saved.ID.Primary, update); err != nil {
+		t.Fatalf("failed to update event: %v", err)
+	}
+
+	if err := cache.RefreshSavedEvents(); err != nil {
+		t.Fatalf("failed to refresh events: %v", err)
+	}
+	events := cache.GetSavedEvents()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	got := events[0]
+	if got.ID.Primary != saved.ID.Primary {
+		t.Errorf("expected event ID %s to be kept, got %s", saved.ID.Primary, got.ID.Primary)
+	}
+	if len(got.Openers) != 2 || got.Openers[1].ID.Primary == "" {
+		t.Errorf("expected new opener to be saved, got %+v", got.Openers)
+	}
+	if got.Venue.Name != "Terminal West" || got.Date != domain.MustParseDate("3/15/2024") || !got.Purchased {
+		t.Errorf("unexpected updated event %+v", got)
+	}
+}
+
+func TestUpdateSavedEventNotCached(t *testing.T) {
+	cache := newTestCache()
+	if err := cache.UpdateSavedEvent("missing", testEvent()); err == nil {
+		t.Error("expected error updating an event that does not exist")
+	}
+}
+
+func TestDeleteReferencedArtist(t *testing.T) {
+	cache := newTestCache()
+	saved, _ := cache.AddSavedEvent(testEvent())
+
+	err := cache.DeleteArtist(saved.MainAct.ID.Primary)
+	refErr, ok := err.(domain.ReferencedError)
+	if !ok {
+		t.Fatalf("expected referenced error, got %v", err)
+	}
+	if len(refErr.References.Events) != 1 || refErr.References.Events[0] != saved.ID.Primary {
+		t.Errorf("unexpected references %+v", refErr.References)
+	}
+	if len(cache.GetArtists()) != 2 {
+		t.Errorf("expected artist to be kept, got %+v", cache.GetArtists())
+	}
+}
+
+func TestDeleteArtistCascade(t *testing.T) {
+	cache := newTestCache()
+	saved, _ := cache.AddSavedEvent(testEvent())
+	opener := saved.Openers[0]
+	cache.AddAlbum(domain.Album{Name: "Solo", Artists: []domain.Artist{opener}})
+	cache.AddAlbum(domain.Album{Name: "Split", Artists: []domain.Artist{opener, *saved.MainAct}})
+
+	refs, err := cache.DeleteArtistCascade(saved.MainAct.ID.Primary)
+	if err != nil {
+		t.Fatalf("failed to cascade delete: %v", err)
+	}
+	if len(refs.Events) != 1 || len(refs.Albums) != 1 {
+		t.Errorf("unexpected references %+v", refs)
+	}
+	if len(cache.GetSavedEvents()) != 0 {
+		t.Errorf("expected the headliner's event to be deleted, got %+v", cache.GetSavedEvents())
+	}
+
+	if _, err := cache.DeleteArtistCascade(opener.ID.Primary); err != nil {
+		t.Fatalf("failed to cascade delete: %v", err)
+	}
+	albums := cache.GetAlbums()
+	if len(albums) != 0 {
+		t.Errorf("expected the albums left without artists to be deleted, got %+v", albums)
+	}
+	if len(cache.GetArtists()) != 0 {
+		t.Errorf("expected the artists to be deleted, got %+v", cache.GetArtists())
+	}
+}
+
+func TestDeleteArtistCascadeKeepsOpenedEvents(t *testing.T) {
+	cache := newTestCache()
+	event := testEvent()
+	event.Festival = &domain.Festival{Name: "Fest", EndDate: domain.MustParseDate("3/15/2024"), Days: []domain.FestivalDay{{Date: event.Date}}}
+	saved, _ := cache.AddSavedEvent(event)
+	mainActID, openerID := saved.MainAct.ID.Primary, saved.Openers[0].ID.Primary
+	saved.Rating = 5
+	saved.Notes = "great night"
+	saved.Performances = []domain.Performance{{ArtistID: mainActID, Rating: 5}, {ArtistID: openerID, Rating: 3}}
+	saved.Festival.Days[0].Sets = []domain.Set{{ArtistID: mainActID}, {ArtistID: openerID}}
+	if err := cache.UpdateSavedEvent(saved.ID.Primary, *saved); err != nil {
+		t.Fatalf("failed to update event: %v", err)
+	}
+
+	refs, err := cache.DeleteArtistCascade(openerID)
+	if err != nil {
+		t.Fatalf("failed to cascade delete: %v", err)
+	}
+	if len(refs.Events) != 1 {
+		t.Errorf("unexpected references %+v", refs)
+	}
+	events := cache.GetSavedEvents()
+	if len(events) != 1 {
+		t.Fatalf("expected the event the artist opened to be kept, got %+v", events)
+	}
+	kept := events[0]
+	if len(kept.Openers) != 0 || kept.MainAct.ID.Primary != mainActID || kept.Rating != 5 || kept.Notes != "great night" {
+		t.Errorf("expected only the opener to be removed, got %+v", kept)
+	}
+	if len(kept.Performances) != 1 || kept.Performances[0].ArtistID != mainActID {
+		t.Errorf("expected the opener's performance to be removed, got %+v", kept.Performances)
+	}
+	if sets := kept.Festival.Days[0].Sets; len(sets) != 1 || sets[0].ArtistID != mainActID {
+		t.Errorf("expected the opener's set to be removed, got %+v", sets)
+	}
+	if len(cache.GetArtists()) != 1 {
+		t.Errorf("expected the opener to be deleted, got %+v", cache.GetArtists())
+	}
+}
+
+func TestAddSavedEventResolvesNewArtistRefs(t *testing.T) {
+	cache := newTestCache()
+	event := testEvent()
+	event.Festival = &domain.Festival{Name: "Fest", EndDate: domain.MustParseDate("3/15/2024"), Days: []domain.FestivalDay{
+		{Date: event.Date, Sets: []domain.Set{{ArtistID: "opener", Seen: true}}},
+	}}
+	// the lineup is created with the event, so its artists can only be referred to by name
+	event.Performances = []domain.Performance{{ArtistID: "Main", Rating: 5}, {ArtistID: "Opener", Rating: 3}}
+	if err := event.ValidateDetails(); err != nil {
+		t.Fatalf("expected references by name to be valid, got %v", err)
+	}
+
+	saved, err := cache.AddSavedEvent(event)
+	if err != nil {
+		t.Fatalf("failed to add event: %v", err)
+	}
+	mainActID, openerID := saved.MainAct.ID.Primary, saved.Openers[0].ID.Primary
+	if saved.Performances[0].ArtistID != mainActID || saved.Performances[1].ArtistID != openerID {
+		t.Errorf("expected the performances to refer to the new artist IDs, got %+v", saved.Performances)
+	}
+	if set := saved.Festival.Days[0].Sets[0]; set.ArtistID != openerID {
+		t.Errorf("expected the set to refer to the new opener ID, got %+v", set)
+	}
+
+	// an opener added by an update is only created with it too
+	saved.Openers = append(saved.Openers, domain.Artist{Name: "Late Addition"})
+	saved.Performances = append(saved.Performances, domain.Performance{ArtistID: "Late Addition", Rating: 4})
+	if err := saved.ValidateDetails(); err != nil {
+		t.Fatalf("expected the new opener's name to be valid, got %v", err)
+	}
+	if err := cache.UpdateSavedEvent(saved.ID.Primary, *saved); err != nil {
+		t.Fatalf("failed to update event: %v", err)
+	}
+	updated := cache.GetSavedEvents()[0]
+	if performance := updated.Performances[2]; performance.ArtistID == "Late Addition" || performance.ArtistID != updated.Openers[1].ID.Primary {
+		t.Errorf("expected the performance to refer to the new opener ID, got %+v", updated.Performances)
+	}
+
+	event.Performances = []domain.Performance{{ArtistID: "Nobody", Rating: 1}}
+	if err := event.ValidateDetails(); err == nil {
+		t.Error("expected a performance by an artist outside the lineup to be invalid")
+	}
+}
+
+func TestDeleteArtistReassign(t *testing.T) {
+	cache := newTestCache()
+	saved, _ := cache.AddSavedEvent(testEvent())
+	opener := saved.Openers[0]
+	cache.AddAlbum(domain.Album{Name: "Split", Artists: []domain.Artist{opener, *saved.MainAct}})
+
+	refs, err := cache.DeleteArtistReassign(opener.ID.Primary, saved.MainAct.ID.Primary)
+	if err != nil {
+		t.Fatalf("failed to reassign: %v", err)
+	}
+	if len(refs.Events) != 1 || len(refs.Albums) != 1 {
+		t.Errorf("unexpected references %+v", refs)
+	}
+	cache.RefreshSavedEvents()
+	events := cache.GetSavedEvents()
+	if len(events) != 1 || len(events[0].Openers) != 0 || events[0].MainAct.ID.Primary != saved.MainAct.ID.Primary {
+		t.Errorf("expected opener to be folded into the main act, got %+v", events)
+	}
+	albums := cache.GetAlbums()
+	if len(albums) != 1 || len(albums[0].Artists) != 1 || albums[0].Artists[0].ID.Primary != saved.MainAct.ID.Primary {
+		t.Errorf("expected album to reference the main act once, got %+v", albums)
+	}
+
+	if _, err := cache.DeleteArtistReassign(saved.MainAct.ID.Primary, "missing"); err == nil {
+		t.Error("expected error reassigning to a missing artist")
+	}
+}
+
+func TestDeleteArtistReassignMovesPerformances(t *testing.T) {
+	cache := newTestCache()
+	saved, _ := cache.AddSavedEvent(testEvent())
+	mainActID, openerID := saved.MainAct.ID.Primary, saved.Openers[0].ID.Primary
+	saved.Performances = []domain.Performance{{ArtistID: mainActID, Rating: 5}, {ArtistID: openerID, Rating: 2}}
+	if err := cache.UpdateSavedEvent(saved.ID.Primary, *saved); err != nil {
+		t.Fatalf("failed to update event: %v", err)
+	}
+	other, _ := cache.AddArtist(domain.Artist{Name: "Other"})
+
+	if _, err := cache.DeleteArtistReassign(openerID, other.ID.Primary); err != nil {
+		t.Fatalf("failed to reassign opener: %v", err)
+	}
+	event := cache.GetSavedEvents()[0]
+	if performance, ok := event.Performance(other.ID.Primary); !ok || performance.Rating != 2 {
+		t.Errorf("expected the opener performance to move to the replacement, got %+v", event.Performances)
+	}
+
+	// the main act already has a performance, so the one being reassigned is dropped
+	if _, err := cache.DeleteArtistReassign(other.ID.Primary, mainActID); err != nil {
+		t.Fatalf("failed to reassign to main act: %v", err)
+	}
+	event = cache.GetSavedEvents()[0]
+	if len(event.Performances) != 1 || event.Performances[0].Rating != 5 {
+		t.Errorf("expected only the main act performance to remain, got %+v", event.Performances)
+	}
+}
+
+func TestDeleteVenueReassign(t *testing.T) {
+	cache := newTestCache()
+	saved, _ := cache.AddSavedEvent(testEvent())
+	other, _ := cache.AddVenue(domain.Venue{Name: "Terminal West", City: "Atlanta", State: "GA"})
+
+	if err := cache.DeleteVenue(saved.Venue.ID.Primary); err == nil {
+		t.Fatal("expected error deleting a referenced venue")
+	}
+	if _, err := cache.DeleteVenueReassign(saved.Venue.ID.Primary, other.ID.Primary); err != nil {
+		t.Fatalf("failed to reassign: %v", err)
+	}
+	venues := cache.GetVenues()
+	if len(venues) != 1 || cache.GetSavedEvents()[0].Venue.ID.Primary != other.ID.Primary {
+		t.Errorf("expected event to move to the other venue, got %+v", cache.GetSavedEvents())
+	}
+}
+
+func TestMergeArtists(t *testing.T) {
+	cache := newTestCache()
+	event := testEvent()
+	event.MainAct = &domain.Artist{Name: "The National", Genres: domain.GenreInfo{User: []string{"indie"}}}
+	saved, _ := cache.AddSavedEvent(event)
+	duplicate, _ := cache.AddArtist(domain.Artist{
+		Name:   "National",
+		ID:     domain.ID{Spotify: "sp1"},
+		Genres: domain.GenreInfo{User: []string{"indie", "rock"}},
+	})
+	cache.AddAlbum(domain.Album{Name: "Boxer", Artists: []domain.Artist{*duplicate}})
+
+	merged, refs, err := cache.MergeArtists(saved.MainAct.ID.Primary, duplicate.ID.Primary)
+	if err != nil {
+		t.Fatalf("failed to merge artists: %v", err)
+	}
+	if merged.Name != "The National" || merged.ID.Spotify != "sp1" || len(merged.Genres.User) != 2 {
+		t.Errorf("unexpected merged artist %+v", merged)
+	}
+	if len(refs.Events) != 0 || len(refs.Albums) != 1 {
+		t.Errorf("unexpected references %+v", refs)
+	}
+	if slices.ContainsFunc(cache.GetArtists(), duplicate.Equals) {
+		t.Error("expected duplicate artist to be deleted")
+	}
+	if album := cache.GetAlbums()[0]; album.Artists[0].ID.Primary != merged.ID.Primary {
+		t.Errorf("expected album to reference the merged artist, got %+v", album.Artists)
+	}
+	if mainAct := cache.GetSavedEvents()[0].MainAct; mainAct.ID.Spotify != "sp1" {
+		t.Errorf("expected event to use the merged artist, got %+v", mainAct)
+	}
+}
+
+type failingDatabase struct {
+	*EventRepository
+}
+
+func (d failingDatabase) AddEventWithReferences(context.Context, domain.Event) (domain.Event, error) {
+	return domain.Event{}, errors.New("write failed")
+}
+
+func (d failingDatabase) AddAlbumWithArtists(context.Context, domain.Album) (domain.Album, error) {
+	return domain.Album{}, errors.New("write failed")
+}
+
+func (d failingDatabase) ApplyBatch(context.Context, domain.Batch) error {
+	return errors.New("write failed")
+}
+
+func TestAddSavedEventFailureLeavesCacheUnchanged(t *testing.T) {
+	cache := newTestCache()
+	cache.Database = failingDatabase{cache.Database.(*EventRepository)}
+
+	if _, err := cache.AddSavedEvent(testEvent()); err == nil {
+		t.Fatal("expected error when the write fails")
+	}
+	if _, err := cache.AddAlbum(domain.Album{Name: "Album", Artists: []domain.Artist{{Name: "New"}}}); err == nil {
+		t.Fatal("expected error when the write fails")
+	}
+	if len(cache.GetArtists()) != 0 || len(cache.GetVenues()) != 0 || len(cache.GetSavedEvents()) != 0 || len(cache.GetAlbums()) != 0 {
+		t.Errorf("expected nothing to be cached, got %d artists, %d venues, %d events, %d albums",
+			len(cache.GetArtists()), len(cache.GetVenues()), len(cache.GetSavedEvents()), len(cache.GetAlbums()))
+	}
+}
+
+func TestCascadeFailureLeavesDataUnchanged(t *testing.T) {
+	cache := newTestCache()
+	saved, _ := cache.AddSavedEvent(testEvent())
+	album, _ := cache.AddAlbum(domain.Album{Name: "Split", Artists: []domain.Artist{saved.Openers[0], *saved.MainAct}, EventID: saved.ID.Primary})
+	repo := cache.Database.(*EventRepository)
+	cache.Database = failingDatabase{repo}
+
+	if _, err := cache.DeleteArtistCascade(saved.MainAct.ID.Primary); err == nil {
+		t.Fatal("expected error when the write fails")
+	}
+	if _, err := cache.DeleteVenueCascade(saved.Venue.ID.Primary); err == nil {
+		t.Fatal("expected error when the write fails")
+	}
+	if _, _, err := cache.MergeArtists(saved.MainAct.ID.Primary, saved.Openers[0].ID.Primary); err == nil {
+		t.Fatal("expected error when the write fails")
+	}
+	if err := cache.DeleteSavedEvent(saved.ID.Primary); err == nil {
+		t.Fatal("expected error when the write fails")
+	}
+
+	if len(cache.GetSavedEvents()) != 1 || len(cache.GetArtists()) != 2 || len(cache.GetVenues()) != 1 {
+		t.Errorf("expected the cache to be unchanged, got %d events, %d artists, %d venues",
+			len(cache.GetSavedEvents()), len(cache.GetArtists()), len(cache.GetVenues()))
+	}
+	if albums := cache.GetAlbums(); len(albums) != 1 || albums[0].EventID != saved.ID.Primary || len(albums[0].Artists) != 2 {
+		t.Errorf("expected the cached album to be unchanged, got %+v", albums)
+	}
+	events, _ := repo.ListEvents(context.Background())
+	albums, _ := repo.ListAlbums(context.Background())
+	artists, _ := repo.ListArtists(context.Background())
+	if len(events) != 1 || len(artists) != 2 || len(albums) != 1 || albums[0].EventID != album.EventID {
+		t.Errorf("expected the database to be unchanged, got %d events, %d artists, %+v", len(events), len(artists), albums)
+	}
+}
+
+func TestDeleteVenueCascadeUnlinksAlbums(t *testing.T) {
+	cache := newTestCache()
+	saved, _ := cache.AddSavedEvent(testEvent())
+	cache.AddAlbum(domain.Album{Name: "Live", Artists: []domain.Artist{*saved.MainAct}, EventID: saved.ID.Primary})
+
+	refs, err := cache.DeleteVenueCascade(saved.Venue.ID.Primary)
+	if err != nil {
+		t.Fatalf("failed to cascade delete: %v", err)
+	}
+	if len(refs.Events) != 1 || len(cache.GetSavedEvents()) != 0 || len(cache.GetVenues()) != 0 {
+		t.Errorf("expected the venue and its event to be deleted, got %+v", refs)
+	}
+	if albums := cache.GetAlbums(); len(albums) != 1 || albums[0].EventID != "" {
+		t.Errorf("expected the album to outlive the event, got %+v", albums)
+	}
+	albums, _ := cache.Database.ListAlbums(context.Background())
+	if len(albums) != 1 || albums[0].EventID != "" {
+		t.Errorf("expected the stored album to be unlinked, got %+v", albums)
+	}
+}
+
+func TestAddSavedEventExistingInDatabase(t *testing.T) {
+	cache := newTestCache()
+	stored, _ := cache.Database.AddEventWithReferences(context.Background(), testEvent())
+
+	stored.Openers = append(stored.Openers, domain.Artist{Name: "Late Addition"})
+	if _, err := cache.AddSavedEvent(stored); domain.KindOf(err) != domain.KindConflict {
+		t.Fatalf("expected a conflict adding an event that already exists, got %v", err)
+	}
+	if len(cache.GetSavedEvents()) != 0 || len(cache.GetArtists()) != 0 {
+		t.Errorf("expected nothing to be cached, got %+v and %+v", cache.GetSavedEvents(), cache.GetArtists())
+	}
+}
+
+func TestAddAlbumReusesCachedArtists(t *testing.T) {
+	cache := newTestCache()
+	saved, _ := cache.AddSavedEvent(testEvent())
+
+	album, err := cache.AddAlbum(domain.Album{Name: "Split", Artists: []domain.Artist{*saved.MainAct, {Name: "New"}}})
+	if err != nil {
+		t.Fatalf("failed to add album: %v", err)
+	}
+	if album.Artists[0].ID.Primary != saved.MainAct.ID.Primary || album.Artists[1].ID.Primary == "" {
+		t.Errorf("unexpected album artists %+v", album.Artists)
+	}
+	if len(cache.GetArtists()) != 3 {
+		t.Errorf("expected the new album artist to be cached, got %+v", cache.GetArtists())
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	cache := newTestCache()
+	saved, _ := cache.AddSavedEvent(testEvent())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(3)
+		go func(i int) {
+			defer wg.Done()
+			event := testEvent()
+			event.Date = domain.NewDate(2024, time.January, i+1)
+			if _, err := cache.AddSavedEvent(event); err != nil {
+				t.Errorf("failed to add event: %v", err)
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			artist := *saved.MainAct
+			artist.Genres.User = []string{fmt.Sprintf("genre %d", i)}
+			if err := cache.UpdateArtist(artist.ID.Primary, artist); err != nil {
+				t.Errorf("failed to update artist: %v", err)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			cache.GetSavedEvents()
+			cache.GetPassedSavedEvents()
+			cache.GetArtists()
+			cache.GetVenues()
+			cache.GetUniqueGenres()
+		}()
+	}
+	wg.Wait()
+
+	if len(cache.GetSavedEvents()) != 21 {
+		t.Errorf("expected 21 saved events, got %d", len(cache.GetSavedEvents()))
+	}
+	if len(cache.GetArtists()) != 42 || len(cache.GetVenues()) != 21 {
+		t.Errorf("expected every new artist and venue to be cached, got %d artists and %d venues",
+			len(cache.GetArtists()), len(cache.GetVenues()))
+	}
+}
+
+type recordingSyncHooks struct {
+	calls []string
+}
+
+func (h *recordingSyncHooks) record(call string, id string) error {
+	h.calls = append(h.calls, call+" "+id)
+	return nil
+}
+
+func (h *recordingSyncHooks) SyncArtistAdd(id string) error    { return h.record("artist add", id) }
+func (h *recordingSyncHooks) SyncArtistUpdate(id string) error { return h.record("artist update", id) }
+func (h *recordingSyncHooks) SyncArtistDelete(id string)       { h.record("artist delete", id) }
+func (h *recordingSyncHooks) SyncVenueAdd(id string) error     { return h.record("venue add", id) }
+func (h *recordingSyncHooks) SyncVenueUpdate(id string) error  { return h.record("venue update", id) }
+func (h *recordingSyncHooks) SyncVenueDelete(id string)        { h.record("venue delete", id) }
+func (h *recordingSyncHooks) SyncEventAdd(id string) error     { return h.record("event add", id) }
+func (h *recordingSyncHooks) SyncEventUpdate(id string) error  { return h.record("event update", id) }
+func (h *recordingSyncHooks) SyncEventDelete(id string)        { h.record("event delete", id) }
+
+func TestApplyChange(t *testing.T) {
+	cache := newTestCache()
+	hooks := &recordingSyncHooks{}
+	cache.SyncService = hooks
+	saved, _ := cache.AddSavedEvent(testEvent())
+
+	// changes the cache made itself are ignored
+	cache.ApplyChange(Change{Type: ChangeAdded, Kind: "event", ID: saved.ID.Primary, Event: saved})
+
+	artist := domain.CloneArtist(*saved.MainAct)
+	artist.Name = "Renamed"
+	cache.ApplyChange(Change{Type: ChangeModified, Kind: "artist", ID: artist.ID.Primary, Artist: &artist})
+	venue := domain.Venue{Name: "Terminal West", City: "Atlanta", State: "GA", ID: domain.ID{Primary: "new-venue"}}
+	cache.ApplyChange(Change{Type: ChangeAdded, Kind: "venue", ID: venue.ID.Primary, Venue: &venue})
+	cache.ApplyChange(Change{Type: ChangeRemoved, Kind: "event", ID: saved.ID.Primary})
+	cache.ApplyChange(Change{Type: ChangeRemoved, Kind: "event", ID: "missing"})
+
+	expected := []string{"artist update " + artist.ID.Primary, "venue add new-venue", "event delete " + saved.ID.Primary}
+	if !slices.Equal(hooks.calls, expected) {
+		t.Errorf("expected sync hooks %v, got %v", expected, hooks.calls)
+	}
+	if len(cache.GetSavedEvents()) != 0 || len(cache.GetVenues()) != 2 {
+		t.Errorf("unexpected cache contents %+v and %+v", cache.GetSavedEvents(), cache.GetVenues())
+	}
+	if !slices.ContainsFunc(cache.GetArtists(), func(a domain.Artist) bool { return a.Name == "Renamed" }) {
+		t.Errorf("expected the artist to be renamed, got %+v", cache.GetArtists())
+	}
+}
+
+func TestApplyArtistChangeUpdatesSavedEvents(t *testing.T) {
+	cache := newTestCache()
+	saved, _ := cache.AddSavedEvent(testEvent())
+
+	opener := domain.CloneArtist(saved.Openers[0])
+	opener.Genres.User = []string{"shoegaze"}
+	cache.ApplyChange(Change{Type: ChangeModified, Kind: "artist", ID: opener.ID.Primary, Artist: &opener})
+
+	if genres := cache.GetSavedEvents()[0].Openers[0].Genres.User; !slices.Equal(genres, []string{"shoegaze"}) {
+		t.Errorf("expected the saved event opener to be updated, got %v", genres)
+	}
+}
+
+func TestDeleteSavedEventUnlinksAlbums(t *testing.T) {
+	cache := newTestCache()
+	saved, _ := cache.AddSavedEvent(testEvent())
+
+	if _, err := cache.AddAlbum(domain.Album{Name: "Missing", EventID: "missing"}); err == nil {
+		t.Fatal("expected error linking an album to an unsaved event")
+	}
+	album, err := cache.AddAlbum(domain.Album{Name: "Merch", Artists: []domain.Artist{*saved.MainAct}, EventID: saved.ID.Primary})
+	if err != nil {
+		t.Fatalf("failed to add album: %v", err)
+	}
+
+	if err := cache.DeleteSavedEvent(saved.ID.Primary); err != nil {
+		t.Fatalf("failed to delete event: %v", err)
+	}
+	if err := cache.RefreshAlbums(); err != nil {
+		t.Fatalf("failed to refresh albums: %v", err)
+	}
+	albums := cache.GetAlbums()
+	if len(albums) != 1 || albums[0].ID != album.ID || albums[0].EventID != "" {
+		t.Errorf("expected the album to be kept and unlinked, got %+v", albums)
+	}
+}
+
+func changedIDs[T any](entities []T, id func(T) string) []string {
+	ids := []string{}
+	for _, entity := range entities {
+		ids = append(ids, id(entity))
+	}
+	return ids
+}
+
+func TestChangesSince(t *testing.T) {
+	cache := newTestCache()
+	saved, err := cache.AddSavedEvent(testEvent())
+	if err != nil {
+		t.Fatalf("failed to add event: %v", err)
+	}
+
+	full, err := cache.ChangesSince("")
+	if err != nil {
+		t.Fatalf("failed to list changes: %v", err)
+	}
+	if !full.Reset || len(full.Events.Created) != 1 || len(full.Artists.Created) != 2 || len(full.Venues.Created) != 1 {
+		t.Errorf("expected everything as created, got %+v", full)
+	}
+
+	renamed := domain.CloneArtist(*saved.MainAct)
+	renamed.Name = "Renamed"
+	if err := cache.UpdateArtist(renamed.ID.Primary, renamed); err != nil {
+		t.Fatalf("failed to update artist: %v", err)
+	}
+	album, err := cache.AddAlbum(domain.Album{Name: "Short Lived", Artists: []domain.Artist{renamed}})
+	if err != nil {
+		t.Fatalf("failed to add album: %v", err)
+	}
+	if err := cache.DeleteAlbum(album.ID); err != nil {
+		t.Fatalf("failed to delete album: %v", err)
+	}
+	if err := cache.DeleteSavedEvent(saved.ID.Primary); err != nil {
+		t.Fatalf("failed to delete event: %v", err)
+	}
+
+	changes, err := cache.ChangesSince(full.Token)
+	if err != nil {
+		t.Fatalf("failed to list changes: %v", err)
+	}
+	if changes.Reset || changes.Token == full.Token {
+		t.Errorf("expected an incremental sync with a new token, got %+v", changes)
+	}
+	artistIDs := changedIDs(changes.Artists.Updated, func(a domain.Artist) string { return a.ID.Primary })
+	if !slices.Equal(artistIDs, []string{renamed.ID.Primary}) || changes.Artists.Updated[0].Name != "Renamed" {
+		t.Errorf("expected the renamed artist to be updated, got %+v", changes.Artists)
+	}
+	// the event was updated with the artist and then deleted, the album never existed for the client
+	if !slices.Equal(changes.Events.Deleted, []string{saved.ID.Primary}) || len(changes.Events.Updated) != 0 {
+		t.Errorf("expected the event to be deleted, got %+v", changes.Events)
+	}
+	if len(changes.Albums.Created)+len(changes.Albums.Updated)+len(changes.Albums.Deleted) != 0 {
+		t.Errorf("expected no album changes, got %+v", changes.Albums)
+	}
+
+	unchanged, err := cache.ChangesSince(changes.Token)
+	if err != nil || unchanged.Token != changes.Token || len(unchanged.Events.Deleted) != 0 {
+		t.Errorf("expected no changes, got %+v, %v", unchanged, err)
+	}
+	if stale, err := newTestCache().ChangesSince(changes.Token); err != nil || !stale.Reset {
+		t.Errorf("expected a token from another load to reset, got %+v, %v", stale, err)
+	}
+	if _, err := cache.ChangesSince("not-a-token"); err == nil {
+		t.Error("expected an error for an invalid token")
+	}
+}
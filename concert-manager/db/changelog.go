@@ -0,0 +1,190 @@
+package db
+
+import (
+	"cmp"
+	"concert-manager/domain"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// changeLog numbers every change made to the cache, from the cache's own writes, database change
+// feeds and refreshes alike. Only the latest change of each entity is kept, which is all a sync
+// needs. The sequence starts over when the cache is loaded, so tokens also carry the epoch of
+// the load and tokens from before a restart get a reset rather than missing changes.
+type changeLog struct {
+	epoch   int64
+	seq     uint64
+	entries map[changeKey]*changeEntry
+}
+
+type changeKey struct {
+	kind string
+	id   string
+}
+
+type changeEntry struct {
+	// sequence it was added at, 0 when it was already loaded
+	added   uint64
+	changed uint64
+	removed bool
+}
+
+// requires the lock to be held
+func (c *Cache) resetChanges() {
+	c.changes = changeLog{epoch: time.Now().UnixNano(), entries: map[changeKey]*changeEntry{}}
+}
+
+// requires the lock to be held
+func (c *Cache) recordChange(kind string, id string, changeType ChangeType) {
+	if c.changes.entries == nil {
+		c.resetChanges()
+	}
+	c.changes.seq++
+	key := changeKey{kind: kind, id: id}
+	entry, ok := c.changes.entries[key]
+	if !ok {
+		entry = &changeEntry{}
+		c.changes.entries[key] = entry
+	}
+	switch changeType {
+	case ChangeAdded:
+		entry.added = c.changes.seq
+		entry.removed = false
+	case ChangeRemoved:
+		entry.removed = true
+	}
+	entry.changed = c.changes.seq
+}
+
+// requires the lock to be held, records the difference between a kind of entity before and
+// after it was reloaded from the database
+func recordReload[T any](c *Cache, kind string, before []T, after []T, id func(T) string) {
+	previous := map[string]T{}
+	for _, entity := range before {
+		previous[id(entity)] = entity
+	}
+	for _, entity := range after {
+		old, existed := previous[id(entity)]
+		delete(previous, id(entity))
+		switch {
+		case !existed:
+			c.recordChange(kind, id(entity), ChangeAdded)
+		case !reflect.DeepEqual(old, entity):
+			c.recordChange(kind, id(entity), ChangeModified)
+		}
+	}
+	for _, entity := range before {
+		if _, removed := previous[id(entity)]; removed {
+			c.recordChange(kind, id(entity), ChangeRemoved)
+		}
+	}
+}
+
+// requires the lock to be held
+func (c *Cache) changeToken() string {
+	return fmt.Sprintf("%d.%d", c.changes.epoch, c.changes.seq)
+}
+
+// ChangeToken identifies the current state of the cache, it changes with every change to it
+func (c *Cache) ChangeToken() string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.changeToken()
+}
+
+func parseChangeToken(token string) (int64, uint64, error) {
+	epochPart, seqPart, found := strings.Cut(token, ".")
+	epoch, epochErr := strconv.ParseInt(epochPart, 10, 64)
+	seq, seqErr := strconv.ParseUint(seqPart, 10, 64)
+	if !found || epochErr != nil || seqErr != nil {
+		return 0, 0, domain.Invalid("invalid sync token")
+	}
+	return epoch, seq, nil
+}
+
+// ChangesSince lists the entities created, updated and deleted since the token. An empty token,
+// or one from before the cache was last loaded, lists everything as created with Reset set.
+// Entities created and deleted since the token aren't listed at all.
+func (c *Cache) ChangesSince(token string) (*domain.Changes, error) {
+	var epoch int64
+	var since uint64
+	if token != "" {
+		var err error
+		if epoch, since, err = parseChangeToken(token); err != nil {
+			return nil, err
+		}
+	}
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	changes := &domain.Changes{
+		Token:   c.changeToken(),
+		Events:  newEntityChanges[domain.Event](),
+		Artists: newEntityChanges[domain.Artist](),
+		Venues:  newEntityChanges[domain.Venue](),
+		Albums:  newEntityChanges[domain.Album](),
+	}
+	if token == "" || epoch != c.changes.epoch || since > c.changes.seq {
+		changes.Reset = true
+		changes.Events.Created = append(changes.Events.Created, domain.CloneEvents(c.savedEvents)...)
+		changes.Artists.Created = append(changes.Artists.Created, domain.CloneArtists(c.artists)...)
+		changes.Venues.Created = append(changes.Venues.Created, domain.CloneVenues(c.venues)...)
+		for _, album := range c.albums {
+			changes.Albums.Created = append(changes.Albums.Created, domain.CloneAlbum(album))
+		}
+		return changes, nil
+	}
+
+	keys := []changeKey{}
+	for key, entry := range c.changes.entries {
+		if entry.changed > since {
+			keys = append(keys, key)
+		}
+	}
+	// in the order they changed, so clients can apply them one by one
+	slices.SortFunc(keys, func(a, b changeKey) int {
+		return cmp.Compare(c.changes.entries[a].changed, c.changes.entries[b].changed)
+	})
+	for _, key := range keys {
+		entry := c.changes.entries[key]
+		created := entry.added > since
+		switch key.kind {
+		case "event":
+			sortChange(&changes.Events, entry, created, key.id, c.savedEvents, func(e domain.Event) string { return e.ID.Primary }, domain.CloneEvent)
+		case "artist":
+			sortChange(&changes.Artists, entry, created, key.id, c.artists, func(a domain.Artist) string { return a.ID.Primary }, domain.CloneArtist)
+		case "venue":
+			sortChange(&changes.Venues, entry, created, key.id, c.venues, func(v domain.Venue) string { return v.ID.Primary }, domain.CloneVenue)
+		case "album":
+			sortChange(&changes.Albums, entry, created, key.id, c.albums, func(a domain.Album) string { return a.ID }, domain.CloneAlbum)
+		}
+	}
+	return changes, nil
+}
+
+func newEntityChanges[T any]() domain.EntityChanges[T] {
+	return domain.EntityChanges[T]{Created: []T{}, Updated: []T{}, Deleted: []string{}}
+}
+
+// sortChange adds the current state of a changed entity to the list matching how it changed
+func sortChange[T any](changes *domain.EntityChanges[T], entry *changeEntry, created bool, id string, entities []T, entityID func(T) string, clone func(T) T) {
+	if entry.removed {
+		if !created {
+			changes.Deleted = append(changes.Deleted, id)
+		}
+		return
+	}
+	idx := slices.IndexFunc(entities, func(entity T) bool { return entityID(entity) == id })
+	if idx == -1 {
+		return
+	}
+	if created {
+		changes.Created = append(changes.Created, clone(entities[idx]))
+	} else {
+		changes.Updated = append(changes.Updated, clone(entities[idx]))
+	}
+}
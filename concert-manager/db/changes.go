@@ -0,0 +1,181 @@
+package db
+
+import (
+	"concert-manager/domain"
+	"concert-manager/log"
+	"context"
+	"reflect"
+	"slices"
+)
+
+type ChangeType int
+
+const (
+	ChangeAdded ChangeType = iota
+	ChangeModified
+	ChangeRemoved
+)
+
+// Change is a single document change reported by a ChangeFeed. Kind is one of "venue", "artist",
+// "event" or "album" and only the matching field is set, which is nil for removals.
+type Change struct {
+	Type   ChangeType
+	Kind   string
+	ID     string
+	Venue  *domain.Venue
+	Artist *domain.Artist
+	Event  *domain.Event
+	Album  *domain.Album
+}
+
+// ChangeFeed streams changes made to the database by any client, including this one.
+// Listen blocks until the context is cancelled or the feed fails.
+type ChangeFeed interface {
+	Listen(context.Context, func(Change)) error
+}
+
+// SyncHooks are notified after a change from a ChangeFeed is applied to the cache,
+// so caches derived from the saved data can stay consistent
+type SyncHooks interface {
+	SyncArtistAdd(string) error
+	SyncArtistUpdate(string) error
+	SyncArtistDelete(string)
+	SyncVenueAdd(string) error
+	SyncVenueUpdate(string) error
+	SyncVenueDelete(string)
+	SyncEventAdd(string) error
+	SyncEventUpdate(string) error
+	SyncEventDelete(string)
+}
+
+// Listen keeps the cache up to date with changes from the feed until the context is cancelled.
+// Changes that are already reflected in the cache, such as the ones it wrote itself, are ignored.
+func (c *Cache) Listen(ctx context.Context, feed ChangeFeed) error {
+	log.Info("Listening for database changes")
+	return feed.Listen(ctx, c.ApplyChange)
+}
+
+func (c *Cache) ApplyChange(change Change) {
+	log.Debugf("Applying database change %+v", change)
+	c.mutex.Lock()
+	var hook func() error
+	switch change.Kind {
+	case "venue":
+		hook = c.applyVenueChange(change)
+	case "artist":
+		hook = c.applyArtistChange(change)
+	case "event":
+		hook = c.applyEventChange(change)
+	case "album":
+		c.applyAlbumChange(change)
+	default:
+		log.Errorf("Ignoring database change with unknown kind %+v", change)
+	}
+	c.mutex.Unlock()
+
+	// the hooks read back from the cache so they can only run once the lock is released
+	if hook == nil || c.SyncService == nil {
+		return
+	}
+	if err := hook(); err != nil {
+		log.Errorf("Failed to sync database change %+v, %v", change, err)
+	}
+}
+
+// requires the lock to be held, returns the sync hook to run if the cache changed
+func (c *Cache) applyVenueChange(change Change) func() error {
+	venueIdx := slices.IndexFunc(c.venues, func(v domain.Venue) bool { return v.ID.Primary == change.ID })
+	switch {
+	case change.Type == ChangeRemoved && venueIdx == -1:
+		return nil
+	case change.Type == ChangeRemoved:
+		c.venues = slices.Delete(c.venues, venueIdx, venueIdx+1)
+		c.recordChange("venue", change.ID, ChangeRemoved)
+		log.Debug("Removed venue from cache after database change", change.ID)
+		return func() error { c.SyncService.SyncVenueDelete(change.ID); return nil }
+	case venueIdx == -1:
+		c.venues = append(c.venues, domain.CloneVenue(*change.Venue))
+		c.recordChange("venue", change.ID, ChangeAdded)
+		log.Debug("Added venue to cache after database change", change.ID)
+		return func() error { return c.SyncService.SyncVenueAdd(change.ID) }
+	case !reflect.DeepEqual(c.venues[venueIdx], *change.Venue):
+		c.venues[venueIdx] = domain.CloneVenue(*change.Venue)
+		c.recordChange("venue", change.ID, ChangeModified)
+		c.replaceVenueReferences(*change.Venue)
+		log.Debug("Updated venue in cache after database change", change.ID)
+		return func() error { return c.SyncService.SyncVenueUpdate(change.ID) }
+	}
+	return nil
+}
+
+// requires the lock to be held, returns the sync hook to run if the cache changed
+func (c *Cache) applyArtistChange(change Change) func() error {
+	artistIdx := slices.IndexFunc(c.artists, func(a domain.Artist) bool { return a.ID.Primary == change.ID })
+	switch {
+	case change.Type == ChangeRemoved && artistIdx == -1:
+		return nil
+	case change.Type == ChangeRemoved:
+		c.artists = slices.Delete(c.artists, artistIdx, artistIdx+1)
+		c.recordChange("artist", change.ID, ChangeRemoved)
+		log.Debug("Removed artist from cache after database change", change.ID)
+		return func() error { c.SyncService.SyncArtistDelete(change.ID); return nil }
+	case artistIdx == -1:
+		c.artists = append(c.artists, domain.CloneArtist(*change.Artist))
+		c.recordChange("artist", change.ID, ChangeAdded)
+		log.Debug("Added artist to cache after database change", change.ID)
+		return func() error { return c.SyncService.SyncArtistAdd(change.ID) }
+	case !reflect.DeepEqual(c.artists[artistIdx], *change.Artist):
+		c.artists[artistIdx] = domain.CloneArtist(*change.Artist)
+		c.recordChange("artist", change.ID, ChangeModified)
+		c.replaceArtistReferences(*change.Artist)
+		log.Debug("Updated artist in cache after database change", change.ID)
+		return func() error { return c.SyncService.SyncArtistUpdate(change.ID) }
+	}
+	return nil
+}
+
+// requires the lock to be held, returns the sync hook to run if the cache changed
+func (c *Cache) applyEventChange(change Change) func() error {
+	eventIdx := slices.IndexFunc(c.savedEvents, func(e domain.Event) bool { return e.ID.Primary == change.ID })
+	switch {
+	case change.Type == ChangeRemoved && eventIdx == -1:
+		return nil
+	case change.Type == ChangeRemoved:
+		c.savedEvents = slices.Delete(c.savedEvents, eventIdx, eventIdx+1)
+		c.recordChange("event", change.ID, ChangeRemoved)
+		log.Debug("Removed saved event from cache after database change", change.ID)
+		return func() error { c.SyncService.SyncEventDelete(change.ID); return nil }
+	case eventIdx == -1:
+		c.savedEvents = append(c.savedEvents, domain.CloneEvent(*change.Event))
+		c.recordChange("event", change.ID, ChangeAdded)
+		log.Debug("Added saved event to cache after database change", change.ID)
+		return func() error { return c.SyncService.SyncEventAdd(change.ID) }
+	case !reflect.DeepEqual(c.savedEvents[eventIdx], *change.Event):
+		c.savedEvents[eventIdx] = domain.CloneEvent(*change.Event)
+		c.recordChange("event", change.ID, ChangeModified)
+		log.Debug("Updated saved event in cache after database change", change.ID)
+		return func() error { return c.SyncService.SyncEventUpdate(change.ID) }
+	}
+	return nil
+}
+
+// requires the lock to be held
+func (c *Cache) applyAlbumChange(change Change) {
+	albumIdx := slices.IndexFunc(c.albums, func(a domain.Album) bool { return a.ID == change.ID })
+	switch {
+	case change.Type == ChangeRemoved:
+		if albumIdx >= 0 {
+			c.albums = slices.Delete(c.albums, albumIdx, albumIdx+1)
+			c.recordChange("album", change.ID, ChangeRemoved)
+			log.Debug("Removed album from cache after database change", change.ID)
+		}
+	case albumIdx == -1:
+		c.albums = append(c.albums, domain.CloneAlbum(*change.Album))
+		c.recordChange("album", change.ID, ChangeAdded)
+		log.Debug("Added album to cache after database change", change.ID)
+	case !reflect.DeepEqual(c.albums[albumIdx], *change.Album):
+		c.albums[albumIdx] = domain.CloneAlbum(*change.Album)
+		c.recordChange("album", change.ID, ChangeModified)
+		log.Debug("Updated album in cache after database change", change.ID)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"concert-manager/domain"
 	"concert-manager/log"
 	"context"
-	"errors"
 
 	"cloud.google.com/go/firestore"
 	"google.golang.org/grpc/codes"
@@ -13,7 +12,7 @@ import (
 
 const albumCollection = "albums"
 
-var albumFields = []string{"Name", "ArtistRefs", "Year", "Signed", "Wishlisted", "LimitedEdition", "Variant", "Format", "Genre", "Notes", "CoverImageUrl", "ID"}
+var albumFields = []string{"Name", "ArtistRefs", "Year", "Signed", "Wishlisted", "LimitedEdition", "Variant", "Format", "Genre", "Notes", "CoverImageUrl", "EventID", "ID"}
 
 type (
 	AlbumClient struct {
@@ -33,6 +32,7 @@ type (
 		Genre          string
 		Notes          string
 		CoverImageUrl  string
+		EventID        string
 		ID             string
 	}
 )
@@ -48,7 +48,7 @@ func (c *AlbumClient) Add(ctx context.Context, album domain.Album) (string, erro
 		}
 		if !artistDoc.Exists() {
 			log.Errorf("No existing artist %v while adding album %v", artist.Name, album)
-			return "", errors.New("artist does not exist")
+			return "", domain.NotFound("artist does not exist")
 		}
 		artistRefs = append(artistRefs, artistDoc.Ref)
 	}
@@ -63,22 +63,16 @@ func (c *AlbumClient) Add(ctx context.Context, album domain.Album) (string, erro
 		return existingAlbum.Ref.ID, nil
 	}
 
-	albumEntity := AlbumEntity{
-		Name:           album.Name,
-		ArtistRefs:     artistRefs,
-		Year:           album.Year,
-		Signed:         album.Signed,
-		Wishlisted:     album.Wishlisted,
-		LimitedEdition: album.LimitedEdition,
-		Variant:        album.Variant,
-		Format:         album.Format,
-		Genre:          album.Genre,
-		Notes:          album.Notes,
-		CoverImageUrl:  album.CoverImageUrl,
-	}
+	albumEntity := toAlbumEntity(album, artistRefs)
 
-	albums := c.Connection.Client.Collection(albumCollection)
-	docRef, _, err := albums.Add(ctx, albumEntity)
+	albums := c.Connection.collection(albumCollection)
+	var docRef *firestore.DocumentRef
+	if album.ID != "" {
+		docRef = albums.Doc(album.ID)
+	} else {
+		docRef = albums.NewDoc()
+	}
+	_, err = docRef.Set(ctx, albumEntity)
 	if err != nil {
 		log.Errorf("Failed to add new album %+v, %v", album, err)
 		return "", err
@@ -87,9 +81,61 @@ func (c *AlbumClient) Add(ctx context.Context, album domain.Album) (string, erro
 	return docRef.ID, nil
 }
 
+// AddWithArtists creates the album along with any of its artists that don't exist yet
+// in a single transaction, returning the album with all IDs filled in
+func (c *AlbumClient) AddWithArtists(ctx context.Context, album domain.Album) (domain.Album, error) {
+	log.Debug("Attempting to add album with artists", album)
+	var newAlbum domain.Album
+	err := c.Connection.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		newAlbum = domain.CloneAlbum(album)
+		albums := c.Connection.collection(albumCollection)
+		artists := c.Connection.collection(artistCollection)
+
+		// all reads in a transaction have to happen before any writes
+		albumRef, createAlbum, err := txDocRef(tx, albums, newAlbum.ID)
+		if err != nil {
+			return err
+		}
+		if !createAlbum {
+			log.Debugf("Skipping adding album because it already exists %+v", album)
+			return nil
+		}
+		artistRefs := make([]*firestore.DocumentRef, 0, len(newAlbum.Artists))
+		createArtists := []bool{}
+		for _, artist := range newAlbum.Artists {
+			artistRef, create, err := txDocRef(tx, artists, artist.ID.Primary)
+			if err != nil {
+				return err
+			}
+			artistRefs = append(artistRefs, artistRef)
+			createArtists = append(createArtists, create)
+		}
+
+		for i, artistRef := range artistRefs {
+			if createArtists[i] {
+				if err := tx.Create(artistRef, toArtistEntity(newAlbum.Artists[i])); err != nil {
+					return err
+				}
+			}
+			newAlbum.Artists[i].ID.Primary = artistRef.ID
+		}
+		if err := tx.Create(albumRef, toAlbumEntity(newAlbum, artistRefs)); err != nil {
+			return err
+		}
+		newAlbum.ID = albumRef.ID
+		return nil
+	})
+	if err != nil {
+		log.Errorf("Failed to add new album %+v, %v", album, err)
+		return album, err
+	}
+	log.Infof("Created new album %+v with artists", newAlbum.ID)
+	return newAlbum, nil
+}
+
 func (c *AlbumClient) Update(ctx context.Context, album domain.Album) error {
 	log.Debug("Attempting to update album", album)
-	albumDoc, err := c.Connection.Client.Collection(albumCollection).Doc(album.ID).Get(ctx)
+	albumDoc, err := c.Connection.collection(albumCollection).Doc(album.ID).Get(ctx)
 	if err != nil && status.Code(err) != codes.NotFound {
 		log.Errorf("Error while updating album %+v, %v", album, err)
 		return err
@@ -108,25 +154,12 @@ func (c *AlbumClient) Update(ctx context.Context, album domain.Album) error {
 		}
 		if !artistDoc.Exists() {
 			log.Errorf("No existing artist %v while updating album %v", artist.Name, album)
-			return errors.New("artist does not exist")
+			return domain.NotFound("artist does not exist")
 		}
 		artistRefs = append(artistRefs, artistDoc.Ref)
 	}
 
-	albumEntity := AlbumEntity{
-		Name:           album.Name,
-		ArtistRefs:     artistRefs,
-		Year:           album.Year,
-		Signed:         album.Signed,
-		Wishlisted:     album.Wishlisted,
-		LimitedEdition: album.LimitedEdition,
-		Variant:        album.Variant,
-		Format:         album.Format,
-		Genre:          album.Genre,
-		Notes:          album.Notes,
-		CoverImageUrl:  album.CoverImageUrl,
-		ID:             album.ID,
-	}
+	albumEntity := toAlbumEntity(album, artistRefs)
 
 	_, err = albumDoc.Ref.Set(ctx, albumEntity)
 	if err != nil {
@@ -139,7 +172,7 @@ func (c *AlbumClient) Update(ctx context.Context, album domain.Album) error {
 
 func (c *AlbumClient) Delete(ctx context.Context, id string) error {
 	log.Debug("Attempting to delete album", id)
-	albumDoc, err := c.Connection.Client.Collection(albumCollection).Doc(id).Get(ctx)
+	albumDoc, err := c.Connection.collection(albumCollection).Doc(id).Get(ctx)
 	if err != nil {
 		log.Error("Error while deleting album", id, err)
 		return err
@@ -155,7 +188,7 @@ func (c *AlbumClient) Delete(ctx context.Context, id string) error {
 
 func (c *AlbumClient) FindAll(ctx context.Context) ([]domain.Album, error) {
 	log.Debug("Finding all albums")
-	albumDocs, err := c.Connection.Client.Collection(albumCollection).
+	albumDocs, err := c.Connection.collection(albumCollection).
 		Select(albumFields...).
 		Documents(ctx).
 		GetAll()
@@ -172,59 +205,84 @@ func (c *AlbumClient) FindAll(ctx context.Context) ([]domain.Album, error) {
 
 	albums := []domain.Album{}
 	for _, doc := range albumDocs {
-		data := doc.Data()
-		album := domain.Album{ID: doc.Ref.ID}
-		if name, ok := data["Name"].(string); ok {
-			album.Name = name
-		}
-		if year, ok := data["Year"].(int64); ok {
-			album.Year = int(year)
-		}
-		if signed, ok := data["Signed"].(bool); ok {
-			album.Signed = signed
-		}
-		if wishlisted, ok := data["Wishlisted"].(bool); ok {
-			album.Wishlisted = wishlisted
-		}
-		if limitedEdition, ok := data["LimitedEdition"].(bool); ok {
-			album.LimitedEdition = limitedEdition
-		}
-		if variant, ok := data["Variant"].(string); ok {
-			album.Variant = variant
-		}
-		if format, ok := data["Format"].(string); ok {
-			album.Format = format
-		}
-		if genre, ok := data["Genre"].(string); ok {
-			album.Genre = genre
-		}
-		if notes, ok := data["Notes"].(string); ok {
-			album.Notes = notes
-		}
-		if coverImageUrl, ok := data["CoverImageUrl"].(string); ok {
-			album.CoverImageUrl = coverImageUrl
-		}
-		if artistRefs, ok := data["ArtistRefs"].([]interface{}); ok {
-			for _, ref := range artistRefs {
-				if docRef, ok := ref.(*firestore.DocumentRef); ok {
-					if artist, exists := (*artists)[docRef.ID]; exists {
-						album.Artists = append(album.Artists, artist)
-					}
+		albums = append(albums, toAlbum(doc, *artists))
+	}
+	log.Debugf("Found %d albums", len(albums))
+	return albums, nil
+}
+
+func toAlbum(doc *firestore.DocumentSnapshot, artists map[string]domain.Artist) domain.Album {
+	data := doc.Data()
+	album := domain.Album{ID: doc.Ref.ID}
+	if name, ok := data["Name"].(string); ok {
+		album.Name = name
+	}
+	if year, ok := data["Year"].(int64); ok {
+		album.Year = int(year)
+	}
+	if signed, ok := data["Signed"].(bool); ok {
+		album.Signed = signed
+	}
+	if wishlisted, ok := data["Wishlisted"].(bool); ok {
+		album.Wishlisted = wishlisted
+	}
+	if limitedEdition, ok := data["LimitedEdition"].(bool); ok {
+		album.LimitedEdition = limitedEdition
+	}
+	if variant, ok := data["Variant"].(string); ok {
+		album.Variant = variant
+	}
+	if format, ok := data["Format"].(string); ok {
+		album.Format = format
+	}
+	if genre, ok := data["Genre"].(string); ok {
+		album.Genre = genre
+	}
+	if notes, ok := data["Notes"].(string); ok {
+		album.Notes = notes
+	}
+	if coverImageUrl, ok := data["CoverImageUrl"].(string); ok {
+		album.CoverImageUrl = coverImageUrl
+	}
+	if eventID, ok := data["EventID"].(string); ok {
+		album.EventID = eventID
+	}
+	if artistRefs, ok := data["ArtistRefs"].([]interface{}); ok {
+		for _, ref := range artistRefs {
+			if docRef, ok := ref.(*firestore.DocumentRef); ok {
+				if artist, exists := artists[docRef.ID]; exists {
+					album.Artists = append(album.Artists, artist)
 				}
 			}
 		}
-		if album.Artists == nil {
-			album.Artists = []domain.Artist{}
-		}
-		albums = append(albums, album)
 	}
-	log.Debugf("Found %d albums", len(albums))
-	return albums, nil
+	if album.Artists == nil {
+		album.Artists = []domain.Artist{}
+	}
+	return album
+}
+
+func toAlbumEntity(album domain.Album, artistRefs []*firestore.DocumentRef) AlbumEntity {
+	return AlbumEntity{
+		Name:           album.Name,
+		ArtistRefs:     artistRefs,
+		Year:           album.Year,
+		Signed:         album.Signed,
+		Wishlisted:     album.Wishlisted,
+		LimitedEdition: album.LimitedEdition,
+		Variant:        album.Variant,
+		Format:         album.Format,
+		Genre:          album.Genre,
+		Notes:          album.Notes,
+		CoverImageUrl:  album.CoverImageUrl,
+		EventID:        album.EventID,
+		ID:             album.ID,
+	}
 }
 
 func (c *AlbumClient) findDocRef(ctx context.Context, id string) (*firestore.DocumentSnapshot, error) {
 	if id == "" {
 		return &firestore.DocumentSnapshot{}, nil
 	}
-	return c.Connection.Client.Collection(albumCollection).Doc(id).Get(ctx)
+	return c.Connection.collection(albumCollection).Doc(id).Get(ctx)
 }
@@ -4,6 +4,7 @@ import (
 	"concert-manager/domain"
 	"concert-manager/log"
 	"context"
+	"slices"
 
 	"cloud.google.com/go/firestore"
 	"google.golang.org/grpc/codes"
@@ -53,23 +54,16 @@ func (c *ArtistClient) Add(ctx context.Context, artist domain.Artist) (string, e
 		return existingArtist.Ref.ID, nil
 	}
 
-	genreEntity := GenreInfoEntity{
-		Spotify:      artist.Genres.Spotify,
-		LastFm:       artist.Genres.LastFm,
-		Ticketmaster: artist.Genres.Ticketmaster,
-		User:         artist.Genres.User,
-	}
+	artistEntity := toArtistEntity(artist)
 
-	idEntity := ArtistIDEntity{
-		Primary:      artist.ID.Primary,
-		Spotify:      artist.ID.Spotify,
-		Ticketmaster: artist.ID.Ticketmaster,
-		MusicBrainz:  artist.ID.MusicBrainz,
+	artists := c.Connection.collection(artistCollection)
+	var docRef *firestore.DocumentRef
+	if artist.ID.Primary != "" {
+		docRef = artists.Doc(artist.ID.Primary)
+	} else {
+		docRef = artists.NewDoc()
 	}
-	artistEntity := ArtistEntity{Name: artist.Name, Genre: &artist.Genre, Genres: genreEntity, ID: idEntity}
-
-	artists := c.Connection.Client.Collection(artistCollection)
-	docRef, _, err := artists.Add(ctx, artistEntity)
+	_, err = docRef.Set(ctx, artistEntity)
 	if err != nil {
 		log.Errorf("Failed to add new artist %+v, %v", artist, err)
 		return "", err
@@ -80,7 +74,7 @@ func (c *ArtistClient) Add(ctx context.Context, artist domain.Artist) (string, e
 
 func (c *ArtistClient) Update(ctx context.Context, artist domain.Artist) error {
 	log.Debug("Attempting to update artist", artist)
-	artistDoc, err := c.Connection.Client.Collection(artistCollection).Doc(artist.ID.Primary).Get(ctx)
+	artistDoc, err := c.Connection.collection(artistCollection).Doc(artist.ID.Primary).Get(ctx)
 	if err != nil && status.Code(err) != codes.NotFound {
 		log.Errorf("Error while updating artist %+v, %v", artist, err)
 		return err
@@ -90,19 +84,7 @@ func (c *ArtistClient) Update(ctx context.Context, artist domain.Artist) error {
 		return err
 	}
 
-	genreEntity := GenreInfoEntity{
-		Spotify:      artist.Genres.Spotify,
-		LastFm:       artist.Genres.LastFm,
-		Ticketmaster: artist.Genres.Ticketmaster,
-		User:         artist.Genres.User,
-	}
-	idEntity := ArtistIDEntity{
-		Primary:      artist.ID.Primary,
-		Spotify:      artist.ID.Spotify,
-		Ticketmaster: artist.ID.Ticketmaster,
-		MusicBrainz:  artist.ID.MusicBrainz,
-	}
-	artistEntity := ArtistEntity{Name: artist.Name, Genre: &artist.Genre, Genres: genreEntity, ID: idEntity}
+	artistEntity := toArtistEntity(artist)
 
 	_, err = artistDoc.Ref.Set(ctx, artistEntity)
 	if err != nil {
@@ -115,11 +97,20 @@ func (c *ArtistClient) Update(ctx context.Context, artist domain.Artist) error {
 
 func (c *ArtistClient) Delete(ctx context.Context, id string) error {
 	log.Debug("Attempting to delete artist", id)
-	artistDoc, err := c.Connection.Client.Collection(artistCollection).Doc(id).Get(ctx)
+	artistDoc, err := c.Connection.collection(artistCollection).Doc(id).Get(ctx)
 	if err != nil {
 		log.Error("Error while deleting artist", id, err)
 		return err
 	}
+	refs, err := c.findReferences(ctx, artistDoc.Ref)
+	if err != nil {
+		log.Errorf("Error while checking references to artist %s, %v", id, err)
+		return err
+	}
+	if !refs.Empty() {
+		log.Errorf("Error while deleting artist %s, it is still referenced by %+v", id, refs)
+		return domain.ReferencedError{Kind: "artist", ID: id, References: refs}
+	}
 	_, err = artistDoc.Ref.Delete(ctx)
 	if err != nil {
 		log.Error("Failed to delete artist", id, err)
@@ -131,7 +122,7 @@ func (c *ArtistClient) Delete(ctx context.Context, id string) error {
 
 func (c *ArtistClient) FindAll(ctx context.Context) ([]domain.Artist, error) {
 	log.Debug("Finding all artists")
-	artistDocs, err := c.Connection.Client.Collection(artistCollection).
+	artistDocs, err := c.Connection.collection(artistCollection).
 		Select(artistFields...).
 		Documents(ctx).
 		GetAll()
@@ -148,6 +139,22 @@ func (c *ArtistClient) FindAll(ctx context.Context) ([]domain.Artist, error) {
 	return artists, nil
 }
 
+func toArtistEntity(artist domain.Artist) ArtistEntity {
+	genreEntity := GenreInfoEntity{
+		Spotify:      artist.Genres.Spotify,
+		LastFm:       artist.Genres.LastFm,
+		Ticketmaster: artist.Genres.Ticketmaster,
+		User:         artist.Genres.User,
+	}
+	idEntity := ArtistIDEntity{
+		Primary:      artist.ID.Primary,
+		Spotify:      artist.ID.Spotify,
+		Ticketmaster: artist.ID.Ticketmaster,
+		MusicBrainz:  artist.ID.MusicBrainz,
+	}
+	return ArtistEntity{Name: artist.Name, Genre: &artist.Genre, Genres: genreEntity, ID: idEntity}
+}
+
 func toArtist(doc *firestore.DocumentSnapshot) domain.Artist {
 	artist := domain.Artist{
 		Name: doc.Data()["Name"].(string),
@@ -211,11 +218,11 @@ func (c *ArtistClient) findDocRef(ctx context.Context, id string) (*firestore.Do
 	if id == "" {
 		return &firestore.DocumentSnapshot{}, nil
 	}
-	return c.Connection.Client.Collection(artistCollection).Doc(id).Get(ctx)
+	return c.Connection.collection(artistCollection).Doc(id).Get(ctx)
 }
 
 func (c *ArtistClient) findAllDocs(ctx context.Context) (*map[string]domain.Artist, error) {
-	artistDocs, err := c.Connection.Client.Collection(artistCollection).
+	artistDocs, err := c.Connection.collection(artistCollection).
 		Select(artistFields...).
 		Documents(ctx).
 		GetAll()
@@ -230,3 +237,26 @@ func (c *ArtistClient) findAllDocs(ctx context.Context) (*map[string]domain.Arti
 
 	return &artists, nil
 }
+
+func (c *ArtistClient) findReferences(ctx context.Context, ref *firestore.DocumentRef) (domain.References, error) {
+	mainActEvents, err := c.Connection.findReferencing(ctx, eventCollection, "MainActRef", "==", ref)
+	if err != nil {
+		return domain.References{}, err
+	}
+	openerEvents, err := c.Connection.findReferencing(ctx, eventCollection, "OpenerRefs", "array-contains", ref)
+	if err != nil {
+		return domain.References{}, err
+	}
+	albums, err := c.Connection.findReferencing(ctx, albumCollection, "ArtistRefs", "array-contains", ref)
+	if err != nil {
+		return domain.References{}, err
+	}
+
+	events := mainActEvents
+	for _, id := range openerEvents {
+		if !slices.Contains(events, id) {
+			events = append(events, id)
+		}
+	}
+	return domain.References{Events: events, Albums: albums}, nil
+}
@@ -0,0 +1,224 @@
+package firestore
+
+import (
+	"concert-manager/domain"
+	"concert-manager/log"
+	"context"
+	"slices"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type BatchClient struct {
+	Connection *Firestore
+}
+
+// Apply makes every write of the batch in one transaction, so a failed write leaves the data as it was.
+// Artists and venues are only deleted when every event and album still pointing at them is deleted
+// or rewritten by the same batch.
+func (c *BatchClient) Apply(ctx context.Context, batch domain.Batch) error {
+	log.Debugf("Attempting to apply batch %+v", batch)
+	err := c.Connection.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		venues := c.Connection.collection(venueCollection)
+		artists := c.Connection.collection(artistCollection)
+		events := c.Connection.collection(eventCollection)
+		albums := c.Connection.collection(albumCollection)
+
+		// all reads have to happen before the first write of the transaction
+		found := map[string]bool{}
+		existing := func(collection *firestore.CollectionRef, kind, id string, deleted []string) (*firestore.DocumentRef, error) {
+			if id == "" || slices.Contains(deleted, id) {
+				return nil, domain.NotFound("%s does not exist", kind)
+			}
+			docRef := collection.Doc(id)
+			if found[docRef.Path] {
+				return docRef, nil
+			}
+			_, err := tx.Get(docRef)
+			if status.Code(err) == codes.NotFound {
+				return nil, domain.NotFound("%s does not exist", kind)
+			}
+			if err != nil {
+				return nil, err
+			}
+			found[docRef.Path] = true
+			return docRef, nil
+		}
+
+		for _, venue := range batch.UpdateVenues {
+			if _, err := existing(venues, "venue", venue.ID.Primary, nil); err != nil {
+				return err
+			}
+		}
+		for _, artist := range batch.UpdateArtists {
+			if _, err := existing(artists, "artist", artist.ID.Primary, nil); err != nil {
+				return err
+			}
+		}
+		eventEntities := []EventEntity{}
+		for _, event := range batch.UpdateEvents {
+			if _, err := existing(events, "event", event.ID.Primary, nil); err != nil {
+				return err
+			}
+			var mainActRef *firestore.DocumentRef
+			if event.MainAct != nil && event.MainAct.Populated() {
+				ref, err := existing(artists, "main artist", event.MainAct.ID.Primary, batch.DeleteArtists)
+				if err != nil {
+					return err
+				}
+				mainActRef = ref
+			}
+			openerRefs := []*firestore.DocumentRef{}
+			for _, opener := range event.Openers {
+				ref, err := existing(artists, "opening artist", opener.ID.Primary, batch.DeleteArtists)
+				if err != nil {
+					return err
+				}
+				openerRefs = append(openerRefs, ref)
+			}
+			venueRef, err := existing(venues, "venue", event.Venue.ID.Primary, batch.DeleteVenues)
+			if err != nil {
+				return err
+			}
+			eventEntities = append(eventEntities, toEventEntity(event, mainActRef, openerRefs, venueRef))
+		}
+		albumEntities := []AlbumEntity{}
+		for _, album := range batch.UpdateAlbums {
+			if _, err := existing(albums, "album", album.ID, nil); err != nil {
+				return err
+			}
+			artistRefs := []*firestore.DocumentRef{}
+			for _, artist := range album.Artists {
+				ref, err := existing(artists, "artist", artist.ID.Primary, batch.DeleteArtists)
+				if err != nil {
+					return err
+				}
+				artistRefs = append(artistRefs, ref)
+			}
+			albumEntities = append(albumEntities, toAlbumEntity(album, artistRefs))
+		}
+		for _, id := range batch.DeleteEvents {
+			if _, err := existing(events, "event", id, nil); err != nil {
+				return err
+			}
+		}
+		for _, id := range batch.DeleteAlbums {
+			if _, err := existing(albums, "album", id, nil); err != nil {
+				return err
+			}
+		}
+
+		updatedEvents := []string{}
+		for _, event := range batch.UpdateEvents {
+			updatedEvents = append(updatedEvents, event.ID.Primary)
+		}
+		updatedAlbums := []string{}
+		for _, album := range batch.UpdateAlbums {
+			updatedAlbums = append(updatedAlbums, album.ID)
+		}
+		// the rewritten events and albums were checked above, so only the untouched ones can still refer
+		stillReferencing := func(query firestore.Query, removed, updated []string) ([]string, error) {
+			docs, err := tx.Documents(query).GetAll()
+			if err != nil {
+				return nil, err
+			}
+			ids := []string{}
+			for _, doc := range docs {
+				if !slices.Contains(removed, doc.Ref.ID) && !slices.Contains(updated, doc.Ref.ID) {
+					ids = append(ids, doc.Ref.ID)
+				}
+			}
+			return ids, nil
+		}
+		for _, id := range batch.DeleteArtists {
+			ref, err := existing(artists, "artist", id, nil)
+			if err != nil {
+				return err
+			}
+			refs := domain.References{Events: []string{}, Albums: []string{}}
+			for _, query := range []firestore.Query{
+				events.Where("MainActRef", "==", ref),
+				events.Where("OpenerRefs", "array-contains", ref),
+			} {
+				ids, err := stillReferencing(query, batch.DeleteEvents, updatedEvents)
+				if err != nil {
+					return err
+				}
+				for _, eventID := range ids {
+					if !slices.Contains(refs.Events, eventID) {
+						refs.Events = append(refs.Events, eventID)
+					}
+				}
+			}
+			refs.Albums, err = stillReferencing(albums.Where("ArtistRefs", "array-contains", ref), batch.DeleteAlbums, updatedAlbums)
+			if err != nil {
+				return err
+			}
+			if !refs.Empty() {
+				log.Errorf("Error while deleting artist %s in batch, it is still referenced by %+v", id, refs)
+				return domain.ReferencedError{Kind: "artist", ID: id, References: refs}
+			}
+		}
+		for _, id := range batch.DeleteVenues {
+			ref, err := existing(venues, "venue", id, nil)
+			if err != nil {
+				return err
+			}
+			ids, err := stillReferencing(events.Where("VenueRef", "==", ref), batch.DeleteEvents, updatedEvents)
+			if err != nil {
+				return err
+			}
+			if len(ids) > 0 {
+				refs := domain.References{Events: ids, Albums: []string{}}
+				log.Errorf("Error while deleting venue %s in batch, it is still referenced by %+v", id, refs)
+				return domain.ReferencedError{Kind: "venue", ID: id, References: refs}
+			}
+		}
+
+		for _, venue := range batch.UpdateVenues {
+			if err := tx.Set(venues.Doc(venue.ID.Primary), toVenueEntity(venue)); err != nil {
+				return err
+			}
+		}
+		for _, artist := range batch.UpdateArtists {
+			if err := tx.Set(artists.Doc(artist.ID.Primary), toArtistEntity(artist)); err != nil {
+				return err
+			}
+		}
+		for i, event := range batch.UpdateEvents {
+			if err := tx.Set(events.Doc(event.ID.Primary), eventEntities[i]); err != nil {
+				return err
+			}
+		}
+		for i, album := range batch.UpdateAlbums {
+			if err := tx.Set(albums.Doc(album.ID), albumEntities[i]); err != nil {
+				return err
+			}
+		}
+		deletes := []struct {
+			collection *firestore.CollectionRef
+			ids        []string
+		}{
+			{events, batch.DeleteEvents},
+			{albums, batch.DeleteAlbums},
+			{artists, batch.DeleteArtists},
+			{venues, batch.DeleteVenues},
+		}
+		for _, d := range deletes {
+			for _, id := range d.ids {
+				if err := tx.Delete(d.collection.Doc(id)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Errorf("Failed to apply batch, no changes were made, %v", err)
+		return err
+	}
+	log.Info("Successfully applied batch")
+	return nil
+}
@@ -2,12 +2,10 @@ package firestore
 
 import (
 	"context"
-	"errors"
 	"time"
 
 	"concert-manager/domain"
 	"concert-manager/log"
-	"concert-manager/util"
 
 	"cloud.google.com/go/firestore"
 	"google.golang.org/grpc/codes"
@@ -16,7 +14,8 @@ import (
 
 const eventCollection string = "events"
 
-var eventFields = []string{"MainActRef", "OpenerRefs", "VenueRef", "Date", "Purchased"}
+var eventFields = []string{"MainActRef", "OpenerRefs", "VenueRef", "Date", "Purchased",
+	"Rating", "Performances", "Notes", "Seat", "Companions", "Ticket", "Festival", "StartTime"}
 
 type (
 	EventClient struct {
@@ -30,8 +29,49 @@ type (
 		OpenerRefs []*firestore.DocumentRef
 		VenueRef   *firestore.DocumentRef
 		Date       time.Time
+		StartTime  string
 		Purchased  bool
 		ID         EventIDEntity
+
+		Rating       int
+		Performances []PerformanceEntity
+		Notes        string
+		Seat         string
+		Companions   []string
+		Ticket       TicketEntity
+		Festival     *FestivalEntity
+	}
+
+	PerformanceEntity struct {
+		ArtistID   string
+		Rating     int
+		Highlights string
+	}
+
+	TicketEntity struct {
+		PriceCents   int
+		Quantity     int
+		FeesCents    int
+		PurchaseDate *time.Time
+	}
+
+	FestivalEntity struct {
+		Name    string
+		EndDate time.Time
+		Days    []FestivalDayEntity
+	}
+
+	FestivalDayEntity struct {
+		Date time.Time
+		Sets []SetEntity
+	}
+
+	SetEntity struct {
+		ArtistID string
+		Stage    string
+		Start    string
+		End      string
+		Seen     bool
 	}
 
 	EventIDEntity struct {
@@ -42,19 +82,159 @@ type (
 
 func (c *EventClient) Add(ctx context.Context, event domain.Event) (string, error) {
 	log.Debug("Attemping to add event", event)
+	eventEntity, err := c.toEntity(ctx, event)
+	if err != nil {
+		return "", err
+	}
+
+	existingEvent, err := c.findEventDocRef(ctx, event.ID.Primary)
+	if err != nil && status.Code(err) != codes.NotFound {
+		log.Errorf("Error occurred while checking if event %v already exists, %v", event, err)
+		return "", err
+	}
+	if existingEvent.Exists() {
+		log.Debugf("Skipped adding event because it already existed as %+v", event)
+		return existingEvent.Ref.ID, nil
+	}
+
+	events := c.Connection.collection(eventCollection)
+	var docRef *firestore.DocumentRef
+	if event.ID.Primary != "" {
+		docRef = events.Doc(event.ID.Primary)
+	} else {
+		docRef = events.NewDoc()
+	}
+	_, err = docRef.Set(ctx, eventEntity)
+	if err != nil {
+		log.Errorf("Failed to add event %+v, %v", event, err)
+		return "", err
+	}
+	log.Infof("Created new event %+v", docRef.ID)
+	return docRef.ID, nil
+}
+
+// AddWithReferences creates the event along with any of its artists and venue that don't exist yet
+// in a single transaction, returning the event with all IDs filled in.
+// Nothing is written when an event with the ID already exists.
+func (c *EventClient) AddWithReferences(ctx context.Context, event domain.Event) (domain.Event, error) {
+	log.Debug("Attempting to add event with references", event)
+	var newEvent domain.Event
+	err := c.Connection.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		newEvent = domain.CloneEvent(event)
+		events := c.Connection.collection(eventCollection)
+		artists := c.Connection.collection(artistCollection)
+		venues := c.Connection.collection(venueCollection)
+
+		// all reads in a transaction have to happen before any writes
+		eventRef, createEvent, err := txDocRef(tx, events, newEvent.ID.Primary)
+		if err != nil {
+			return err
+		}
+		if !createEvent {
+			log.Errorf("Event already exists when adding with references %+v", event)
+			return domain.Conflict("event %s already exists", newEvent.ID.Primary)
+		}
+		eventArtists := []*domain.Artist{}
+		artistRefs := []*firestore.DocumentRef{}
+		createArtists := []bool{}
+		for _, artist := range newEvent.ArtistsMut() {
+			if !artist.Populated() {
+				continue
+			}
+			artistRef, create, err := txDocRef(tx, artists, artist.ID.Primary)
+			if err != nil {
+				return err
+			}
+			eventArtists = append(eventArtists, artist)
+			artistRefs = append(artistRefs, artistRef)
+			createArtists = append(createArtists, create)
+		}
+		venueRef, createVenue, err := txDocRef(tx, venues, newEvent.Venue.ID.Primary)
+		if err != nil {
+			return err
+		}
+
+		for i, artist := range eventArtists {
+			if createArtists[i] {
+				if err := tx.Create(artistRefs[i], toArtistEntity(*artist)); err != nil {
+					return err
+				}
+			}
+			artist.ID.Primary = artistRefs[i].ID
+		}
+		if createVenue {
+			if err := tx.Create(venueRef, toVenueEntity(newEvent.Venue)); err != nil {
+				return err
+			}
+		}
+		newEvent.Venue.ID.Primary = venueRef.ID
+		newEvent.ResolveArtistRefs()
+
+		var mainActRef *firestore.DocumentRef
+		openerRefs := []*firestore.DocumentRef{}
+		for i, artist := range eventArtists {
+			if artist == newEvent.MainAct {
+				mainActRef = artistRefs[i]
+			} else {
+				openerRefs = append(openerRefs, artistRefs[i])
+			}
+		}
+		eventEntity := toEventEntity(newEvent, mainActRef, openerRefs, venueRef)
+		if err := tx.Create(eventRef, eventEntity); err != nil {
+			return err
+		}
+		newEvent.ID.Primary = eventRef.ID
+		return nil
+	})
+	if err != nil {
+		log.Errorf("Failed to add event %+v, %v", event, err)
+		return event, err
+	}
+	log.Infof("Created new event %+v with references", newEvent.ID.Primary)
+	return newEvent, nil
+}
+
+func (c *EventClient) Update(ctx context.Context, event domain.Event) error {
+	log.Debug("Attempting to update event", event)
+	eventDoc, err := c.findEventDocRef(ctx, event.ID.Primary)
+	if err != nil && status.Code(err) != codes.NotFound {
+		log.Errorf("Error while updating event %+v, %v", event, err)
+		return err
+	}
+	if !eventDoc.Exists() {
+		log.Errorf("Event does not exist in update for %+v", event)
+		return domain.NotFound("event does not exist")
+	}
+
+	eventEntity, err := c.toEntity(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	_, err = eventDoc.Ref.Set(ctx, eventEntity)
+	if err != nil {
+		log.Errorf("Failed to update event %+v, %v", event, err)
+		return err
+	}
+	log.Info("Successfully updated event", event.ID.Primary)
+	return nil
+}
+
+// resolves the artist and venue references for the event, which must already exist
+func (c *EventClient) toEntity(ctx context.Context, event domain.Event) (EventEntity, error) {
 	var mainActDoc *firestore.DocumentSnapshot
 	var err error
 	if event.MainAct.Populated() {
 		mainActDoc, err = c.ArtistClient.findDocRef(ctx, event.MainAct.ID.Primary)
 		if err != nil && status.Code(err) != codes.NotFound {
-			log.Errorf("Error finding existing artist %v while creating event %v", event.MainAct.Name, event)
-			return "", err
+			log.Errorf("Error finding existing artist %v for event %v", event.MainAct.Name, event)
+			return EventEntity{}, err
 		}
 		if !mainActDoc.Exists() {
-			log.Errorf("No existing artist %v while creating event %v", event.MainAct.Name, event)
-			return "", errors.New("main artist does not exist")
+			log.Errorf("No existing artist %v for event %v", event.MainAct.Name, event)
+			return EventEntity{}, domain.NotFound("main artist does not exist")
 		}
-		log.Debugf("Found existing artist %v with document ID %v while adding event",
+		log.Debugf("Found existing artist %v with document ID %v for event",
 			event.MainAct.Name, mainActDoc.Ref.ID)
 	}
 	var mainActRef *firestore.DocumentRef
@@ -66,64 +246,107 @@ func (c *EventClient) Add(ctx context.Context, event domain.Event) (string, erro
 	for _, opener := range event.Openers {
 		openerDoc, err := c.ArtistClient.findDocRef(ctx, opener.ID.Primary)
 		if err != nil && status.Code(err) != codes.NotFound {
-			log.Errorf("Error finding existing opening artist %v while creating event %v", opener.Name, event)
-			return "", err
+			log.Errorf("Error finding existing opening artist %v for event %v", opener.Name, event)
+			return EventEntity{}, err
 		}
 		if !openerDoc.Exists() {
-			log.Errorf("No existing opening artist %v while creating event %v", opener.Name, event)
-			return "", errors.New("opering artist does not exist")
+			log.Errorf("No existing opening artist %v for event %v", opener.Name, event)
+			return EventEntity{}, domain.NotFound("opering artist does not exist")
 		}
-		log.Debugf("Found existing artist %v with document ID %v while adding event",
+		log.Debugf("Found existing artist %v with document ID %v for event",
 			opener.Name, openerDoc.Ref.ID)
 		openerRefs = append(openerRefs, openerDoc.Ref)
 	}
 
 	venueDoc, err := c.VenueClient.findDocRef(ctx, event.Venue.ID.Primary)
 	if err != nil && status.Code(err) != codes.NotFound {
-		log.Errorf("Error finding existing venue %+v while creating event", event.Venue)
-		return "", err
+		log.Errorf("Error finding existing venue %+v for event", event.Venue)
+		return EventEntity{}, err
 	}
 	if !venueDoc.Exists() {
-		log.Errorf("No existing venue %+v while creating event", event.Venue)
-		return "", errors.New("venue does not exist")
+		log.Errorf("No existing venue %+v for event", event.Venue)
+		return EventEntity{}, domain.NotFound("venue does not exist")
 	}
-	log.Debugf("Found existing venue %v with document ID %v while adding event", event.Venue, venueDoc.Ref.ID)
+	log.Debugf("Found existing venue %v with document ID %v for event", event.Venue, venueDoc.Ref.ID)
 
-	existingEvent, err := c.findEventDocRef(ctx, event.ID.Primary)
-	if err != nil && status.Code(err) != codes.NotFound {
-		log.Errorf("Error occurred while checking if event %v already exists, %v", event, err)
-		return "", err
+	return toEventEntity(event, mainActRef, openerRefs, venueDoc.Ref), nil
+}
+
+func toEventEntity(event domain.Event, mainActRef *firestore.DocumentRef, openerRefs []*firestore.DocumentRef, venueRef *firestore.DocumentRef) EventEntity {
+	performances := []PerformanceEntity{}
+	for _, performance := range event.Performances {
+		performances = append(performances, PerformanceEntity{
+			ArtistID:   performance.ArtistID,
+			Rating:     performance.Rating,
+			Highlights: performance.Highlights,
+		})
 	}
-	if existingEvent.Exists() {
-		log.Debugf("Skipped adding event because it already existed as %+v", event)
-		return existingEvent.Ref.ID, nil
+	companions := []string{}
+	if event.Companions != nil {
+		companions = event.Companions
+	}
+	return EventEntity{
+		MainActRef: mainActRef,
+		OpenerRefs: openerRefs,
+		VenueRef:   venueRef,
+		Date:       toTimestamp(event.Date),
+		StartTime:  domain.FormatOptionalTimeOfDay(event.StartTime),
+		Purchased:  event.Purchased,
+		ID: EventIDEntity{
+			Primary:      event.ID.Primary,
+			Ticketmaster: event.ID.Ticketmaster,
+		},
+		Rating:       event.Rating,
+		Performances: performances,
+		Notes:        event.Notes,
+		Seat:         event.Seat,
+		Companions:   companions,
+		Ticket:       toTicketEntity(event.Ticket),
+		Festival:     toFestivalEntity(event.Festival),
 	}
+}
 
-	idEntity := EventIDEntity{
-		Primary:      event.ID.Primary,
-		Ticketmaster: event.ID.Ticketmaster,
+func toFestivalEntity(festival *domain.Festival) *FestivalEntity {
+	if festival == nil {
+		return nil
+	}
+	days := []FestivalDayEntity{}
+	for _, day := range festival.Days {
+		sets := []SetEntity{}
+		for _, set := range day.Sets {
+			sets = append(sets, SetEntity{
+				ArtistID: set.ArtistID,
+				Stage:    set.Stage,
+				Start:    domain.FormatOptionalTimeOfDay(set.Start),
+				End:      domain.FormatOptionalTimeOfDay(set.End),
+				Seen:     set.Seen,
+			})
+		}
+		days = append(days, FestivalDayEntity{Date: toTimestamp(day.Date), Sets: sets})
 	}
-	eventEntity := EventEntity{mainActRef, openerRefs, venueDoc.Ref, util.Timestamp(event.Date), event.Purchased, idEntity}
+	return &FestivalEntity{
+		Name:    festival.Name,
+		EndDate: toTimestamp(festival.EndDate),
+		Days:    days,
+	}
+}
 
-	events := c.Connection.Client.Collection(eventCollection)
-	var docRef *firestore.DocumentRef
-	if event.ID.Primary != "" {
-		docRef = events.Doc(event.ID.Primary)
-	} else {
-		docRef = events.NewDoc()
+func toTicketEntity(ticket domain.Ticket) TicketEntity {
+	entity := TicketEntity{
+		PriceCents: ticket.PriceCents,
+		Quantity:   ticket.Quantity,
+		FeesCents:  ticket.FeesCents,
 	}
-	_, err = docRef.Set(ctx, eventEntity)
-	if err != nil {
-		log.Errorf("Failed to add event %+v, %v", event, err)
-		return "", err
+	if !ticket.PurchaseDate.IsZero() {
+		purchaseDate := toTimestamp(ticket.PurchaseDate)
+		entity.PurchaseDate = &purchaseDate
 	}
-	log.Infof("Created new event %+v", docRef.ID)
-	return docRef.ID, nil
+	return entity
 }
 
 func (c *EventClient) Delete(ctx context.Context, id string) error {
 	log.Debug("Attemting to delete event", id)
-	eventDoc, err := c.Connection.Client.Collection(eventCollection).Doc(id).Get(ctx)
+	eventDoc, err := c.Connection.collection(eventCollection).Doc(id).Get(ctx)
 	if err != nil {
 		log.Errorf("Error while deleting event %s", id)
 		return err
@@ -139,7 +362,7 @@ func (c *EventClient) Delete(ctx context.Context, id string) error {
 
 func (c *EventClient) FindAll(ctx context.Context) ([]domain.Event, error) {
 	log.Debug("Finding all events")
-	eventDocs, err := c.Connection.Client.Collection(eventCollection).
+	eventDocs, err := c.Connection.collection(eventCollection).
 		Select(eventFields...).
 		Documents(ctx).
 		GetAll()
@@ -165,52 +388,148 @@ func (c *EventClient) FindAll(ctx context.Context) ([]domain.Event, error) {
 	}
 	log.Debugf("Found %d venues while retrieving all events", len(*venues))
 
-	// TODO: This logic could use better error handling for when the firestore event is invalid
-	// Currently, the whole app panics if the event data is invalid or the artist or venue is missing
-	// It would be better to log an error and ignore invalid events
 	events := []domain.Event{}
 	for _, e := range eventDocs {
-		eventData := e.Data()
+		events = append(events, toEvent(e, *artists, *venues))
+	}
 
-		var mainAct domain.Artist
-		if mainActRef, ok := eventData["MainActRef"].(*firestore.DocumentRef); ok {
-			mainAct = (*artists)[mainActRef.ID]
-		}
-		venueRef := eventData["VenueRef"].(*firestore.DocumentRef)
-		venue := (*venues)[venueRef.ID]
+	log.Debugf("Returning %d constructed events", len(events))
+	return events, nil
+}
 
-		openers := []domain.Artist{}
-		if openerRefs, ok := eventData["OpenerRefs"].([]interface{}); ok {
-			for _, openerRef := range openerRefs {
-				openers = append(openers, (*artists)[openerRef.(*firestore.DocumentRef).ID])
-			}
+// TODO: This logic could use better error handling for when the firestore event is invalid
+// Currently, the whole app panics if the event data is invalid or the artist or venue is missing
+// It would be better to log an error and ignore invalid events
+func toEvent(e *firestore.DocumentSnapshot, artists map[string]domain.Artist, venues map[string]domain.Venue) domain.Event {
+	eventData := e.Data()
+
+	var mainAct domain.Artist
+	if mainActRef, ok := eventData["MainActRef"].(*firestore.DocumentRef); ok {
+		mainAct = artists[mainActRef.ID]
+	}
+	venueRef := eventData["VenueRef"].(*firestore.DocumentRef)
+	venue := venues[venueRef.ID]
+
+	openers := []domain.Artist{}
+	if openerRefs, ok := eventData["OpenerRefs"].([]interface{}); ok {
+		for _, openerRef := range openerRefs {
+			openers = append(openers, artists[openerRef.(*firestore.DocumentRef).ID])
 		}
+	}
+
+	event := domain.Event{
+		MainAct:   &mainAct,
+		Openers:   openers,
+		Venue:     venue,
+		Date:      fromTimestamp(eventData["Date"].(time.Time)),
+		Purchased: eventData["Purchased"].(bool),
+		ID:        domain.ID{Primary: e.Ref.ID},
+	}
 
-		event := domain.Event{
-			MainAct:   &mainAct,
-			Openers:   openers,
-			Venue:     venue,
-			Date:      util.Date(eventData["Date"].(time.Time)),
-			Purchased: eventData["Purchased"].(bool),
-			ID:        domain.ID{Primary: e.Ref.ID},
+	if ids, ok := eventData["ID"].(map[string]any); ok {
+		if ticketmasterId, ok := ids["Ticketmaster"].(string); ok {
+			event.ID.Ticketmaster = ticketmasterId
 		}
+	}
 
-		if ids, ok := e.Data()["ID"].(map[string]any); ok {
-			if ticketmasterId, ok := ids["Ticketmaster"].(string); ok {
-				event.ID.Ticketmaster = ticketmasterId
+	// events saved before ratings and notes were added don't have these fields
+	if rating, ok := eventData["Rating"].(int64); ok {
+		event.Rating = int(rating)
+	}
+	event.Notes, _ = eventData["Notes"].(string)
+	event.Seat, _ = eventData["Seat"].(string)
+	event.Companions = []string{}
+	if companions, ok := eventData["Companions"].([]any); ok {
+		for _, companion := range companions {
+			if companionStr, ok := companion.(string); ok {
+				event.Companions = append(event.Companions, companionStr)
+			}
+		}
+	}
+	event.Performances = []domain.Performance{}
+	if performances, ok := eventData["Performances"].([]any); ok {
+		for _, p := range performances {
+			performance, ok := p.(map[string]any)
+			if !ok {
+				continue
 			}
+			artistID, _ := performance["ArtistID"].(string)
+			rating, _ := performance["Rating"].(int64)
+			highlights, _ := performance["Highlights"].(string)
+			event.Performances = append(event.Performances, domain.Performance{
+				ArtistID:   artistID,
+				Rating:     int(rating),
+				Highlights: highlights,
+			})
+		}
+	}
+	if ticket, ok := eventData["Ticket"].(map[string]any); ok {
+		priceCents, _ := ticket["PriceCents"].(int64)
+		quantity, _ := ticket["Quantity"].(int64)
+		feesCents, _ := ticket["FeesCents"].(int64)
+		event.Ticket = domain.Ticket{PriceCents: int(priceCents), Quantity: int(quantity), FeesCents: int(feesCents)}
+		if purchaseDate, ok := ticket["PurchaseDate"].(time.Time); ok {
+			event.Ticket.PurchaseDate = fromTimestamp(purchaseDate)
 		}
+	}
+	if startTime, ok := eventData["StartTime"].(string); ok {
+		event.StartTime, _ = domain.ParseOptionalTimeOfDay(startTime)
+	}
+	if festival, ok := eventData["Festival"].(map[string]any); ok {
+		event.Festival = toFestival(festival)
+	}
+	return event
+}
 
-		events = append(events, event)
+func toFestival(festivalData map[string]any) *domain.Festival {
+	festival := domain.Festival{Days: []domain.FestivalDay{}}
+	festival.Name, _ = festivalData["Name"].(string)
+	if endDate, ok := festivalData["EndDate"].(time.Time); ok {
+		festival.EndDate = fromTimestamp(endDate)
 	}
+	days, _ := festivalData["Days"].([]any)
+	for _, d := range days {
+		dayData, ok := d.(map[string]any)
+		if !ok {
+			continue
+		}
+		day := domain.FestivalDay{Sets: []domain.Set{}}
+		if date, ok := dayData["Date"].(time.Time); ok {
+			day.Date = fromTimestamp(date)
+		}
+		sets, _ := dayData["Sets"].([]any)
+		for _, s := range sets {
+			setData, ok := s.(map[string]any)
+			if !ok {
+				continue
+			}
+			set := domain.Set{}
+			set.ArtistID, _ = setData["ArtistID"].(string)
+			set.Stage, _ = setData["Stage"].(string)
+			start, _ := setData["Start"].(string)
+			set.Start, _ = domain.ParseOptionalTimeOfDay(start)
+			end, _ := setData["End"].(string)
+			set.End, _ = domain.ParseOptionalTimeOfDay(end)
+			set.Seen, _ = setData["Seen"].(bool)
+			day.Sets = append(day.Sets, set)
+		}
+		festival.Days = append(festival.Days, day)
+	}
+	return &festival
+}
 
-	log.Debugf("Returning %d constructed events", len(events))
-	return events, nil
+// dates are stored as midnight UTC timestamps
+func toTimestamp(date domain.Date) time.Time {
+	return date.Time(time.UTC)
+}
+
+func fromTimestamp(ts time.Time) domain.Date {
+	return domain.DateOf(ts.UTC())
 }
 
 func (c *EventClient) findEventDocRef(ctx context.Context, id string) (*firestore.DocumentSnapshot, error) {
 	if id == "" {
 		return &firestore.DocumentSnapshot{}, nil
 	}
-	return c.Connection.Client.Collection(eventCollection).Doc(id).Get(ctx)
+	return c.Connection.collection(eventCollection).Doc(id).Get(ctx)
 }
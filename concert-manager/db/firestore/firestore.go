@@ -7,16 +7,23 @@ import (
 	"os"
 
 	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 const (
 	projectIdEnv = "CM_PROJ_ID"
 )
 
+// Firestore keeps the data of the default user in top level collections and the data of every
+// other user in the same collections nested under users/{UserID}
 type Firestore struct {
 	Client *firestore.Client
+	UserID string
 }
 
+const userCollection = "users"
+
 func Setup() (*Firestore, error) {
 	projectID := os.Getenv(projectIdEnv)
 	if projectID == "" {
@@ -30,7 +37,50 @@ func Setup() (*Firestore, error) {
 		return nil, err
 	}
 
-	fs := Firestore{client}
+	fs := Firestore{Client: client}
 	log.Info("Successfully initialized database")
 	return &fs, nil
 }
+
+// ForUser returns a connection sharing the client that reads and writes the user's data
+func (fs *Firestore) ForUser(userID string) *Firestore {
+	return &Firestore{Client: fs.Client, UserID: userID}
+}
+
+func (fs *Firestore) collection(name string) *firestore.CollectionRef {
+	if fs.UserID == "" {
+		return fs.Client.Collection(name)
+	}
+	return fs.Client.Collection(userCollection).Doc(fs.UserID).Collection(name)
+}
+
+// returns the IDs of the documents in collection where field matches ref using op,
+// which is "==" for single references and "array-contains" for lists of references
+func (fs *Firestore) findReferencing(ctx context.Context, collection, field, op string, ref *firestore.DocumentRef) ([]string, error) {
+	docs, err := fs.collection(collection).Where(field, op, ref).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	for _, doc := range docs {
+		ids = append(ids, doc.Ref.ID)
+	}
+	return ids, nil
+}
+
+// finds the document with the ID in a transaction, reporting whether it still has to be created.
+// A new document is returned when the ID is empty.
+func txDocRef(tx *firestore.Transaction, collection *firestore.CollectionRef, id string) (*firestore.DocumentRef, bool, error) {
+	if id == "" {
+		return collection.NewDoc(), true, nil
+	}
+	docRef := collection.Doc(id)
+	_, err := tx.Get(docRef)
+	if status.Code(err) == codes.NotFound {
+		return docRef, true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return docRef, false, nil
+}
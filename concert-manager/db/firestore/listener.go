@@ -0,0 +1,194 @@
+package firestore
+
+import (
+	"concert-manager/db"
+	"concert-manager/domain"
+	"concert-manager/log"
+	"context"
+	"errors"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+)
+
+// Listener streams changes to every collection using Firestore snapshot listeners.
+// The first snapshot of each collection reports every existing document as added.
+type Listener struct {
+	Connection *Firestore
+}
+
+type snapshotHandler func(context.Context, []firestore.DocumentChange) ([]db.Change, error)
+
+func (l *Listener) Listen(ctx context.Context, apply func(db.Change)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	handlers := map[string]snapshotHandler{
+		venueCollection:  l.venueChanges,
+		artistCollection: l.artistChanges,
+		eventCollection:  l.eventChanges,
+		albumCollection:  l.albumChanges,
+	}
+	errs := make(chan error, len(handlers))
+	for collection, handler := range handlers {
+		go func(collection string, handler snapshotHandler) {
+			errs <- l.listenCollection(ctx, collection, handler, apply)
+		}(collection, handler)
+	}
+
+	// stop every listener as soon as one of them fails
+	err := <-errs
+	cancel()
+	for i := 1; i < len(handlers); i++ {
+		<-errs
+	}
+	return err
+}
+
+func (l *Listener) listenCollection(ctx context.Context, collection string, handler snapshotHandler, apply func(db.Change)) error {
+	log.Info("Starting snapshot listener for collection", collection)
+	snapshots := l.Connection.collection(collection).Snapshots(ctx)
+	defer snapshots.Stop()
+	for {
+		snapshot, err := snapshots.Next()
+		if ctx.Err() != nil || errors.Is(err, iterator.Done) {
+			log.Info("Stopped snapshot listener for collection", collection)
+			return nil
+		}
+		if err != nil {
+			log.Errorf("Snapshot listener for collection %s failed, %v", collection, err)
+			return err
+		}
+		if len(snapshot.Changes) == 0 {
+			continue
+		}
+
+		log.Debugf("Received %d changes for collection %s", len(snapshot.Changes), collection)
+		changes, err := handler(ctx, snapshot.Changes)
+		if err != nil {
+			log.Errorf("Failed to read changes for collection %s, %v", collection, err)
+			return err
+		}
+		for _, change := range changes {
+			apply(change)
+		}
+	}
+}
+
+func (l *Listener) venueChanges(ctx context.Context, docChanges []firestore.DocumentChange) ([]db.Change, error) {
+	changes := []db.Change{}
+	for _, docChange := range docChanges {
+		change := toChange(docChange, "venue")
+		if change.Type != db.ChangeRemoved {
+			venue := toVenue(docChange.Doc)
+			change.Venue = &venue
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+func (l *Listener) artistChanges(ctx context.Context, docChanges []firestore.DocumentChange) ([]db.Change, error) {
+	changes := []db.Change{}
+	for _, docChange := range docChanges {
+		change := toChange(docChange, "artist")
+		if change.Type != db.ChangeRemoved {
+			artist := toArtist(docChange.Doc)
+			change.Artist = &artist
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+func (l *Listener) eventChanges(ctx context.Context, docChanges []firestore.DocumentChange) ([]db.Change, error) {
+	artists, venues, err := l.findReferenced(ctx, docChanges, "MainActRef", "OpenerRefs", "VenueRef")
+	if err != nil {
+		return nil, err
+	}
+	changes := []db.Change{}
+	for _, docChange := range docChanges {
+		change := toChange(docChange, "event")
+		if change.Type != db.ChangeRemoved {
+			event := toEvent(docChange.Doc, artists, venues)
+			change.Event = &event
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+func (l *Listener) albumChanges(ctx context.Context, docChanges []firestore.DocumentChange) ([]db.Change, error) {
+	artists, _, err := l.findReferenced(ctx, docChanges, "ArtistRefs")
+	if err != nil {
+		return nil, err
+	}
+	changes := []db.Change{}
+	for _, docChange := range docChanges {
+		change := toChange(docChange, "album")
+		if change.Type != db.ChangeRemoved {
+			album := toAlbum(docChange.Doc, artists)
+			change.Album = &album
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+func toChange(docChange firestore.DocumentChange, kind string) db.Change {
+	change := db.Change{Kind: kind, ID: docChange.Doc.Ref.ID}
+	switch docChange.Kind {
+	case firestore.DocumentAdded:
+		change.Type = db.ChangeAdded
+	case firestore.DocumentModified:
+		change.Type = db.ChangeModified
+	case firestore.DocumentRemoved:
+		change.Type = db.ChangeRemoved
+	}
+	return change
+}
+
+// loads the artists and venues referenced by the given fields of the changed documents in one read
+func (l *Listener) findReferenced(ctx context.Context, docChanges []firestore.DocumentChange, fields ...string) (map[string]domain.Artist, map[string]domain.Venue, error) {
+	refs := []*firestore.DocumentRef{}
+	for _, docChange := range docChanges {
+		if docChange.Kind == firestore.DocumentRemoved {
+			continue
+		}
+		data := docChange.Doc.Data()
+		for _, field := range fields {
+			switch value := data[field].(type) {
+			case *firestore.DocumentRef:
+				refs = append(refs, value)
+			case []interface{}:
+				for _, ref := range value {
+					if docRef, ok := ref.(*firestore.DocumentRef); ok {
+						refs = append(refs, docRef)
+					}
+				}
+			}
+		}
+	}
+
+	artists := map[string]domain.Artist{}
+	venues := map[string]domain.Venue{}
+	if len(refs) == 0 {
+		return artists, venues, nil
+	}
+	docs, err := l.Connection.Client.GetAll(ctx, refs)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, doc := range docs {
+		if !doc.Exists() {
+			continue
+		}
+		switch doc.Ref.Parent.ID {
+		case artistCollection:
+			artists[doc.Ref.ID] = toArtist(doc)
+		case venueCollection:
+			venues[doc.Ref.ID] = toVenue(doc)
+		}
+	}
+	return artists, venues, nil
+}
@@ -0,0 +1,53 @@
+package firestore
+
+import (
+	"concert-manager/log"
+	"context"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const migrationCollection = "migrations"
+
+type (
+	MigrationClient struct {
+		Connection *Firestore
+	}
+
+	MigrationEntity struct {
+		Version   int
+		Name      string
+		AppliedAt time.Time
+	}
+)
+
+func (c *MigrationClient) AppliedVersions(ctx context.Context) ([]int, error) {
+	log.Debug("Finding applied migration versions")
+	migrationDocs, err := c.Connection.collection(migrationCollection).Documents(ctx).GetAll()
+	if err != nil {
+		log.Error("Error while finding applied migrations,", err)
+		return nil, err
+	}
+
+	versions := []int{}
+	for _, doc := range migrationDocs {
+		if version, ok := doc.Data()["Version"].(int64); ok {
+			versions = append(versions, int(version))
+		}
+	}
+	slices.Sort(versions)
+	return versions, nil
+}
+
+func (c *MigrationClient) RecordVersion(ctx context.Context, version int, name string) error {
+	log.Debugf("Recording migration version %d %s", version, name)
+	migrationEntity := MigrationEntity{Version: version, Name: name, AppliedAt: time.Now().UTC()}
+	docRef := c.Connection.collection(migrationCollection).Doc(strconv.Itoa(version))
+	if _, err := docRef.Set(ctx, migrationEntity); err != nil {
+		log.Errorf("Failed to record migration version %d, %v", version, err)
+		return err
+	}
+	log.Infof("Recorded migration version %d", version)
+	return nil
+}
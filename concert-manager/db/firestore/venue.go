@@ -12,7 +12,7 @@ import (
 
 const venueCollection = "venues"
 
-var venueFields = []string{"Name", "City", "State"}
+var venueFields = []string{"Name", "City", "State", "TimeZone"}
 
 type (
 	VenueClient struct {
@@ -20,10 +20,11 @@ type (
 	}
 
 	VenueEntity struct {
-		Name  string
-		City  string
-		State string
-		ID    VenueIDEntity
+		Name     string
+		City     string
+		State    string
+		ID       VenueIDEntity
+		TimeZone string
 	}
 
 	VenueIDEntity struct {
@@ -44,14 +45,16 @@ func (c *VenueClient) Add(ctx context.Context, venue domain.Venue) (string, erro
 		return existingVenue.Ref.ID, nil
 	}
 
-	idEntity := VenueIDEntity{
-		Primary:      venue.ID.Primary,
-		Ticketmaster: venue.ID.Ticketmaster,
-	}
-	venueEntity := VenueEntity{venue.Name, venue.City, venue.State, idEntity}
+	venueEntity := toVenueEntity(venue)
 
-	venues := c.Connection.Client.Collection(venueCollection)
-	docRef, _, err := venues.Add(ctx, venueEntity)
+	venues := c.Connection.collection(venueCollection)
+	var docRef *firestore.DocumentRef
+	if venue.ID.Primary != "" {
+		docRef = venues.Doc(venue.ID.Primary)
+	} else {
+		docRef = venues.NewDoc()
+	}
+	_, err = docRef.Set(ctx, venueEntity)
 	if err != nil {
 		log.Errorf("Failed to add new venue %+v, %v", venue, err)
 		return "", err
@@ -62,7 +65,7 @@ func (c *VenueClient) Add(ctx context.Context, venue domain.Venue) (string, erro
 
 func (c *VenueClient) Update(ctx context.Context, venue domain.Venue) error {
 	log.Debug("Attempting to update venue", venue)
-	venueDoc, err := c.Connection.Client.Collection(venueCollection).Doc(venue.ID.Primary).Get(ctx)
+	venueDoc, err := c.Connection.collection(venueCollection).Doc(venue.ID.Primary).Get(ctx)
 	if err != nil && status.Code(err) != codes.NotFound {
 		log.Errorf("Error while updating venue %+v, %v", venue, err)
 		return err
@@ -72,11 +75,7 @@ func (c *VenueClient) Update(ctx context.Context, venue domain.Venue) error {
 		return err
 	}
 
-	idEntity := VenueIDEntity{
-		Primary:      venue.ID.Primary,
-		Ticketmaster: venue.ID.Ticketmaster,
-	}
-	venueEntity := VenueEntity{venue.Name, venue.City, venue.State, idEntity}
+	venueEntity := toVenueEntity(venue)
 
 	_, err = venueDoc.Ref.Set(ctx, venueEntity)
 	if err != nil {
@@ -89,11 +88,21 @@ func (c *VenueClient) Update(ctx context.Context, venue domain.Venue) error {
 
 func (c *VenueClient) Delete(ctx context.Context, id string) error {
 	log.Debug("Attemping to delete venue", id)
-	venueDoc, err := c.Connection.Client.Collection(venueCollection).Doc(id).Get(ctx)
+	venueDoc, err := c.Connection.collection(venueCollection).Doc(id).Get(ctx)
 	if err != nil {
 		log.Error("Error while deleting venue", id, err)
 		return err
 	}
+	events, err := c.Connection.findReferencing(ctx, eventCollection, "VenueRef", "==", venueDoc.Ref)
+	if err != nil {
+		log.Errorf("Error while checking references to venue %s, %v", id, err)
+		return err
+	}
+	if len(events) > 0 {
+		refs := domain.References{Events: events, Albums: []string{}}
+		log.Errorf("Error while deleting venue %s, it is still referenced by %+v", id, refs)
+		return domain.ReferencedError{Kind: "venue", ID: id, References: refs}
+	}
 	_, err = venueDoc.Ref.Delete(ctx)
 	if err != nil {
 		log.Error("Failed to delete venue", id, err)
@@ -105,7 +114,7 @@ func (c *VenueClient) Delete(ctx context.Context, id string) error {
 
 func (c *VenueClient) FindAll(ctx context.Context) ([]domain.Venue, error) {
 	log.Debug("Finding all venues")
-	venueDocs, err := c.Connection.Client.Collection(venueCollection).
+	venueDocs, err := c.Connection.collection(venueCollection).
 		Select(venueFields...).
 		Documents(ctx).
 		GetAll()
@@ -122,6 +131,14 @@ func (c *VenueClient) FindAll(ctx context.Context) ([]domain.Venue, error) {
 	return venues, nil
 }
 
+func toVenueEntity(venue domain.Venue) VenueEntity {
+	idEntity := VenueIDEntity{
+		Primary:      venue.ID.Primary,
+		Ticketmaster: venue.ID.Ticketmaster,
+	}
+	return VenueEntity{venue.Name, venue.City, venue.State, idEntity, venue.TimeZone}
+}
+
 func toVenue(doc *firestore.DocumentSnapshot) domain.Venue {
 	venueData := doc.Data()
 	venue := domain.Venue{
@@ -136,6 +153,8 @@ func toVenue(doc *firestore.DocumentSnapshot) domain.Venue {
 			venue.ID.Ticketmaster = ticketmasterId
 		}
 	}
+	// venues saved before time zones were added don't have one
+	venue.TimeZone, _ = venueData["TimeZone"].(string)
 
 	return venue
 }
@@ -144,11 +163,11 @@ func (c *VenueClient) findDocRef(ctx context.Context, id string) (*firestore.Doc
 	if id == "" {
 		return &firestore.DocumentSnapshot{}, nil
 	}
-	return c.Connection.Client.Collection(venueCollection).Doc(id).Get(ctx)
+	return c.Connection.collection(venueCollection).Doc(id).Get(ctx)
 }
 
 func (c *VenueClient) findAllDocs(ctx context.Context) (*map[string]domain.Venue, error) {
-	venueDocs, err := c.Connection.Client.Collection(venueCollection).
+	venueDocs, err := c.Connection.collection(venueCollection).
 		Select(venueFields...).
 		Documents(ctx).
 		GetAll()
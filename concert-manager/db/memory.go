@@ -0,0 +1,17 @@
+package db
+
+import "concert-manager/db/memory"
+
+// NewMemoryRepository is a repository over an empty in-memory database, for demos and tests.
+// Nothing is persisted, so all data is lost when the process exits.
+func NewMemoryRepository() *EventRepository {
+	conn := memory.New()
+	return &EventRepository{
+		VenueRepo:     &memory.VenueClient{Connection: conn},
+		ArtistRepo:    &memory.ArtistClient{Connection: conn},
+		EventRepo:     &memory.EventClient{Connection: conn},
+		AlbumRepo:     &memory.AlbumClient{Connection: conn},
+		BatchRepo:     &memory.BatchClient{Connection: conn},
+		MigrationRepo: &memory.MigrationClient{Connection: conn},
+	}
+}
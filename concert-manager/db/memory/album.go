@@ -0,0 +1,140 @@
+package memory
+
+import (
+	"concert-manager/domain"
+	"concert-manager/log"
+	"concert-manager/util"
+	"context"
+)
+
+type AlbumClient struct {
+	Connection *Memory
+}
+
+func (c *AlbumClient) Add(ctx context.Context, album domain.Album) (string, error) {
+	log.Debug("Attempting to add album", album)
+	c.Connection.mutex.Lock()
+	defer c.Connection.mutex.Unlock()
+
+	record, err := c.toRecord(album)
+	if err != nil {
+		return "", err
+	}
+
+	if _, exists := c.Connection.albums[album.ID]; exists {
+		log.Debugf("Skipping adding album because it already exists %+v", album)
+		return album.ID, nil
+	}
+
+	id := album.ID
+	if id == "" {
+		id = util.NewID()
+	}
+	c.Connection.albums[id] = record
+	log.Infof("Created new album %+v", id)
+	return id, nil
+}
+
+// AddWithArtists creates the album along with any of its artists that don't exist yet
+// as a single change, returning the album with all IDs filled in
+func (c *AlbumClient) AddWithArtists(ctx context.Context, album domain.Album) (domain.Album, error) {
+	log.Debug("Attempting to add album with artists", album)
+	c.Connection.mutex.Lock()
+	defer c.Connection.mutex.Unlock()
+
+	newAlbum := domain.CloneAlbum(album)
+	for i, artist := range newAlbum.Artists {
+		newAlbum.Artists[i].ID.Primary, _ = c.Connection.addArtist(artist)
+	}
+	record, err := c.toRecord(newAlbum)
+	if err != nil {
+		return album, err
+	}
+
+	if _, exists := c.Connection.albums[newAlbum.ID]; exists {
+		log.Debugf("Skipping adding album because it already exists %+v", album)
+		return newAlbum, nil
+	}
+	if newAlbum.ID == "" {
+		newAlbum.ID = util.NewID()
+	}
+	c.Connection.albums[newAlbum.ID] = record
+	log.Infof("Created new album %+v with artists", newAlbum.ID)
+	return newAlbum, nil
+}
+
+func (c *AlbumClient) Update(ctx context.Context, album domain.Album) error {
+	log.Debug("Attempting to update album", album)
+	c.Connection.mutex.Lock()
+	defer c.Connection.mutex.Unlock()
+	return c.update(album)
+}
+
+// requires the connection lock to be held
+func (c *AlbumClient) update(album domain.Album) error {
+	if _, exists := c.Connection.albums[album.ID]; !exists {
+		log.Errorf("Album does not exist in update for %+v", album)
+		return domain.NotFound("album does not exist")
+	}
+	record, err := c.toRecord(album)
+	if err != nil {
+		return err
+	}
+	c.Connection.albums[album.ID] = record
+	log.Info("Successfully updated album", album)
+	return nil
+}
+
+func (c *AlbumClient) Delete(ctx context.Context, id string) error {
+	log.Debug("Attempting to delete album", id)
+	c.Connection.mutex.Lock()
+	defer c.Connection.mutex.Unlock()
+	return c.remove(id)
+}
+
+// requires the connection lock to be held
+func (c *AlbumClient) remove(id string) error {
+	if _, exists := c.Connection.albums[id]; !exists {
+		log.Error("Error while deleting album, album does not exist", id)
+		return domain.NotFound("album does not exist")
+	}
+	delete(c.Connection.albums, id)
+	log.Info("Successfully deleted album", id)
+	return nil
+}
+
+func (c *AlbumClient) FindAll(ctx context.Context) ([]domain.Album, error) {
+	log.Debug("Finding all albums")
+	c.Connection.mutex.Lock()
+	defer c.Connection.mutex.Unlock()
+
+	albums := []domain.Album{}
+	for _, id := range sortedKeys(c.Connection.albums) {
+		record := c.Connection.albums[id]
+		album := record.Album
+		album.ID = id
+		album.Artists = []domain.Artist{}
+		for _, artistID := range record.ArtistIDs {
+			if artist, exists := c.Connection.artists[artistID]; exists {
+				album.Artists = append(album.Artists, domain.CloneArtist(artist))
+			}
+		}
+		albums = append(albums, album)
+	}
+	log.Debugf("Found %d albums", len(albums))
+	return albums, nil
+}
+
+// requires the connection lock to be held
+func (c *AlbumClient) toRecord(album domain.Album) (albumRecord, error) {
+	record := albumRecord{Album: album, ArtistIDs: []string{}}
+	record.Album.Artists = nil
+	for _, artist := range album.Artists {
+		if _, exists := c.Connection.artists[artist.ID.Primary]; !exists {
+			log.Errorf("No existing artist %v for album %v", artist.Name, album)
+			return albumRecord{}, domain.NotFound("artist does not exist")
+		}
+		record.ArtistIDs = append(record.ArtistIDs, artist.ID.Primary)
+	}
+	return record, nil
+}
@@ -0,0 +1,89 @@
+package memory
+
+import (
+	"concert-manager/domain"
+	"concert-manager/log"
+	"concert-manager/util"
+	"context"
+)
+
+type ArtistClient struct {
+	Connection *Memory
+}
+
+func (c *ArtistClient) Add(ctx context.Context, artist domain.Artist) (string, error) {
+	log.Debug("Attempting to add artist", artist)
+	c.Connection.mutex.Lock()
+	defer c.Connection.mutex.Unlock()
+	id, _ := c.Connection.addArtist(artist)
+	return id, nil
+}
+
+// requires the lock to be held, reports whether a new artist was created
+func (m *Memory) addArtist(artist domain.Artist) (string, bool) {
+	if _, exists := m.artists[artist.ID.Primary]; exists {
+		log.Debugf("Skipping adding artist because it already exists %+v", artist)
+		return artist.ID.Primary, false
+	}
+
+	newArtist := domain.CloneArtist(artist)
+	if newArtist.ID.Primary == "" {
+		newArtist.ID.Primary = util.NewID()
+	}
+	m.artists[newArtist.ID.Primary] = newArtist
+	log.Infof("Created new artist %+v", newArtist.ID.Primary)
+	return newArtist.ID.Primary, true
+}
+
+func (c *ArtistClient) Update(ctx context.Context, artist domain.Artist) error {
+	log.Debug("Attempting to update artist", artist)
+	c.Connection.mutex.Lock()
+	defer c.Connection.mutex.Unlock()
+	return c.update(artist)
+}
+
+// requires the connection lock to be held
+func (c *ArtistClient) update(artist domain.Artist) error {
+	if _, exists := c.Connection.artists[artist.ID.Primary]; !exists {
+		log.Errorf("Artist does not exist in update for %+v", artist)
+		return domain.NotFound("artist does not exist")
+	}
+	c.Connection.artists[artist.ID.Primary] = domain.CloneArtist(artist)
+	log.Info("Successfully updated artist", artist)
+	return nil
+}
+
+func (c *ArtistClient) Delete(ctx context.Context, id string) error {
+	log.Debug("Attempting to delete artist", id)
+	c.Connection.mutex.Lock()
+	defer c.Connection.mutex.Unlock()
+	return c.remove(id)
+}
+
+// requires the connection lock to be held
+func (c *ArtistClient) remove(id string) error {
+	if _, exists := c.Connection.artists[id]; !exists {
+		log.Error("Error while deleting artist, artist does not exist", id)
+		return domain.NotFound("artist does not exist")
+	}
+	if refs := c.Connection.artistReferences(id); !refs.Empty() {
+		log.Errorf("Error while deleting artist %s, it is still referenced by %+v", id, refs)
+		return domain.ReferencedError{Kind: "artist", ID: id, References: refs}
+	}
+	delete(c.Connection.artists, id)
+	log.Info("Successfully deleted artist", id)
+	return nil
+}
+
+func (c *ArtistClient) FindAll(ctx context.Context) ([]domain.Artist, error) {
+	log.Debug("Finding all artists")
+	c.Connection.mutex.Lock()
+	defer c.Connection.mutex.Unlock()
+
+	artists := []domain.Artist{}
+	for _, id := range sortedKeys(c.Connection.artists) {
+		artists = append(artists, domain.CloneArtist(c.Connection.artists[id]))
+	}
+	log.Debugf("Found %d artists", len(artists))
+	return artists, nil
+}
@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"concert-manager/domain"
+	"concert-manager/log"
+	"context"
+	"maps"
+)
+
+type BatchClient struct {
+	Connection *Memory
+}
+
+// Apply makes every write of the batch on a copy of the data and only keeps the copy when all
+// of them succeed, so a failed write leaves the data as it was
+func (c *BatchClient) Apply(ctx context.Context, batch domain.Batch) error {
+	log.Debugf("Attempting to apply batch %+v", batch)
+	c.Connection.mutex.Lock()
+	defer c.Connection.mutex.Unlock()
+
+	staged := &Memory{
+		venues:     maps.Clone(c.Connection.venues),
+		artists:    maps.Clone(c.Connection.artists),
+		events:     maps.Clone(c.Connection.events),
+		albums:     maps.Clone(c.Connection.albums),
+		migrations: c.Connection.migrations,
+	}
+	if err := applyBatch(staged, batch); err != nil {
+		log.Errorf("Error while applying batch, no changes were made, %v", err)
+		return err
+	}
+	c.Connection.venues = staged.venues
+	c.Connection.artists = staged.artists
+	c.Connection.events = staged.events
+	c.Connection.albums = staged.albums
+	log.Info("Successfully applied batch")
+	return nil
+}
+
+func applyBatch(m *Memory, batch domain.Batch) error {
+	venues := &VenueClient{Connection: m}
+	artists := &ArtistClient{Connection: m}
+	events := &EventClient{Connection: m}
+	albums := &AlbumClient{Connection: m}
+	for _, venue := range batch.UpdateVenues {
+		if err := venues.update(venue); err != nil {
+			return err
+		}
+	}
+	for _, artist := range batch.UpdateArtists {
+		if err := artists.update(artist); err != nil {
+			return err
+		}
+	}
+	for _, event := range batch.UpdateEvents {
+		if err := events.update(event); err != nil {
+			return err
+		}
+	}
+	for _, album := range batch.UpdateAlbums {
+		if err := albums.update(album); err != nil {
+			return err
+		}
+	}
+	for _, id := range batch.DeleteEvents {
+		if err := events.remove(id); err != nil {
+			return err
+		}
+	}
+	for _, id := range batch.DeleteAlbums {
+		if err := albums.remove(id); err != nil {
+			return err
+		}
+	}
+	for _, id := range batch.DeleteArtists {
+		if err := artists.remove(id); err != nil {
+			return err
+		}
+	}
+	for _, id := range batch.DeleteVenues {
+		if err := venues.remove(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
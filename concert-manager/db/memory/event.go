@@ -0,0 +1,214 @@
+package memory
+
+import (
+	"concert-manager/domain"
+	"concert-manager/log"
+	"concert-manager/util"
+	"context"
+	"slices"
+)
+
+type EventClient struct {
+	Connection *Memory
+}
+
+func (c *EventClient) Add(ctx context.Context, event domain.Event) (string, error) {
+	log.Debug("Attempting to add event", event)
+	c.Connection.mutex.Lock()
+	defer c.Connection.mutex.Unlock()
+
+	record, err := c.toRecord(event)
+	if err != nil {
+		return "", err
+	}
+
+	if _, exists := c.Connection.events[event.ID.Primary]; exists {
+		log.Debugf("Skipped adding event because it already existed as %+v", event)
+		return event.ID.Primary, nil
+	}
+
+	id := event.ID.Primary
+	if id == "" {
+		id = util.NewID()
+	}
+	c.Connection.events[id] = record
+	log.Infof("Created new event %+v", id)
+	return id, nil
+}
+
+// AddWithReferences creates the event along with any of its artists and venue that don't exist yet
+// as a single change, returning the event with all IDs filled in.
+// Nothing is written when an event with the ID already exists.
+func (c *EventClient) AddWithReferences(ctx context.Context, event domain.Event) (domain.Event, error) {
+	log.Debug("Attempting to add event with references", event)
+	c.Connection.mutex.Lock()
+	defer c.Connection.mutex.Unlock()
+
+	if _, exists := c.Connection.events[event.ID.Primary]; exists {
+		log.Errorf("Event already exists when adding with references %+v", event)
+		return event, domain.Conflict("event %s already exists", event.ID.Primary)
+	}
+	newEvent := domain.CloneEvent(event)
+	createdArtists := []string{}
+	for _, artist := range newEvent.ArtistsMut() {
+		if !artist.Populated() {
+			continue
+		}
+		id, created := c.Connection.addArtist(*artist)
+		if created {
+			createdArtists = append(createdArtists, id)
+		}
+		artist.ID.Primary = id
+	}
+	venueID, createdVenue := c.Connection.addVenue(newEvent.Venue)
+	newEvent.Venue.ID.Primary = venueID
+	newEvent.ResolveArtistRefs()
+
+	record, err := c.toRecord(newEvent)
+	if err != nil {
+		for _, id := range createdArtists {
+			delete(c.Connection.artists, id)
+		}
+		if createdVenue {
+			delete(c.Connection.venues, venueID)
+		}
+		return event, err
+	}
+
+	if newEvent.ID.Primary == "" {
+		newEvent.ID.Primary = util.NewID()
+	}
+	c.Connection.events[newEvent.ID.Primary] = record
+	log.Infof("Created new event %+v with references", newEvent.ID.Primary)
+	return newEvent, nil
+}
+
+func (c *EventClient) Update(ctx context.Context, event domain.Event) error {
+	log.Debug("Attempting to update event", event)
+	c.Connection.mutex.Lock()
+	defer c.Connection.mutex.Unlock()
+	return c.update(event)
+}
+
+// requires the connection lock to be held
+func (c *EventClient) update(event domain.Event) error {
+	if _, exists := c.Connection.events[event.ID.Primary]; !exists {
+		log.Errorf("Event does not exist in update for %+v", event)
+		return domain.NotFound("event does not exist")
+	}
+	record, err := c.toRecord(event)
+	if err != nil {
+		return err
+	}
+	c.Connection.events[event.ID.Primary] = record
+	log.Info("Successfully updated event", event.ID.Primary)
+	return nil
+}
+
+func (c *EventClient) Delete(ctx context.Context, id string) error {
+	log.Debug("Attempting to delete event", id)
+	c.Connection.mutex.Lock()
+	defer c.Connection.mutex.Unlock()
+	return c.remove(id)
+}
+
+// requires the connection lock to be held
+func (c *EventClient) remove(id string) error {
+	if _, exists := c.Connection.events[id]; !exists {
+		log.Errorf("Error while deleting event %s, event does not exist", id)
+		return domain.NotFound("event does not exist")
+	}
+	delete(c.Connection.events, id)
+	log.Infof("Successfully deleted event %+v", id)
+	return nil
+}
+
+func (c *EventClient) FindAll(ctx context.Context) ([]domain.Event, error) {
+	log.Debug("Finding all events")
+	c.Connection.mutex.Lock()
+	defer c.Connection.mutex.Unlock()
+
+	events := []domain.Event{}
+	for _, id := range sortedKeys(c.Connection.events) {
+		record := c.Connection.events[id]
+
+		var mainAct domain.Artist
+		if record.MainActID != "" {
+			mainAct = domain.CloneArtist(c.Connection.artists[record.MainActID])
+		}
+		openers := []domain.Artist{}
+		for _, openerID := range record.OpenerIDs {
+			openers = append(openers, domain.CloneArtist(c.Connection.artists[openerID]))
+		}
+
+		events = append(events, domain.Event{
+			MainAct:   &mainAct,
+			Openers:   openers,
+			Venue:     domain.CloneVenue(c.Connection.venues[record.VenueID]),
+			Date:      record.Date,
+			StartTime: domain.CloneTimeOfDay(record.StartTime),
+			Purchased: record.Purchased,
+			ID:        domain.ID{Primary: id, Ticketmaster: record.TicketmasterID},
+
+			Rating:       record.Rating,
+			Performances: slices.Clone(record.Performances),
+			Notes:        record.Notes,
+			Seat:         record.Seat,
+			Companions:   slices.Clone(record.Companions),
+			Ticket:       record.Ticket,
+			Festival:     cloneFestival(record.Festival),
+		})
+	}
+
+	log.Debugf("Returning %d constructed events", len(events))
+	return events, nil
+}
+
+// requires the connection lock to be held
+func (c *EventClient) toRecord(event domain.Event) (eventRecord, error) {
+	record := eventRecord{
+		OpenerIDs:      []string{},
+		Date:           event.Date,
+		StartTime:      domain.CloneTimeOfDay(event.StartTime),
+		Purchased:      event.Purchased,
+		TicketmasterID: event.ID.Ticketmaster,
+		Rating:         event.Rating,
+		Performances:   append([]domain.Performance{}, event.Performances...),
+		Notes:          event.Notes,
+		Seat:           event.Seat,
+		Companions:     append([]string{}, event.Companions...),
+		Ticket:         event.Ticket,
+		Festival:       cloneFestival(event.Festival),
+	}
+
+	if event.MainAct.Populated() {
+		if _, exists := c.Connection.artists[event.MainAct.ID.Primary]; !exists {
+			log.Errorf("No existing artist %v for event %v", event.MainAct.Name, event)
+			return eventRecord{}, domain.NotFound("main artist does not exist")
+		}
+		record.MainActID = event.MainAct.ID.Primary
+	}
+
+	for _, opener := range event.Openers {
+		if _, exists := c.Connection.artists[opener.ID.Primary]; !exists {
+			log.Errorf("No existing opening artist %v for event %v", opener.Name, event)
+			return eventRecord{}, domain.NotFound("opening artist does not exist")
+		}
+		record.OpenerIDs = append(record.OpenerIDs, opener.ID.Primary)
+	}
+
+	if _, exists := c.Connection.venues[event.Venue.ID.Primary]; !exists {
+		log.Errorf("No existing venue %+v for event", event.Venue)
+		return eventRecord{}, domain.NotFound("venue does not exist")
+	}
+	record.VenueID = event.Venue.ID.Primary
+	return record, nil
+}
+
+func cloneFestival(festival *domain.Festival) *domain.Festival {
+	if festival == nil {
+		return nil
+	}
+	clone := domain.CloneFestival(*festival)
+	return &clone
+}
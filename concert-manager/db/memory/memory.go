@@ -0,0 +1,93 @@
+package memory
+
+import (
+	"concert-manager/domain"
+	"slices"
+	"sync"
+)
+
+type (
+	Memory struct {
+		mutex   sync.Mutex
+		venues  map[string]domain.Venue
+		artists map[string]domain.Artist
+		events  map[string]eventRecord
+		albums  map[string]albumRecord
+		// applied migration names by version
+		migrations map[int]string
+	}
+
+	// events and albums only hold the IDs of the artists and venues they reference,
+	// so changes to those entities show up in later reads like Firestore document refs
+	eventRecord struct {
+		MainActID      string
+		OpenerIDs      []string
+		VenueID        string
+		Date           domain.Date
+		StartTime      *domain.TimeOfDay
+		Purchased      bool
+		TicketmasterID string
+		Rating         int
+		Performances   []domain.Performance
+		Notes          string
+		Seat           string
+		Companions     []string
+		Ticket         domain.Ticket
+		Festival       *domain.Festival
+	}
+
+	albumRecord struct {
+		Album     domain.Album
+		ArtistIDs []string
+	}
+)
+
+// New returns an empty database. Nothing is persisted, so all data is lost when the process exits.
+func New() *Memory {
+	return &Memory{
+		venues:  map[string]domain.Venue{},
+		artists: map[string]domain.Artist{},
+		events:  map[string]eventRecord{},
+		albums:  map[string]albumRecord{},
+
+		migrations: map[int]string{},
+	}
+}
+
+// map iteration order is random, so reads are sorted by ID to keep results stable
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// requires the lock to be held
+func (m *Memory) artistReferences(id string) domain.References {
+	refs := domain.References{Events: []string{}, Albums: []string{}}
+	for _, eventID := range sortedKeys(m.events) {
+		record := m.events[eventID]
+		if record.MainActID == id || slices.Contains(record.OpenerIDs, id) {
+			refs.Events = append(refs.Events, eventID)
+		}
+	}
+	for _, albumID := range sortedKeys(m.albums) {
+		if slices.Contains(m.albums[albumID].ArtistIDs, id) {
+			refs.Albums = append(refs.Albums, albumID)
+		}
+	}
+	return refs
+}
+
+// requires the lock to be held
+func (m *Memory) venueReferences(id string) domain.References {
+	refs := domain.References{Events: []string{}, Albums: []string{}}
+	for _, eventID := range sortedKeys(m.events) {
+		if m.events[eventID].VenueID == id {
+			refs.Events = append(refs.Events, eventID)
+		}
+	}
+	return refs
+}
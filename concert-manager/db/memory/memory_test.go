@@ -0,0 +1,170 @@
+package memory
+
+import (
+	"concert-manager/domain"
+	"context"
+	"testing"
+)
+
+func TestAddEventResolvesReferences(t *testing.T) {
+	ctx := context.Background()
+	conn := New()
+	venues, artists, events := &VenueClient{conn}, &ArtistClient{conn}, &EventClient{conn}
+
+	venueID, _ := venues.Add(ctx, domain.Venue{Name: "The Earl", City: "Atlanta", State: "GA"})
+	artistID, _ := artists.Add(ctx, domain.Artist{Name: "Before"})
+	if venueID == "" || artistID == "" {
+		t.Fatal("expected generated IDs")
+	}
+
+	event := domain.Event{
+		MainAct: &domain.Artist{Name: "Before", ID: domain.ID{Primary: artistID}},
+		Venue:   domain.Venue{ID: domain.ID{Primary: venueID}},
+		Date:    domain.MustParseDate("1/2/2024"),
+	}
+	eventID, err := events.Add(ctx, event)
+	if err != nil {
+		t.Fatalf("failed to add event: %v", err)
+	}
+
+	event.ID.Primary = eventID
+	if againID, err := events.Add(ctx, event); err != nil || againID != eventID {
+		t.Errorf("expected re-adding an existing event to return %s, got %s, %v", eventID, againID, err)
+	}
+
+	if err := artists.Update(ctx, domain.Artist{Name: "After", ID: domain.ID{Primary: artistID}}); err != nil {
+		t.Fatalf("failed to update artist: %v", err)
+	}
+	found, _ := events.FindAll(ctx)
+	if len(found) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(found))
+	}
+	if found[0].MainAct.Name != "After" || found[0].Venue.Name != "The Earl" {
+		t.Errorf("expected event to reflect referenced entities, got %+v", found[0])
+	}
+}
+
+func TestAddEventMissingReferences(t *testing.T) {
+	ctx := context.Background()
+	conn := New()
+	venues, events := &VenueClient{conn}, &EventClient{conn}
+
+	venueID, _ := venues.Add(ctx, domain.Venue{Name: "The Earl", City: "Atlanta", State: "GA"})
+	event := domain.Event{
+		MainAct: &domain.Artist{Name: "Unknown", ID: domain.ID{Primary: "missing"}},
+		Venue:   domain.Venue{ID: domain.ID{Primary: venueID}},
+		Date:    domain.MustParseDate("1/2/2024"),
+	}
+	if _, err := events.Add(ctx, event); err == nil {
+		t.Error("expected error when main act does not exist")
+	}
+
+	event.MainAct = nil
+	event.Openers = []domain.Artist{{Name: "Unknown"}}
+	if _, err := events.Add(ctx, event); err == nil {
+		t.Error("expected error when opener does not exist")
+	}
+
+	event.Openers = nil
+	event.Venue.ID.Primary = "missing"
+	if _, err := events.Add(ctx, event); err == nil {
+		t.Error("expected error when venue does not exist")
+	}
+}
+
+func TestDeleteReferencedArtist(t *testing.T) {
+	ctx := context.Background()
+	conn := New()
+	artists, albums := &ArtistClient{conn}, &AlbumClient{conn}
+
+	artistID, _ := artists.Add(ctx, domain.Artist{Name: "Artist"})
+	album := domain.Album{Name: "Album", Artists: []domain.Artist{{ID: domain.ID{Primary: artistID}}}}
+	albumID, err := albums.Add(ctx, album)
+	if err != nil {
+		t.Fatalf("failed to add album: %v", err)
+	}
+
+	err = artists.Delete(ctx, artistID)
+	refErr, ok := err.(domain.ReferencedError)
+	if !ok {
+		t.Fatalf("expected referenced error, got %v", err)
+	}
+	if len(refErr.References.Albums) != 1 || refErr.References.Albums[0] != albumID {
+		t.Errorf("unexpected references %+v", refErr.References)
+	}
+
+	albums.Delete(ctx, albumID)
+	if err := artists.Delete(ctx, artistID); err != nil {
+		t.Errorf("expected unreferenced artist to be deleted, got %v", err)
+	}
+}
+
+func TestAddEventWithReferences(t *testing.T) {
+	ctx := context.Background()
+	conn := New()
+	venues, artists, events := &VenueClient{conn}, &ArtistClient{conn}, &EventClient{conn}
+	venueID, _ := venues.Add(ctx, domain.Venue{Name: "The Earl", City: "Atlanta", State: "GA"})
+
+	saved, err := events.AddWithReferences(ctx, domain.Event{
+		MainAct: &domain.Artist{Name: "New"},
+		Venue:   domain.Venue{Name: "The Earl", City: "Atlanta", State: "GA", ID: domain.ID{Primary: venueID}},
+		Date:    domain.MustParseDate("1/2/2024"),
+	})
+	if err != nil {
+		t.Fatalf("failed to add event: %v", err)
+	}
+	if saved.Venue.ID.Primary != venueID || saved.MainAct.ID.Primary == "" {
+		t.Errorf("unexpected saved event %+v", saved)
+	}
+	found, _ := artists.FindAll(ctx)
+	if len(found) != 1 || found[0].ID.Primary != saved.MainAct.ID.Primary {
+		t.Errorf("expected the new artist to be saved, got %+v", found)
+	}
+
+	saved.Openers = []domain.Artist{{Name: "Another"}}
+	if _, err := events.AddWithReferences(ctx, saved); domain.KindOf(err) != domain.KindConflict {
+		t.Errorf("expected a conflict re-adding the event, got %v", err)
+	}
+	if found, _ := artists.FindAll(ctx); len(found) != 1 {
+		t.Errorf("expected no artists to be created for an existing event, got %+v", found)
+	}
+}
+
+func TestApplyBatch(t *testing.T) {
+	ctx := context.Background()
+	conn := New()
+	venues, artists, events, batches := &VenueClient{conn}, &ArtistClient{conn}, &EventClient{conn}, &BatchClient{conn}
+
+	venueID, _ := venues.Add(ctx, domain.Venue{Name: "The Earl", City: "Atlanta", State: "GA"})
+	mainID, _ := artists.Add(ctx, domain.Artist{Name: "Main"})
+	openerID, _ := artists.Add(ctx, domain.Artist{Name: "Opener"})
+	event := domain.Event{
+		MainAct: &domain.Artist{Name: "Main", ID: domain.ID{Primary: mainID}},
+		Openers: []domain.Artist{{Name: "Opener", ID: domain.ID{Primary: openerID}}},
+		Venue:   domain.Venue{ID: domain.ID{Primary: venueID}},
+		Date:    domain.MustParseDate("1/2/2024"),
+	}
+	event.ID.Primary, _ = events.Add(ctx, event)
+
+	// the opener is still on the event the batch leaves alone, so nothing is written
+	renamed := domain.Artist{Name: "Renamed", ID: domain.ID{Primary: mainID}}
+	err := batches.Apply(ctx, domain.Batch{UpdateArtists: []domain.Artist{renamed}, DeleteArtists: []string{openerID}})
+	if _, ok := err.(domain.ReferencedError); !ok {
+		t.Fatalf("expected referenced error, got %v", err)
+	}
+	found, _ := events.FindAll(ctx)
+	if found[0].MainAct.Name != "Main" || len(found[0].Openers) != 1 {
+		t.Errorf("expected a failed batch to change nothing, got %+v", found[0])
+	}
+
+	event.Openers = nil
+	err = batches.Apply(ctx, domain.Batch{UpdateEvents: []domain.Event{event}, DeleteArtists: []string{openerID}})
+	if err != nil {
+		t.Fatalf("failed to apply batch: %v", err)
+	}
+	remaining, _ := artists.FindAll(ctx)
+	found, _ = events.FindAll(ctx)
+	if len(remaining) != 1 || len(found[0].Openers) != 0 {
+		t.Errorf("expected the opener to be removed and deleted, got %+v and %+v", remaining, found[0])
+	}
+}
@@ -0,0 +1,34 @@
+package memory
+
+import (
+	"concert-manager/log"
+	"context"
+	"slices"
+)
+
+type MigrationClient struct {
+	Connection *Memory
+}
+
+func (c *MigrationClient) AppliedVersions(ctx context.Context) ([]int, error) {
+	log.Debug("Finding applied migration versions")
+	c.Connection.mutex.Lock()
+	defer c.Connection.mutex.Unlock()
+
+	versions := []int{}
+	for version := range c.Connection.migrations {
+		versions = append(versions, version)
+	}
+	slices.Sort(versions)
+	return versions, nil
+}
+
+func (c *MigrationClient) RecordVersion(ctx context.Context, version int, name string) error {
+	log.Debugf("Recording migration version %d %s", version, name)
+	c.Connection.mutex.Lock()
+	defer c.Connection.mutex.Unlock()
+
+	c.Connection.migrations[version] = name
+	log.Infof("Recorded migration version %d", version)
+	return nil
+}
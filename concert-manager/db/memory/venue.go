@@ -0,0 +1,89 @@
+package memory
+
+import (
+	"concert-manager/domain"
+	"concert-manager/log"
+	"concert-manager/util"
+	"context"
+)
+
+type VenueClient struct {
+	Connection *Memory
+}
+
+func (c *VenueClient) Add(ctx context.Context, venue domain.Venue) (string, error) {
+	log.Debug("Attempting to add venue", venue)
+	c.Connection.mutex.Lock()
+	defer c.Connection.mutex.Unlock()
+	id, _ := c.Connection.addVenue(venue)
+	return id, nil
+}
+
+// requires the lock to be held, reports whether a new venue was created
+func (m *Memory) addVenue(venue domain.Venue) (string, bool) {
+	if _, exists := m.venues[venue.ID.Primary]; exists {
+		log.Debugf("Skipping adding venue because it already exists %+v", venue)
+		return venue.ID.Primary, false
+	}
+
+	newVenue := domain.CloneVenue(venue)
+	if newVenue.ID.Primary == "" {
+		newVenue.ID.Primary = util.NewID()
+	}
+	m.venues[newVenue.ID.Primary] = newVenue
+	log.Infof("Created new venue %+v", newVenue.ID.Primary)
+	return newVenue.ID.Primary, true
+}
+
+func (c *VenueClient) Update(ctx context.Context, venue domain.Venue) error {
+	log.Debug("Attempting to update venue", venue)
+	c.Connection.mutex.Lock()
+	defer c.Connection.mutex.Unlock()
+	return c.update(venue)
+}
+
+// requires the connection lock to be held
+func (c *VenueClient) update(venue domain.Venue) error {
+	if _, exists := c.Connection.venues[venue.ID.Primary]; !exists {
+		log.Errorf("Venue does not exist in update %+v", venue)
+		return domain.NotFound("venue does not exist")
+	}
+	c.Connection.venues[venue.ID.Primary] = domain.CloneVenue(venue)
+	log.Info("Successfully updated venue", venue)
+	return nil
+}
+
+func (c *VenueClient) Delete(ctx context.Context, id string) error {
+	log.Debug("Attempting to delete venue", id)
+	c.Connection.mutex.Lock()
+	defer c.Connection.mutex.Unlock()
+	return c.remove(id)
+}
+
+// requires the connection lock to be held
+func (c *VenueClient) remove(id string) error {
+	if _, exists := c.Connection.venues[id]; !exists {
+		log.Error("Error while deleting venue, venue does not exist", id)
+		return domain.NotFound("venue does not exist")
+	}
+	if refs := c.Connection.venueReferences(id); !refs.Empty() {
+		log.Errorf("Error while deleting venue %s, it is still referenced by %+v", id, refs)
+		return domain.ReferencedError{Kind: "venue", ID: id, References: refs}
+	}
+	delete(c.Connection.venues, id)
+	log.Info("Successfully deleted venue", id)
+	return nil
+}
+
+func (c *VenueClient) FindAll(ctx context.Context) ([]domain.Venue, error) {
+	log.Debug("Finding all venues")
+	c.Connection.mutex.Lock()
+	defer c.Connection.mutex.Unlock()
+
+	venues := []domain.Venue{}
+	for _, id := range sortedKeys(c.Connection.venues) {
+		venues = append(venues, domain.CloneVenue(c.Connection.venues[id]))
+	}
+	log.Debugf("Found %d venues", len(venues))
+	return venues, nil
+}
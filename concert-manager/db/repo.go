@@ -5,7 +5,6 @@ import (
 	"concert-manager/log"
 
 	"context"
-	"errors"
 )
 
 type (
@@ -23,20 +22,32 @@ type (
 	}
 	EventDatabase interface {
 		Add(context.Context, domain.Event) (string, error)
+		AddWithReferences(context.Context, domain.Event) (domain.Event, error)
+		Update(context.Context, domain.Event) error
 		Delete(context.Context, string) error
 		FindAll(context.Context) ([]domain.Event, error)
 	}
 	AlbumDatabase interface {
 		Add(context.Context, domain.Album) (string, error)
+		AddWithArtists(context.Context, domain.Album) (domain.Album, error)
 		Update(context.Context, domain.Album) error
 		Delete(context.Context, string) error
 		FindAll(context.Context) ([]domain.Album, error)
 	}
+	BatchDatabase interface {
+		Apply(context.Context, domain.Batch) error
+	}
+	MigrationDatabase interface {
+		AppliedVersions(context.Context) ([]int, error)
+		RecordVersion(context.Context, int, string) error
+	}
 	EventRepository struct {
-		VenueRepo  VenueDatabase
-		ArtistRepo ArtistDatabase
-		EventRepo  EventDatabase
-		AlbumRepo  AlbumDatabase
+		VenueRepo     VenueDatabase
+		ArtistRepo    ArtistDatabase
+		EventRepo     EventDatabase
+		AlbumRepo     AlbumDatabase
+		BatchRepo     BatchDatabase
+		MigrationRepo MigrationDatabase
 	}
 )
 
@@ -44,7 +55,7 @@ func (r *EventRepository) AddVenue(ctx context.Context, venue domain.Venue) (dom
 	log.Debug("Request to add venue", venue)
 	if !venue.Populated() {
 		log.Debug("Skipping adding venue because required fields are missing", venue)
-		return venue, errors.New("failed to create venue due to empty fields")
+		return venue, domain.Invalid("failed to create venue due to empty fields")
 	}
 	newVenue := domain.CloneVenue(venue)
 	id, err := r.VenueRepo.Add(ctx, newVenue)
@@ -94,21 +105,9 @@ func (r *EventRepository) AddArtist(ctx context.Context, artist domain.Artist) (
 	log.Debug("Request to add artist", artist)
 	if !artist.Populated() {
 		log.Debug("Skipping adding artist because required fields are missing", artist)
-		return artist, errors.New("failed to create artist due to empty fields")
-	}
-	newArtist := domain.CloneArtist(artist)
-	if newArtist.Genres.Spotify == nil {
-		newArtist.Genres.Spotify = []string{}
-	}
-	if newArtist.Genres.Ticketmaster == nil {
-		newArtist.Genres.Ticketmaster = []string{}
-	}
-	if newArtist.Genres.LastFm == nil {
-		newArtist.Genres.LastFm = []string{}
-	}
-	if newArtist.Genres.User == nil {
-		newArtist.Genres.User = []string{}
+		return artist, domain.Invalid("failed to create artist due to empty fields")
 	}
+	newArtist := withGenreDefaults(artist)
 	id, err := r.ArtistRepo.Add(ctx, newArtist)
 	if err != nil {
 		log.Errorf("Error while adding artist %v, %v\n", artist, err)
@@ -143,6 +142,24 @@ func (r *EventRepository) DeleteArtist(ctx context.Context, id string) error {
 	return nil
 }
 
+// genre lists are always stored as empty lists rather than null
+func withGenreDefaults(artist domain.Artist) domain.Artist {
+	newArtist := domain.CloneArtist(artist)
+	if newArtist.Genres.Spotify == nil {
+		newArtist.Genres.Spotify = []string{}
+	}
+	if newArtist.Genres.Ticketmaster == nil {
+		newArtist.Genres.Ticketmaster = []string{}
+	}
+	if newArtist.Genres.LastFm == nil {
+		newArtist.Genres.LastFm = []string{}
+	}
+	if newArtist.Genres.User == nil {
+		newArtist.Genres.User = []string{}
+	}
+	return newArtist
+}
+
 func (r *EventRepository) ListArtists(ctx context.Context) ([]domain.Artist, error) {
 	log.Debug("Request to list all artists")
 	artists, err := r.ArtistRepo.FindAll(ctx)
@@ -158,7 +175,7 @@ func (r *EventRepository) AddEvent(ctx context.Context, event domain.Event) (dom
 	log.Debug("Request to add event", event)
 	if !event.Populated() {
 		log.Debug("Skipping adding event because required fields are missing", event)
-		return event, errors.New("failed to create event due to empty fields")
+		return event, domain.Invalid("failed to create event due to empty fields")
 	}
 	newEvent := domain.CloneEvent(event)
 	id, err := r.EventRepo.Add(ctx, newEvent)
@@ -171,6 +188,50 @@ func (r *EventRepository) AddEvent(ctx context.Context, event domain.Event) (dom
 	return newEvent, nil
 }
 
+// Creates the event along with any of its artists and venue that don't exist yet as one atomic write,
+// so a failure part way through doesn't leave behind orphaned artists or venues
+func (r *EventRepository) AddEventWithReferences(ctx context.Context, event domain.Event) (domain.Event, error) {
+	log.Debug("Request to add event with references", event)
+	if !event.Populated() {
+		log.Debug("Skipping adding event because required fields are missing", event)
+		return event, domain.Invalid("failed to create event due to empty fields")
+	}
+	for _, opener := range event.Openers {
+		if !opener.Populated() {
+			log.Debug("Skipping adding event because an opener is missing required fields", event)
+			return event, domain.Invalid("failed to create event due to empty opener fields")
+		}
+	}
+	newEvent := domain.CloneEvent(event)
+	for _, artist := range newEvent.ArtistsMut() {
+		*artist = withGenreDefaults(*artist)
+	}
+	newEvent, err := r.EventRepo.AddWithReferences(ctx, newEvent)
+	if err != nil {
+		log.Errorf("Error while adding event with references %v, %v\n", event, err)
+		return event, err
+	}
+	log.Debug("Added event with references to database", newEvent)
+	return newEvent, nil
+}
+
+// Requires that all the artists and the venue already exist
+func (r *EventRepository) UpdateEvent(ctx context.Context, event domain.Event) (domain.Event, error) {
+	log.Debug("Request to update event", event)
+	if !event.Populated() {
+		log.Debug("Skipping updating event because required fields are missing", event)
+		return event, domain.Invalid("failed to update event due to empty fields")
+	}
+	updateEvent := domain.CloneEvent(event)
+	err := r.EventRepo.Update(ctx, updateEvent)
+	if err != nil {
+		log.Errorf("Error while updating event %v, %v\n", event, err)
+		return event, err
+	}
+	log.Debug("Updated event in database", updateEvent)
+	return updateEvent, nil
+}
+
 func (r *EventRepository) DeleteEvent(ctx context.Context, id string) error {
 	log.Debug("Request to delete event", id)
 	err := r.EventRepo.Delete(ctx, id)
@@ -205,6 +266,26 @@ func (r *EventRepository) AddAlbum(ctx context.Context, album domain.Album) (dom
 	return newAlbum, nil
 }
 
+// Creates the album along with any of its artists that don't exist yet as one atomic write
+func (r *EventRepository) AddAlbumWithArtists(ctx context.Context, album domain.Album) (domain.Album, error) {
+	log.Debug("Request to add album with artists", album)
+	newAlbum := domain.CloneAlbum(album)
+	for i, artist := range newAlbum.Artists {
+		if !artist.Populated() {
+			log.Debug("Skipping adding album because an artist is missing required fields", album)
+			return album, domain.Invalid("failed to create album due to empty artist fields")
+		}
+		newAlbum.Artists[i] = withGenreDefaults(artist)
+	}
+	newAlbum, err := r.AlbumRepo.AddWithArtists(ctx, newAlbum)
+	if err != nil {
+		log.Errorf("Error while adding album with artists %v, %v\n", album, err)
+		return album, err
+	}
+	log.Debug("Added album with artists to database", newAlbum)
+	return newAlbum, nil
+}
+
 func (r *EventRepository) UpdateAlbum(ctx context.Context, album domain.Album) (domain.Album, error) {
 	log.Debug("Request to update album", album)
 	updateAlbum := domain.CloneAlbum(album)
@@ -237,3 +318,15 @@ func (r *EventRepository) ListAlbums(ctx context.Context) ([]domain.Album, error
 	}
 	return albums, nil
 }
+
+// Applies every write of the batch as one atomic write, either all of them are made or none are
+func (r *EventRepository) ApplyBatch(ctx context.Context, batch domain.Batch) error {
+	log.Debugf("Request to apply batch %+v", batch)
+	err := r.BatchRepo.Apply(ctx, batch)
+	if err != nil {
+		log.Errorf("Error while applying batch %+v, %v\n", batch, err)
+		return err
+	}
+	log.Debug("Applied batch to database")
+	return nil
+}
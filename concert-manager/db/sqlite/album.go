@@ -0,0 +1,262 @@
+package sqlite
+
+import (
+	"concert-manager/domain"
+	"concert-manager/log"
+	"concert-manager/util"
+	"context"
+	"database/sql"
+)
+
+const albumTable = "albums"
+
+const albumColumns = "id, name, year, signed, wishlisted, limited_edition, variant, format, genre, notes, cover_image_url, event_id"
+
+type AlbumClient struct {
+	Connection *SQLite
+}
+
+func (c *AlbumClient) Add(ctx context.Context, album domain.Album) (string, error) {
+	log.Debug("Attempting to add album", album)
+	tx, err := c.Connection.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Errorf("Failed to start transaction while adding album %v, %v", album, err)
+		return "", err
+	}
+	defer tx.Rollback()
+
+	id, err := addAlbum(ctx, tx, album)
+	if err != nil {
+		return "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Errorf("Failed to add new album %+v, %v", album, err)
+		return "", err
+	}
+	log.Infof("Created new album %+v", id)
+	return id, nil
+}
+
+// AddWithArtists creates the album along with any of its artists that don't exist yet
+// in a single transaction, returning the album with all IDs filled in
+func (c *AlbumClient) AddWithArtists(ctx context.Context, album domain.Album) (domain.Album, error) {
+	log.Debug("Attempting to add album with artists", album)
+	tx, err := c.Connection.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Errorf("Failed to start transaction while adding album %v, %v", album, err)
+		return album, err
+	}
+	defer tx.Rollback()
+
+	newAlbum := domain.CloneAlbum(album)
+	for i, artist := range newAlbum.Artists {
+		if newAlbum.Artists[i].ID.Primary, err = addArtist(ctx, tx, artist); err != nil {
+			return album, err
+		}
+	}
+	if newAlbum.ID, err = addAlbum(ctx, tx, newAlbum); err != nil {
+		return album, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Errorf("Failed to add new album %+v, %v", album, err)
+		return album, err
+	}
+	log.Infof("Created new album %+v with artists", newAlbum.ID)
+	return newAlbum, nil
+}
+
+func addAlbum(ctx context.Context, tx *sql.Tx, album domain.Album) (string, error) {
+	if err := checkAlbumArtists(ctx, tx, album); err != nil {
+		return "", err
+	}
+
+	exists, err := rowExists(ctx, tx, albumTable, album.ID)
+	if err != nil {
+		log.Errorf("Error occurred while checking if album %v already exists, %v", album, err)
+		return "", err
+	}
+	if exists {
+		log.Debugf("Skipping adding album because it already exists %+v", album)
+		return album.ID, nil
+	}
+
+	id := album.ID
+	if id == "" {
+		id = util.NewID()
+	}
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO albums ("+albumColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		id, album.Name, album.Year, album.Signed, album.Wishlisted, album.LimitedEdition,
+		album.Variant, album.Format, album.Genre, album.Notes, album.CoverImageUrl, album.EventID)
+	if err != nil {
+		log.Errorf("Failed to add new album %+v, %v", album, err)
+		return "", err
+	}
+	if err := insertAlbumArtists(ctx, tx, id, album.Artists); err != nil {
+		log.Errorf("Failed to add artists for new album %+v, %v", album, err)
+		return "", err
+	}
+	return id, nil
+}
+
+func (c *AlbumClient) Update(ctx context.Context, album domain.Album) error {
+	log.Debug("Attempting to update album", album)
+	tx, err := c.Connection.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Errorf("Failed to start transaction while updating album %v, %v", album, err)
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := updateAlbum(ctx, tx, album); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		log.Errorf("Failed to update album %+v, %v", album, err)
+		return err
+	}
+	log.Info("Successfully updated album", album)
+	return nil
+}
+
+func updateAlbum(ctx context.Context, q querier, album domain.Album) error {
+	if err := checkAlbumArtists(ctx, q, album); err != nil {
+		return err
+	}
+
+	result, err := q.ExecContext(ctx,
+		"UPDATE albums SET name = ?, year = ?, signed = ?, wishlisted = ?, limited_edition = ?, "+
+			"variant = ?, format = ?, genre = ?, notes = ?, cover_image_url = ?, event_id = ? WHERE id = ?",
+		album.Name, album.Year, album.Signed, album.Wishlisted, album.LimitedEdition,
+		album.Variant, album.Format, album.Genre, album.Notes, album.CoverImageUrl, album.EventID, album.ID)
+	if err != nil {
+		log.Errorf("Failed to update album %+v, %v", album, err)
+		return err
+	}
+	if updated, _ := result.RowsAffected(); updated == 0 {
+		log.Errorf("Album does not exist in update for %+v", album)
+		return domain.NotFound("album does not exist")
+	}
+
+	if _, err := q.ExecContext(ctx, "DELETE FROM album_artists WHERE album_id = ?", album.ID); err != nil {
+		log.Errorf("Failed to clear artists while updating album %+v, %v", album, err)
+		return err
+	}
+	if err := insertAlbumArtists(ctx, q, album.ID, album.Artists); err != nil {
+		log.Errorf("Failed to update artists for album %+v, %v", album, err)
+		return err
+	}
+	return nil
+}
+
+func (c *AlbumClient) Delete(ctx context.Context, id string) error {
+	log.Debug("Attempting to delete album", id)
+	return deleteAlbum(ctx, c.Connection.DB, id)
+}
+
+func deleteAlbum(ctx context.Context, q querier, id string) error {
+	result, err := q.ExecContext(ctx, "DELETE FROM albums WHERE id = ?", id)
+	if err != nil {
+		log.Error("Failed to delete album", id, err)
+		return err
+	}
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		log.Error("Error while deleting album, album does not exist", id)
+		return domain.NotFound("album does not exist")
+	}
+	log.Info("Successfully deleted album", id)
+	return nil
+}
+
+func (c *AlbumClient) FindAll(ctx context.Context) ([]domain.Album, error) {
+	log.Debug("Finding all albums")
+	artists, err := findAllArtists(ctx, c.Connection.DB)
+	if err != nil {
+		log.Error("Error retrieving artists while finding all albums,", err)
+		return nil, err
+	}
+
+	albumArtists, err := findAllAlbumArtists(ctx, c.Connection.DB, artists)
+	if err != nil {
+		log.Error("Error retrieving album artists while finding all albums,", err)
+		return nil, err
+	}
+
+	rows, err := c.Connection.DB.QueryContext(ctx, "SELECT "+albumColumns+" FROM albums ORDER BY id")
+	if err != nil {
+		log.Error("Error while finding all albums,", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	albums := []domain.Album{}
+	for rows.Next() {
+		album := domain.Album{}
+		err := rows.Scan(&album.ID, &album.Name, &album.Year, &album.Signed, &album.Wishlisted, &album.LimitedEdition,
+			&album.Variant, &album.Format, &album.Genre, &album.Notes, &album.CoverImageUrl, &album.EventID)
+		if err != nil {
+			log.Error("Error while reading album,", err)
+			return nil, err
+		}
+		album.Artists = albumArtists[album.ID]
+		if album.Artists == nil {
+			album.Artists = []domain.Artist{}
+		}
+		albums = append(albums, album)
+	}
+	if err := rows.Err(); err != nil {
+		log.Error("Error while finding all albums,", err)
+		return nil, err
+	}
+	log.Debugf("Found %d albums", len(albums))
+	return albums, nil
+}
+
+func checkAlbumArtists(ctx context.Context, q querier, album domain.Album) error {
+	for _, artist := range album.Artists {
+		exists, err := rowExists(ctx, q, artistTable, artist.ID.Primary)
+		if err != nil {
+			log.Errorf("Error finding existing artist %v for album %v", artist.Name, album)
+			return err
+		}
+		if !exists {
+			log.Errorf("No existing artist %v for album %v", artist.Name, album)
+			return domain.NotFound("artist does not exist")
+		}
+	}
+	return nil
+}
+
+func insertAlbumArtists(ctx context.Context, q querier, albumID string, artists []domain.Artist) error {
+	for i, artist := range artists {
+		_, err := q.ExecContext(ctx,
+			"INSERT INTO album_artists (album_id, artist_id, position) VALUES (?, ?, ?)",
+			albumID, artist.ID.Primary, i)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func findAllAlbumArtists(ctx context.Context, q querier, artists map[string]domain.Artist) (map[string][]domain.Artist, error) {
+	rows, err := q.QueryContext(ctx, "SELECT album_id, artist_id FROM album_artists ORDER BY album_id, position")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	albumArtists := make(map[string][]domain.Artist)
+	for rows.Next() {
+		var albumID, artistID string
+		if err := rows.Scan(&albumID, &artistID); err != nil {
+			return nil, err
+		}
+		if artist, exists := artists[artistID]; exists {
+			albumArtists[albumID] = append(albumArtists[albumID], artist)
+		}
+	}
+	return albumArtists, rows.Err()
+}
@@ -0,0 +1,167 @@
+package sqlite
+
+import (
+	"concert-manager/domain"
+	"concert-manager/log"
+	"concert-manager/util"
+	"context"
+)
+
+const artistTable = "artists"
+
+const artistColumns = "id, name, genre, spotify_genres, lastfm_genres, ticketmaster_genres, user_genres, " +
+	"spotify_id, ticketmaster_id, musicbrainz_id"
+
+type ArtistClient struct {
+	Connection *SQLite
+}
+
+func (c *ArtistClient) Add(ctx context.Context, artist domain.Artist) (string, error) {
+	log.Debug("Attempting to add artist", artist)
+	return addArtist(ctx, c.Connection.DB, artist)
+}
+
+func addArtist(ctx context.Context, q querier, artist domain.Artist) (string, error) {
+	exists, err := rowExists(ctx, q, artistTable, artist.ID.Primary)
+	if err != nil {
+		log.Errorf("Error occurred while checking if artist %v already exists, %v", artist, err)
+		return "", err
+	}
+	if exists {
+		log.Debugf("Skipping adding artist because it already exists %+v", artist)
+		return artist.ID.Primary, nil
+	}
+
+	id := artist.ID.Primary
+	if id == "" {
+		id = util.NewID()
+	}
+	_, err = q.ExecContext(ctx,
+		"INSERT INTO artists ("+artistColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		id, artist.Name, artist.Genre,
+		encodeStrings(artist.Genres.Spotify), encodeStrings(artist.Genres.LastFm),
+		encodeStrings(artist.Genres.Ticketmaster), encodeStrings(artist.Genres.User),
+		artist.ID.Spotify, artist.ID.Ticketmaster, artist.ID.MusicBrainz)
+	if err != nil {
+		log.Errorf("Failed to add new artist %+v, %v", artist, err)
+		return "", err
+	}
+	log.Infof("Created new artist %+v", id)
+	return id, nil
+}
+
+func (c *ArtistClient) Update(ctx context.Context, artist domain.Artist) error {
+	log.Debug("Attempting to update artist", artist)
+	return updateArtist(ctx, c.Connection.DB, artist)
+}
+
+func updateArtist(ctx context.Context, q querier, artist domain.Artist) error {
+	result, err := q.ExecContext(ctx,
+		"UPDATE artists SET name = ?, genre = ?, spotify_genres = ?, lastfm_genres = ?, ticketmaster_genres = ?, "+
+			"user_genres = ?, spotify_id = ?, ticketmaster_id = ?, musicbrainz_id = ? WHERE id = ?",
+		artist.Name, artist.Genre,
+		encodeStrings(artist.Genres.Spotify), encodeStrings(artist.Genres.LastFm),
+		encodeStrings(artist.Genres.Ticketmaster), encodeStrings(artist.Genres.User),
+		artist.ID.Spotify, artist.ID.Ticketmaster, artist.ID.MusicBrainz, artist.ID.Primary)
+	if err != nil {
+		log.Errorf("Failed to update artist %+v, %v", artist, err)
+		return err
+	}
+	if updated, _ := result.RowsAffected(); updated == 0 {
+		log.Errorf("Artist does not exist in update for %+v", artist)
+		return domain.NotFound("artist does not exist")
+	}
+	log.Info("Successfully updated artist", artist)
+	return nil
+}
+
+func (c *ArtistClient) Delete(ctx context.Context, id string) error {
+	log.Debug("Attempting to delete artist", id)
+	return deleteArtist(ctx, c.Connection.DB, id)
+}
+
+func deleteArtist(ctx context.Context, q querier, id string) error {
+	refs, err := findArtistReferences(ctx, q, id)
+	if err != nil {
+		log.Errorf("Error while checking references to artist %s, %v", id, err)
+		return err
+	}
+	if !refs.Empty() {
+		log.Errorf("Error while deleting artist %s, it is still referenced by %+v", id, refs)
+		return domain.ReferencedError{Kind: "artist", ID: id, References: refs}
+	}
+	result, err := q.ExecContext(ctx, "DELETE FROM artists WHERE id = ?", id)
+	if err != nil {
+		log.Error("Failed to delete artist", id, err)
+		return err
+	}
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		log.Error("Error while deleting artist, artist does not exist", id)
+		return domain.NotFound("artist does not exist")
+	}
+	log.Info("Successfully deleted artist", id)
+	return nil
+}
+
+func (c *ArtistClient) FindAll(ctx context.Context) ([]domain.Artist, error) {
+	log.Debug("Finding all artists")
+	artists, err := queryArtists(ctx, c.Connection.DB)
+	if err != nil {
+		log.Error("Error while finding all artists,", err)
+		return nil, err
+	}
+	log.Debugf("Found %d artists", len(artists))
+	return artists, nil
+}
+
+func queryArtists(ctx context.Context, q querier) ([]domain.Artist, error) {
+	rows, err := q.QueryContext(ctx, "SELECT "+artistColumns+" FROM artists ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	artists := []domain.Artist{}
+	for rows.Next() {
+		artist := domain.Artist{}
+		var spotifyGenres, lastFmGenres, ticketmasterGenres, userGenres string
+		err := rows.Scan(&artist.ID.Primary, &artist.Name, &artist.Genre,
+			&spotifyGenres, &lastFmGenres, &ticketmasterGenres, &userGenres,
+			&artist.ID.Spotify, &artist.ID.Ticketmaster, &artist.ID.MusicBrainz)
+		if err != nil {
+			return nil, err
+		}
+		artist.Genres = domain.GenreInfo{
+			Spotify:      decodeStrings(spotifyGenres),
+			LastFm:       decodeStrings(lastFmGenres),
+			Ticketmaster: decodeStrings(ticketmasterGenres),
+			User:         decodeStrings(userGenres),
+		}
+		artists = append(artists, artist)
+	}
+	return artists, rows.Err()
+}
+
+func findAllArtists(ctx context.Context, q querier) (map[string]domain.Artist, error) {
+	artists, err := queryArtists(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	artistMap := make(map[string]domain.Artist)
+	for _, artist := range artists {
+		artistMap[artist.ID.Primary] = artist
+	}
+	return artistMap, nil
+}
+
+func findArtistReferences(ctx context.Context, q querier, id string) (domain.References, error) {
+	events, err := queryIDs(ctx, q, "SELECT id FROM events WHERE main_act_id = ? UNION SELECT event_id FROM event_openers WHERE artist_id = ? ORDER BY 1", id, id)
+	if err != nil {
+		return domain.References{}, err
+	}
+	albums, err := queryIDs(ctx, q, "SELECT DISTINCT album_id FROM album_artists WHERE artist_id = ? ORDER BY album_id", id)
+	if err != nil {
+		return domain.References{}, err
+	}
+	return domain.References{Events: events, Albums: albums}, nil
+}
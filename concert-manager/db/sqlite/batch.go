@@ -0,0 +1,70 @@
+package sqlite
+
+import (
+	"concert-manager/domain"
+	"concert-manager/log"
+	"context"
+)
+
+type BatchClient struct {
+	Connection *SQLite
+}
+
+// Apply makes every write of the batch in one transaction, so a failed write leaves the data as it was
+func (c *BatchClient) Apply(ctx context.Context, batch domain.Batch) error {
+	log.Debugf("Attempting to apply batch %+v", batch)
+	tx, err := c.Connection.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Errorf("Failed to start transaction while applying batch, %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, venue := range batch.UpdateVenues {
+		if err := updateVenue(ctx, tx, venue); err != nil {
+			return err
+		}
+	}
+	for _, artist := range batch.UpdateArtists {
+		if err := updateArtist(ctx, tx, artist); err != nil {
+			return err
+		}
+	}
+	for _, event := range batch.UpdateEvents {
+		if err := updateEvent(ctx, tx, event); err != nil {
+			return err
+		}
+	}
+	for _, album := range batch.UpdateAlbums {
+		if err := updateAlbum(ctx, tx, album); err != nil {
+			return err
+		}
+	}
+	for _, id := range batch.DeleteEvents {
+		if err := deleteEvent(ctx, tx, id); err != nil {
+			return err
+		}
+	}
+	for _, id := range batch.DeleteAlbums {
+		if err := deleteAlbum(ctx, tx, id); err != nil {
+			return err
+		}
+	}
+	for _, id := range batch.DeleteArtists {
+		if err := deleteArtist(ctx, tx, id); err != nil {
+			return err
+		}
+	}
+	for _, id := range batch.DeleteVenues {
+		if err := deleteVenue(ctx, tx, id); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Errorf("Failed to apply batch, %v", err)
+		return err
+	}
+	log.Info("Successfully applied batch")
+	return nil
+}
@@ -0,0 +1,548 @@
+package sqlite
+
+import (
+	"concert-manager/domain"
+	"concert-manager/log"
+	"concert-manager/util"
+	"context"
+	"database/sql"
+	"time"
+)
+
+const eventTable = "events"
+
+const eventColumns = "id, main_act_id, venue_id, date, purchased, ticketmaster_id, rating, notes, seat, companions, " +
+	"ticket_price, ticket_quantity, ticket_fees, purchase_date, festival_name, festival_end, start_time"
+
+type EventClient struct {
+	Connection *SQLite
+}
+
+func (c *EventClient) Add(ctx context.Context, event domain.Event) (string, error) {
+	log.Debug("Attempting to add event", event)
+	tx, err := c.Connection.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Errorf("Failed to start transaction while adding event %v, %v", event, err)
+		return "", err
+	}
+	defer tx.Rollback()
+
+	id, err := addEvent(ctx, tx, event)
+	if err != nil {
+		return "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Errorf("Failed to add event %+v, %v", event, err)
+		return "", err
+	}
+	log.Infof("Created new event %+v", id)
+	return id, nil
+}
+
+// AddWithReferences creates the event along with any of its artists and venue that don't exist yet
+// in a single transaction, returning the event with all IDs filled in.
+// Nothing is written when an event with the ID already exists.
+func (c *EventClient) AddWithReferences(ctx context.Context, event domain.Event) (domain.Event, error) {
+	log.Debug("Attempting to add event with references", event)
+	tx, err := c.Connection.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Errorf("Failed to start transaction while adding event %v, %v", event, err)
+		return event, err
+	}
+	defer tx.Rollback()
+
+	exists, err := rowExists(ctx, tx, eventTable, event.ID.Primary)
+	if err != nil {
+		log.Errorf("Error occurred while checking if event %v already exists, %v", event, err)
+		return event, err
+	}
+	if exists {
+		log.Errorf("Event already exists when adding with references %+v", event)
+		return event, domain.Conflict("event %s already exists", event.ID.Primary)
+	}
+	newEvent := domain.CloneEvent(event)
+	for _, artist := range newEvent.ArtistsMut() {
+		if !artist.Populated() {
+			continue
+		}
+		if artist.ID.Primary, err = addArtist(ctx, tx, *artist); err != nil {
+			return event, err
+		}
+	}
+	if newEvent.Venue.ID.Primary, err = addVenue(ctx, tx, newEvent.Venue); err != nil {
+		return event, err
+	}
+	newEvent.ResolveArtistRefs()
+	if newEvent.ID.Primary, err = addEvent(ctx, tx, newEvent); err != nil {
+		return event, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Errorf("Failed to add event %+v, %v", event, err)
+		return event, err
+	}
+	log.Infof("Created new event %+v with references", newEvent.ID.Primary)
+	return newEvent, nil
+}
+
+func addEvent(ctx context.Context, tx *sql.Tx, event domain.Event) (string, error) {
+	mainActID, err := checkEventReferences(ctx, tx, event)
+	if err != nil {
+		return "", err
+	}
+
+	exists, err := rowExists(ctx, tx, eventTable, event.ID.Primary)
+	if err != nil {
+		log.Errorf("Error occurred while checking if event %v already exists, %v", event, err)
+		return "", err
+	}
+	if exists {
+		log.Debugf("Skipped adding event because it already existed as %+v", event)
+		return event.ID.Primary, nil
+	}
+
+	id := event.ID.Primary
+	if id == "" {
+		id = util.NewID()
+	}
+	festivalName, festivalEnd := toFestivalColumns(event.Festival)
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO events ("+eventColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		id, mainActID, event.Venue.ID.Primary, toDateColumn(event.Date), event.Purchased, event.ID.Ticketmaster,
+		event.Rating, event.Notes, event.Seat, encodeStrings(event.Companions),
+		event.Ticket.PriceCents, event.Ticket.Quantity, event.Ticket.FeesCents, toOptionalDateColumn(event.Ticket.PurchaseDate),
+		festivalName, festivalEnd, domain.FormatOptionalTimeOfDay(event.StartTime))
+	if err != nil {
+		log.Errorf("Failed to add event %+v, %v", event, err)
+		return "", err
+	}
+	if err := insertOpeners(ctx, tx, id, event.Openers); err != nil {
+		log.Errorf("Failed to add openers for event %+v, %v", event, err)
+		return "", err
+	}
+	if err := insertPerformances(ctx, tx, id, event.Performances); err != nil {
+		log.Errorf("Failed to add performances for event %+v, %v", event, err)
+		return "", err
+	}
+	if err := insertFestivalDays(ctx, tx, id, event.Festival); err != nil {
+		log.Errorf("Failed to add festival days for event %+v, %v", event, err)
+		return "", err
+	}
+	return id, nil
+}
+
+func (c *EventClient) Update(ctx context.Context, event domain.Event) error {
+	log.Debug("Attempting to update event", event)
+	tx, err := c.Connection.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.Errorf("Failed to start transaction while updating event %v, %v", event, err)
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := updateEvent(ctx, tx, event); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		log.Errorf("Failed to update event %+v, %v", event, err)
+		return err
+	}
+	log.Info("Successfully updated event", event.ID.Primary)
+	return nil
+}
+
+func updateEvent(ctx context.Context, q querier, event domain.Event) error {
+	mainActID, err := checkEventReferences(ctx, q, event)
+	if err != nil {
+		return err
+	}
+
+	festivalName, festivalEnd := toFestivalColumns(event.Festival)
+	result, err := q.ExecContext(ctx,
+		"UPDATE events SET main_act_id = ?, venue_id = ?, date = ?, purchased = ?, ticketmaster_id = ?, "+
+			"rating = ?, notes = ?, seat = ?, companions = ?, "+
+			"ticket_price = ?, ticket_quantity = ?, ticket_fees = ?, purchase_date = ?, "+
+			"festival_name = ?, festival_end = ?, start_time = ? WHERE id = ?",
+		mainActID, event.Venue.ID.Primary, toDateColumn(event.Date), event.Purchased, event.ID.Ticketmaster,
+		event.Rating, event.Notes, event.Seat, encodeStrings(event.Companions),
+		event.Ticket.PriceCents, event.Ticket.Quantity, event.Ticket.FeesCents, toOptionalDateColumn(event.Ticket.PurchaseDate),
+		festivalName, festivalEnd, domain.FormatOptionalTimeOfDay(event.StartTime), event.ID.Primary)
+	if err != nil {
+		log.Errorf("Failed to update event %+v, %v", event, err)
+		return err
+	}
+	if updated, _ := result.RowsAffected(); updated == 0 {
+		log.Errorf("Event does not exist in update for %+v", event)
+		return domain.NotFound("event does not exist")
+	}
+
+	if _, err := q.ExecContext(ctx, "DELETE FROM event_openers WHERE event_id = ?", event.ID.Primary); err != nil {
+		log.Errorf("Failed to clear openers while updating event %+v, %v", event, err)
+		return err
+	}
+	if err := insertOpeners(ctx, q, event.ID.Primary, event.Openers); err != nil {
+		log.Errorf("Failed to update openers for event %+v, %v", event, err)
+		return err
+	}
+	if _, err := q.ExecContext(ctx, "DELETE FROM event_performances WHERE event_id = ?", event.ID.Primary); err != nil {
+		log.Errorf("Failed to clear performances while updating event %+v, %v", event, err)
+		return err
+	}
+	if err := insertPerformances(ctx, q, event.ID.Primary, event.Performances); err != nil {
+		log.Errorf("Failed to update performances for event %+v, %v", event, err)
+		return err
+	}
+	// the sets reference their day by position so both are rewritten together
+	for _, table := range []string{"festival_sets", "festival_days"} {
+		if _, err := q.ExecContext(ctx, "DELETE FROM "+table+" WHERE event_id = ?", event.ID.Primary); err != nil {
+			log.Errorf("Failed to clear %s while updating event %+v, %v", table, event, err)
+			return err
+		}
+	}
+	if err := insertFestivalDays(ctx, q, event.ID.Primary, event.Festival); err != nil {
+		log.Errorf("Failed to update festival days for event %+v, %v", event, err)
+		return err
+	}
+	return nil
+}
+
+// verifies the artists and venue for the event already exist, returning the main act ID to store
+func checkEventReferences(ctx context.Context, q querier, event domain.Event) (sql.NullString, error) {
+	var mainActID sql.NullString
+	if event.MainAct.Populated() {
+		exists, err := rowExists(ctx, q, artistTable, event.MainAct.ID.Primary)
+		if err != nil {
+			log.Errorf("Error finding existing artist %v for event %v", event.MainAct.Name, event)
+			return mainActID, err
+		}
+		if !exists {
+			log.Errorf("No existing artist %v for event %v", event.MainAct.Name, event)
+			return mainActID, domain.NotFound("main artist does not exist")
+		}
+		mainActID = sql.NullString{String: event.MainAct.ID.Primary, Valid: true}
+	}
+
+	for _, opener := range event.Openers {
+		exists, err := rowExists(ctx, q, artistTable, opener.ID.Primary)
+		if err != nil {
+			log.Errorf("Error finding existing opening artist %v for event %v", opener.Name, event)
+			return mainActID, err
+		}
+		if !exists {
+			log.Errorf("No existing opening artist %v for event %v", opener.Name, event)
+			return mainActID, domain.NotFound("opening artist does not exist")
+		}
+	}
+
+	exists, err := rowExists(ctx, q, venueTable, event.Venue.ID.Primary)
+	if err != nil {
+		log.Errorf("Error finding existing venue %+v for event", event.Venue)
+		return mainActID, err
+	}
+	if !exists {
+		log.Errorf("No existing venue %+v for event", event.Venue)
+		return mainActID, domain.NotFound("venue does not exist")
+	}
+	return mainActID, nil
+}
+
+func insertOpeners(ctx context.Context, q querier, eventID string, openers []domain.Artist) error {
+	for i, opener := range openers {
+		_, err := q.ExecContext(ctx,
+			"INSERT INTO event_openers (event_id, artist_id, position) VALUES (?, ?, ?)",
+			eventID, opener.ID.Primary, i)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertPerformances(ctx context.Context, q querier, eventID string, performances []domain.Performance) error {
+	for i, performance := range performances {
+		_, err := q.ExecContext(ctx,
+			"INSERT INTO event_performances (event_id, artist_id, rating, highlights, position) VALUES (?, ?, ?, ?, ?)",
+			eventID, performance.ArtistID, performance.Rating, performance.Highlights, i)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func toFestivalColumns(festival *domain.Festival) (string, string) {
+	if festival == nil {
+		return "", ""
+	}
+	return festival.Name, toDateColumn(festival.EndDate)
+}
+
+func insertFestivalDays(ctx context.Context, q querier, eventID string, festival *domain.Festival) error {
+	if festival == nil {
+		return nil
+	}
+	for i, day := range festival.Days {
+		_, err := q.ExecContext(ctx,
+			"INSERT INTO festival_days (event_id, date, position) VALUES (?, ?, ?)",
+			eventID, toDateColumn(day.Date), i)
+		if err != nil {
+			return err
+		}
+		for j, set := range day.Sets {
+			_, err := q.ExecContext(ctx,
+				"INSERT INTO festival_sets (event_id, day_position, artist_id, stage, start_time, end_time, seen, position) "+
+					"VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+				eventID, i, set.ArtistID, set.Stage,
+				domain.FormatOptionalTimeOfDay(set.Start), domain.FormatOptionalTimeOfDay(set.End), set.Seen, j)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *EventClient) Delete(ctx context.Context, id string) error {
+	log.Debug("Attempting to delete event", id)
+	return deleteEvent(ctx, c.Connection.DB, id)
+}
+
+func deleteEvent(ctx context.Context, q querier, id string) error {
+	result, err := q.ExecContext(ctx, "DELETE FROM events WHERE id = ?", id)
+	if err != nil {
+		log.Error("Failed to delete event", id, err)
+		return err
+	}
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		log.Errorf("Error while deleting event %s, event does not exist", id)
+		return domain.NotFound("event does not exist")
+	}
+	log.Infof("Successfully deleted event %+v", id)
+	return nil
+}
+
+func (c *EventClient) FindAll(ctx context.Context) ([]domain.Event, error) {
+	log.Debug("Finding all events")
+	artists, err := findAllArtists(ctx, c.Connection.DB)
+	if err != nil {
+		log.Error("Error retrieving artists while finding all events,", err)
+		return nil, err
+	}
+	log.Debugf("Found %d artists while retrieving all events", len(artists))
+
+	venues, err := findAllVenues(ctx, c.Connection.DB)
+	if err != nil {
+		log.Error("Error retrieving venues while finding all events,", err)
+		return nil, err
+	}
+	log.Debugf("Found %d venues while retrieving all events", len(venues))
+
+	openers, err := findAllOpeners(ctx, c.Connection.DB, artists)
+	if err != nil {
+		log.Error("Error retrieving openers while finding all events,", err)
+		return nil, err
+	}
+
+	performances, err := findAllPerformances(ctx, c.Connection.DB)
+	if err != nil {
+		log.Error("Error retrieving performances while finding all events,", err)
+		return nil, err
+	}
+
+	festivalDays, err := findAllFestivalDays(ctx, c.Connection.DB)
+	if err != nil {
+		log.Error("Error retrieving festival days while finding all events,", err)
+		return nil, err
+	}
+
+	rows, err := c.Connection.DB.QueryContext(ctx, "SELECT "+eventColumns+" FROM events ORDER BY id")
+	if err != nil {
+		log.Error("Error while finding all events,", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []domain.Event{}
+	for rows.Next() {
+		var id, venueID, date, ticketmasterID, notes, seat, companions, purchaseDate, festivalName, festivalEnd, startTime string
+		var mainActID sql.NullString
+		var purchased bool
+		var rating int
+		var ticket domain.Ticket
+		if err := rows.Scan(&id, &mainActID, &venueID, &date, &purchased, &ticketmasterID,
+			&rating, &notes, &seat, &companions,
+			&ticket.PriceCents, &ticket.Quantity, &ticket.FeesCents, &purchaseDate, &festivalName, &festivalEnd, &startTime); err != nil {
+			log.Error("Error while reading event,", err)
+			return nil, err
+		}
+
+		var mainAct domain.Artist
+		if mainActID.Valid {
+			mainAct = artists[mainActID.String]
+		}
+		eventOpeners := openers[id]
+		if eventOpeners == nil {
+			eventOpeners = []domain.Artist{}
+		}
+		eventPerformances := performances[id]
+		if eventPerformances == nil {
+			eventPerformances = []domain.Performance{}
+		}
+		ticket.PurchaseDate = fromOptionalDateColumn(purchaseDate)
+		var festival *domain.Festival
+		if festivalEnd != "" {
+			days := festivalDays[id]
+			if days == nil {
+				days = []domain.FestivalDay{}
+			}
+			festival = &domain.Festival{Name: festivalName, EndDate: fromDateColumn(festivalEnd), Days: days}
+		}
+
+		events = append(events, domain.Event{
+			MainAct:   &mainAct,
+			Openers:   eventOpeners,
+			Venue:     venues[venueID],
+			Date:      fromDateColumn(date),
+			StartTime: fromTimeColumn(startTime),
+			Purchased: purchased,
+			ID:        domain.ID{Primary: id, Ticketmaster: ticketmasterID},
+
+			Rating:       rating,
+			Performances: eventPerformances,
+			Notes:        notes,
+			Seat:         seat,
+			Companions:   decodeStrings(companions),
+			Ticket:       ticket,
+			Festival:     festival,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		log.Error("Error while finding all events,", err)
+		return nil, err
+	}
+
+	log.Debugf("Returning %d constructed events", len(events))
+	return events, nil
+}
+
+func findAllOpeners(ctx context.Context, q querier, artists map[string]domain.Artist) (map[string][]domain.Artist, error) {
+	rows, err := q.QueryContext(ctx, "SELECT event_id, artist_id FROM event_openers ORDER BY event_id, position")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	openers := make(map[string][]domain.Artist)
+	for rows.Next() {
+		var eventID, artistID string
+		if err := rows.Scan(&eventID, &artistID); err != nil {
+			return nil, err
+		}
+		openers[eventID] = append(openers[eventID], artists[artistID])
+	}
+	return openers, rows.Err()
+}
+
+func findAllPerformances(ctx context.Context, q querier) (map[string][]domain.Performance, error) {
+	rows, err := q.QueryContext(ctx, "SELECT event_id, artist_id, rating, highlights FROM event_performances ORDER BY event_id, position")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	performances := make(map[string][]domain.Performance)
+	for rows.Next() {
+		var eventID string
+		var performance domain.Performance
+		if err := rows.Scan(&eventID, &performance.ArtistID, &performance.Rating, &performance.Highlights); err != nil {
+			return nil, err
+		}
+		performances[eventID] = append(performances[eventID], performance)
+	}
+	return performances, rows.Err()
+}
+
+func findAllFestivalDays(ctx context.Context, q querier) (map[string][]domain.FestivalDay, error) {
+	rows, err := q.QueryContext(ctx, "SELECT event_id, date FROM festival_days ORDER BY event_id, position")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := make(map[string][]domain.FestivalDay)
+	for rows.Next() {
+		var eventID, date string
+		if err := rows.Scan(&eventID, &date); err != nil {
+			return nil, err
+		}
+		days[eventID] = append(days[eventID], domain.FestivalDay{Date: fromDateColumn(date), Sets: []domain.Set{}})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	setRows, err := q.QueryContext(ctx,
+		"SELECT event_id, day_position, artist_id, stage, start_time, end_time, seen FROM festival_sets "+
+			"ORDER BY event_id, day_position, position")
+	if err != nil {
+		return nil, err
+	}
+	defer setRows.Close()
+
+	for setRows.Next() {
+		var eventID string
+		var dayPosition int
+		var startTime, endTime string
+		var set domain.Set
+		if err := setRows.Scan(&eventID, &dayPosition, &set.ArtistID, &set.Stage, &startTime, &endTime, &set.Seen); err != nil {
+			return nil, err
+		}
+		set.Start, set.End = fromTimeColumn(startTime), fromTimeColumn(endTime)
+		if dayPosition < 0 || dayPosition >= len(days[eventID]) {
+			log.Errorf("Festival set %+v for event %s has no matching day", set, eventID)
+			continue
+		}
+		days[eventID][dayPosition].Sets = append(days[eventID][dayPosition].Sets, set)
+	}
+	return days, setRows.Err()
+}
+
+// dates are stored as ISO dates so they sort and compare correctly in queries
+func toDateColumn(date domain.Date) string {
+	return date.Time(time.UTC).Format(time.DateOnly)
+}
+
+// dates that are optional are stored as an empty string when unset
+func toOptionalDateColumn(date domain.Date) string {
+	if date.IsZero() {
+		return ""
+	}
+	return toDateColumn(date)
+}
+
+func fromOptionalDateColumn(date string) domain.Date {
+	if date == "" {
+		return domain.Date{}
+	}
+	return fromDateColumn(date)
+}
+
+func fromDateColumn(date string) domain.Date {
+	ts, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		log.Errorf("Invalid stored event date %s, %v", date, err)
+		return domain.Date{}
+	}
+	return domain.DateOf(ts)
+}
+
+// times of day are stored as "HH:MM", or an empty string when unset
+func fromTimeColumn(value string) *domain.TimeOfDay {
+	t, err := domain.ParseOptionalTimeOfDay(value)
+	if err != nil {
+		log.Errorf("Invalid stored time %s, %v", value, err)
+		return nil
+	}
+	return t
+}
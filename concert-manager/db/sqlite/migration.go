@@ -0,0 +1,44 @@
+package sqlite
+
+import (
+	"concert-manager/log"
+	"context"
+	"time"
+)
+
+type MigrationClient struct {
+	Connection *SQLite
+}
+
+func (c *MigrationClient) AppliedVersions(ctx context.Context) ([]int, error) {
+	log.Debug("Finding applied migration versions")
+	rows, err := c.Connection.DB.QueryContext(ctx, "SELECT version FROM schema_migrations ORDER BY version")
+	if err != nil {
+		log.Error("Error while finding applied migrations,", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []int{}
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+	return versions, rows.Err()
+}
+
+func (c *MigrationClient) RecordVersion(ctx context.Context, version int, name string) error {
+	log.Debugf("Recording migration version %d %s", version, name)
+	_, err := c.Connection.DB.ExecContext(ctx,
+		"INSERT OR REPLACE INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+		version, name, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		log.Errorf("Failed to record migration version %d, %v", version, err)
+		return err
+	}
+	log.Infof("Recorded migration version %d", version)
+	return nil
+}
@@ -0,0 +1,270 @@
+package sqlite
+
+import (
+	"concert-manager/file"
+	"concert-manager/log"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite"
+)
+
+const (
+	pathEnv         = "CM_SQLITE_PATH"
+	defaultFileName = "beacon.db"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS venues (
+	id              TEXT PRIMARY KEY,
+	name            TEXT NOT NULL,
+	city            TEXT NOT NULL,
+	state           TEXT NOT NULL,
+	ticketmaster_id TEXT NOT NULL DEFAULT '',
+	time_zone       TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS artists (
+	id                  TEXT PRIMARY KEY,
+	name                TEXT NOT NULL,
+	genre               TEXT NOT NULL DEFAULT '',
+	spotify_genres      TEXT NOT NULL DEFAULT '[]',
+	lastfm_genres       TEXT NOT NULL DEFAULT '[]',
+	ticketmaster_genres TEXT NOT NULL DEFAULT '[]',
+	user_genres         TEXT NOT NULL DEFAULT '[]',
+	spotify_id          TEXT NOT NULL DEFAULT '',
+	ticketmaster_id     TEXT NOT NULL DEFAULT '',
+	musicbrainz_id      TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS events (
+	id              TEXT PRIMARY KEY,
+	main_act_id     TEXT REFERENCES artists(id),
+	venue_id        TEXT NOT NULL REFERENCES venues(id),
+	date            TEXT NOT NULL,
+	start_time      TEXT NOT NULL DEFAULT '',
+	purchased       INTEGER NOT NULL DEFAULT 0,
+	ticketmaster_id TEXT NOT NULL DEFAULT '',
+	rating          INTEGER NOT NULL DEFAULT 0,
+	notes           TEXT NOT NULL DEFAULT '',
+	seat            TEXT NOT NULL DEFAULT '',
+	companions      TEXT NOT NULL DEFAULT '[]',
+	ticket_price    INTEGER NOT NULL DEFAULT 0,
+	ticket_quantity INTEGER NOT NULL DEFAULT 0,
+	ticket_fees     INTEGER NOT NULL DEFAULT 0,
+	purchase_date   TEXT NOT NULL DEFAULT '',
+	festival_name   TEXT NOT NULL DEFAULT '',
+	festival_end    TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS event_openers (
+	event_id  TEXT NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+	artist_id TEXT NOT NULL REFERENCES artists(id),
+	position  INTEGER NOT NULL,
+	PRIMARY KEY (event_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS event_performances (
+	event_id   TEXT NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+	artist_id  TEXT NOT NULL REFERENCES artists(id),
+	rating     INTEGER NOT NULL DEFAULT 0,
+	highlights TEXT NOT NULL DEFAULT '',
+	position   INTEGER NOT NULL,
+	PRIMARY KEY (event_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS festival_days (
+	event_id TEXT NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+	date     TEXT NOT NULL,
+	position INTEGER NOT NULL,
+	PRIMARY KEY (event_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS festival_sets (
+	event_id     TEXT NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+	day_position INTEGER NOT NULL,
+	artist_id    TEXT NOT NULL REFERENCES artists(id),
+	stage        TEXT NOT NULL DEFAULT '',
+	start_time   TEXT NOT NULL DEFAULT '',
+	end_time     TEXT NOT NULL DEFAULT '',
+	seen         INTEGER NOT NULL DEFAULT 0,
+	position     INTEGER NOT NULL,
+	PRIMARY KEY (event_id, day_position, position)
+);
+
+CREATE TABLE IF NOT EXISTS albums (
+	id              TEXT PRIMARY KEY,
+	name            TEXT NOT NULL,
+	year            INTEGER NOT NULL DEFAULT 0,
+	signed          INTEGER NOT NULL DEFAULT 0,
+	wishlisted      INTEGER NOT NULL DEFAULT 0,
+	limited_edition INTEGER NOT NULL DEFAULT 0,
+	variant         TEXT NOT NULL DEFAULT '',
+	format          TEXT NOT NULL DEFAULT '',
+	genre           TEXT NOT NULL DEFAULT '',
+	notes           TEXT NOT NULL DEFAULT '',
+	cover_image_url TEXT NOT NULL DEFAULT '',
+	event_id        TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS album_artists (
+	album_id  TEXT NOT NULL REFERENCES albums(id) ON DELETE CASCADE,
+	artist_id TEXT NOT NULL REFERENCES artists(id),
+	position  INTEGER NOT NULL,
+	PRIMARY KEY (album_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS schema_migrations (
+	version    INTEGER PRIMARY KEY,
+	name       TEXT NOT NULL,
+	applied_at TEXT NOT NULL
+);
+`
+
+// columns added after a table was first released, which CREATE TABLE IF NOT EXISTS
+// doesn't add to databases created before them
+var addedColumns = []struct {
+	table      string
+	column     string
+	definition string
+}{
+	{"events", "rating", "INTEGER NOT NULL DEFAULT 0"},
+	{"events", "notes", "TEXT NOT NULL DEFAULT ''"},
+	{"events", "seat", "TEXT NOT NULL DEFAULT ''"},
+	{"events", "companions", "TEXT NOT NULL DEFAULT '[]'"},
+	{"events", "ticket_price", "INTEGER NOT NULL DEFAULT 0"},
+	{"events", "ticket_quantity", "INTEGER NOT NULL DEFAULT 0"},
+	{"events", "ticket_fees", "INTEGER NOT NULL DEFAULT 0"},
+	{"events", "purchase_date", "TEXT NOT NULL DEFAULT ''"},
+	{"events", "festival_name", "TEXT NOT NULL DEFAULT ''"},
+	// an empty end date means the event isn't a festival
+	{"events", "festival_end", "TEXT NOT NULL DEFAULT ''"},
+	{"events", "start_time", "TEXT NOT NULL DEFAULT ''"},
+	{"venues", "time_zone", "TEXT NOT NULL DEFAULT ''"},
+	{"albums", "event_id", "TEXT NOT NULL DEFAULT ''"},
+}
+
+type SQLite struct {
+	DB *sql.DB
+}
+
+// querier is satisfied by both *sql.DB and *sql.Tx so lookups can be shared
+// between standalone queries and multi-statement writes
+type querier interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+	QueryContext(context.Context, string, ...any) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...any) *sql.Row
+}
+
+// Setup opens the database of the user, every user other than the default one has its own file
+func Setup(userID string) (*SQLite, error) {
+	path := os.Getenv(pathEnv)
+	if path == "" {
+		defaultPath, err := file.GetCacheFilePath(defaultFileName)
+		if err != nil {
+			return nil, err
+		}
+		path = defaultPath
+	}
+	path = filepath.Join(filepath.Dir(path), file.UserFileName(filepath.Base(path), userID))
+	log.Debug("Opening SQLite database at", path)
+
+	conn, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Successfully initialized database")
+	return conn, nil
+}
+
+// Open connects to the database file at path, creating it and the schema if needed.
+// Use ":memory:" for a throwaway database.
+func Open(path string) (*SQLite, error) {
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s?_pragma=foreign_keys(1)", path))
+	if err != nil {
+		return nil, err
+	}
+	// SQLite only supports a single writer, and each connection to ":memory:" is a separate database
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create database schema: %w", err)
+	}
+	if err := addMissingColumns(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to upgrade database schema: %w", err)
+	}
+	return &SQLite{db}, nil
+}
+
+func addMissingColumns(db *sql.DB) error {
+	for _, added := range addedColumns {
+		var found int
+		err := db.QueryRow("SELECT 1 FROM pragma_table_info(?) WHERE name = ?", added.table, added.column).Scan(&found)
+		if err == nil {
+			continue
+		}
+		if err != sql.ErrNoRows {
+			return err
+		}
+		log.Infof("Adding column %s to table %s", added.column, added.table)
+		if _, err := db.Exec("ALTER TABLE " + added.table + " ADD COLUMN " + added.column + " " + added.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func rowExists(ctx context.Context, q querier, table, id string) (bool, error) {
+	if id == "" {
+		return false, nil
+	}
+	var found int
+	err := q.QueryRowContext(ctx, "SELECT 1 FROM "+table+" WHERE id = ?", id).Scan(&found)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func queryIDs(ctx context.Context, q querier, query string, args ...any) ([]string, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func encodeStrings(values []string) string {
+	if values == nil {
+		return "[]"
+	}
+	encoded, _ := json.Marshal(values)
+	return string(encoded)
+}
+
+func decodeStrings(encoded string) []string {
+	values := []string{}
+	if err := json.Unmarshal([]byte(encoded), &values); err != nil || values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,466 @@
+package sqlite
+
+import (
+	"concert-manager/domain"
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func setupClients(t *testing.T) (*VenueClient, *ArtistClient, *EventClient, *AlbumClient) {
+	conn, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { conn.DB.Close() })
+	return &VenueClient{conn}, &ArtistClient{conn}, &EventClient{conn}, &AlbumClient{conn}
+}
+
+func TestAddAndFindEvent(t *testing.T) {
+	ctx := context.Background()
+	venues, artists, events, _ := setupClients(t)
+
+	venueID, err := venues.Add(ctx, domain.Venue{Name: "The Earl", City: "Atlanta", State: "GA"})
+	if err != nil {
+		t.Fatalf("failed to add venue: %v", err)
+	}
+	mainAct := domain.Artist{Name: "Main", Genres: domain.GenreInfo{User: []string{"rock"}}}
+	mainActID, err := artists.Add(ctx, mainAct)
+	if err != nil {
+		t.Fatalf("failed to add main act: %v", err)
+	}
+	openerID, err := artists.Add(ctx, domain.Artist{Name: "Opener"})
+	if err != nil {
+		t.Fatalf("failed to add opener: %v", err)
+	}
+
+	event := domain.Event{
+		MainAct:   &domain.Artist{Name: "Main", ID: domain.ID{Primary: mainActID}},
+		Openers:   []domain.Artist{{Name: "Opener", ID: domain.ID{Primary: openerID}}},
+		Venue:     domain.Venue{Name: "The Earl", City: "Atlanta", State: "GA", ID: domain.ID{Primary: venueID}},
+		Date:      domain.MustParseDate("3/14/2024"),
+		Purchased: true,
+		ID:        domain.ID{Ticketmaster: "tm1"},
+	}
+	eventID, err := events.Add(ctx, event)
+	if err != nil {
+		t.Fatalf("failed to add event: %v", err)
+	}
+
+	found, err := events.FindAll(ctx)
+	if err != nil {
+		t.Fatalf("failed to find events: %v", err)
+	}
+	if len(found) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(found))
+	}
+	got := found[0]
+	if got.ID.Primary != eventID || got.ID.Ticketmaster != "tm1" {
+		t.Errorf("unexpected event IDs %+v", got.ID)
+	}
+	if got.Date != domain.MustParseDate("3/14/2024") || !got.Purchased {
+		t.Errorf("unexpected event fields %+v", got)
+	}
+	if got.MainAct.Name != "Main" || len(got.MainAct.Genres.User) != 1 || got.MainAct.Genres.User[0] != "rock" {
+		t.Errorf("unexpected main act %+v", got.MainAct)
+	}
+	if len(got.Openers) != 1 || got.Openers[0].ID.Primary != openerID {
+		t.Errorf("unexpected openers %+v", got.Openers)
+	}
+	if got.Venue.ID.Primary != venueID || got.Venue.Name != "The Earl" {
+		t.Errorf("unexpected venue %+v", got.Venue)
+	}
+
+	againID, err := events.Add(ctx, got)
+	if err != nil || againID != eventID {
+		t.Errorf("expected re-adding an existing event to return %s, got %s, %v", eventID, againID, err)
+	}
+
+	got.Openers = []domain.Artist{}
+	got.Date = domain.MustParseDate("3/15/2024")
+	if err := events.Update(ctx, got); err != nil {
+		t.Fatalf("failed to update event: %v", err)
+	}
+	found, _ = events.FindAll(ctx)
+	if len(found) != 1 || len(found[0].Openers) != 0 || found[0].Date != domain.MustParseDate("3/15/2024") {
+		t.Errorf("unexpected event after update %+v", found)
+	}
+
+	got.ID.Primary = "missing"
+	if err := events.Update(ctx, got); err == nil {
+		t.Error("expected error updating missing event")
+	}
+}
+
+func TestAddEventMissingReferences(t *testing.T) {
+	ctx := context.Background()
+	venues, artists, events, _ := setupClients(t)
+
+	venueID, _ := venues.Add(ctx, domain.Venue{Name: "The Earl", City: "Atlanta", State: "GA"})
+	artistID, _ := artists.Add(ctx, domain.Artist{Name: "Main"})
+
+	missingArtist := domain.Event{
+		MainAct: &domain.Artist{Name: "Unknown", ID: domain.ID{Primary: "missing"}},
+		Venue:   domain.Venue{ID: domain.ID{Primary: venueID}},
+		Date:    domain.MustParseDate("1/1/2024"),
+	}
+	if _, err := events.Add(ctx, missingArtist); err == nil {
+		t.Error("expected error when main act does not exist")
+	}
+
+	missingVenue := domain.Event{
+		MainAct: &domain.Artist{Name: "Main", ID: domain.ID{Primary: artistID}},
+		Venue:   domain.Venue{ID: domain.ID{Primary: "missing"}},
+		Date:    domain.MustParseDate("1/1/2024"),
+	}
+	if _, err := events.Add(ctx, missingVenue); err == nil {
+		t.Error("expected error when venue does not exist")
+	}
+
+	found, _ := events.FindAll(ctx)
+	if len(found) != 0 {
+		t.Errorf("expected no events to be saved, got %d", len(found))
+	}
+}
+
+func TestUpdateAndDeleteArtist(t *testing.T) {
+	ctx := context.Background()
+	_, artists, _, _ := setupClients(t)
+
+	id, _ := artists.Add(ctx, domain.Artist{Name: "Before"})
+	updated := domain.Artist{Name: "After", ID: domain.ID{Primary: id, Spotify: "sp1"}}
+	if err := artists.Update(ctx, updated); err != nil {
+		t.Fatalf("failed to update artist: %v", err)
+	}
+	found, _ := artists.FindAll(ctx)
+	if len(found) != 1 || found[0].Name != "After" || found[0].ID.Spotify != "sp1" {
+		t.Errorf("unexpected artists after update %+v", found)
+	}
+
+	if err := artists.Update(ctx, domain.Artist{Name: "Nope", ID: domain.ID{Primary: "missing"}}); err == nil {
+		t.Error("expected error updating missing artist")
+	}
+	if err := artists.Delete(ctx, id); err != nil {
+		t.Fatalf("failed to delete artist: %v", err)
+	}
+	if err := artists.Delete(ctx, id); err == nil {
+		t.Error("expected error deleting missing artist")
+	}
+}
+
+func TestAlbumArtists(t *testing.T) {
+	ctx := context.Background()
+	_, artists, _, albums := setupClients(t)
+
+	first, _ := artists.Add(ctx, domain.Artist{Name: "First"})
+	second, _ := artists.Add(ctx, domain.Artist{Name: "Second"})
+
+	album := domain.Album{
+		Name:    "Split",
+		Year:    2020,
+		Signed:  true,
+		Artists: []domain.Artist{{Name: "First", ID: domain.ID{Primary: first}}},
+	}
+	id, err := albums.Add(ctx, album)
+	if err != nil {
+		t.Fatalf("failed to add album: %v", err)
+	}
+
+	album.ID = id
+	album.EventID = "event"
+	album.Artists = append(album.Artists, domain.Artist{Name: "Second", ID: domain.ID{Primary: second}})
+	if err := albums.Update(ctx, album); err != nil {
+		t.Fatalf("failed to update album: %v", err)
+	}
+
+	found, err := albums.FindAll(ctx)
+	if err != nil {
+		t.Fatalf("failed to find albums: %v", err)
+	}
+	if len(found) != 1 {
+		t.Fatalf("expected 1 album, got %d", len(found))
+	}
+	got := found[0]
+	if got.Name != "Split" || got.Year != 2020 || !got.Signed || got.EventID != "event" {
+		t.Errorf("unexpected album fields %+v", got)
+	}
+	if len(got.Artists) != 2 || got.Artists[0].Name != "First" || got.Artists[1].Name != "Second" {
+		t.Errorf("unexpected album artists %+v", got.Artists)
+	}
+
+	album.Artists = []domain.Artist{{Name: "Ghost", ID: domain.ID{Primary: "missing"}}}
+	if err := albums.Update(ctx, album); err == nil {
+		t.Error("expected error when album artist does not exist")
+	}
+}
+
+func TestDeleteReferencedVenue(t *testing.T) {
+	ctx := context.Background()
+	venues, artists, events, _ := setupClients(t)
+
+	venueID, _ := venues.Add(ctx, domain.Venue{Name: "The Earl", City: "Atlanta", State: "GA"})
+	artistID, _ := artists.Add(ctx, domain.Artist{Name: "Main"})
+	eventID, _ := events.Add(ctx, domain.Event{
+		MainAct: &domain.Artist{Name: "Main", ID: domain.ID{Primary: artistID}},
+		Venue:   domain.Venue{ID: domain.ID{Primary: venueID}},
+		Date:    domain.MustParseDate("1/1/2024"),
+	})
+
+	err := venues.Delete(ctx, venueID)
+	refErr, ok := err.(domain.ReferencedError)
+	if !ok {
+		t.Fatalf("expected referenced error, got %v", err)
+	}
+	if len(refErr.References.Events) != 1 || refErr.References.Events[0] != eventID {
+		t.Errorf("unexpected references %+v", refErr.References)
+	}
+	if err := artists.Delete(ctx, artistID); err == nil {
+		t.Error("expected error deleting a referenced artist")
+	}
+
+	events.Delete(ctx, eventID)
+	if err := venues.Delete(ctx, venueID); err != nil {
+		t.Errorf("expected unreferenced venue to be deleted, got %v", err)
+	}
+}
+
+func TestApplyBatch(t *testing.T) {
+	ctx := context.Background()
+	venues, artists, events, albums := setupClients(t)
+	batches := &BatchClient{venues.Connection}
+
+	venueID, _ := venues.Add(ctx, domain.Venue{Name: "The Earl", City: "Atlanta", State: "GA"})
+	otherVenueID, _ := venues.Add(ctx, domain.Venue{Name: "Terminal West", City: "Atlanta", State: "GA"})
+	artistID, _ := artists.Add(ctx, domain.Artist{Name: "Main"})
+	event := domain.Event{
+		MainAct: &domain.Artist{Name: "Main", ID: domain.ID{Primary: artistID}},
+		Venue:   domain.Venue{ID: domain.ID{Primary: venueID}},
+		Date:    domain.MustParseDate("1/1/2024"),
+	}
+	event.ID.Primary, _ = events.Add(ctx, event)
+	albumID, _ := albums.Add(ctx, domain.Album{Name: "Live", Artists: []domain.Artist{*event.MainAct}, EventID: event.ID.Primary})
+
+	// the venue is still used by the event, so the album unlink before it is rolled back
+	unlinked := domain.Album{ID: albumID, Name: "Live", Artists: []domain.Artist{*event.MainAct}}
+	err := batches.Apply(ctx, domain.Batch{UpdateAlbums: []domain.Album{unlinked}, DeleteVenues: []string{venueID}})
+	if _, ok := err.(domain.ReferencedError); !ok {
+		t.Fatalf("expected referenced error, got %v", err)
+	}
+	if found, _ := albums.FindAll(ctx); found[0].EventID != event.ID.Primary {
+		t.Errorf("expected a failed batch to change nothing, got %+v", found[0])
+	}
+
+	event.Venue.ID.Primary = otherVenueID
+	err = batches.Apply(ctx, domain.Batch{UpdateEvents: []domain.Event{event}, UpdateAlbums: []domain.Album{unlinked}, DeleteVenues: []string{venueID}})
+	if err != nil {
+		t.Fatalf("failed to apply batch: %v", err)
+	}
+	foundEvents, _ := events.FindAll(ctx)
+	foundVenues, _ := venues.FindAll(ctx)
+	foundAlbums, _ := albums.FindAll(ctx)
+	if foundEvents[0].Venue.ID.Primary != otherVenueID || len(foundVenues) != 1 || foundAlbums[0].EventID != "" {
+		t.Errorf("unexpected data after batch %+v, %+v, %+v", foundEvents[0], foundVenues, foundAlbums[0])
+	}
+}
+
+func TestAddEventWithReferences(t *testing.T) {
+	ctx := context.Background()
+	venues, artists, events, albums := setupClients(t)
+	existingID, _ := artists.Add(ctx, domain.Artist{Name: "Existing"})
+
+	event := domain.Event{
+		MainAct: &domain.Artist{Name: "Existing", ID: domain.ID{Primary: existingID}},
+		Openers: []domain.Artist{{Name: "New"}},
+		Venue:   domain.Venue{Name: "The Earl", City: "Atlanta", State: "GA"},
+		Date:    domain.MustParseDate("3/14/2024"),
+	}
+	saved, err := events.AddWithReferences(ctx, event)
+	if err != nil {
+		t.Fatalf("failed to add event: %v", err)
+	}
+	if saved.ID.Primary == "" || saved.Venue.ID.Primary == "" || saved.Openers[0].ID.Primary == "" {
+		t.Errorf("expected IDs to be filled in, got %+v", saved)
+	}
+	if saved.MainAct.ID.Primary != existingID {
+		t.Errorf("expected existing artist to be reused, got %+v", saved.MainAct)
+	}
+	foundArtists, _ := artists.FindAll(ctx)
+	foundVenues, _ := venues.FindAll(ctx)
+	if len(foundArtists) != 2 || len(foundVenues) != 1 {
+		t.Errorf("unexpected saved data, %d artists and %d venues", len(foundArtists), len(foundVenues))
+	}
+
+	again := domain.CloneEvent(saved)
+	again.Openers = append(again.Openers, domain.Artist{Name: "Late Addition"})
+	if _, err := events.AddWithReferences(ctx, again); domain.KindOf(err) != domain.KindConflict {
+		t.Errorf("expected a conflict re-adding the event, got %v", err)
+	}
+	if foundArtists, _ := artists.FindAll(ctx); len(foundArtists) != 2 {
+		t.Errorf("expected no artists to be created for an existing event, got %+v", foundArtists)
+	}
+
+	album, err := albums.AddWithArtists(ctx, domain.Album{Name: "Split", Artists: []domain.Artist{saved.Openers[0], {Name: "Another"}}})
+	if err != nil {
+		t.Fatalf("failed to add album: %v", err)
+	}
+	if album.ID == "" || album.Artists[0].ID.Primary != saved.Openers[0].ID.Primary || album.Artists[1].ID.Primary == "" {
+		t.Errorf("unexpected album %+v", album)
+	}
+}
+
+func TestEventDetails(t *testing.T) {
+	ctx := context.Background()
+	_, _, events, _ := setupClients(t)
+
+	saved, err := events.AddWithReferences(ctx, domain.Event{
+		MainAct:    &domain.Artist{Name: "Main"},
+		Openers:    []domain.Artist{{Name: "Opener"}},
+		Venue:      domain.Venue{Name: "The Earl", City: "Atlanta", State: "GA"},
+		Date:       domain.MustParseDate("3/14/2024"),
+		Rating:     4,
+		Notes:      "Played the whole first album",
+		Seat:       "GA floor",
+		Companions: []string{"Sam", "Jo"},
+		Ticket:     domain.Ticket{PriceCents: 4550, Quantity: 2, FeesCents: 1200, PurchaseDate: domain.MustParseDate("1/5/2024")},
+	})
+	if err != nil {
+		t.Fatalf("failed to add event: %v", err)
+	}
+	saved.Performances = []domain.Performance{
+		{ArtistID: saved.MainAct.ID.Primary, Rating: 5, Highlights: "Encore"},
+		{ArtistID: saved.Openers[0].ID.Primary, Rating: 3},
+	}
+	if err := events.Update(ctx, saved); err != nil {
+		t.Fatalf("failed to update event: %v", err)
+	}
+
+	found, err := events.FindAll(ctx)
+	if err != nil || len(found) != 1 {
+		t.Fatalf("expected 1 event, got %d, %v", len(found), err)
+	}
+	got := found[0]
+	if got.Rating != 4 || got.Notes != "Played the whole first album" || got.Seat != "GA floor" {
+		t.Errorf("unexpected event details %+v", got)
+	}
+	if got.Ticket != saved.Ticket {
+		t.Errorf("expected ticket %+v, got %+v", saved.Ticket, got.Ticket)
+	}
+	if len(got.Companions) != 2 || got.Companions[0] != "Sam" || got.Companions[1] != "Jo" {
+		t.Errorf("unexpected companions %v", got.Companions)
+	}
+	if len(got.Performances) != 2 || got.Performances[0] != saved.Performances[0] || got.Performances[1] != saved.Performances[1] {
+		t.Errorf("unexpected performances %+v", got.Performances)
+	}
+}
+
+func TestFestival(t *testing.T) {
+	ctx := context.Background()
+	_, _, events, _ := setupClients(t)
+
+	saved, err := events.AddWithReferences(ctx, domain.Event{
+		MainAct:  &domain.Artist{Name: "Headliner"},
+		Openers:  []domain.Artist{{Name: "Opener"}},
+		Venue:    domain.Venue{Name: "Piedmont Park", City: "Atlanta", State: "GA"},
+		Date:     domain.MustParseDate("9/20/2024"),
+		Festival: &domain.Festival{Name: "Music Midtown", EndDate: domain.MustParseDate("9/21/2024")},
+	})
+	if err != nil {
+		t.Fatalf("failed to add festival: %v", err)
+	}
+	saved.Festival.Days = []domain.FestivalDay{
+		{Date: domain.MustParseDate("9/20/2024"), Sets: []domain.Set{
+			{ArtistID: saved.Openers[0].ID.Primary, Stage: "Park",
+				Start: &domain.TimeOfDay{Hour: 18}, End: &domain.TimeOfDay{Hour: 19}, Seen: true},
+		}},
+		{Date: domain.MustParseDate("9/21/2024"), Sets: []domain.Set{
+			{ArtistID: saved.MainAct.ID.Primary, Stage: "Peach",
+				Start: &domain.TimeOfDay{Hour: 21, Minute: 30}, End: &domain.TimeOfDay{Hour: 23}},
+		}},
+	}
+	if err := saved.ValidateDetails(); err != nil {
+		t.Fatalf("expected valid festival, got %v", err)
+	}
+	if err := events.Update(ctx, saved); err != nil {
+		t.Fatalf("failed to update festival: %v", err)
+	}
+
+	found, err := events.FindAll(ctx)
+	if err != nil || len(found) != 1 {
+		t.Fatalf("expected 1 event, got %d, %v", len(found), err)
+	}
+	got := found[0].Festival
+	if got == nil || got.Name != "Music Midtown" || got.EndDate != saved.Festival.EndDate || len(got.Days) != 2 {
+		t.Fatalf("unexpected festival %+v", got)
+	}
+	for i, day := range saved.Festival.Days {
+		if !reflect.DeepEqual(got.Days[i], day) {
+			t.Errorf("expected day %+v, got %+v", day, got.Days[i])
+		}
+	}
+
+	saved.Festival = nil
+	if err := events.Update(ctx, saved); err != nil {
+		t.Fatalf("failed to update festival: %v", err)
+	}
+	found, _ = events.FindAll(ctx)
+	if found[0].Festival != nil {
+		t.Errorf("expected festival to be removed, got %+v", found[0].Festival)
+	}
+}
+
+func TestAddResolvesNewArtistRefs(t *testing.T) {
+	ctx := context.Background()
+	_, _, events, _ := setupClients(t)
+
+	saved, err := events.AddWithReferences(ctx, domain.Event{
+		MainAct:      &domain.Artist{Name: "Headliner"},
+		Openers:      []domain.Artist{{Name: "Opener"}},
+		Venue:        domain.Venue{Name: "Piedmont Park", City: "Atlanta", State: "GA"},
+		Date:         domain.MustParseDate("9/20/2024"),
+		Performances: []domain.Performance{{ArtistID: "Headliner", Rating: 5}},
+		Festival: &domain.Festival{Name: "Music Midtown", EndDate: domain.MustParseDate("9/21/2024"), Days: []domain.FestivalDay{
+			{Date: domain.MustParseDate("9/20/2024"), Sets: []domain.Set{{ArtistID: "Opener", Seen: true}}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("failed to add festival: %v", err)
+	}
+
+	found, err := events.FindAll(ctx)
+	if err != nil || len(found) != 1 {
+		t.Fatalf("expected 1 event, got %d, %v", len(found), err)
+	}
+	if got := found[0].Performances; len(got) != 1 || got[0].ArtistID != saved.MainAct.ID.Primary {
+		t.Errorf("expected the performance stored by artist ID, got %+v", got)
+	}
+	if got := found[0].Festival.Days[0].Sets; len(got) != 1 || got[0].ArtistID != saved.Openers[0].ID.Primary {
+		t.Errorf("expected the set stored by artist ID, got %+v", got)
+	}
+}
+
+func TestOpenAddsMissingColumns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	// the events table as it was before ratings and notes were added
+	_, err = db.Exec(`CREATE TABLE events (
+		id TEXT PRIMARY KEY, main_act_id TEXT, venue_id TEXT NOT NULL, date TEXT NOT NULL,
+		purchased INTEGER NOT NULL DEFAULT 0, ticketmaster_id TEXT NOT NULL DEFAULT '')`)
+	db.Close()
+	if err != nil {
+		t.Fatalf("failed to create old schema: %v", err)
+	}
+
+	conn, err := Open(path)
+	if err != nil {
+		t.Fatalf("failed to open old database: %v", err)
+	}
+	defer conn.DB.Close()
+	if _, err := (&EventClient{conn}).FindAll(context.Background()); err != nil {
+		t.Errorf("expected events to be readable after upgrade, got %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package sqlite
+
+import (
+	"concert-manager/domain"
+	"concert-manager/log"
+	"concert-manager/util"
+	"context"
+)
+
+const venueTable = "venues"
+
+const venueColumns = "id, name, city, state, ticketmaster_id, time_zone"
+
+type VenueClient struct {
+	Connection *SQLite
+}
+
+func (c *VenueClient) Add(ctx context.Context, venue domain.Venue) (string, error) {
+	log.Debug("Attempting to add venue", venue)
+	return addVenue(ctx, c.Connection.DB, venue)
+}
+
+func addVenue(ctx context.Context, q querier, venue domain.Venue) (string, error) {
+	exists, err := rowExists(ctx, q, venueTable, venue.ID.Primary)
+	if err != nil {
+		log.Errorf("Error occurred while checking if venue %v already exists, %v", venue, err)
+		return "", err
+	}
+	if exists {
+		log.Debugf("Skipping adding venue because it already exists %+v", venue)
+		return venue.ID.Primary, nil
+	}
+
+	id := venue.ID.Primary
+	if id == "" {
+		id = util.NewID()
+	}
+	_, err = q.ExecContext(ctx,
+		"INSERT INTO venues ("+venueColumns+") VALUES (?, ?, ?, ?, ?, ?)",
+		id, venue.Name, venue.City, venue.State, venue.ID.Ticketmaster, venue.TimeZone)
+	if err != nil {
+		log.Errorf("Failed to add new venue %+v, %v", venue, err)
+		return "", err
+	}
+	log.Infof("Created new venue %+v", id)
+	return id, nil
+}
+
+func (c *VenueClient) Update(ctx context.Context, venue domain.Venue) error {
+	log.Debug("Attempting to update venue", venue)
+	return updateVenue(ctx, c.Connection.DB, venue)
+}
+
+func updateVenue(ctx context.Context, q querier, venue domain.Venue) error {
+	result, err := q.ExecContext(ctx,
+		"UPDATE venues SET name = ?, city = ?, state = ?, ticketmaster_id = ?, time_zone = ? WHERE id = ?",
+		venue.Name, venue.City, venue.State, venue.ID.Ticketmaster, venue.TimeZone, venue.ID.Primary)
+	if err != nil {
+		log.Errorf("Failed to update venue %+v, %v", venue, err)
+		return err
+	}
+	if updated, _ := result.RowsAffected(); updated == 0 {
+		log.Errorf("Venue does not exist in update %+v", venue)
+		return domain.NotFound("venue does not exist")
+	}
+	log.Info("Successfully updated venue", venue)
+	return nil
+}
+
+func (c *VenueClient) Delete(ctx context.Context, id string) error {
+	log.Debug("Attempting to delete venue", id)
+	return deleteVenue(ctx, c.Connection.DB, id)
+}
+
+func deleteVenue(ctx context.Context, q querier, id string) error {
+	refs, err := findVenueReferences(ctx, q, id)
+	if err != nil {
+		log.Errorf("Error while checking references to venue %s, %v", id, err)
+		return err
+	}
+	if !refs.Empty() {
+		log.Errorf("Error while deleting venue %s, it is still referenced by %+v", id, refs)
+		return domain.ReferencedError{Kind: "venue", ID: id, References: refs}
+	}
+	result, err := q.ExecContext(ctx, "DELETE FROM venues WHERE id = ?", id)
+	if err != nil {
+		log.Error("Failed to delete venue", id, err)
+		return err
+	}
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		log.Error("Error while deleting venue, venue does not exist", id)
+		return domain.NotFound("venue does not exist")
+	}
+	log.Info("Successfully deleted venue", id)
+	return nil
+}
+
+func (c *VenueClient) FindAll(ctx context.Context) ([]domain.Venue, error) {
+	log.Debug("Finding all venues")
+	venues, err := queryVenues(ctx, c.Connection.DB)
+	if err != nil {
+		log.Error("Error while finding all venues,", err)
+		return nil, err
+	}
+	log.Debugf("Found %d venues", len(venues))
+	return venues, nil
+}
+
+func queryVenues(ctx context.Context, q querier) ([]domain.Venue, error) {
+	rows, err := q.QueryContext(ctx, "SELECT "+venueColumns+" FROM venues ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	venues := []domain.Venue{}
+	for rows.Next() {
+		venue := domain.Venue{}
+		err := rows.Scan(&venue.ID.Primary, &venue.Name, &venue.City, &venue.State, &venue.ID.Ticketmaster, &venue.TimeZone)
+		if err != nil {
+			return nil, err
+		}
+		venues = append(venues, venue)
+	}
+	return venues, rows.Err()
+}
+
+func findAllVenues(ctx context.Context, q querier) (map[string]domain.Venue, error) {
+	venues, err := queryVenues(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	venueMap := make(map[string]domain.Venue)
+	for _, venue := range venues {
+		venueMap[venue.ID.Primary] = venue
+	}
+	return venueMap, nil
+}
+
+func findVenueReferences(ctx context.Context, q querier, id string) (domain.References, error) {
+	events, err := queryIDs(ctx, q, "SELECT id FROM events WHERE venue_id = ? ORDER BY id", id)
+	if err != nil {
+		return domain.References{}, err
+	}
+	return domain.References{Events: events, Albums: []string{}}, nil
+}
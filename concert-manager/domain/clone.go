@@ -61,9 +61,40 @@ func CloneEvent(event Event) Event {
 		clone.MainAct = &mainActClone
 	}
 	clone.Openers = slices.Clone(event.Openers)
+	clone.Performances = slices.Clone(event.Performances)
+	clone.Companions = slices.Clone(event.Companions)
+	clone.StartTime = CloneTimeOfDay(event.StartTime)
+	if event.Festival != nil {
+		festivalClone := CloneFestival(*event.Festival)
+		clone.Festival = &festivalClone
+	}
+	return clone
+}
+
+func CloneFestival(festival Festival) Festival {
+	clone := festival
+	clone.Days = []FestivalDay{}
+	for _, day := range festival.Days {
+		dayClone := day
+		dayClone.Sets = []Set{}
+		for _, set := range day.Sets {
+			set.Start = CloneTimeOfDay(set.Start)
+			set.End = CloneTimeOfDay(set.End)
+			dayClone.Sets = append(dayClone.Sets, set)
+		}
+		clone.Days = append(clone.Days, dayClone)
+	}
 	return clone
 }
 
+func CloneTimeOfDay(t *TimeOfDay) *TimeOfDay {
+	if t == nil {
+		return nil
+	}
+	clone := *t
+	return &clone
+}
+
 func CloneEvents(events []Event) []Event {
 	clone := []Event{}
 	for _, event := range events {
@@ -0,0 +1,236 @@
+package domain
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const timeOfDayFmt = "15:04"
+
+// DefaultLocation is the time zone of venues that don't have one
+var DefaultLocation = time.Local
+
+// Date is a calendar day, which is only tied to an instant in the time zone of a venue.
+// It is written as "m/d/yyyy" in JSON like the string dates it replaced, the zero Date
+// is unset and written as "".
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// TimeOfDay is a wall clock time at the venue, written as "HH:MM" in JSON
+type TimeOfDay struct {
+	Hour   int
+	Minute int
+}
+
+func NewDate(year int, month time.Month, day int) Date {
+	return Date{Year: year, Month: month, Day: day}
+}
+
+// DateOf is the calendar day of ts in its own location
+func DateOf(ts time.Time) Date {
+	year, month, day := ts.Date()
+	return NewDate(year, month, day)
+}
+
+// Today is the current calendar day in the location
+func Today(loc *time.Location) Date {
+	return DateOf(time.Now().In(loc))
+}
+
+// ParseDate reads a "m/d/yyyy" date, with leading zeros optional. Days that don't exist,
+// like 2/30, are rejected rather than rolled over into the next month.
+func ParseDate(value string) (Date, error) {
+	parts := strings.Split(strings.TrimSpace(value), "/")
+	if len(parts) != 3 {
+		return Date{}, fmt.Errorf("invalid date %q, expected m/d/yyyy", value)
+	}
+	nums := [3]int{}
+	for i, part := range parts {
+		num, err := strconv.Atoi(part)
+		if err != nil {
+			return Date{}, fmt.Errorf("invalid date %q, expected m/d/yyyy", value)
+		}
+		nums[i] = num
+	}
+	date := NewDate(nums[2], time.Month(nums[0]), nums[1])
+	if date.Year < 1 || date.Year > 9999 || DateOf(date.Time(time.UTC)) != date {
+		return Date{}, fmt.Errorf("invalid date %q, day does not exist", value)
+	}
+	return date, nil
+}
+
+// MustParseDate is ParseDate for dates known to be valid, it panics otherwise
+func MustParseDate(value string) Date {
+	date, err := ParseDate(value)
+	if err != nil {
+		panic(err)
+	}
+	return date
+}
+
+// ParseOptionalDate is ParseDate where an empty value is the zero Date
+func ParseOptionalDate(value string) (Date, error) {
+	if strings.TrimSpace(value) == "" {
+		return Date{}, nil
+	}
+	return ParseDate(value)
+}
+
+func (d Date) IsZero() bool {
+	return d == Date{}
+}
+
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d/%d", d.Month, d.Day, d.Year)
+}
+
+// Padded formats the date as "mm/dd/yyyy"
+func (d Date) Padded() string {
+	if d.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%02d/%02d/%d", d.Month, d.Day, d.Year)
+}
+
+// Time is the start of the day in the location
+func (d Date) Time(loc *time.Location) time.Time {
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, loc)
+}
+
+func (d Date) AddDays(days int) Date {
+	return DateOf(d.Time(time.UTC).AddDate(0, 0, days))
+}
+
+func (d Date) Compare(o Date) int {
+	if c := cmp.Compare(d.Year, o.Year); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(d.Month, o.Month); c != 0 {
+		return c
+	}
+	return cmp.Compare(d.Day, o.Day)
+}
+
+func (d Date) Before(o Date) bool {
+	return d.Compare(o) < 0
+}
+
+func (d Date) After(o Date) bool {
+	return d.Compare(o) > 0
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	date, err := ParseOptionalDate(value)
+	if err != nil {
+		return err
+	}
+	*d = date
+	return nil
+}
+
+func ParseTimeOfDay(value string) (TimeOfDay, error) {
+	ts, err := time.Parse(timeOfDayFmt, strings.TrimSpace(value))
+	if err != nil {
+		return TimeOfDay{}, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return TimeOfDay{Hour: ts.Hour(), Minute: ts.Minute()}, nil
+}
+
+// ParseOptionalTimeOfDay is ParseTimeOfDay where an empty value is unset
+func ParseOptionalTimeOfDay(value string) (*TimeOfDay, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	t, err := ParseTimeOfDay(value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (t TimeOfDay) String() string {
+	return fmt.Sprintf("%02d:%02d", t.Hour, t.Minute)
+}
+
+// FormatOptionalTimeOfDay is the "HH:MM" time, or "" when it is unset
+func FormatOptionalTimeOfDay(t *TimeOfDay) string {
+	if t == nil {
+		return ""
+	}
+	return t.String()
+}
+
+func (t TimeOfDay) On(d Date, loc *time.Location) time.Time {
+	return time.Date(d.Year, d.Month, d.Day, t.Hour, t.Minute, 0, 0, loc)
+}
+
+func (t TimeOfDay) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+func (t *TimeOfDay) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	parsed, err := ParseTimeOfDay(value)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// Location is the time zone of the venue, falling back to DefaultLocation when it isn't
+// set or isn't a known IANA name
+func (v *Venue) Location() *time.Location {
+	if v.TimeZone == "" {
+		return DefaultLocation
+	}
+	loc, err := time.LoadLocation(v.TimeZone)
+	if err != nil {
+		return DefaultLocation
+	}
+	return loc
+}
+
+// Start is when the event starts at the venue, the start of its first day if there is no start time
+func (e *Event) Start() time.Time {
+	if e.StartTime != nil {
+		return e.StartTime.On(e.Date, e.Venue.Location())
+	}
+	return e.Date.Time(e.Venue.Location())
+}
+
+// LastDate is the final day of the event, which is only after Date for festivals
+func (e *Event) LastDate() Date {
+	if e.Festival != nil && e.Festival.EndDate.After(e.Date) {
+		return e.Festival.EndDate
+	}
+	return e.Date
+}
+
+// Passed is whether the last day of the event is over at the venue. Shows often run past
+// their start time, so an event only passes at midnight after it in the venue's time zone.
+func (e *Event) Passed(now time.Time) bool {
+	end := e.LastDate().AddDays(1).Time(e.Venue.Location())
+	return !now.Before(end)
+}
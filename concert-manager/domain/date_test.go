@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	date, err := ParseDate("3/04/2024")
+	if err != nil || date != NewDate(2024, time.March, 4) {
+		t.Errorf("expected 3/4/2024, got %v, %v", date, err)
+	}
+	for _, invalid := range []string{"", "3/4", "a/4/2024", "3/32/2024", "2/30/2024", "13/1/2024"} {
+		if _, err := ParseDate(invalid); err == nil {
+			t.Errorf("expected %q to be invalid", invalid)
+		}
+	}
+}
+
+func TestDateJSONMatchesStringDates(t *testing.T) {
+	event := Event{Date: NewDate(2024, time.March, 4), StartTime: &TimeOfDay{Hour: 19, Minute: 30}}
+	data, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("failed to marshal event: %v", err)
+	}
+	var fields map[string]any
+	json.Unmarshal(data, &fields)
+	if fields["date"] != "3/4/2024" || fields["startTime"] != "19:30" {
+		t.Errorf("unexpected date fields %v, %v", fields["date"], fields["startTime"])
+	}
+	ticket := fields["ticket"].(map[string]any)
+	if ticket["purchaseDate"] != "" {
+		t.Errorf("expected an unset date to be empty, got %v", ticket["purchaseDate"])
+	}
+
+	var decoded Event
+	if err := json.Unmarshal([]byte(`{"date":"03/04/2024","startTime":null}`), &decoded); err != nil {
+		t.Fatalf("failed to unmarshal event: %v", err)
+	}
+	if decoded.Date != event.Date || decoded.StartTime != nil {
+		t.Errorf("unexpected decoded event %+v", decoded)
+	}
+	if err := json.Unmarshal([]byte(`{"date":"2/30/2024"}`), &decoded); err == nil {
+		t.Error("expected an invalid date to fail to unmarshal")
+	}
+}
+
+func TestPassedUsesVenueTimeZone(t *testing.T) {
+	event := Event{
+		Date:      NewDate(2024, time.March, 4),
+		StartTime: &TimeOfDay{Hour: 20},
+		Venue:     Venue{TimeZone: "America/New_York"},
+	}
+	// 8pm in New York is already the next day in UTC
+	showTime := time.Date(2024, time.March, 5, 1, 0, 0, 0, time.UTC)
+	if !event.Start().Equal(showTime) {
+		t.Errorf("expected the show to start at %v, got %v", showTime, event.Start())
+	}
+	if event.Passed(showTime) {
+		t.Error("expected the show not to have passed while it is on")
+	}
+	if !event.Passed(time.Date(2024, time.March, 5, 5, 0, 0, 0, time.UTC)) {
+		t.Error("expected the show to have passed after midnight in New York")
+	}
+
+	event.Festival = &Festival{EndDate: NewDate(2024, time.March, 6)}
+	if event.Passed(time.Date(2024, time.March, 6, 12, 0, 0, 0, time.UTC)) {
+		t.Error("expected the festival not to have passed before its last day is over")
+	}
+}
@@ -0,0 +1,120 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// References lists the IDs of saved events and albums pointing at an artist or venue
+type References struct {
+	Events []string `json:"events"`
+	Albums []string `json:"albums"`
+}
+
+func (r References) Empty() bool {
+	return len(r.Events) == 0 && len(r.Albums) == 0
+}
+
+// ReferencedError is returned when deleting an artist or venue that is still referenced
+type ReferencedError struct {
+	Kind       string
+	ID         string
+	References References
+}
+
+func (e ReferencedError) Error() string {
+	parts := []string{}
+	if len(e.References.Events) > 0 {
+		parts = append(parts, fmt.Sprintf("events [%s]", strings.Join(e.References.Events, ", ")))
+	}
+	if len(e.References.Albums) > 0 {
+		parts = append(parts, fmt.Sprintf("albums [%s]", strings.Join(e.References.Albums, ", ")))
+	}
+	return fmt.Sprintf("%s %s is still referenced by %s", e.Kind, e.ID, strings.Join(parts, " and "))
+}
+
+// ErrorKind classifies an error so the server can report it with the matching status
+type ErrorKind int
+
+const (
+	KindNotFound ErrorKind = iota + 1
+	KindConflict
+	KindInvalid
+	KindUnavailable
+)
+
+// Error is an error of a known kind, with the problems of each field when an entity is invalid
+type Error struct {
+	Kind    ErrorKind
+	Message string
+	Fields  []FieldError
+	// the underlying error, such as the failed call to an upstream service
+	Err error
+}
+
+// FieldError is a problem with one field of an invalid entity, using its JSON path like festival.days[0].date
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func NotFound(format string, args ...any) error {
+	return &Error{Kind: KindNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+func Conflict(format string, args ...any) error {
+	return &Error{Kind: KindConflict, Message: fmt.Sprintf(format, args...)}
+}
+
+func Invalid(format string, args ...any) error {
+	return &Error{Kind: KindInvalid, Message: fmt.Sprintf(format, args...)}
+}
+
+// InvalidFields is an invalid error listing every problem found, which must not be empty
+func InvalidFields(fields ...FieldError) error {
+	messages := []string{}
+	for _, field := range fields {
+		messages = append(messages, field.Message)
+	}
+	return &Error{Kind: KindInvalid, Message: strings.Join(messages, "; "), Fields: fields}
+}
+
+// Unavailable is an error for a failed call to a service like Ticketmaster, Spotify or storage
+func Unavailable(err error, format string, args ...any) error {
+	return &Error{Kind: KindUnavailable, Message: fmt.Sprintf(format, args...), Err: err}
+}
+
+// KindOf is the kind of the first classified error in the chain, 0 when there is none.
+// A ReferencedError is a conflict.
+func KindOf(err error) ErrorKind {
+	var kindErr *Error
+	if errors.As(err, &kindErr) {
+		return kindErr.Kind
+	}
+	var referencedErr ReferencedError
+	if errors.As(err, &referencedErr) {
+		return KindConflict
+	}
+	return 0
+}
+
+// FieldsOf lists the field problems of the first invalid error in the chain
+func FieldsOf(err error) []FieldError {
+	var kindErr *Error
+	if errors.As(err, &kindErr) {
+		return kindErr.Fields
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package domain
+
+import "slices"
+
+// MergeArtists combines a duplicate source artist into the survivor. The survivor keeps its
+// name and any IDs it already has, missing IDs are taken from the source and genres are unioned.
+func MergeArtists(survivor Artist, source Artist) Artist {
+	merged := CloneArtist(survivor)
+	merged.ID = mergeIDs(survivor.ID, source.ID)
+	if merged.Genre == "" {
+		merged.Genre = source.Genre
+	}
+	merged.Genres = GenreInfo{
+		Spotify:      unionStrings(survivor.Genres.Spotify, source.Genres.Spotify),
+		LastFm:       unionStrings(survivor.Genres.LastFm, source.Genres.LastFm),
+		Ticketmaster: unionStrings(survivor.Genres.Ticketmaster, source.Genres.Ticketmaster),
+		User:         unionStrings(survivor.Genres.User, source.Genres.User),
+	}
+	return merged
+}
+
+// MergeVenues combines a duplicate source venue into the survivor, filling in missing IDs from the source.
+func MergeVenues(survivor Venue, source Venue) Venue {
+	merged := CloneVenue(survivor)
+	merged.ID = mergeIDs(survivor.ID, source.ID)
+	return merged
+}
+
+func mergeIDs(survivor ID, source ID) ID {
+	merged := survivor
+	if merged.Spotify == "" {
+		merged.Spotify = source.Spotify
+	}
+	if merged.Ticketmaster == "" {
+		merged.Ticketmaster = source.Ticketmaster
+	}
+	if merged.MusicBrainz == "" {
+		merged.MusicBrainz = source.MusicBrainz
+	}
+	return merged
+}
+
+func unionStrings(first []string, second []string) []string {
+	union := []string{}
+	for _, value := range append(slices.Clone(first), second...) {
+		if !slices.Contains(union, value) {
+			union = append(union, value)
+		}
+	}
+	return union
+}
@@ -1,30 +1,14 @@
 package domain
 
-import (
-	"concert-manager/util"
-)
-
 func EventSorterDateAsc() func(a, b Event) int {
 	return func(a, b Event) int {
-		if util.Timestamp(a.Date).Before(util.Timestamp(b.Date)) {
-			return -1
-		} else if util.Timestamp(a.Date).After(util.Timestamp(b.Date)) {
-			return 1
-		} else {
-			return 0
-		}
+		return a.Date.Compare(b.Date)
 	}
 }
 
 func EventSorterDateDesc() func(a, b Event) int {
 	return func(a, b Event) int {
-		if util.Timestamp(a.Date).Before(util.Timestamp(b.Date)) {
-			return 1
-		} else if util.Timestamp(a.Date).After(util.Timestamp(b.Date)) {
-			return -1
-		} else {
-			return 0
-		}
+		return b.Date.Compare(a.Date)
 	}
 }
 
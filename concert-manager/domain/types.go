@@ -1,6 +1,12 @@
 package domain
 
-import "fmt"
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const MaxRating = 5
 
 type (
 	Venue struct {
@@ -8,6 +14,8 @@ type (
 		City  string `json:"city"`
 		State string `json:"state"`
 		ID    ID     `json:"id"`
+		// IANA name like "America/New_York", DefaultLocation is used when it's empty
+		TimeZone string `json:"timeZone"`
 	}
 	Artist struct {
 		Name   string    `json:"name"`
@@ -28,12 +36,57 @@ type (
 		MusicBrainz  string `json:"musicbrainz"`
 	}
 	Event struct {
-		MainAct   *Artist  `json:"mainAct"`
-		Openers   []Artist `json:"openers"`
-		Venue     Venue    `json:"venue"`
-		Date      string   `json:"date"`
-		Purchased bool     `json:"purchased"`
-		ID        ID       `json:"id"`
+		MainAct *Artist  `json:"mainAct"`
+		Openers []Artist `json:"openers"`
+		Venue   Venue    `json:"venue"`
+		Date    Date     `json:"date"`
+		// optional, in the time zone of the venue
+		StartTime *TimeOfDay `json:"startTime"`
+		Purchased bool       `json:"purchased"`
+		ID        ID         `json:"id"`
+		// star rating for the whole night, 0 when it hasn't been rated
+		Rating       int           `json:"rating"`
+		Performances []Performance `json:"performances"`
+		Notes        string        `json:"notes"`
+		Seat         string        `json:"seat"`
+		Companions   []string      `json:"companions"`
+		Ticket       Ticket        `json:"ticket"`
+		// set for multi-day festivals, Date is then the first day of the festival
+		Festival *Festival `json:"festival"`
+	}
+	// Festival spans Event.Date to EndDate. Every artist playing a set must also be in the
+	// lineup of the event, the days only say when, where and whether we saw them.
+	Festival struct {
+		Name    string        `json:"name"`
+		EndDate Date          `json:"endDate"`
+		Days    []FestivalDay `json:"days"`
+	}
+	FestivalDay struct {
+		Date Date  `json:"date"`
+		Sets []Set `json:"sets"`
+	}
+	// Set is one artist playing a festival day, ArtistID is the primary ID of the artist.
+	// Stage and the start and end times are optional.
+	Set struct {
+		ArtistID string     `json:"artistId"`
+		Stage    string     `json:"stage"`
+		Start    *TimeOfDay `json:"start"`
+		End      *TimeOfDay `json:"end"`
+		Seen     bool       `json:"seen"`
+	}
+	// Ticket is what was paid for the event. Amounts are in cents, the price is per ticket
+	// and the fees are for the whole order.
+	Ticket struct {
+		PriceCents   int  `json:"priceCents"`
+		Quantity     int  `json:"quantity"`
+		FeesCents    int  `json:"feesCents"`
+		PurchaseDate Date `json:"purchaseDate"`
+	}
+	// Performance is how one artist in the lineup of an event played, ArtistID is the primary ID of the artist
+	Performance struct {
+		ArtistID   string `json:"artistId"`
+		Rating     int    `json:"rating"`
+		Highlights string `json:"highlights"`
 	}
 	EventDetails struct {
 		Name       string    `json:"name"`
@@ -68,8 +121,37 @@ type (
 		Genre          string   `json:"genre"`
 		Notes          string   `json:"notes"`
 		CoverImageUrl  string   `json:"coverImageUrl"`
+		EventID        string   `json:"eventId"` // saved event it was bought or signed at
 		ID             string   `json:"id"`
 	}
+	// Changes is everything saved that changed since a sync token, pass Token to the next sync.
+	// When Reset is set the old token couldn't be used and everything is listed as created.
+	Changes struct {
+		Token   string                `json:"token"`
+		Reset   bool                  `json:"reset"`
+		Events  EntityChanges[Event]  `json:"events"`
+		Artists EntityChanges[Artist] `json:"artists"`
+		Venues  EntityChanges[Venue]  `json:"venues"`
+		Albums  EntityChanges[Album]  `json:"albums"`
+	}
+	// EntityChanges lists one kind of entity by how it changed, deleted entities by ID
+	EntityChanges[T any] struct {
+		Created []T      `json:"created"`
+		Updated []T      `json:"updated"`
+		Deleted []string `json:"deleted"`
+	}
+	// Batch is a set of writes applied all together or not at all. Updates are applied before
+	// deletes, and events and albums are deleted before the artists and venues they referenced.
+	Batch struct {
+		UpdateVenues  []Venue
+		UpdateArtists []Artist
+		UpdateEvents  []Event
+		UpdateAlbums  []Album
+		DeleteEvents  []string
+		DeleteAlbums  []string
+		DeleteArtists []string
+		DeleteVenues  []string
+	}
 )
 
 func (e *Event) Artists() []Artist {
@@ -129,7 +211,169 @@ func (e *Event) Populated() bool {
 	for _, opener := range e.Openers {
 		artistsPopulated = artistsPopulated || opener.Populated()
 	}
-	return artistsPopulated && e.Venue.Populated() && e.Date != ""
+	return artistsPopulated && e.Venue.Populated() && !e.Date.IsZero()
+}
+
+// ValidateDetails checks the ratings are in range, every performance is by an artist in the lineup
+// and the ticket cost isn't negative, listing every problem found. Performances and festival sets
+// can refer to a lineup artist without an ID, one created along with the event, by its name.
+func (e *Event) ValidateDetails() error {
+	problems := []FieldError{}
+	if e.Rating < 0 || e.Rating > MaxRating {
+		problems = append(problems, FieldError{"rating", fmt.Sprintf("rating must be between 0 and %d", MaxRating)})
+	}
+	seen := map[string]bool{}
+	for i, performance := range e.Performances {
+		field := fmt.Sprintf("performances[%d]", i)
+		if performance.Rating < 0 || performance.Rating > MaxRating {
+			problems = append(problems, FieldError{field + ".rating", fmt.Sprintf("performance rating must be between 0 and %d", MaxRating)})
+		}
+		if !e.inLineup(performance.ArtistID) {
+			problems = append(problems, FieldError{field + ".artistId", "performance artist is not in the lineup"})
+		} else if seen[performance.ArtistID] {
+			problems = append(problems, FieldError{field + ".artistId", "artist has more than one performance"})
+		}
+		seen[performance.ArtistID] = true
+	}
+	problems = append(problems, e.validateFestival()...)
+	problems = append(problems, e.Ticket.validate()...)
+	if len(problems) > 0 {
+		return InvalidFields(problems...)
+	}
+	return nil
+}
+
+func (e *Event) validateFestival() []FieldError {
+	if e.Festival == nil {
+		return nil
+	}
+	if e.Date.IsZero() || e.Festival.EndDate.IsZero() {
+		return []FieldError{{"festival.endDate", "festival requires a start and end date"}}
+	}
+	if e.Festival.EndDate.Before(e.Date) {
+		return []FieldError{{"festival.endDate", "festival cannot end before it starts"}}
+	}
+	problems := []FieldError{}
+	days := map[Date]bool{}
+	for i, day := range e.Festival.Days {
+		field := fmt.Sprintf("festival.days[%d]", i)
+		if day.Date.Before(e.Date) || day.Date.After(e.Festival.EndDate) {
+			problems = append(problems, FieldError{field + ".date", fmt.Sprintf("festival day %s is outside of the festival dates", day.Date)})
+		}
+		if days[day.Date] {
+			problems = append(problems, FieldError{field + ".date", fmt.Sprintf("festival day %s is listed more than once", day.Date)})
+		}
+		days[day.Date] = true
+		for j, set := range day.Sets {
+			if !e.inLineup(set.ArtistID) {
+				problems = append(problems, FieldError{fmt.Sprintf("%s.sets[%d].artistId", field, j), "set artist is not in the lineup"})
+			}
+		}
+	}
+	return problems
+}
+
+// inLineup is whether the reference is the ID of a lineup artist, or the name of one with no ID yet
+func (e *Event) inLineup(ref string) bool {
+	return ref != "" && slices.ContainsFunc(e.Artists(), func(a Artist) bool {
+		return a.ID.Primary == ref || (a.ID.Primary == "" && strings.EqualFold(a.Name, ref))
+	})
+}
+
+// ResolveArtistRefs points the performances and festival sets that refer to a lineup artist by name
+// at the artist's ID, once the artists are saved and have IDs
+func (e *Event) ResolveArtistRefs() {
+	artists := e.Artists()
+	resolve := func(ref string) string {
+		if slices.ContainsFunc(artists, func(a Artist) bool { return a.ID.Primary == ref }) {
+			return ref
+		}
+		idx := slices.IndexFunc(artists, func(a Artist) bool { return a.ID.Primary != "" && strings.EqualFold(a.Name, ref) })
+		if idx == -1 {
+			return ref
+		}
+		return artists[idx].ID.Primary
+	}
+	for i := range e.Performances {
+		e.Performances[i].ArtistID = resolve(e.Performances[i].ArtistID)
+	}
+	if e.Festival != nil {
+		e.Festival.Sets(func(set *Set) {
+			set.ArtistID = resolve(set.ArtistID)
+		})
+	}
+}
+
+func (e *Event) IsFestival() bool {
+	return e.Festival != nil
+}
+
+// SeenArtists is the part of the lineup we actually saw. For a festival that is the artists
+// of the sets marked as seen, unless no sets were recorded at all, in which case it falls
+// back to the whole lineup like any other event.
+func (e *Event) SeenArtists() []Artist {
+	artists := e.Artists()
+	if e.Festival == nil || !e.Festival.hasSets() {
+		return artists
+	}
+	seen := []Artist{}
+	for _, artist := range artists {
+		if artist.ID.Primary != "" && e.Festival.Seen(artist.ID.Primary) {
+			seen = append(seen, artist)
+		}
+	}
+	return seen
+}
+
+// Seen is whether any set by the artist was marked as seen
+func (f *Festival) Seen(artistID string) bool {
+	for _, day := range f.Days {
+		for _, set := range day.Sets {
+			if set.ArtistID == artistID && set.Seen {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (f *Festival) hasSets() bool {
+	return slices.ContainsFunc(f.Days, func(d FestivalDay) bool { return len(d.Sets) > 0 })
+}
+
+// Sets calls fn with every set of the festival so they can be updated in place
+func (f *Festival) Sets(fn func(*Set)) {
+	for i := range f.Days {
+		for j := range f.Days[i].Sets {
+			fn(&f.Days[i].Sets[j])
+		}
+	}
+}
+
+func (t Ticket) TotalCents() int {
+	return t.PriceCents*t.Quantity + t.FeesCents
+}
+
+func (t Ticket) Populated() bool {
+	return t.TotalCents() > 0
+}
+
+func (t Ticket) validate() []FieldError {
+	if t.PriceCents < 0 || t.Quantity < 0 || t.FeesCents < 0 {
+		return []FieldError{{"ticket", "ticket price, quantity and fees cannot be negative"}}
+	}
+	if t.PriceCents > 0 && t.Quantity == 0 {
+		return []FieldError{{"ticket.quantity", "ticket quantity is required with a price"}}
+	}
+	return nil
+}
+
+func (e *Event) Performance(artistID string) (Performance, bool) {
+	idx := slices.IndexFunc(e.Performances, func(p Performance) bool { return p.ArtistID == artistID })
+	if idx == -1 {
+		return Performance{}, false
+	}
+	return e.Performances[idx], true
 }
 
 func allNotEmpty(fields ...string) bool {
@@ -20,6 +20,11 @@ type GCS struct {
 	bucket string
 }
 
+// Configured reports whether a bucket is set for album images, without one there are no uploads
+func Configured() bool {
+	return os.Getenv(albumImageBucketEnv) != ""
+}
+
 func Setup() (*GCS, error) {
 	bucket := os.Getenv(albumImageBucketEnv)
 	if bucket == "" {
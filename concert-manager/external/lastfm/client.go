@@ -1,9 +1,9 @@
 package lastfm
 
 import (
+	"concert-manager/domain"
 	"concert-manager/log"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -66,15 +66,14 @@ func (c *Client) call(reqEntity requestEntity, response any) error {
 		resp, err := http.DefaultClient.Do(req)
 		log.Debugf("Request response time: %v ms", time.Since(startTs).Milliseconds())
 		if err != nil {
-			return err
+			return domain.Unavailable(err, "failed to call LastFM")
 		}
 
 		log.Debugf("For URL %v, received response: %+v", stripApiKey(*req.URL), resp)
 		if resp.StatusCode == http.StatusOK {
 			defer resp.Body.Close()
 			if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
-				errMsg := fmt.Sprintf("failed to parse response: %v", err)
-				return errors.New(errMsg)
+				return domain.Unavailable(err, "failed to parse LastFM response")
 			}
 			return nil
 		}
@@ -92,7 +91,8 @@ func (c *Client) call(reqEntity requestEntity, response any) error {
 			time.Sleep(delay)
 		}
 	}
-	return errors.New("max retries exceeded calling LastFM URL: " + req.URL.Host + req.URL.Path + "?" + req.URL.RawQuery)
+	strippedUrl := stripApiKey(*req.URL)
+	return domain.Unavailable(nil, "max retries exceeded calling LastFM URL: %s", strippedUrl.String())
 }
 
 func stripApiKey(reqUrl url.URL) url.URL {
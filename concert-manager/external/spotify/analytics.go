@@ -1,10 +1,9 @@
 package spotify
 
 import (
+	"concert-manager/domain"
 	"concert-manager/external"
 	"concert-manager/log"
-	"errors"
-	"fmt"
 	"net/http"
 )
 
@@ -56,8 +55,7 @@ func (c *Client) TopTracks(timeRange external.TimeRange) ([]external.Track, erro
 	retrievedCount := len(tracks)
 	if retrievedCount < total && !TEST_MODE {
 		// tracks may still be valid for any successful batches; let the caller decide to use it or not
-		errMsg := fmt.Sprintf("failed to retrieve all top tracks, found %d/%d", retrievedCount, total)
-		return mapSpotifyTracks(tracks), errors.New(errMsg)
+		return mapSpotifyTracks(tracks), domain.Unavailable(nil, "failed to retrieve all top tracks, found %d/%d", retrievedCount, total)
 	}
 	log.Infof("Found %v top tracks", len(tracks))
 	return mapSpotifyTracks(tracks), nil
@@ -112,8 +110,7 @@ func (c *Client) TopArtists(timeRange external.TimeRange) ([]external.Artist, er
 	retrievedCount := len(artists)
 	if retrievedCount < total && !TEST_MODE {
 		// artists may still be valid for any successful batches; let the caller decide to use it or not
-		errMsg := fmt.Sprintf("failed to retrieve all top artists, found %d/%d", retrievedCount, total)
-		return mapSpotifyArtists(artists), errors.New(errMsg)
+		return mapSpotifyArtists(artists), domain.Unavailable(nil, "failed to retrieve all top artists, found %d/%d", retrievedCount, total)
 	}
 	log.Infof("Found %v top artists", len(artists))
 	return mapSpotifyArtists(artists), nil
@@ -1,6 +1,7 @@
 package spotify
 
 import (
+	"concert-manager/domain"
 	"concert-manager/file"
 	"concert-manager/log"
 	"crypto/rand"
@@ -28,10 +29,13 @@ const (
 	authScopes               = "playlist-read-private playlist-read-collaborative user-top-read user-library-read"
 	reauthStateTTL           = 10 * time.Minute
 	reauthStateByteLength    = 32
+	// never part of a base64 URL encoded state
+	stateSeparator = "."
 )
 
 type authentication struct {
 	RefreshTokenExpireTs time.Time
+	userID               string
 	clientId             string
 	authKey              string
 	callbackUrl          string
@@ -48,7 +52,8 @@ type storedRefreshToken struct {
 	ExpireTs time.Time `json:"expireTs"`
 }
 
-func NewAuthentication() *authentication {
+// NewAuthentication sets up Spotify access for the user, each user authorizes their own account
+func NewAuthentication(userID string) *authentication {
 	clientId := os.Getenv(clientIdKey)
 	if clientId == "" {
 		log.Fatalf("%s env var must be set", clientIdKey)
@@ -64,15 +69,16 @@ func NewAuthentication() *authentication {
 		log.Fatalf("%s env var must be set", callbackUrlKey)
 	}
 
-	stored, err := loadRefreshToken()
+	stored, err := loadRefreshToken(userID)
 	if err != nil {
-		log.Alertf("Failed to load Spotify refresh token. Reauthentication is required, %v", err)
+		log.Alertf("Failed to load Spotify refresh token for user %q. Reauthentication is required, %v", userID, err)
 		stored = storedRefreshToken{}
 	} else {
 		log.Infof("Loaded Spotify refresh token")
 	}
 
 	auth := &authentication{
+		userID:               userID,
 		clientId:             clientId,
 		authKey:              authToken,
 		callbackUrl:          callbackUrl,
@@ -117,12 +123,15 @@ func (a *authentication) StartReauth() (string, error) {
 
 func (a *authentication) CompleteReauth(code, state string) error {
 	if strings.TrimSpace(code) == "" {
-		return errors.New("missing authorization code")
+		return domain.Invalid("missing authorization code")
 	}
 	if !a.consumeState(state) {
-		return errors.New("invalid or expired state")
+		return domain.Invalid("invalid or expired state")
 	}
-	return a.exchangeCode(code)
+	if err := a.exchangeCode(code); err != nil {
+		return domain.Unavailable(err, "failed to exchange Spotify authorization code")
+	}
+	return nil
 }
 
 func buildAuthToken(clientId, clientSecret string) string {
@@ -130,8 +139,8 @@ func buildAuthToken(clientId, clientSecret string) string {
 	return "Basic " + base64.StdEncoding.EncodeToString(authToken)
 }
 
-func loadRefreshToken() (storedRefreshToken, error) {
-	path, err := file.GetCacheFilePath(refreshTokenFile)
+func loadRefreshToken(userID string) (storedRefreshToken, error) {
+	path, err := file.GetCacheFilePath(file.UserFileName(refreshTokenFile, userID))
 	if err != nil {
 		return storedRefreshToken{}, err
 	}
@@ -158,8 +167,8 @@ func loadRefreshToken() (storedRefreshToken, error) {
 	return record, nil
 }
 
-func persistRefreshToken(record storedRefreshToken) error {
-	path, err := file.GetCacheFilePath(refreshTokenFile)
+func persistRefreshToken(userID string, record storedRefreshToken) error {
+	path, err := file.GetCacheFilePath(file.UserFileName(refreshTokenFile, userID))
 	if err != nil {
 		return err
 	}
@@ -183,8 +192,7 @@ func (a *authentication) getAccessToken() (string, error) {
 	defer a.tokenMutex.Unlock()
 	if a.accessToken == "" {
 		if err := a.refreshAccessToken(); err != nil {
-			errMsg := fmt.Sprintf("failed to refresh Spotify access token: %v", err)
-			return "", errors.New(errMsg)
+			return "", domain.Unavailable(err, "failed to refresh Spotify access token")
 		}
 	}
 	return a.accessToken, nil
@@ -254,7 +262,7 @@ func (a *authentication) markAccessTokenExpired(token string) {
 		time.AfterFunc(cleanupDelay, func() {
 			a.tokenMutex.Lock()
 			defer a.tokenMutex.Unlock()
-			slices.DeleteFunc(a.accessTokenInvalid, func(t string) bool {
+			a.accessTokenInvalid = slices.DeleteFunc(a.accessTokenInvalid, func(t string) bool {
 				return t == token
 			})
 		})
@@ -267,6 +275,10 @@ func (a *authentication) issueReauthState() (string, error) {
 		return "", err
 	}
 	state := base64.RawURLEncoding.EncodeToString(buf)
+	if a.userID != "" {
+		// lets the shared OAuth callback find the user the state was issued for
+		state = a.userID + stateSeparator + state
+	}
 
 	a.reauthStateMutex.Lock()
 	defer a.reauthStateMutex.Unlock()
@@ -274,13 +286,20 @@ func (a *authentication) issueReauthState() (string, error) {
 	time.AfterFunc(reauthStateTTL, func() {
 		a.reauthStateMutex.Lock()
 		defer a.reauthStateMutex.Unlock()
-		slices.DeleteFunc(a.reauthStateStore, func(s string) bool {
+		a.reauthStateStore = slices.DeleteFunc(a.reauthStateStore, func(s string) bool {
 			return s == state
 		})
 	})
 	return state, nil
 }
 
+// StateUser is the user an OAuth state was issued for, from its prefix, so the shared callback can
+// find the user's server. States issued for the default user have no prefix.
+func StateUser(state string) (string, bool) {
+	userID, _, found := strings.Cut(state, stateSeparator)
+	return userID, found
+}
+
 func (a *authentication) consumeState(state string) bool {
 	a.reauthStateMutex.Lock()
 	defer a.reauthStateMutex.Unlock()
@@ -288,7 +307,7 @@ func (a *authentication) consumeState(state string) bool {
 		return false
 	}
 
-	slices.DeleteFunc(a.reauthStateStore, func(s string) bool {
+	a.reauthStateStore = slices.DeleteFunc(a.reauthStateStore, func(s string) bool {
 		return s == state
 	})
 	return true
@@ -339,8 +358,8 @@ func (a *authentication) exchangeCode(code string) error {
 	defer a.tokenMutex.Unlock()
 	expireTs := time.Now().Add(refreshTokenDurationDays)
 	record := storedRefreshToken{Token: body.RefreshToken, ExpireTs: expireTs}
-	if err := persistRefreshToken(record); err != nil {
-		log.Alertf("Failed to persist new Spotify refresh token: %w", err)
+	if err := persistRefreshToken(a.userID, record); err != nil {
+		log.Alertf("Failed to persist new Spotify refresh token: %v", err)
 	}
 	a.refreshToken = body.RefreshToken
 	a.RefreshTokenExpireTs = expireTs
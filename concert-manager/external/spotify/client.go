@@ -1,6 +1,7 @@
 package spotify
 
 import (
+	"concert-manager/domain"
 	"concert-manager/log"
 	"encoding/json"
 	"errors"
@@ -62,14 +63,13 @@ func (c *Client) call(httpMethod string, reqEntity RequestEntity, respBody any)
 
 	resp, err := c.execute(req)
 	if err != nil {
-		return err
+		return domain.Unavailable(err, "failed to call Spotify")
 	}
 	defer resp.Body.Close()
 
 	if respBody != nil {
 		if err := json.NewDecoder(resp.Body).Decode(respBody); err != nil {
-			errMsg := fmt.Sprintf("failed to parse response body: %v", err)
-			return errors.New(errMsg)
+			return domain.Unavailable(err, "failed to parse Spotify response body")
 		}
 	}
 
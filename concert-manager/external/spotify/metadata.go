@@ -20,17 +20,16 @@ func (c *Client) ArtistInfoById(artistId string) (external.ArtistInfo, error) {
 
 	err := c.call(http.MethodGet, request, response)
 	if err != nil {
-		if errorResponse, ok := err.(errorResponse); ok {
+		var errorResponse *errorResponse
+		if errors.As(err, &errorResponse) {
 			switch errorResponse.ErrorDetails.Status {
 			case http.StatusNotFound:
 				fallthrough
 			case http.StatusBadRequest:
 				return external.ArtistInfo{}, external.NotFoundError{Message: "Spotify artist not found for ID " + artistId}
-			default:
-				return external.ArtistInfo{}, errors.New("failed to retrieve Spotify artist details: " + errorResponse.Error())
 			}
 		}
-		return external.ArtistInfo{}, err
+		return external.ArtistInfo{}, fmt.Errorf("failed to retrieve Spotify artist details: %w", err)
 	}
 
 	artistInfo := external.ArtistInfo{
@@ -60,17 +59,16 @@ func (c *Client) SearchByName(name string) (external.ArtistInfo, error) {
 
 	err := c.call(http.MethodGet, request, response)
 	if err != nil {
-		if errorResponse, ok := err.(errorResponse); ok {
+		var errorResponse *errorResponse
+		if errors.As(err, &errorResponse) {
 			switch errorResponse.ErrorDetails.Status {
 			case http.StatusNotFound:
 				fallthrough
 			case http.StatusBadRequest:
 				return external.ArtistInfo{}, external.NotFoundError{Message: "Spotify artist not found for name " + name}
-			default:
-				return external.ArtistInfo{}, fmt.Errorf("failed to retrieve Spotify artist details for name %s: %v", name, errorResponse.Error())
 			}
 		}
-		return external.ArtistInfo{}, err
+		return external.ArtistInfo{}, fmt.Errorf("failed to retrieve Spotify artist details for name %s: %w", name, err)
 	}
 
 	artists := response.Artists.Items
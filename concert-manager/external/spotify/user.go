@@ -1,10 +1,9 @@
 package spotify
 
 import (
+	"concert-manager/domain"
 	"concert-manager/external"
 	"concert-manager/log"
-	"errors"
-	"fmt"
 	"net/http"
 )
 
@@ -60,8 +59,7 @@ func (c *Client) SavedTracks() ([]external.Track, error) {
 	retrievedCount := len(tracks)
 	if retrievedCount < total && !TEST_MODE {
 		// tracks may still be valid for any successful batches; let the caller decide to use it or not
-		errMsg := fmt.Sprintf("failed to retrieve all saved tracks, found %d/%d", retrievedCount, total)
-		return mapSpotifyTracks(tracks), errors.New(errMsg)
+		return mapSpotifyTracks(tracks), domain.Unavailable(nil, "failed to retrieve all saved tracks, found %d/%d", retrievedCount, total)
 	}
 	log.Infof("Found %v saved tracks", retrievedCount)
 
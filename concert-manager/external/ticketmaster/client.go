@@ -54,7 +54,7 @@ func (t Ticketmaster) GetUpcomingEvents(city string, stateCd string) ([]domain.E
 	if err != nil {
 		// Assume no rate violation here since it's the first request
 		log.Error("Error retrieving event data from Ticketmaster", err)
-		return nil, err
+		return nil, domain.Unavailable(err, "failed to retrieve events from Ticketmaster")
 	}
 
 	// ticketmaster max 1k events
@@ -76,8 +76,7 @@ func (t Ticketmaster) GetUpcomingEvents(city string, stateCd string) ([]domain.E
 	expectedNotCancelledCount := expectedEventCount - eventCount.cancelledCount
 	if len(eventDetails) != expectedNotCancelledCount {
 		errFmt := "Unable to retrieve all expected events. Read %v/%v"
-		errMsg := fmt.Sprintf(errFmt, len(eventDetails), expectedNotCancelledCount)
-		return eventDetails, errors.New(errMsg)
+		return eventDetails, domain.Unavailable(nil, errFmt, len(eventDetails), expectedNotCancelledCount)
 	}
 
 	log.Infof("Ticketmaster read counts: %+v", eventCount)
@@ -3,7 +3,6 @@ package ticketmaster
 import (
 	"concert-manager/domain"
 	"concert-manager/log"
-	"concert-manager/util"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -33,7 +32,12 @@ type tmEventResponse struct {
 	Dates     struct {
 		Start struct {
 			Date string `json:"localDate"`
+			Time string `json:"localTime"`
 		} `json:"start"`
+		// only set for events running over more than one day, like festivals
+		End struct {
+			Date string `json:"localDate"`
+		} `json:"end"`
 		Status struct {
 			Code string `json:"code"`
 		} `json:"status"`
@@ -41,9 +45,10 @@ type tmEventResponse struct {
 	Classification []tmGenreResponse `json:"classification"`
 	Details        struct {
 		Venues []struct {
-			Name string `json:"name"`
-			ID   string `json:"id"`
-			City struct {
+			Name     string `json:"name"`
+			ID       string `json:"id"`
+			TimeZone string `json:"timezone"`
+			City     struct {
 				Name string `json:"Name"`
 			} `json:"city"`
 			State struct {
@@ -180,6 +185,7 @@ func parseEventDetails(event *tmEventResponse) (*domain.EventDetails, error) {
 		venue.Name = venueDetails.Name
 		venue.City = venueDetails.City.Name
 		venue.State = venueDetails.State.Name
+		venue.TimeZone = venueDetails.TimeZone
 	}
 
 	dateRaw := event.Dates.Start.Date
@@ -189,15 +195,32 @@ func parseEventDetails(event *tmEventResponse) (*domain.EventDetails, error) {
 		return nil, errors.New(errMsg)
 	}
 
+	// the start time is optional, events with a time still to be announced don't have one
+	var startTime *domain.TimeOfDay
+	if timeRaw := event.Dates.Start.Time; timeRaw != "" {
+		if start, err := time.Parse(timeFmt, timeRaw); err == nil {
+			startTime = &domain.TimeOfDay{Hour: start.Hour(), Minute: start.Minute()}
+		} else {
+			log.Infof("Ignoring invalid start time %s for event %s", timeRaw, event.Id)
+		}
+	}
+
+	festival, err := parseFestival(event, date)
+	if err != nil {
+		return nil, err
+	}
+
 	eventDetails := domain.EventDetails{
 		Name:       eventName,
 		EventGenre: eventGenre,
 		Event: domain.Event{
-			MainAct: &mainAct,
-			Openers: openers,
-			Venue:   venue,
-			Date:    util.Date(date),
-			ID:      domain.ID{Ticketmaster: event.Id},
+			MainAct:   &mainAct,
+			Openers:   openers,
+			Venue:     venue,
+			Date:      domain.DateOf(date),
+			StartTime: startTime,
+			ID:        domain.ID{Ticketmaster: event.Id},
+			Festival:  festival,
 		},
 	}
 
@@ -207,6 +230,33 @@ func parseEventDetails(event *tmEventResponse) (*domain.EventDetails, error) {
 	return &eventDetails, nil
 }
 
+// ticketmaster lists the whole lineup of a festival as its attractions without saying
+// who plays which day, so the festival only gets its days and the sets are left empty
+func parseFestival(event *tmEventResponse, start time.Time) (*domain.Festival, error) {
+	endRaw := event.Dates.End.Date
+	if endRaw == "" {
+		return nil, nil
+	}
+	end, err := time.Parse(dateFmt, endRaw)
+	if err != nil {
+		errMsg := fmt.Sprintf("unable to parse event end date %s", endRaw)
+		return nil, errors.New(errMsg)
+	}
+	if !end.After(start) {
+		return nil, nil
+	}
+
+	days := []domain.FestivalDay{}
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		days = append(days, domain.FestivalDay{Date: domain.DateOf(day), Sets: []domain.Set{}})
+	}
+	return &domain.Festival{
+		Name:    event.EventName,
+		EndDate: domain.DateOf(end),
+		Days:    days,
+	}, nil
+}
+
 func getGenre(genres tmGenreResponse) string {
 	subGenre := genres.Subgenre.Name
 	genre := genres.Genre.Name
@@ -17,6 +17,7 @@ const (
 	apiKeyFmt   = "&apikey=%s"
 	dateTimeFmt = "2006-01-02T15:04:05"
 	dateFmt     = "2006-01-02"
+	timeFmt     = "15:04:05"
 	sort        = "date,asc"
 	radius      = 50
 	pageSize    = 50
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -18,6 +19,16 @@ func GetCacheFilePath(filename string) (string, error) {
 	return filepath.Join(execDir, filename), nil
 }
 
+// UserFileName returns the per-user variant of a file name, e.g. ranks-alex.json for ranks.json.
+// The default user, with an empty ID, keeps the original name.
+func UserFileName(filename string, userID string) string {
+	if userID == "" {
+		return filename
+	}
+	ext := filepath.Ext(filename)
+	return strings.TrimSuffix(filename, ext) + "-" + userID + ext
+}
+
 func WriteJSONFile(filePath string, data interface{}) error {
 	tempPath := filePath + ".tmp"
 
@@ -3,10 +3,13 @@ package finder
 import (
 	"concert-manager/domain"
 	"concert-manager/file"
+	"concert-manager/jobs"
 	"concert-manager/log"
 	"concert-manager/ranker"
+	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -46,15 +49,29 @@ const (
 	eventCacheFile    = "events.json"
 )
 
+// Cache is safe for concurrent use. The mutex guards the location and upcoming events, and
+// refreshMutex allows only one refresh at a time. A refresh works on a snapshot of the saved data
+// without holding the mutex, so any Sync* changes made while it runs are recorded and replayed
+// onto the refreshed events before they are stored.
 type Cache struct {
 	Location       Location
 	Finder         finder
 	Ranker         eventRanker
 	SavedDataCache savedDataCache
 	MetadataFinder MetadataFinder
-	upcomingEvents map[string]upcomingEventsData
+	UserID         string
+	// set when the events are refreshed on a schedule, so reading expired events doesn't start a refresh
+	ScheduledRefresh bool
+	mutex            sync.RWMutex
+	refreshMutex     sync.Mutex
+	refreshing       bool
+	pendingSyncs     []syncFunc
+	upcomingEvents   map[string]upcomingEventsData
 }
 
+// applies a change in the saved data to a list of upcoming events
+type syncFunc func([]domain.EventDetails)
+
 const (
 	defaultCity      = "Atlanta"
 	defaultStateCode = "GA"
@@ -72,13 +89,18 @@ func (c *Cache) GetUpcomingEvents() []domain.EventDetails {
 }
 
 func (c *Cache) GetRecommendedEvents(level ranker.RecLevel) []domain.EventDetails {
+	c.mutex.RLock()
 	key := c.Location.key()
-	if d, ok := c.upcomingEvents[key]; !ok {
+	d, ok := c.upcomingEvents[key]
+	c.mutex.RUnlock()
+	if !ok {
 		c.doRefresh()
-	} else if isExpired(d.LastLoaded, upcomingEventTTL) {
-		go c.doRefresh()
+	} else if !c.ScheduledRefresh && isExpired(d.LastLoaded, upcomingEventTTL) {
+		c.startBackgroundRefresh()
 	}
 
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
 	threshold, _ := ranker.ToThreshold(level)
 	var events []domain.EventDetails
 	for _, event := range c.upcomingEvents[key].Events {
@@ -89,23 +111,83 @@ func (c *Cache) GetRecommendedEvents(level ranker.RecLevel) []domain.EventDetail
 	return events
 }
 
+// CachedUpcomingEvents is the upcoming events already loaded for the location. It never starts a
+// refresh, so it is nil when none were loaded yet and can be stale.
+func (c *Cache) CachedUpcomingEvents() []domain.EventDetails {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	d, ok := c.upcomingEvents[c.Location.key()]
+	if !ok {
+		return nil
+	}
+	events := []domain.EventDetails{}
+	for _, event := range d.Events {
+		events = append(events, domain.CloneEventDetail(event))
+	}
+	return events
+}
+
 func (c *Cache) doRefresh() {
-	err := c.RefreshUpcomingEvents()
+	err := c.RefreshUpcomingEvents(context.Background())
 	if err != nil {
 		log.Alert("Failed to refresh upcoming events", err)
 	}
 }
 
-func (c *Cache) RefreshUpcomingEvents() error {
+// starts a refresh in the background unless one is already running
+func (c *Cache) startBackgroundRefresh() {
+	if !c.refreshMutex.TryLock() {
+		log.Debug("Skipping background refresh of upcoming events because a refresh is already running")
+		return
+	}
+	go func() {
+		defer c.refreshMutex.Unlock()
+		if err := c.refresh(context.Background()); err != nil {
+			log.Alert("Failed to refresh upcoming events", err)
+		}
+	}()
+}
+
+// RefreshUpcomingEvents finds the events at the current location again. A cancelled refresh stops
+// between its steps and keeps the events found before.
+func (c *Cache) RefreshUpcomingEvents(ctx context.Context) error {
+	c.refreshMutex.Lock()
+	defer c.refreshMutex.Unlock()
+	return c.refresh(ctx)
+}
+
+// steps of a refresh reported as the progress of its job
+const refreshSteps = 3
+
+// requires the refresh lock to be held
+func (c *Cache) refresh(ctx context.Context) error {
+	c.mutex.Lock()
 	loc := c.Location
 	key := c.Location.key()
+	c.refreshing = true
+	c.pendingSyncs = nil
+	c.mutex.Unlock()
+	defer func() {
+		c.mutex.Lock()
+		c.refreshing = false
+		c.pendingSyncs = nil
+		c.mutex.Unlock()
+	}()
+
 	log.Info("Refreshing upcoming events for", key)
 	events, err := c.Finder.FindAllEvents(loc.City, loc.StateCode)
 	if err != nil {
+		c.mutex.Lock()
 		if _, ok := c.upcomingEvents[key]; !ok {
 			eventData := upcomingEventsData{Events: []domain.EventDetails{}, LastLoaded: time.Time{}}
 			c.upcomingEvents[key] = eventData
 		}
+		c.mutex.Unlock()
+		return err
+	}
+
+	jobs.ReportProgress(ctx, 1, refreshSteps)
+	if err := ctx.Err(); err != nil {
 		return err
 	}
 
@@ -113,6 +195,10 @@ func (c *Cache) RefreshUpcomingEvents() error {
 		events[i] = c.enrichSavedData(event)
 	}
 	events = c.MetadataFinder.PopulateMetadata(events)
+	jobs.ReportProgress(ctx, 2, refreshSteps)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
 	for i, event := range events {
 		rank := c.Ranker.Rank(event)
@@ -120,17 +206,39 @@ func (c *Cache) RefreshUpcomingEvents() error {
 	}
 
 	log.Infof("Finished upcoming event refresh, found %d events for key %s", len(events), key)
+	c.mutex.Lock()
+	for _, apply := range c.pendingSyncs {
+		apply(events)
+	}
 	eventData := upcomingEventsData{Events: events, LastLoaded: time.Now().Round(0)}
 	c.upcomingEvents[key] = eventData
+	c.mutex.Unlock()
 	c.saveEventsToFile()
+	jobs.ReportProgress(ctx, refreshSteps, refreshSteps)
 	return nil
 }
 
+// applies a saved data change to every cached location, and to the results of a running refresh
+func (c *Cache) applySync(apply syncFunc) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, eventData := range c.upcomingEvents {
+		apply(eventData.Events)
+	}
+	if c.refreshing {
+		c.pendingSyncs = append(c.pendingSyncs, apply)
+	}
+}
+
 func (c *Cache) GetLocation() Location {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
 	return c.Location
 }
 
 func (c *Cache) ChangeLocation(city, stateCode string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 	loc := Location{City: city, StateCode: stateCode}
 	log.Debugf("Updating upcomingEventCache location from %s to %s", c.Location, loc)
 	c.Location = loc
@@ -150,6 +258,8 @@ func (c Location) String() string {
 }
 
 func (c *Cache) Invalidate() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 	c.upcomingEvents = map[string]upcomingEventsData{}
 }
 
@@ -160,7 +270,7 @@ func isExpired(lastLoad time.Time, ttl time.Duration) bool {
 }
 
 func (c *Cache) InitializeFromFile() error {
-	filePath, err := file.GetCacheFilePath(eventCacheFile)
+	filePath, err := file.GetCacheFilePath(file.UserFileName(eventCacheFile, c.UserID))
 	if err != nil {
 		return fmt.Errorf("failed to get event cache file path: %w", err)
 	}
@@ -177,19 +287,23 @@ func (c *Cache) InitializeFromFile() error {
 		return fmt.Errorf("failed to load upcoming events from file: %v", err)
 	}
 
-	key := c.Location.key()
-	if _, ok := c.upcomingEvents[key]; ok && file.IsFileStale(filePath, upcomingEventTTL) {
+	c.mutex.RLock()
+	_, ok := c.upcomingEvents[c.Location.key()]
+	c.mutex.RUnlock()
+	if ok && file.IsFileStale(filePath, upcomingEventTTL) {
 		log.Info("Event cache file is stale, starting background refresh")
-		go c.doRefresh()
+		c.startBackgroundRefresh()
 	} else if !ok {
 		log.Debug("No events for current location, starting background refresh")
-		go c.doRefresh()
+		c.startBackgroundRefresh()
 	}
 
 	return nil
 }
 
 func (c *Cache) initializeEmpty() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 	c.upcomingEvents = make(map[string]upcomingEventsData)
 }
 
@@ -201,10 +315,12 @@ func (c *Cache) loadEventsFromFile(filePath string) error {
 	}
 
 	if cacheFile.Version != eventCacheVersion {
-		go c.doRefresh()
+		c.startBackgroundRefresh()
 		return nil
 	}
 
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 	c.upcomingEvents = cacheFile.UpcomingEvents
 	if c.upcomingEvents == nil {
 		c.upcomingEvents = make(map[string]upcomingEventsData)
@@ -215,12 +331,14 @@ func (c *Cache) loadEventsFromFile(filePath string) error {
 }
 
 func (c *Cache) saveEventsToFile() {
-	filePath, err := file.GetCacheFilePath(eventCacheFile)
+	filePath, err := file.GetCacheFilePath(file.UserFileName(eventCacheFile, c.UserID))
 	if err != nil {
 		log.Errorf("Failed to get event cache file path for saving: %v", err)
 		return
 	}
 
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
 	cacheFile := EventCacheFile{
 		Timestamp:      time.Now().Round(0),
 		Version:        eventCacheVersion,
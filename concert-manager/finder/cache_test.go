@@ -0,0 +1,162 @@
+package finder
+
+import (
+	"concert-manager/domain"
+	"concert-manager/external"
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+)
+
+type stubFinder struct {
+	mutex   sync.Mutex
+	events  []domain.EventDetails
+	release chan struct{}
+	started chan struct{}
+}
+
+func (f *stubFinder) FindAllEvents(string, string) ([]domain.EventDetails, error) {
+	if f.started != nil {
+		f.started <- struct{}{}
+		<-f.release
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return domain.CloneEventDetails(f.events), nil
+}
+
+type stubRanker struct{}
+
+func (stubRanker) Rank(domain.EventDetails) domain.RankInfo {
+	return domain.RankInfo{Rank: 1}
+}
+
+type stubMetadataProvider struct{}
+
+func (stubMetadataProvider) ArtistInfoById(string) (external.ArtistInfo, error) {
+	return external.ArtistInfo{}, errors.New("not supported")
+}
+
+func (stubMetadataProvider) SearchByName(string) (external.ArtistInfo, error) {
+	return external.ArtistInfo{}, errors.New("not supported")
+}
+
+type stubSavedData struct {
+	mutex   sync.Mutex
+	artists []domain.Artist
+}
+
+func (s *stubSavedData) GetArtists() []domain.Artist {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return domain.CloneArtists(s.artists)
+}
+
+func (s *stubSavedData) setArtists(artists []domain.Artist) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.artists = artists
+}
+
+func (s *stubSavedData) UpdateArtist(string, domain.Artist) error    { return nil }
+func (s *stubSavedData) GetVenues() []domain.Venue                   { return []domain.Venue{} }
+func (s *stubSavedData) UpdateVenue(string, domain.Venue) error      { return nil }
+func (s *stubSavedData) GetSavedEvents() []domain.Event              { return []domain.Event{} }
+func (s *stubSavedData) UpdateSavedEvent(string, domain.Event) error { return nil }
+
+func upcomingEvent(artist string) domain.EventDetails {
+	return domain.EventDetails{Event: domain.Event{
+		MainAct: &domain.Artist{Name: artist},
+		Venue:   domain.Venue{Name: "The Earl", City: "Atlanta", State: "GA"},
+		Date:    domain.MustParseDate("1/2/2030"),
+	}}
+}
+
+func newTestCache(finder *stubFinder, saved *stubSavedData) *Cache {
+	cache := NewUpcomingEventCache()
+	cache.Finder = finder
+	cache.Ranker = stubRanker{}
+	cache.SavedDataCache = saved
+	cache.MetadataFinder = MetadataFinder{Spotify: stubMetadataProvider{}, LastFm: stubMetadataProvider{}}
+	return cache
+}
+
+func TestConcurrentRefreshAndSync(t *testing.T) {
+	saved := &stubSavedData{artists: []domain.Artist{{Name: "Artist", ID: domain.ID{Primary: "1"}}}}
+	cache := newTestCache(&stubFinder{events: []domain.EventDetails{upcomingEvent("Artist"), upcomingEvent("Other")}}, saved)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(4)
+		go func() {
+			defer wg.Done()
+			cache.GetUpcomingEvents()
+		}()
+		go func() {
+			defer wg.Done()
+			if err := cache.RefreshUpcomingEvents(context.Background()); err != nil {
+				t.Errorf("failed to refresh: %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			cache.SyncArtistAdd("1")
+			cache.SyncArtistDelete("1")
+		}()
+		go func() {
+			defer wg.Done()
+			cache.ChangeLocation("Atlanta", "GA")
+			cache.GetLocation()
+		}()
+	}
+	wg.Wait()
+
+	if events := cache.GetUpcomingEvents(); len(events) != 2 {
+		t.Errorf("expected 2 upcoming events, got %d", len(events))
+	}
+}
+
+func TestSyncDuringRefreshIsApplied(t *testing.T) {
+	saved := &stubSavedData{artists: []domain.Artist{}}
+	finder := &stubFinder{
+		events:  []domain.EventDetails{upcomingEvent("Artist")},
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	cache := newTestCache(finder, saved)
+
+	done := make(chan error)
+	go func() { done <- cache.RefreshUpcomingEvents(context.Background()) }()
+	<-finder.started
+
+	// the artist is saved after the refresh has already read the saved data
+	saved.setArtists([]domain.Artist{{Name: "Artist", ID: domain.ID{Primary: "1"}}})
+	if err := cache.SyncArtistAdd("1"); err != nil {
+		t.Fatalf("failed to sync artist: %v", err)
+	}
+	close(finder.release)
+	if err := <-done; err != nil {
+		t.Fatalf("failed to refresh: %v", err)
+	}
+
+	events := cache.GetUpcomingEvents()
+	if !slices.ContainsFunc(events, func(e domain.EventDetails) bool { return e.Event.MainAct.ID.Primary == "1" }) {
+		t.Errorf("expected the artist synced during the refresh to be applied, got %+v", events)
+	}
+}
+
+func TestCachedUpcomingEventsNeverRefreshes(t *testing.T) {
+	cache := newTestCache(&stubFinder{events: []domain.EventDetails{upcomingEvent("Artist"), upcomingEvent("Other")}}, &stubSavedData{})
+
+	if events := cache.CachedUpcomingEvents(); events != nil || len(cache.upcomingEvents) != 0 {
+		t.Errorf("expected no events and no refresh before any were loaded, got %+v", events)
+	}
+	if err := cache.RefreshUpcomingEvents(context.Background()); err != nil {
+		t.Fatalf("failed to refresh: %v", err)
+	}
+	if events := cache.CachedUpcomingEvents(); len(events) != 2 {
+		t.Errorf("expected the 2 loaded events, got %+v", events)
+	}
+}
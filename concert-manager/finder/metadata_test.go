@@ -3,170 +3,130 @@ package finder
 import (
 	"concert-manager/domain"
 	"concert-manager/external"
+	"errors"
 	"testing"
 )
 
-type MockSavedArtistCache struct {
-	getArtistsFunc func() []domain.Artist
-	calls          struct {
-		getArtists int
+type MockMetadataProvider struct {
+	byID   map[string]external.ArtistInfo
+	byName map[string]external.ArtistInfo
+	calls  struct {
+		artistInfoById map[string]int
+		searchByName   map[string]int
 	}
 }
 
-func (m *MockSavedArtistCache) GetArtists() []domain.Artist {
-	m.calls.getArtists++
-	return m.getArtistsFunc()
+func newMockMetadataProvider() *MockMetadataProvider {
+	m := &MockMetadataProvider{byID: map[string]external.ArtistInfo{}, byName: map[string]external.ArtistInfo{}}
+	m.calls.artistInfoById = map[string]int{}
+	m.calls.searchByName = map[string]int{}
+	return m
 }
 
-type MockGenreProvider struct {
-	artistInfoByIdsFunc  func(ids []string) (map[string]external.ArtistInfo, error)
-	artistInfoByNameFunc func(name string) (external.ArtistInfo, error)
-	calls                struct {
-		artistInfoByIds  map[string]int
-		artistInfoByName map[string]int
+func (m *MockMetadataProvider) ArtistInfoById(id string) (external.ArtistInfo, error) {
+	m.calls.artistInfoById[id]++
+	info, ok := m.byID[id]
+	if !ok {
+		return external.ArtistInfo{}, external.NotFoundError{Message: "no artist with ID " + id}
 	}
+	return info, nil
 }
 
-func newMockGenreProvider() *MockGenreProvider {
-	return &MockGenreProvider{
-		calls: struct {
-			artistInfoByIds  map[string]int
-			artistInfoByName map[string]int
-		}{
-			artistInfoByIds:  make(map[string]int),
-			artistInfoByName: make(map[string]int),
-		},
-	}
-}
-
-func (m *MockGenreProvider) ArtistInfoByIds(ids []string) (map[string]external.ArtistInfo, error) {
-	key := ""
-	for _, id := range ids {
-		key += id + ","
-	}
-	if m.calls.artistInfoByIds == nil {
-		m.calls.artistInfoByIds = make(map[string]int)
-	}
-	m.calls.artistInfoByIds[key]++
-	return m.artistInfoByIdsFunc(ids)
-}
-
-func (m *MockGenreProvider) ArtistInfoByName(name string) (external.ArtistInfo, error) {
-	if m.calls.artistInfoByName == nil {
-		m.calls.artistInfoByName = make(map[string]int)
+func (m *MockMetadataProvider) SearchByName(name string) (external.ArtistInfo, error) {
+	m.calls.searchByName[name]++
+	info, ok := m.byName[name]
+	if !ok {
+		return external.ArtistInfo{}, errors.New("no artist named " + name)
 	}
-	m.calls.artistInfoByName[name]++
-	return m.artistInfoByNameFunc(name)
+	return info, nil
 }
 
-func TestPopulateGenres(t *testing.T) {
-	mockCache := &MockSavedArtistCache{}
-	mockProvider := newMockGenreProvider()
-
-	savedArtists := []domain.Artist{
-		{Name: "Saved Artist", ID: domain.ID{Spotify: "saved-id"}, Genres: domain.GenreInfo{Spotify: []string{"indie"}}},
-	}
+func TestPopulateMetadata(t *testing.T) {
+	spotify := newMockMetadataProvider()
+	spotify.byID["main-id"] = external.ArtistInfo{Id: "main-id", Genres: []string{"Rock", "Alternative"}}
+	spotify.byName["Opener"] = external.ArtistInfo{Id: "opener-id", Genres: []string{"Pop"}}
+	spotify.byName["Moved Artist"] = external.ArtistInfo{Id: "new-id", Genres: []string{"Indie"}}
+	lastFm := newMockMetadataProvider()
+	lastFm.byName["Opener"] = external.ArtistInfo{Id: "mbid", Genres: []string{"Synthpop"}}
 
 	events := []domain.EventDetails{
 		{
 			Event: domain.Event{
 				MainAct: &domain.Artist{Name: "Main Artist", ID: domain.ID{Spotify: "main-id"}},
-				Openers: []domain.Artist{
-					{Name: "Opener", ID: domain.ID{Spotify: ""}},
-				},
+				Openers: []domain.Artist{{Name: "Opener"}},
 			},
 		},
 		{
 			Event: domain.Event{
-				MainAct: &domain.Artist{Name: "Saved Artist", ID: domain.ID{Spotify: "main-id"}},
+				// a Spotify ID that is no longer found is looked up by name instead
+				MainAct: &domain.Artist{Name: "Moved Artist", ID: domain.ID{Spotify: "old-id"}},
+			},
+		},
+		{
+			Event: domain.Event{
+				MainAct: &domain.Artist{Name: "Known Artist", Genres: domain.GenreInfo{Spotify: []string{"jazz"}, LastFm: []string{"jazz"}}},
 			},
 		},
 	}
 
-	mockCache.getArtistsFunc = func() []domain.Artist {
-		return savedArtists
-	}
-
-	mockProvider.artistInfoByIdsFunc = func(ids []string) (map[string]external.ArtistInfo, error) {
-		return map[string]external.ArtistInfo{
-			"main-id": {Id: "main-id", Genres: []string{"rock", "alternative"}},
-		}, nil
-	}
+	finder := MetadataFinder{Spotify: spotify, LastFm: lastFm}
+	result := finder.PopulateMetadata(events)
 
-	mockProvider.artistInfoByNameFunc = func(name string) (external.ArtistInfo, error) {
-		return external.ArtistInfo{
-			Id: "opener-id", Genres: []string{"pop"},
-		}, nil
+	if len(result) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(result))
 	}
-
-	finder := ArtistInfoFinder{
-		ArtistCache:   mockCache,
-		GenreProvider: mockProvider,
+	if genres := result[0].Event.MainAct.Genres.Spotify; len(genres) != 2 || genres[0] != "rock" {
+		t.Errorf("Expected main act genres [rock, alternative], got %v", genres)
 	}
-
-	result := finder.PopulateGenres(events)
-
-	if len(result) != 2 {
-		t.Errorf("Expected 2 events, got %d", len(result))
+	opener := result[0].Event.Openers[0]
+	if opener.ID.Spotify != "opener-id" || len(opener.Genres.Spotify) != 1 || opener.Genres.Spotify[0] != "pop" {
+		t.Errorf("Expected the opener found by name on Spotify, got %+v", opener)
 	}
-	if len(result[0].Event.MainAct.Genres.Spotify) != 2 || result[0].Event.MainAct.Genres.Spotify[0] != "rock" {
-		t.Errorf("Expected main act genres [rock, alternative], got %v", result[0].Event.MainAct.Genres.Spotify)
+	if opener.ID.MusicBrainz != "mbid" || len(opener.Genres.LastFm) != 1 || opener.Genres.LastFm[0] != "synthpop" {
+		t.Errorf("Expected the opener found by name on LastFm, got %+v", opener)
 	}
-	if len(result[0].Event.Openers[0].Genres.Spotify) != 1 || result[0].Event.Openers[0].Genres.Spotify[0] != "pop" {
-		t.Errorf("Expected opener genres [pop], got %v", result[0].Event.Openers[0].Genres.Spotify)
+	if moved := result[1].Event.MainAct; moved.ID.Spotify != "new-id" || len(moved.Genres.Spotify) != 1 {
+		t.Errorf("Expected the missing Spotify ID to be replaced, got %+v", moved)
 	}
-	if result[0].Event.Openers[0].ID.Spotify != "opener-id" {
-		t.Errorf("Expected opener ID opener-id, got %s", result[0].Event.Openers[0].ID.Spotify)
+	if spotify.calls.searchByName["Known Artist"] != 0 || lastFm.calls.searchByName["Known Artist"] != 0 {
+		t.Error("Expected artists with genres not to be looked up")
 	}
-	if len(result[1].Event.MainAct.Genres.Spotify) != 1 || result[1].Event.MainAct.Genres.Spotify[0] != "indie" {
-		t.Errorf("Expected indie genre for second event, got %v", result[1].Event.MainAct.Genres.Spotify)
+	if events[0].Event.Openers[0].ID.Spotify != "" {
+		t.Error("Expected the given events to be left unchanged")
 	}
 }
 
-func TestReloadGenres(t *testing.T) {
-	mockCache := &MockSavedArtistCache{}
-	mockProvider := newMockGenreProvider()
+func TestReloadMetadata(t *testing.T) {
+	spotify := newMockMetadataProvider()
+	spotify.byID["id1"] = external.ArtistInfo{Id: "id1", Genres: []string{"rock"}}
+	spotify.byName["Artist2"] = external.ArtistInfo{Id: "id2", Genres: []string{"pop"}}
+	lastFm := newMockMetadataProvider()
 
 	artists := []domain.Artist{
 		{Name: "Artist1", ID: domain.ID{Spotify: "id1"}},
-		{Name: "Artist1"},
-	}
-
-	mockProvider.artistInfoByIdsFunc = func(ids []string) (map[string]external.ArtistInfo, error) {
-		return map[string]external.ArtistInfo{
-			"id1": {Id: "id1", Genres: []string{"rock"}},
-		}, nil
-	}
-
-	mockProvider.artistInfoByNameFunc = func(name string) (external.ArtistInfo, error) {
-		return external.ArtistInfo{
-			Id: "id2", Genres: []string{"pop"},
-		}, nil
-	}
-
-	finder := ArtistInfoFinder{
-		ArtistCache:   mockCache,
-		GenreProvider: mockProvider,
+		{Name: "Artist2"},
 	}
 
-	result, err := finder.ReloadGenres(artists)
+	finder := MetadataFinder{Spotify: spotify, LastFm: lastFm}
+	result, err := finder.ReloadMetadata(artists)
 
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
 	if len(result) != 2 {
-		t.Errorf("Expected 2 artists, got %d", len(result))
+		t.Fatalf("Expected 2 artists, got %d", len(result))
 	}
-	if result[0].ID.Spotify != "id1" {
-		t.Errorf("Expected spotify ID id1, got %s", result[0].ID.Spotify)
+	if result[0].ID.Spotify != "id1" || len(result[0].Genres.Spotify) != 1 || result[0].Genres.Spotify[0] != "rock" {
+		t.Errorf("Expected Artist1 reloaded by ID, got %+v", result[0])
 	}
-	if len(result[0].Genres.Spotify) != 1 || result[0].Genres.Spotify[0] != "rock" {
-		t.Errorf("Expected genres [rock], got %v", result[0].Genres.Spotify)
+	if result[1].ID.Spotify != "id2" || len(result[1].Genres.Spotify) != 1 || result[1].Genres.Spotify[0] != "pop" {
+		t.Errorf("Expected Artist2 reloaded by name, got %+v", result[1])
 	}
-	if result[1].ID.Spotify != "id2" {
-		t.Errorf("Expected spotify ID id2, got %s", result[1].ID.Spotify)
+	if artists[1].ID.Spotify != "" {
+		t.Error("Expected the given artists to be left unchanged")
 	}
-	if len(result[1].Genres.Spotify) != 1 || result[1].Genres.Spotify[0] != "pop" {
-		t.Errorf("Expected genres [pop], got %v", result[1].Genres.Spotify)
+	// LastFm failures leave the genres as they were
+	if len(result[0].Genres.LastFm) != 0 {
+		t.Errorf("Expected no LastFm genres, got %v", result[0].Genres.LastFm)
 	}
 }
@@ -83,6 +83,17 @@ func (c *Cache) mergeEvent(source domain.Event, target domain.Event) domain.Even
 	event.Venue = source.Venue
 	event.Purchased = source.Purchased
 	event.ID.Primary = source.ID.Primary
+	event.Rating = source.Rating
+	event.Performances = slices.Clone(source.Performances)
+	event.Notes = source.Notes
+	event.Seat = source.Seat
+	event.Companions = slices.Clone(source.Companions)
+	event.Ticket = source.Ticket
+	// a saved festival has the sets, ticketmaster only knows the dates
+	if source.Festival != nil {
+		festival := domain.CloneFestival(*source.Festival)
+		event.Festival = &festival
+	}
 
 	// due to match logic, either the TM IDs match or the source didn't have an ID
 	if source.ID.Ticketmaster == "" && target.ID.Ticketmaster != "" {
@@ -163,8 +174,7 @@ func (c *Cache) SyncArtistAdd(id string) error {
 	}
 	newArtist := savedArtists[newArtistIdx]
 
-	for _, eventData := range c.upcomingEvents {
-		events := eventData.Events
+	c.applySync(func(events []domain.EventDetails) {
 		for i, event := range events {
 			mainAct := event.Event.MainAct
 			if mainAct != nil && mainAct.EqualsFields(newArtist) {
@@ -179,7 +189,7 @@ func (c *Cache) SyncArtistAdd(id string) error {
 				}
 			}
 		}
-	}
+	})
 	return nil
 }
 
@@ -192,8 +202,7 @@ func (c *Cache) SyncArtistUpdate(id string) error {
 	}
 	updatedArtist := savedArtists[updatedArtistIdx]
 
-	for _, eventData := range c.upcomingEvents {
-		events := eventData.Events
+	c.applySync(func(events []domain.EventDetails) {
 		for i, event := range events {
 			mainAct := event.Event.MainAct
 			if mainAct != nil && (mainAct.EqualsFields(updatedArtist) || mainAct.Equals(updatedArtist)) {
@@ -208,13 +217,12 @@ func (c *Cache) SyncArtistUpdate(id string) error {
 				}
 			}
 		}
-	}
+	})
 	return nil
 }
 
 func (c *Cache) SyncArtistDelete(id string) {
-	for _, eventData := range c.upcomingEvents {
-		events := eventData.Events
+	c.applySync(func(events []domain.EventDetails) {
 		for i, event := range events {
 			mainAct := event.Event.MainAct
 			if mainAct != nil && mainAct.ID.Primary == id {
@@ -227,7 +235,35 @@ func (c *Cache) SyncArtistDelete(id string) {
 				}
 			}
 		}
+	})
+}
+
+// SyncArtistMerge points upcoming events matched to the merged source artist at the surviving artist
+func (c *Cache) SyncArtistMerge(survivorID string, sourceID string) error {
+	savedArtists := c.SavedDataCache.GetArtists()
+	survivorIdx := slices.IndexFunc(savedArtists, func(o domain.Artist) bool { return survivorID == o.ID.Primary })
+	if survivorIdx < 0 {
+		errMsg := fmt.Sprintf("unable to find merged cached artist with id %s in SyncArtistMerge", survivorID)
+		return errors.New(errMsg)
 	}
+	survivor := savedArtists[survivorIdx]
+
+	c.applySync(func(events []domain.EventDetails) {
+		for i, event := range events {
+			mainAct := event.Event.MainAct
+			if mainAct != nil && (mainAct.ID.Primary == sourceID || mainAct.Equals(survivor)) {
+				clonedArtist := domain.CloneArtist(survivor)
+				events[i].Event.MainAct = &clonedArtist
+			}
+
+			for j, opener := range event.Event.Openers {
+				if opener.ID.Primary == sourceID || opener.Equals(survivor) {
+					events[i].Event.Openers[j] = domain.CloneArtist(survivor)
+				}
+			}
+		}
+	})
+	return nil
 }
 
 func (c *Cache) SyncVenueAdd(id string) error {
@@ -239,14 +275,13 @@ func (c *Cache) SyncVenueAdd(id string) error {
 	}
 	newVenue := savedVenues[newVenueIdx]
 
-	for _, eventData := range c.upcomingEvents {
-		events := eventData.Events
+	c.applySync(func(events []domain.EventDetails) {
 		for i, event := range events {
 			if event.Event.Venue.Equals(newVenue) {
 				events[i].Event.Venue = newVenue
 			}
 		}
-	}
+	})
 	return nil
 }
 
@@ -259,27 +294,46 @@ func (c *Cache) SyncVenueUpdate(id string) error {
 	}
 	updatedVenue := savedVenues[updatedVenueIdx]
 
-	for _, eventData := range c.upcomingEvents {
-		events := eventData.Events
+	c.applySync(func(events []domain.EventDetails) {
 		for i, event := range events {
 			venue := event.Event.Venue
 			if venue.EqualsFields(updatedVenue) || venue.Equals(updatedVenue) {
 				events[i].Event.Venue = updatedVenue
 			}
 		}
-	}
+	})
 	return nil
 }
 
 func (c *Cache) SyncVenueDelete(id string) {
-	for _, eventData := range c.upcomingEvents {
-		events := eventData.Events
+	c.applySync(func(events []domain.EventDetails) {
 		for i, event := range events {
 			if event.Event.Venue.ID.Primary == id {
 				events[i].Event.Venue.ID.Primary = ""
 			}
 		}
+	})
+}
+
+// SyncVenueMerge points upcoming events matched to the merged source venue at the surviving venue
+func (c *Cache) SyncVenueMerge(survivorID string, sourceID string) error {
+	savedVenues := c.SavedDataCache.GetVenues()
+	survivorIdx := slices.IndexFunc(savedVenues, func(o domain.Venue) bool { return survivorID == o.ID.Primary })
+	if survivorIdx < 0 {
+		errMsg := fmt.Sprintf("unable to find merged cached venue with id %s in SyncVenueMerge", survivorID)
+		return errors.New(errMsg)
 	}
+	survivor := savedVenues[survivorIdx]
+
+	c.applySync(func(events []domain.EventDetails) {
+		for i, event := range events {
+			venue := event.Event.Venue
+			if venue.ID.Primary == sourceID || venue.ID.Primary == survivorID {
+				events[i].Event.Venue = survivor
+			}
+		}
+	})
+	return nil
 }
 
 func (c *Cache) SyncEventAdd(id string) error {
@@ -291,14 +345,13 @@ func (c *Cache) SyncEventAdd(id string) error {
 	}
 	newEvent := savedEvents[newEventIdx]
 
-	for _, eventData := range c.upcomingEvents {
-		events := eventData.Events
+	c.applySync(func(events []domain.EventDetails) {
 		for i, event := range events {
 			if event.Event.EqualsFields(newEvent) {
-				events[i].Event = newEvent
+				events[i].Event = domain.CloneEvent(newEvent)
 			}
 		}
-	}
+	})
 	return nil
 }
 
@@ -311,24 +364,22 @@ func (c *Cache) SyncEventUpdate(id string) error {
 	}
 	updatedEvent := savedEvents[updatedEventIdx]
 
-	for _, eventData := range c.upcomingEvents {
-		events := eventData.Events
+	c.applySync(func(events []domain.EventDetails) {
 		for i, event := range events {
 			if event.Event.EqualsFields(updatedEvent) || event.Event.Equals(updatedEvent) {
-				events[i].Event = updatedEvent
+				events[i].Event = domain.CloneEvent(updatedEvent)
 			}
 		}
-	}
+	})
 	return nil
 }
 
 func (c *Cache) SyncEventDelete(id string) {
-	for _, eventData := range c.upcomingEvents {
-		events := eventData.Events
+	c.applySync(func(events []domain.EventDetails) {
 		for i, event := range events {
 			if event.Event.ID.Primary == id {
 				events[i].Event.ID.Primary = ""
 			}
 		}
-	}
+	})
 }
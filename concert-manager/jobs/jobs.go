@@ -0,0 +1,300 @@
+// Package jobs runs long refreshes in the background and keeps their status, so a request can
+// start one and check on it later rather than waiting for it to finish.
+package jobs
+
+import (
+	"concert-manager/domain"
+	"concert-manager/file"
+	"concert-manager/log"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+type Status string
+
+const (
+	Running   Status = "running"
+	Succeeded Status = "succeeded"
+	Failed    Status = "failed"
+	Cancelled Status = "cancelled"
+)
+
+// finished jobs kept in the history, older ones are dropped
+const maxHistory = 50
+
+type Job struct {
+	ID   string `json:"id"`
+	Kind string `json:"kind"`
+	// what the job was started with, like the artists of a targeted genre reload
+	Args       []string   `json:"args,omitempty"`
+	Status     Status     `json:"status"`
+	Progress   Progress   `json:"progress"`
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	// what the job returned, once it succeeded
+	Result any    `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Progress counts the steps of a job done so far, Total is 0 until the job knows it
+type Progress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+func (j Job) Finished() bool {
+	return j.Status != Running
+}
+
+// Run is the work of a job. It should return early with the context's error once the context is
+// cancelled, and can report its progress with ReportProgress.
+type Run func(context.Context) (any, error)
+
+type progressKey struct{}
+
+// ReportProgress updates the progress of the job running with the context, if there is one
+func ReportProgress(ctx context.Context, done, total int) {
+	if report, ok := ctx.Value(progressKey{}).(func(int, int)); ok {
+		report(done, total)
+	}
+}
+
+// Manager runs jobs and keeps the recent ones, in FilePath when it is set so the history
+// survives a restart. The zero value is ready to use.
+type Manager struct {
+	FilePath string
+	mutex    sync.Mutex
+	// newest first
+	jobs    []*Job
+	cancels map[string]context.CancelFunc
+	done    map[string]chan struct{}
+}
+
+type historyFile struct {
+	Jobs []*Job `json:"jobs"`
+}
+
+// Load reads the history kept in FilePath. Jobs that were still running when it was written were
+// stopped by the restart, so they are marked as failed.
+func (m *Manager) Load() error {
+	if m.FilePath == "" || !file.FileExists(m.FilePath) {
+		return nil
+	}
+	var history historyFile
+	if err := file.ReadJSONFile(m.FilePath, &history); err != nil {
+		return fmt.Errorf("failed to load job history: %w", err)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, job := range history.Jobs {
+		if !job.Finished() {
+			job.Status = Failed
+			job.Error = "interrupted by a restart"
+			finishedAt := job.StartedAt
+			job.FinishedAt = &finishedAt
+		}
+	}
+	m.jobs = history.Jobs
+	log.Infof("Loaded %d jobs from history", len(m.jobs))
+	return nil
+}
+
+// Start runs a job of the kind in the background. Only one job of a kind runs at a time, so while
+// one is running it is returned instead of starting another.
+func (m *Manager) Start(kind string, run Run) Job {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if running := m.running(kind); running != nil {
+		log.Infof("Job %s of kind %s is already running", running.ID, kind)
+		return *running
+	}
+	return m.start(kind, nil, run)
+}
+
+// StartWithArgs is Start for a job whose work depends on its arguments. The running job of the kind
+// is only returned when it has the same arguments, otherwise it is a conflict rather than handing
+// back a job doing other work.
+func (m *Manager) StartWithArgs(kind string, args []string, run Run) (Job, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if running := m.running(kind); running != nil {
+		if !slices.Equal(running.Args, args) {
+			log.Infof("Job %s of kind %s is already running with arguments %v", running.ID, kind, running.Args)
+			return Job{}, domain.Conflict("job %s of kind %s is already running with other arguments", running.ID, kind)
+		}
+		log.Infof("Job %s of kind %s is already running", running.ID, kind)
+		return *running, nil
+	}
+	return m.start(kind, args, run), nil
+}
+
+// requires the lock to be held
+func (m *Manager) running(kind string) *Job {
+	if idx := slices.IndexFunc(m.jobs, func(j *Job) bool { return j.Kind == kind && !j.Finished() }); idx != -1 {
+		return m.jobs[idx]
+	}
+	return nil
+}
+
+// requires the lock to be held
+func (m *Manager) start(kind string, args []string, run Run) Job {
+	if m.cancels == nil {
+		m.cancels = map[string]context.CancelFunc{}
+		m.done = map[string]chan struct{}{}
+	}
+
+	job := &Job{ID: newID(), Kind: kind, Args: args, Status: Running, StartedAt: time.Now().Round(0)}
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = context.WithValue(ctx, progressKey{}, func(done, total int) {
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+		job.Progress = Progress{Done: done, Total: total}
+	})
+	m.jobs = slices.Insert(m.jobs, 0, job)
+	m.cancels[job.ID] = cancel
+	m.done[job.ID] = make(chan struct{})
+	m.save()
+
+	log.Infof("Starting job %s of kind %s", job.ID, kind)
+	go m.run(ctx, job, run)
+	return *job
+}
+
+func (m *Manager) run(ctx context.Context, job *Job, run Run) {
+	var result any
+	var err error
+	func() {
+		// a failing job shouldn't take the server down with it
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("job panicked: %v", r)
+			}
+		}()
+		result, err = run(ctx)
+	}()
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	finishedAt := time.Now().Round(0)
+	job.FinishedAt = &finishedAt
+	switch {
+	case ctx.Err() != nil:
+		job.Status = Cancelled
+		log.Infof("Job %s of kind %s was cancelled", job.ID, job.Kind)
+	case err != nil:
+		job.Status = Failed
+		job.Error = err.Error()
+		log.Errorf("Job %s of kind %s failed, %v", job.ID, job.Kind, err)
+	default:
+		job.Status = Succeeded
+		job.Result = result
+		log.Infof("Job %s of kind %s finished in %v", job.ID, job.Kind, finishedAt.Sub(job.StartedAt))
+	}
+	m.cancels[job.ID]()
+	delete(m.cancels, job.ID)
+	close(m.done[job.ID])
+	delete(m.done, job.ID)
+	m.trim()
+	m.save()
+}
+
+func (m *Manager) Get(id string) (Job, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	job, err := m.find(id)
+	if err != nil {
+		return Job{}, err
+	}
+	return *job, nil
+}
+
+// List is every job kept, newest first
+func (m *Manager) List() []Job {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	jobs := []Job{}
+	for _, job := range m.jobs {
+		jobs = append(jobs, *job)
+	}
+	return jobs
+}
+
+// Cancel asks a running job to stop, it is cancelled once its work returns
+func (m *Manager) Cancel(id string) (Job, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	job, err := m.find(id)
+	if err != nil {
+		return Job{}, err
+	}
+	if job.Finished() {
+		return Job{}, domain.Conflict("job %s already %s", id, job.Status)
+	}
+	log.Infof("Cancelling job %s of kind %s", job.ID, job.Kind)
+	m.cancels[id]()
+	return *job, nil
+}
+
+// Wait blocks until the job finishes or the context is done
+func (m *Manager) Wait(ctx context.Context, id string) (Job, error) {
+	m.mutex.Lock()
+	job, err := m.find(id)
+	done := m.done[id]
+	m.mutex.Unlock()
+	if err != nil {
+		return Job{}, err
+	}
+	if done != nil {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return Job{}, ctx.Err()
+		}
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return *job, nil
+}
+
+// requires the lock to be held
+func (m *Manager) find(id string) (*Job, error) {
+	idx := slices.IndexFunc(m.jobs, func(j *Job) bool { return j.ID == id })
+	if idx == -1 {
+		return nil, domain.NotFound("job %s not found", id)
+	}
+	return m.jobs[idx], nil
+}
+
+// requires the lock to be held, drops the oldest finished jobs past the history limit
+func (m *Manager) trim() {
+	finished := 0
+	m.jobs = slices.DeleteFunc(m.jobs, func(j *Job) bool {
+		if !j.Finished() {
+			return false
+		}
+		finished++
+		return finished > maxHistory
+	})
+}
+
+// requires the lock to be held, so an older history can't overwrite a newer one
+func (m *Manager) save() {
+	if m.FilePath == "" {
+		return
+	}
+	if err := file.WriteJSONFile(m.FilePath, historyFile{Jobs: m.jobs}); err != nil {
+		log.Error("Failed to save job history", err)
+	}
+}
+
+func newID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
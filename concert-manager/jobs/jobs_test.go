@@ -0,0 +1,130 @@
+package jobs
+
+import (
+	"concert-manager/domain"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func waitFor(t *testing.T, m *Manager, id string) Job {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	job, err := m.Wait(ctx, id)
+	if err != nil {
+		t.Fatalf("failed to wait for job %s: %v", id, err)
+	}
+	return job
+}
+
+func TestJobLifecycle(t *testing.T) {
+	m := &Manager{}
+	release := make(chan struct{})
+	job := m.Start("refresh", func(ctx context.Context) (any, error) {
+		ReportProgress(ctx, 1, 2)
+		<-release
+		return 2, nil
+	})
+	if job.Status != Running {
+		t.Errorf("expected the job to be running, got %+v", job)
+	}
+	// the running job is returned rather than starting another of the kind
+	if again := m.Start("refresh", nil); again.ID != job.ID {
+		t.Errorf("expected the running job %s, got %+v", job.ID, again)
+	}
+	close(release)
+
+	done := waitFor(t, m, job.ID)
+	if done.Status != Succeeded || done.Result != 2 || done.Progress != (Progress{Done: 1, Total: 2}) || done.FinishedAt == nil {
+		t.Errorf("unexpected finished job %+v", done)
+	}
+	if _, err := m.Cancel(job.ID); domain.KindOf(err) != domain.KindConflict {
+		t.Errorf("expected a conflict cancelling a finished job, got %v", err)
+	}
+	if _, err := m.Get("missing"); domain.KindOf(err) != domain.KindNotFound {
+		t.Errorf("expected not found, got %v", err)
+	}
+
+	failed := waitFor(t, m, m.Start("refresh", func(context.Context) (any, error) { return nil, errors.New("upstream down") }).ID)
+	if failed.Status != Failed || failed.Error != "upstream down" {
+		t.Errorf("expected a failed job, got %+v", failed)
+	}
+	panicked := waitFor(t, m, m.Start("refresh", func(context.Context) (any, error) { panic("boom") }).ID)
+	if panicked.Status != Failed {
+		t.Errorf("expected a panicking job to fail, got %+v", panicked)
+	}
+	if jobs := m.List(); len(jobs) != 3 || jobs[0].ID != panicked.ID {
+		t.Errorf("expected 3 jobs, newest first, got %+v", jobs)
+	}
+}
+
+func TestStartWithArgs(t *testing.T) {
+	m := &Manager{}
+	release := make(chan struct{})
+	job, err := m.StartWithArgs("reload", []string{"a", "b"}, func(ctx context.Context) (any, error) {
+		<-release
+		return nil, nil
+	})
+	if err != nil || job.Status != Running {
+		t.Fatalf("expected the job to be running, got %+v, %v", job, err)
+	}
+	if again, err := m.StartWithArgs("reload", []string{"a", "b"}, nil); err != nil || again.ID != job.ID {
+		t.Errorf("expected the running job %s for the same arguments, got %+v, %v", job.ID, again, err)
+	}
+	// a reload of other artists would be handed the wrong work
+	for _, args := range [][]string{{"c"}, nil} {
+		if _, err := m.StartWithArgs("reload", args, nil); domain.KindOf(err) != domain.KindConflict {
+			t.Errorf("expected a conflict for arguments %v, got %v", args, err)
+		}
+	}
+	close(release)
+
+	if done := waitFor(t, m, job.ID); len(done.Args) != 2 {
+		t.Errorf("expected the arguments to be kept, got %+v", done)
+	}
+}
+
+func TestCancelJob(t *testing.T) {
+	m := &Manager{}
+	job := m.Start("refresh", func(ctx context.Context) (any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if _, err := m.Cancel(job.ID); err != nil {
+		t.Fatalf("failed to cancel job: %v", err)
+	}
+	if cancelled := waitFor(t, m, job.ID); cancelled.Status != Cancelled || cancelled.Error != "" {
+		t.Errorf("expected a cancelled job, got %+v", cancelled)
+	}
+}
+
+func TestJobHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.json")
+	m := &Manager{FilePath: path}
+	for i := 0; i < maxHistory+5; i++ {
+		waitFor(t, m, m.Start("refresh", func(context.Context) (any, error) { return i, nil }).ID)
+	}
+	running := m.Start("genres", func(ctx context.Context) (any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	defer m.Cancel(running.ID)
+
+	// the history is read as if the server restarted with the genres job still running
+	reloaded := &Manager{FilePath: path}
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("failed to load history: %v", err)
+	}
+	jobs := reloaded.List()
+	if len(jobs) != maxHistory+1 {
+		t.Fatalf("expected %d jobs, got %d", maxHistory+1, len(jobs))
+	}
+	if jobs[0].ID != running.ID || jobs[0].Status != Failed || jobs[0].Error == "" {
+		t.Errorf("expected the running job to be interrupted, got %+v", jobs[0])
+	}
+	if jobs[1].Status != Succeeded || jobs[1].Result != float64(maxHistory+4) {
+		t.Errorf("expected the latest refresh, got %+v", jobs[1])
+	}
+}
@@ -0,0 +1,386 @@
+package loader
+
+import (
+	"concert-manager/domain"
+	"concert-manager/log"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type AlbumFormat string
+
+const (
+	// GenericFormat has a header row naming the album fields, see albumColumns
+	GenericFormat AlbumFormat = "csv"
+	// DiscogsFormat is the collection export from discogs.com
+	DiscogsFormat AlbumFormat = "discogs"
+)
+
+type ImportStatus string
+
+const (
+	ImportCreated ImportStatus = "created"
+	ImportNew     ImportStatus = "new" // an album a dry run would create
+	ImportExists  ImportStatus = "exists"
+	ImportInvalid ImportStatus = "invalid"
+	ImportFailed  ImportStatus = "failed"
+)
+
+type AlbumImportRow struct {
+	// Row is the line of the file, counting the header as line 1
+	Row    int           `json:"row"`
+	Status ImportStatus  `json:"status"`
+	Album  *domain.Album `json:"album,omitempty"`
+	// NewArtists are the artists the row created, or would create in a dry run
+	NewArtists []string `json:"newArtists,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+type AlbumImportReport struct {
+	Format  AlbumFormat      `json:"format"`
+	DryRun  bool             `json:"dryRun"`
+	Created int              `json:"created"`
+	Exists  int              `json:"exists"`
+	Invalid int              `json:"invalid"`
+	Failed  int              `json:"failed"`
+	Rows    []AlbumImportRow `json:"rows"`
+}
+
+func (r *AlbumImportReport) add(row AlbumImportRow) {
+	switch row.Status {
+	case ImportCreated, ImportNew:
+		r.Created++
+	case ImportExists:
+		r.Exists++
+	case ImportInvalid:
+		r.Invalid++
+	case ImportFailed:
+		r.Failed++
+	}
+	r.Rows = append(r.Rows, row)
+}
+
+type albumCache interface {
+	GetAlbums() []domain.Album
+	GetArtists() []domain.Artist
+	AddArtist(domain.Artist) (*domain.Artist, error)
+	AddAlbum(domain.Album) (*domain.Album, error)
+}
+
+type AlbumLoader struct {
+	Cache albumCache
+}
+
+// generic CSV columns, found by their header name in any order. Only the name and artists are required.
+var albumColumns = map[string][]string{
+	"name":           {"name", "title", "album"},
+	"artists":        {"artists", "artist"},
+	"year":           {"year"},
+	"format":         {"format"},
+	"variant":        {"variant"},
+	"genre":          {"genre"},
+	"signed":         {"signed"},
+	"wishlisted":     {"wishlisted"},
+	"limitedEdition": {"limited edition"},
+	"notes":          {"notes"},
+	"coverImageUrl":  {"cover image url"},
+}
+
+// Discogs collection export columns
+var discogsColumns = map[string][]string{
+	"artists": {"artist"},
+	"name":    {"title"},
+	"format":  {"format"},
+	"year":    {"released"},
+	"notes":   {"collection notes"},
+}
+
+// Discogs format descriptions that are already covered by the album fields, any other
+// descriptions like colours are kept as the variant
+var discogsDescriptions = []string{
+	"vinyl", "lp", "ep", "single", "album", "compilation", "mini-album", "maxi-single",
+	"ltd", "limited edition", "numbered", "re", "rp", "reissue", "repress", "remastered",
+	"stereo", "mono", "gat", "gatefold", "12\"", "10\"", "7\"", "33 ⅓ rpm", "45 rpm",
+	"promo", "unofficial release", "club edition", "deluxe edition", "special edition",
+	"test pressing", "cd", "cass", "file", "box set", "dlx", "s/edition", "signed", "autographed",
+}
+
+// Discogs tells apart artists with the same name with a number, e.g. "Nirvana (2)",
+// and marks name variations with a "*"
+var discogsArtistSuffix = regexp.MustCompile(`(\s\(\d+\))?\*?$`)
+
+// Import adds the albums in a UTF-8 encoded CSV file, reusing existing artists by name and
+// skipping albums that already exist. The format is detected from the header when it's empty.
+// Rows are imported independently, so the report has the outcome of every row and an error is
+// only returned when the file can't be read at all. A dry run reports what would happen
+// without saving anything.
+func (l *AlbumLoader) Import(ctx context.Context, file io.Reader, format AlbumFormat, dryRun bool) (*AlbumImportReport, error) {
+	log.Debugf("Starting album import, format=%q, dryRun=%v", format, dryRun)
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read header: %v", err)
+	}
+
+	if format == "" {
+		format = detectAlbumFormat(header)
+	}
+	var columns map[string]int
+	switch format {
+	case GenericFormat:
+		columns = findColumns(header, albumColumns)
+	case DiscogsFormat:
+		columns = findColumns(header, discogsColumns)
+	default:
+		return nil, fmt.Errorf("unsupported album format %q, expected %s or %s", format, GenericFormat, DiscogsFormat)
+	}
+	if columns["name"] == -1 || columns["artists"] == -1 {
+		return nil, errors.New("missing name or artist column")
+	}
+
+	report := &AlbumImportReport{Format: format, DryRun: dryRun, Rows: []AlbumImportRow{}}
+	importer := l.newImporter(dryRun)
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Errorf("Error while reading album row %d: %v", line, err)
+			return nil, fmt.Errorf("unable to read row %d: %v", line, err)
+		}
+		var album domain.Album
+		if format == DiscogsFormat {
+			album, err = toDiscogsAlbum(row, columns)
+		} else {
+			album, err = toAlbum(row, columns)
+		}
+		if err != nil {
+			report.add(AlbumImportRow{Row: line, Status: ImportInvalid, Error: err.Error()})
+			continue
+		}
+		report.add(importer.add(line, album))
+	}
+
+	log.Infof("Imported albums, %d created, %d already existed, %d invalid, %d failed, dryRun=%v",
+		report.Created, report.Exists, report.Invalid, report.Failed, dryRun)
+	return report, nil
+}
+
+func detectAlbumFormat(header []string) AlbumFormat {
+	if slices.ContainsFunc(header, func(h string) bool { return strings.EqualFold(strings.TrimSpace(h), "release_id") }) {
+		return DiscogsFormat
+	}
+	return GenericFormat
+}
+
+// findColumns maps each field to the index of the first header matching one of its names, or -1
+func findColumns(header []string, fields map[string][]string) map[string]int {
+	columns := map[string]int{}
+	for field, names := range fields {
+		columns[field] = slices.IndexFunc(header, func(h string) bool {
+			return slices.Contains(names, strings.ToLower(strings.TrimSpace(h)))
+		})
+	}
+	return columns
+}
+
+func column(row []string, columns map[string]int, field string) string {
+	idx, ok := columns[field]
+	if !ok || idx < 0 || idx >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[idx])
+}
+
+func toAlbum(row []string, columns map[string]int) (domain.Album, error) {
+	album := domain.Album{
+		Name:           column(row, columns, "name"),
+		Format:         column(row, columns, "format"),
+		Variant:        column(row, columns, "variant"),
+		Genre:          column(row, columns, "genre"),
+		Signed:         strings.EqualFold(column(row, columns, "signed"), "TRUE"),
+		Wishlisted:     strings.EqualFold(column(row, columns, "wishlisted"), "TRUE"),
+		LimitedEdition: strings.EqualFold(column(row, columns, "limitedEdition"), "TRUE"),
+		Notes:          column(row, columns, "notes"),
+		CoverImageUrl:  column(row, columns, "coverImageUrl"),
+		Artists:        []domain.Artist{},
+	}
+	for _, name := range strings.Split(column(row, columns, "artists"), ";") {
+		if name = strings.TrimSpace(name); name != "" {
+			album.Artists = append(album.Artists, domain.Artist{Name: name})
+		}
+	}
+	if year := column(row, columns, "year"); year != "" {
+		parsed, err := strconv.Atoi(year)
+		if err != nil {
+			return domain.Album{}, fmt.Errorf("invalid year %q", year)
+		}
+		album.Year = parsed
+	}
+	return album, validateImportedAlbum(album)
+}
+
+// toDiscogsAlbum reads a Discogs export row. The artist is kept whole since Discogs joins
+// artists with separators like "&" that are also part of band names. Discogs has no field
+// for signed copies, so an album counts as signed when its format or notes mention it.
+func toDiscogsAlbum(row []string, columns map[string]int) (domain.Album, error) {
+	album := domain.Album{
+		Name:    column(row, columns, "name"),
+		Notes:   column(row, columns, "notes"),
+		Artists: []domain.Artist{},
+	}
+	if artist := discogsArtistSuffix.ReplaceAllString(column(row, columns, "artists"), ""); artist != "" {
+		album.Artists = append(album.Artists, domain.Artist{Name: artist})
+	}
+	// Discogs uses 0 for an unknown release year, and some releases have a full date
+	if released := column(row, columns, "year"); len(released) >= 4 {
+		if year, err := strconv.Atoi(released[:4]); err == nil {
+			album.Year = year
+		}
+	}
+
+	variants := []string{}
+	for _, description := range strings.Split(column(row, columns, "format"), ",") {
+		description = strings.TrimSpace(description)
+		lower := strings.ToLower(description)
+		switch {
+		case lower == "":
+			continue
+		case lower == "lp" && album.Format == "":
+			album.Format = "LP"
+		case strings.HasSuffix(lower, "xlp") && album.Format == "":
+			album.Format = "2xLP"
+		case lower == "ep" || lower == "mini-album":
+			album.Format = "EP"
+		case lower == "single" || lower == "maxi-single" || lower == "7\"":
+			if album.Format == "" {
+				album.Format = "Single"
+			}
+		case lower == "ltd" || lower == "limited edition" || lower == "numbered":
+			album.LimitedEdition = true
+		case lower == "signed" || lower == "autographed":
+			album.Signed = true
+		}
+		if !slices.Contains(discogsDescriptions, lower) && !strings.HasSuffix(lower, "xlp") {
+			variants = append(variants, description)
+		}
+	}
+	album.Variant = strings.Join(variants, ", ")
+	notes := strings.ToLower(album.Notes)
+	if strings.Contains(notes, "signed") || strings.Contains(notes, "autographed") {
+		album.Signed = true
+	}
+	return album, validateImportedAlbum(album)
+}
+
+func validateImportedAlbum(album domain.Album) error {
+	if album.Name == "" {
+		return errors.New("missing album name")
+	}
+	if len(album.Artists) == 0 {
+		return errors.New("missing album artist")
+	}
+	if album.Year < 0 || album.Year > 9999 {
+		return fmt.Errorf("invalid year %d", album.Year)
+	}
+	return nil
+}
+
+// albumImporter saves albums one row at a time, keeping track of the artists and albums
+// added so far so later rows in the same file reuse them
+type albumImporter struct {
+	cache   albumCache
+	dryRun  bool
+	artists map[string]domain.Artist
+	albums  map[string]bool
+}
+
+func (l *AlbumLoader) newImporter(dryRun bool) *albumImporter {
+	importer := &albumImporter{
+		cache:   l.Cache,
+		dryRun:  dryRun,
+		artists: map[string]domain.Artist{},
+		albums:  map[string]bool{},
+	}
+	for _, artist := range l.Cache.GetArtists() {
+		key := strings.ToLower(artist.Name)
+		if _, exists := importer.artists[key]; !exists {
+			importer.artists[key] = artist
+		}
+	}
+	for _, album := range l.Cache.GetAlbums() {
+		importer.albums[albumKey(album)] = true
+	}
+	return importer
+}
+
+// albumKey identifies an album by its name, artists, format and variant, since a collection
+// can have the same album more than once in different pressings
+func albumKey(album domain.Album) string {
+	names := []string{}
+	for _, artist := range album.Artists {
+		names = append(names, strings.ToLower(artist.Name))
+	}
+	slices.Sort(names)
+	return strings.ToLower(strings.Join([]string{album.Name, strings.Join(names, ";"), album.Format, album.Variant}, "|"))
+}
+
+func (i *albumImporter) add(line int, album domain.Album) AlbumImportRow {
+	result := AlbumImportRow{Row: line, Album: &album}
+	key := albumKey(album)
+	if i.albums[key] {
+		result.Status = ImportExists
+		return result
+	}
+
+	for idx, artist := range album.Artists {
+		name := strings.ToLower(artist.Name)
+		if existing, ok := i.artists[name]; ok {
+			album.Artists[idx] = existing
+			continue
+		}
+		result.NewArtists = append(result.NewArtists, artist.Name)
+		if i.dryRun {
+			i.artists[name] = artist
+			continue
+		}
+		saved, err := i.cache.AddArtist(artist)
+		if err != nil {
+			log.Errorf("Failed to add artist %v for album row %d, %v", artist.Name, line, err)
+			result.Status = ImportFailed
+			result.Error = fmt.Sprintf("failed to add artist %s: %v", artist.Name, err)
+			return result
+		}
+		i.artists[name] = *saved
+		album.Artists[idx] = *saved
+	}
+
+	i.albums[key] = true
+	if i.dryRun {
+		result.Status = ImportNew
+		return result
+	}
+	saved, err := i.cache.AddAlbum(album)
+	if err != nil {
+		log.Errorf("Failed to add album row %d, %+v, %v", line, album, err)
+		delete(i.albums, key)
+		result.Status = ImportFailed
+		result.Error = fmt.Sprintf("failed to add album: %v", err)
+		return result
+	}
+	result.Status = ImportCreated
+	result.Album = saved
+	return result
+}
@@ -0,0 +1,93 @@
+package loader
+
+import (
+	"concert-manager/db"
+	"concert-manager/domain"
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func newAlbumCache(t *testing.T) *db.Cache {
+	cache := &db.Cache{Database: db.NewMemoryRepository()}
+	cache.LoadCaches()
+	if _, err := cache.AddArtist(domain.Artist{Name: "Existing"}); err != nil {
+		t.Fatalf("failed to add artist: %v", err)
+	}
+	return cache
+}
+
+func statuses(report *AlbumImportReport) []ImportStatus {
+	out := []ImportStatus{}
+	for _, row := range report.Rows {
+		out = append(out, row.Status)
+	}
+	return out
+}
+
+func TestImportGenericAlbums(t *testing.T) {
+	cache := newAlbumCache(t)
+	loader := &AlbumLoader{Cache: cache}
+	file := `Title,Artists,Year,Format,Variant,Signed
+First,existing;New,2020,LP,Red,TRUE
+First,Existing;new,2020,LP,Red,TRUE
+,Existing,2021,LP,,
+Second,New,twenty,LP,,
+`
+
+	report, err := loader.Import(context.Background(), strings.NewReader(file), "", false)
+	if err != nil {
+		t.Fatalf("failed to import: %v", err)
+	}
+	want := []ImportStatus{ImportCreated, ImportExists, ImportInvalid, ImportInvalid}
+	if report.Format != GenericFormat || !slices.Equal(statuses(report), want) {
+		t.Fatalf("expected %v in generic format, got %+v", want, report)
+	}
+	if report.Rows[0].Row != 2 || len(report.Rows[0].NewArtists) != 1 || report.Rows[0].NewArtists[0] != "New" {
+		t.Errorf("unexpected first row %+v", report.Rows[0])
+	}
+
+	albums := cache.GetAlbums()
+	if len(albums) != 1 || !albums[0].Signed || albums[0].Variant != "Red" || albums[0].Year != 2020 {
+		t.Fatalf("unexpected albums %+v", albums)
+	}
+	if len(cache.GetArtists()) != 2 || albums[0].Artists[0].Name != "Existing" {
+		t.Errorf("expected the existing artist to be reused, got %+v", cache.GetArtists())
+	}
+}
+
+func TestImportDiscogsAlbumsDryRun(t *testing.T) {
+	cache := newAlbumCache(t)
+	loader := &AlbumLoader{Cache: cache}
+	file := `Catalog#,Artist,Title,Label,Format,Rating,Released,release_id,CollectionFolder,Date Added,Collection Media Condition,Collection Sleeve Condition,Collection Notes
+XL1,Existing (2),Album,XL,"2xLP, Album, Ltd, Blue Marbled",,2019-05-03,123,Uncategorized,2024-01-01 10:00:00,Mint (M),Mint (M),Signed at the merch table
+XL2,Newcomer*,Single,XL,"Vinyl, 7"", Single",,0,124,Uncategorized,2024-01-01 10:00:00,,,
+XL3,Newcomer,Other,XL,"Vinyl, 7"", EP",,2001,125,Uncategorized,2024-01-01 10:00:00,,,
+`
+
+	report, err := loader.Import(context.Background(), strings.NewReader(file), "", true)
+	if err != nil {
+		t.Fatalf("failed to import: %v", err)
+	}
+	if report.Format != DiscogsFormat || !report.DryRun || report.Created != 3 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	first := report.Rows[0].Album
+	if first.Artists[0].Name != "Existing" || first.Artists[0].ID.Primary == "" || first.Format != "2xLP" ||
+		first.Variant != "Blue Marbled" || !first.LimitedEdition || !first.Signed || first.Year != 2019 {
+		t.Errorf("unexpected first album %+v", first)
+	}
+	second := report.Rows[1].Album
+	if second.Artists[0].Name != "Newcomer" || second.Format != "Single" || second.Year != 0 || second.Signed {
+		t.Errorf("unexpected second album %+v", second)
+	}
+	if len(report.Rows[1].NewArtists) != 1 || len(report.Rows[2].NewArtists) != 0 || report.Rows[2].Album.Format != "EP" {
+		t.Errorf("expected the new artist to be reported once, got %+v and %+v", report.Rows[1], report.Rows[2])
+	}
+
+	if len(cache.GetAlbums()) != 0 || len(cache.GetArtists()) != 1 {
+		t.Errorf("expected a dry run to save nothing, got %+v and %+v", cache.GetAlbums(), cache.GetArtists())
+	}
+}
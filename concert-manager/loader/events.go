@@ -1,54 +1,154 @@
 package loader
 
 import (
-	"bufio"
-
 	"concert-manager/domain"
 	"concert-manager/log"
 	"context"
+	"encoding/csv"
 	"errors"
 	"fmt"
 	"io"
+	"math"
+	"slices"
+	"strconv"
 	"strings"
 )
 
 const minColumns = 7
 
+// optional columns, found by their header name anywhere after the required columns
+const (
+	ratingColumn        = "rating"
+	notesColumn         = "notes"
+	seatColumn          = "seat"
+	companionsColumn    = "companions"
+	artistRatingsColumn = "artist ratings"
+	priceColumn         = "price"
+	quantityColumn      = "quantity"
+	feesColumn          = "fees"
+	purchaseDateColumn  = "purchase date"
+	festivalColumn      = "festival"
+	endDateColumn       = "end date"
+	setsColumn          = "sets"
+	startTimeColumn     = "start time"
+)
+
 type eventCache interface {
 	AddSavedEvent(domain.Event) (*domain.Event, error)
+	UpdateSavedEvent(string, domain.Event) error
 }
 
 type EventLoader struct {
 	Cache eventCache
 }
 
+// detailColumns holds the index of each optional column in the file, or -1 if it isn't present
+type detailColumns struct {
+	rating        int
+	notes         int
+	seat          int
+	companions    int
+	artistRatings int
+	price         int
+	quantity      int
+	fees          int
+	purchaseDate  int
+	festival      int
+	endDate       int
+	sets          int
+	startTime     int
+}
+
+// artistDetails are the parts of a row that reference artists by ID, so they can only be
+// added once the event is saved and any new artists have IDs. Names are lower case.
+type artistDetails struct {
+	ratings map[string]int
+	sets    []festivalSet
+}
+
+type festivalSet struct {
+	artist string
+	set    domain.Set
+	date   domain.Date
+}
+
+func (a artistDetails) empty() bool {
+	return len(a.ratings) == 0 && len(a.sets) == 0
+}
+
+func (d detailColumns) contains(idx int) bool {
+	return slices.Contains([]int{d.rating, d.notes, d.seat, d.companions, d.artistRatings,
+		d.price, d.quantity, d.fees, d.purchaseDate, d.festival, d.endDate, d.sets, d.startTime}, idx)
+}
+
+func toDetailColumns(header []string) detailColumns {
+	find := func(name string) int {
+		return slices.IndexFunc(header, func(h string) bool {
+			return strings.EqualFold(strings.TrimSpace(h), name)
+		})
+	}
+	return detailColumns{
+		rating:        find(ratingColumn),
+		notes:         find(notesColumn),
+		seat:          find(seatColumn),
+		companions:    find(companionsColumn),
+		artistRatings: find(artistRatingsColumn),
+		price:         find(priceColumn),
+		quantity:      find(quantityColumn),
+		fees:          find(feesColumn),
+		purchaseDate:  find(purchaseDateColumn),
+		festival:      find(festivalColumn),
+		endDate:       find(endDateColumn),
+		sets:          find(setsColumn),
+		startTime:     find(startTimeColumn),
+	}
+}
+
 // requires a UTF-8 encoded CSV file
 func (l *EventLoader) Upload(ctx context.Context, file io.ReadCloser) (int, error) {
 	log.Debug("Starting processing event file upload")
-	scanner := bufio.NewScanner(file)
-	first := true
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err == io.EOF {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("unable to read header: %v", err)
+	}
+	columns := toDetailColumns(header)
+
 	events := []domain.Event{}
-	for scanner.Scan() {
-		if first {
-			first = false
-			continue
+	details := []artistDetails{}
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
 		}
-		line := scanner.Text()
-		log.Debugf("Parsed event line: %s", line)
-		event, err := toEvent(line)
+		if err != nil {
+			log.Errorf("Error while reading event row: %v", err)
+			return 0, fmt.Errorf("unable to read row: %v", err)
+		}
+		log.Debugf("Parsed event row: %v", row)
+		event, eventDetails, err := toEvent(row, columns)
 		if err != nil {
 			log.Errorf("Error while parsing event: %v", err)
-			return 0, fmt.Errorf("unable to convert line to event: %s, %v", line, err)
+			return 0, fmt.Errorf("unable to convert line to event: %s, %v", strings.Join(row, ","), err)
 		}
 		log.Debugf("Converted input to event %v", event)
 		events = append(events, event)
+		details = append(details, eventDetails)
 	}
 
 	hasErr := false
 	successCount := 0
 	for i, event := range events {
 		log.Debugf("Starting upload for event %v", event)
-		if _, err := l.Cache.AddSavedEvent(event); err != nil {
+		savedEvent, err := l.Cache.AddSavedEvent(event)
+		if err == nil && !details[i].empty() {
+			err = l.addArtistDetails(*savedEvent, details[i])
+		}
+		if err != nil {
 			log.Errorf("Failed to add event at row %d, %+v, %v", i+2, event, err)
 			hasErr = true
 		} else {
@@ -65,10 +165,42 @@ func (l *EventLoader) Upload(ctx context.Context, file io.ReadCloser) (int, erro
 	return successCount, nil
 }
 
-func toEvent(row string) (domain.Event, error) {
-	parts := strings.Split(row, ",")
+// performances and sets reference artists by ID, which new artists only have once the event is saved
+func (l *EventLoader) addArtistDetails(event domain.Event, details artistDetails) error {
+	artistIDs := map[string]string{}
+	for _, artist := range event.Artists() {
+		if !artist.Populated() {
+			continue
+		}
+		name := strings.ToLower(artist.Name)
+		artistIDs[name] = artist.ID.Primary
+		rating, ok := details.ratings[name]
+		if !ok {
+			continue
+		}
+		if _, exists := event.Performance(artist.ID.Primary); exists {
+			continue
+		}
+		event.Performances = append(event.Performances, domain.Performance{ArtistID: artist.ID.Primary, Rating: rating})
+	}
+	for _, festivalSet := range details.sets {
+		dayIdx := slices.IndexFunc(event.Festival.Days, func(d domain.FestivalDay) bool { return d.Date == festivalSet.date })
+		if dayIdx == -1 {
+			return fmt.Errorf("set date %s is not a day of the festival", festivalSet.date)
+		}
+		set := festivalSet.set
+		set.ArtistID = artistIDs[festivalSet.artist]
+		event.Festival.Days[dayIdx].Sets = append(event.Festival.Days[dayIdx].Sets, set)
+	}
+	if err := event.ValidateDetails(); err != nil {
+		return err
+	}
+	return l.Cache.UpdateSavedEvent(event.ID.Primary, event)
+}
+
+func toEvent(parts []string, columns detailColumns) (domain.Event, artistDetails, error) {
 	if len(parts) < minColumns {
-		return domain.Event{}, errors.New("not enough columns in row")
+		return domain.Event{}, artistDetails{}, errors.New("not enough columns in row")
 	}
 
 	mainAct := domain.Artist{
@@ -78,32 +210,39 @@ func toEvent(row string) (domain.Event, error) {
 	genres := strings.Split(strings.TrimSpace(parts[1]), ";")
 	mainAct.Genres.User = append(mainAct.Genres.User, genres...)
 	if !mainAct.Populated() {
-		return domain.Event{}, errors.New("invalid main act")
+		return domain.Event{}, artistDetails{}, errors.New("invalid main act")
 	}
 
-	date := strings.TrimSpace(parts[2])
+	date, err := domain.ParseDate(parts[2])
+	if err != nil {
+		return domain.Event{}, artistDetails{}, err
+	}
 	venue := domain.Venue{
 		Name:  strings.TrimSpace(parts[3]),
 		City:  strings.TrimSpace(parts[4]),
 		State: strings.TrimSpace(parts[5]),
 	}
 	if !venue.Populated() {
-		return domain.Event{}, errors.New("invalid venue")
+		return domain.Event{}, artistDetails{}, errors.New("invalid venue")
 	}
 	purchased := strings.TrimSpace(parts[6]) == "TRUE"
 
+	// the remaining columns are pairs of opener names and genres, skipping any optional columns
+	openerColumns := []string{}
+	for i := minColumns; i < len(parts); i++ {
+		if !columns.contains(i) {
+			openerColumns = append(openerColumns, parts[i])
+		}
+	}
 	openers := []domain.Artist{}
-	i, j := 7, 8
-	for i < len(parts) && j < len(parts) {
+	i, j := 0, 1
+	for i < len(openerColumns) && j < len(openerColumns) {
 		opener := domain.Artist{
-			Name: strings.TrimSpace(parts[i]),
+			Name: strings.TrimSpace(openerColumns[i]),
 		}
 		mainAct.Genres.Ticketmaster = []string{}
-		genres := strings.Split(strings.TrimSpace(parts[j]), ";")
+		genres := strings.Split(strings.TrimSpace(openerColumns[j]), ";")
 		mainAct.Genres.User = append(mainAct.Genres.User, genres...)
-		if !opener.Populated() {
-			return domain.Event{}, errors.New("invalid opener")
-		}
 		if !opener.Populated() {
 			break
 		}
@@ -119,5 +258,148 @@ func toEvent(row string) (domain.Event, error) {
 		Purchased: purchased,
 	}
 
-	return event, nil
+	column := func(idx int) string {
+		if idx < 0 || idx >= len(parts) {
+			return ""
+		}
+		return strings.TrimSpace(parts[idx])
+	}
+	if rating := column(columns.rating); rating != "" {
+		value, err := strconv.Atoi(rating)
+		if err != nil {
+			return domain.Event{}, artistDetails{}, errors.New("invalid rating")
+		}
+		event.Rating = value
+	}
+	event.Notes = column(columns.notes)
+	event.Seat = column(columns.seat)
+	event.Companions = splitList(column(columns.companions))
+
+	if event.StartTime, err = domain.ParseOptionalTimeOfDay(column(columns.startTime)); err != nil {
+		return domain.Event{}, artistDetails{}, err
+	}
+	if event.Ticket.PriceCents, err = parseCents(column(columns.price)); err != nil {
+		return domain.Event{}, artistDetails{}, errors.New("invalid ticket price")
+	}
+	if event.Ticket.FeesCents, err = parseCents(column(columns.fees)); err != nil {
+		return domain.Event{}, artistDetails{}, errors.New("invalid ticket fees")
+	}
+	if quantity := column(columns.quantity); quantity != "" {
+		if event.Ticket.Quantity, err = strconv.Atoi(quantity); err != nil {
+			return domain.Event{}, artistDetails{}, errors.New("invalid ticket quantity")
+		}
+	} else if event.Ticket.PriceCents > 0 {
+		// a price on its own is for a single ticket
+		event.Ticket.Quantity = 1
+	}
+	if event.Ticket.PurchaseDate, err = domain.ParseOptionalDate(column(columns.purchaseDate)); err != nil {
+		return domain.Event{}, artistDetails{}, errors.New("invalid ticket purchase date")
+	}
+	endDate, err := domain.ParseOptionalDate(column(columns.endDate))
+	if err != nil {
+		return domain.Event{}, artistDetails{}, errors.New("invalid festival end date")
+	}
+	if !endDate.IsZero() {
+		event.Festival = &domain.Festival{
+			Name:    column(columns.festival),
+			EndDate: endDate,
+			Days:    festivalDays(date, endDate),
+		}
+	}
+	if err := event.ValidateDetails(); err != nil {
+		return domain.Event{}, artistDetails{}, err
+	}
+
+	// artist ratings are written as name=rating pairs, e.g. "Artist=5;Opener=3"
+	artistRatings := map[string]int{}
+	for _, pair := range splitList(column(columns.artistRatings)) {
+		name, value, found := strings.Cut(pair, "=")
+		rating, err := strconv.Atoi(strings.TrimSpace(value))
+		if !found || err != nil || rating < 0 || rating > domain.MaxRating {
+			return domain.Event{}, artistDetails{}, fmt.Errorf("invalid artist rating %s", pair)
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.ContainsFunc(event.Artists(), func(a domain.Artist) bool { return strings.ToLower(a.Name) == name }) {
+			return domain.Event{}, artistDetails{}, fmt.Errorf("rated artist %s is not in the lineup", pair)
+		}
+		artistRatings[name] = rating
+	}
+
+	sets := []festivalSet{}
+	for _, value := range splitList(column(columns.sets)) {
+		set, err := toFestivalSet(value, event)
+		if err != nil {
+			return domain.Event{}, artistDetails{}, err
+		}
+		sets = append(sets, set)
+	}
+
+	return event, artistDetails{ratings: artistRatings, sets: sets}, nil
+}
+
+// festivalDays lists every day from the start to the end date
+func festivalDays(start domain.Date, end domain.Date) []domain.FestivalDay {
+	days := []domain.FestivalDay{}
+	for day := start; !day.After(end); day = day.AddDays(1) {
+		days = append(days, domain.FestivalDay{Date: day, Sets: []domain.Set{}})
+	}
+	return days
+}
+
+// sets are written as "Artist|Date|Stage|Start-End|Seen" with everything after the date optional,
+// e.g. "Artist|7/12/2024|Main Stage|20:00-21:15|TRUE"
+func toFestivalSet(value string, event domain.Event) (festivalSet, error) {
+	if event.Festival == nil {
+		return festivalSet{}, errors.New("sets require a festival end date")
+	}
+	fields := strings.Split(value, "|")
+	for len(fields) < 5 {
+		fields = append(fields, "")
+	}
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+
+	name := strings.ToLower(fields[0])
+	if !slices.ContainsFunc(event.Artists(), func(a domain.Artist) bool { return strings.ToLower(a.Name) == name }) {
+		return festivalSet{}, fmt.Errorf("set artist %s is not in the lineup", value)
+	}
+	date, err := domain.ParseDate(fields[1])
+	if err != nil || !slices.ContainsFunc(event.Festival.Days, func(d domain.FestivalDay) bool { return d.Date == date }) {
+		return festivalSet{}, fmt.Errorf("set date is not a day of the festival %s", value)
+	}
+	set := domain.Set{Stage: fields[2], Seen: fields[4] == "TRUE"}
+	if fields[3] != "" {
+		start, end, found := strings.Cut(fields[3], "-")
+		startTime, startErr := domain.ParseTimeOfDay(start)
+		endTime, endErr := domain.ParseTimeOfDay(end)
+		if !found || startErr != nil || endErr != nil {
+			return festivalSet{}, fmt.Errorf("invalid set times %s", value)
+		}
+		set.Start, set.End = &startTime, &endTime
+	}
+	return festivalSet{artist: name, set: set, date: date}, nil
+}
+
+// parses an amount in dollars like "$45.50" or "45" to cents, an empty amount is 0
+func parseCents(amount string) (int, error) {
+	amount = strings.TrimPrefix(strings.TrimSpace(amount), "$")
+	if amount == "" {
+		return 0, nil
+	}
+	value, err := strconv.ParseFloat(amount, 64)
+	if err != nil {
+		return 0, err
+	}
+	return int(math.Round(value * 100)), nil
+}
+
+func splitList(value string) []string {
+	values := []string{}
+	for _, part := range strings.Split(value, ";") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
 }
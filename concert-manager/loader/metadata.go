@@ -2,6 +2,7 @@ package loader
 
 import (
 	"concert-manager/domain"
+	"concert-manager/jobs"
 	"concert-manager/log"
 	"context"
 	"errors"
@@ -22,6 +23,8 @@ type GenreLoader struct {
 	MetadataProvider metadataProvider
 }
 
+// ReloadGenres looks up the genres of the artists by name again, or of every artist when none are
+// given. A cancelled reload keeps the genres the artists had.
 func (l *GenreLoader) ReloadGenres(ctx context.Context, artists []string) (int, error) {
 	log.Info("Reloading genres for artists:", artists)
 	var targetArtists []domain.Artist
@@ -40,10 +43,14 @@ func (l *GenreLoader) ReloadGenres(ctx context.Context, artists []string) (int,
 
 	updatedCount := 0
 
+	jobs.ReportProgress(ctx, 0, len(targetArtists))
 	updatedArtists, err := l.MetadataProvider.ReloadMetadata(targetArtists)
 	if err != nil {
 		return 0, err
 	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
 
 	for _, artist := range updatedArtists {
 		if err := l.Cache.UpdateArtist(artist.ID.Primary, artist); err != nil {
@@ -51,6 +58,7 @@ func (l *GenreLoader) ReloadGenres(ctx context.Context, artists []string) (int,
 		}
 
 		updatedCount++
+		jobs.ReportProgress(ctx, updatedCount, len(targetArtists))
 	}
 
 	if updatedCount != len(targetArtists) {
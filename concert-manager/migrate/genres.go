@@ -0,0 +1,39 @@
+package migrate
+
+import (
+	"concert-manager/db"
+	"concert-manager/log"
+	"context"
+	"fmt"
+	"slices"
+)
+
+// moves the legacy single Genre value of each artist into its user genres
+func moveLegacyGenres(ctx context.Context, repo *db.EventRepository, dryRun bool) ([]string, error) {
+	artists, err := repo.ListArtists(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []string{}
+	for _, artist := range artists {
+		if artist.Genre == "" {
+			continue
+		}
+		changes = append(changes, fmt.Sprintf("artist %s (%s): move genre %q into user genres",
+			artist.ID.Primary, artist.Name, artist.Genre))
+		if dryRun {
+			continue
+		}
+
+		if !slices.Contains(artist.Genres.User, artist.Genre) {
+			artist.Genres.User = append(artist.Genres.User, artist.Genre)
+		}
+		artist.Genre = ""
+		if _, err := repo.UpdateArtist(ctx, artist); err != nil {
+			log.Errorf("Failed to migrate genre for artist %+v, %v", artist, err)
+			return changes, err
+		}
+	}
+	return changes, nil
+}
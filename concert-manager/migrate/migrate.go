@@ -0,0 +1,79 @@
+package migrate
+
+import (
+	"concert-manager/db"
+	"concert-manager/log"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Migration is a single versioned change to the stored data. Apply must be idempotent,
+// and when dryRun is set it must only describe the changes it would make.
+type Migration struct {
+	Version int
+	Name    string
+	Apply   func(ctx context.Context, repo *db.EventRepository, dryRun bool) ([]string, error)
+}
+
+// Result describes a migration that was applied, or would be applied in a dry run
+type Result struct {
+	Version int
+	Name    string
+	Changes []string
+}
+
+// Migrations are applied in version order, new migrations must use the next version
+var Migrations = []Migration{
+	{Version: 1, Name: "move legacy artist genre into user genres", Apply: moveLegacyGenres},
+}
+
+type Migrator struct {
+	Repo       *db.EventRepository
+	Migrations []Migration
+}
+
+func NewMigrator(repo *db.EventRepository) *Migrator {
+	return &Migrator{Repo: repo, Migrations: Migrations}
+}
+
+// Run applies every migration that has not been recorded in the database yet.
+// In a dry run nothing is changed or recorded.
+func (m *Migrator) Run(ctx context.Context, dryRun bool) ([]Result, error) {
+	if m.Repo.MigrationRepo == nil {
+		return nil, errors.New("database does not support migrations")
+	}
+	applied, err := m.Repo.MigrationRepo.AppliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := slices.Clone(m.Migrations)
+	slices.SortFunc(migrations, func(a, b Migration) int { return a.Version - b.Version })
+
+	results := []Result{}
+	for _, migration := range migrations {
+		if slices.Contains(applied, migration.Version) {
+			log.Debugf("Skipping migration %d, already applied", migration.Version)
+			continue
+		}
+
+		log.Infof("Running migration %d %q, dry run: %v", migration.Version, migration.Name, dryRun)
+		changes, err := migration.Apply(ctx, m.Repo, dryRun)
+		if err != nil {
+			errMsg := fmt.Sprintf("migration %d failed: %v", migration.Version, err)
+			return results, errors.New(errMsg)
+		}
+		results = append(results, Result{Version: migration.Version, Name: migration.Name, Changes: changes})
+		if dryRun {
+			continue
+		}
+
+		if err := m.Repo.MigrationRepo.RecordVersion(ctx, migration.Version, migration.Name); err != nil {
+			return results, err
+		}
+		log.Infof("Finished migration %d with %d changes", migration.Version, len(changes))
+	}
+	return results, nil
+}
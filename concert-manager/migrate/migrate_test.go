@@ -0,0 +1,52 @@
+package migrate
+
+import (
+	"concert-manager/db"
+	"concert-manager/domain"
+	"context"
+	"testing"
+)
+
+func TestMoveLegacyGenres(t *testing.T) {
+	ctx := context.Background()
+	repo := db.NewMemoryRepository()
+	repo.AddArtist(ctx, domain.Artist{Name: "Legacy", Genre: "rock", Genres: domain.GenreInfo{User: []string{"indie"}}})
+	repo.AddArtist(ctx, domain.Artist{Name: "Current", Genres: domain.GenreInfo{User: []string{"pop"}}})
+	migrator := NewMigrator(repo)
+
+	results, err := migrator.Run(ctx, true)
+	if err != nil {
+		t.Fatalf("failed dry run: %v", err)
+	}
+	if len(results) != 1 || len(results[0].Changes) != 1 {
+		t.Fatalf("expected one change in dry run, got %+v", results)
+	}
+	artists, _ := repo.ListArtists(ctx)
+	if artists[0].Genre == "" && artists[1].Genre == "" {
+		t.Error("expected dry run to leave the legacy genre in place")
+	}
+	if versions, _ := repo.MigrationRepo.AppliedVersions(ctx); len(versions) != 0 {
+		t.Errorf("expected dry run not to record versions, got %v", versions)
+	}
+
+	if _, err := migrator.Run(ctx, false); err != nil {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+	artists, _ = repo.ListArtists(ctx)
+	for _, artist := range artists {
+		if artist.Genre != "" {
+			t.Errorf("expected legacy genre to be cleared, got %+v", artist)
+		}
+		if artist.Name == "Legacy" && (len(artist.Genres.User) != 2 || artist.Genres.User[1] != "rock") {
+			t.Errorf("expected legacy genre in user genres, got %+v", artist.Genres.User)
+		}
+	}
+	if versions, _ := repo.MigrationRepo.AppliedVersions(ctx); len(versions) != 1 || versions[0] != 1 {
+		t.Errorf("expected version 1 to be recorded, got %v", versions)
+	}
+
+	results, err = migrator.Run(ctx, false)
+	if err != nil || len(results) != 0 {
+		t.Errorf("expected no pending migrations, got %+v, %v", results, err)
+	}
+}
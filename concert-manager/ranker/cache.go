@@ -5,6 +5,8 @@ import (
 	"concert-manager/external"
 	"concert-manager/file"
 	"concert-manager/log"
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -21,14 +23,20 @@ type artistProvider interface {
 	SimilarArtists(string) ([]external.RankedArtist, error)
 }
 
+// ArtistRankCache is safe for concurrent use. The mutex guards the ranks and last refresh time,
+// while refreshMutex guards the refreshing flag so only one refresh runs at a time.
 type ArtistRankCache struct {
 	MusicSvc       spotifyService
 	ArtistProvider artistProvider
-	ranks          map[string]domain.ArtistRank
-	lastRefresh    time.Time
-	refreshing     bool
-	refreshMutex   sync.Mutex
-	calculator     *RankCalculator
+	UserID         string
+	// set when the ranks are refreshed on a schedule, so ranking with expired ranks doesn't start a refresh
+	ScheduledRefresh bool
+	mutex            sync.RWMutex
+	ranks            map[string]domain.ArtistRank
+	lastRefresh      time.Time
+	refreshing       bool
+	refreshMutex     sync.Mutex
+	calculator       *RankCalculator
 }
 
 type RankCacheFile struct {
@@ -45,22 +53,43 @@ const (
 var rankTTL, _ = time.ParseDuration("168h")
 
 func (c *ArtistRankCache) Rank(artist domain.Artist) domain.ArtistRank {
-	if c.lastRefresh.IsZero() {
+	c.mutex.RLock()
+	lastRefresh := c.lastRefresh
+	c.mutex.RUnlock()
+	if lastRefresh.IsZero() {
 		c.DoRefresh()
-	} else if time.Since(c.lastRefresh) > rankTTL {
+	} else if !c.ScheduledRefresh && time.Since(lastRefresh) > rankTTL {
 		go c.DoRefresh()
 	}
-	return c.ranks[toKey(artist.Name)]
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return domain.CloneArtistRank(c.ranks[toKey(artist.Name)])
 }
 
 func (c *ArtistRankCache) DoRefresh() {
+	if err := c.Refresh(context.Background()); err != nil && !errors.Is(err, errRefreshing) {
+		log.Alert("Failed to refresh artist ranks", err)
+	}
+}
+
+var errRefreshing = domain.Conflict("artist ranks are already being refreshed")
+
+// Refresh calculates the ranks again, failing when a refresh is already running. The calculation
+// can't be interrupted, so a cancelled refresh only keeps the previous ranks.
+func (c *ArtistRankCache) Refresh(ctx context.Context) error {
 	c.refreshMutex.Lock()
 	if c.refreshing {
 		c.refreshMutex.Unlock()
-		return
+		return errRefreshing
 	}
 	c.refreshing = true
 	c.refreshMutex.Unlock()
+	defer func() {
+		c.refreshMutex.Lock()
+		c.refreshing = false
+		c.refreshMutex.Unlock()
+	}()
 
 	log.Info("Refreshing artist ranks")
 
@@ -72,22 +101,25 @@ func (c *ArtistRankCache) DoRefresh() {
 	}
 
 	newRanks, err := c.calculator.CalculateRanks()
-	if err != nil {
-		log.Alert("Failed to refresh artist ranks", err)
-	} else {
+	if err == nil {
+		err = ctx.Err()
+	}
+	c.mutex.Lock()
+	if err == nil {
 		c.ranks = newRanks
 		log.Info("Successfully refreshed artist ranks")
-		c.saveRanksToFile()
 	}
-
 	c.lastRefresh = time.Now().Round(0)
-	c.refreshMutex.Lock()
-	c.refreshing = false
-	c.refreshMutex.Unlock()
+	c.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+	c.saveRanksToFile()
+	return nil
 }
 
 func (c *ArtistRankCache) InitializeFromFile() error {
-	filePath, err := file.GetCacheFilePath(rankCacheFile)
+	filePath, err := file.GetCacheFilePath(file.UserFileName(rankCacheFile, c.UserID))
 	if err != nil {
 		return fmt.Errorf("failed to get cache file path: %w", err)
 	}
@@ -113,6 +145,8 @@ func (c *ArtistRankCache) InitializeFromFile() error {
 }
 
 func (c *ArtistRankCache) initializeEmpty() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 	c.ranks = make(map[string]domain.ArtistRank)
 	c.lastRefresh = time.Time{}
 }
@@ -129,6 +163,8 @@ func (c *ArtistRankCache) loadRanksFromFile(filePath string) error {
 		return nil
 	}
 
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 	c.ranks = cacheFile.Ranks
 	if c.ranks == nil {
 		c.ranks = make(map[string]domain.ArtistRank)
@@ -140,12 +176,14 @@ func (c *ArtistRankCache) loadRanksFromFile(filePath string) error {
 }
 
 func (c *ArtistRankCache) saveRanksToFile() {
-	filePath, err := file.GetCacheFilePath(rankCacheFile)
+	filePath, err := file.GetCacheFilePath(file.UserFileName(rankCacheFile, c.UserID))
 	if err != nil {
 		log.Errorf("Failed to get cache file path for saving: %v", err)
 		return
 	}
 
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
 	cacheFile := RankCacheFile{
 		Timestamp: time.Now().Round(0),
 		Version:   rankCacheVersion,
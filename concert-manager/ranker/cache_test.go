@@ -0,0 +1,51 @@
+package ranker
+
+import (
+	"concert-manager/domain"
+	"concert-manager/external"
+	"sync"
+	"testing"
+)
+
+type stubSpotify struct{}
+
+func (stubSpotify) SavedTracks() ([]external.Track, error) {
+	return []external.Track{{Title: "Song", Artists: []external.Artist{{Id: "1", Name: "Artist"}}}}, nil
+}
+
+func (stubSpotify) TopTracks(external.TimeRange) ([]external.Track, error) {
+	return []external.Track{}, nil
+}
+
+func (stubSpotify) TopArtists(external.TimeRange) ([]external.Artist, error) {
+	return []external.Artist{{Id: "1", Name: "Artist"}}, nil
+}
+
+type stubArtistProvider struct{}
+
+func (stubArtistProvider) SimilarArtists(string) ([]external.RankedArtist, error) {
+	return []external.RankedArtist{}, nil
+}
+
+func TestConcurrentRankAndRefresh(t *testing.T) {
+	cache := &ArtistRankCache{MusicSvc: stubSpotify{}, ArtistProvider: stubArtistProvider{}}
+	cache.initializeEmpty()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			cache.Rank(domain.Artist{Name: "Artist"})
+		}()
+		go func() {
+			defer wg.Done()
+			cache.DoRefresh()
+		}()
+	}
+	wg.Wait()
+
+	if rank := cache.Rank(domain.Artist{Name: "artist"}); rank.Rank <= 0 {
+		t.Errorf("expected artist to be ranked, got %+v", rank)
+	}
+}
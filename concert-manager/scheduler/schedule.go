@@ -0,0 +1,190 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is when a task runs. It is either a cron expression of minute, hour, day of month,
+// month and day of week, or a fixed interval for "@every <duration>".
+type Schedule struct {
+	spec string
+	// bit n is set when the field matches n
+	minutes, hours, days, months, weekdays uint64
+	// a day matches either restricted day field, as in cron, unless one of them is *
+	anyDay, anyWeekday bool
+	every              time.Duration
+}
+
+// Off is the spec of a task that never runs
+const Off = "off"
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = []field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	// 7 is Sunday as well as 0
+	{"day of week", 0, 7},
+}
+
+// Parse reads a cron expression like "30 4 * * 1-5" or "*/15 * * * *", a descriptor like "@daily",
+// or "@every 6h". Each cron field is *, a number, a range like 1-5, or a comma separated list of
+// them, and any of those can be stepped like */15 or 1-30/2.
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if every, ok := strings.CutPrefix(spec, "@every "); ok {
+		interval, err := time.ParseDuration(strings.TrimSpace(every))
+		if err != nil {
+			return Schedule{}, fmt.Errorf("invalid schedule %q: %w", spec, err)
+		}
+		if interval < time.Minute {
+			errMsg := fmt.Sprintf("invalid schedule %q: interval must be at least a minute", spec)
+			return Schedule{}, errors.New(errMsg)
+		}
+		return Schedule{spec: spec, every: interval}, nil
+	}
+
+	expr := spec
+	if strings.HasPrefix(spec, "@") {
+		var ok bool
+		if expr, ok = descriptors[spec]; !ok {
+			errMsg := fmt.Sprintf("invalid schedule %q: unknown descriptor", spec)
+			return Schedule{}, errors.New(errMsg)
+		}
+	}
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		errMsg := fmt.Sprintf("invalid schedule %q: expected %d fields, got %d", spec, len(fields), len(parts))
+		return Schedule{}, errors.New(errMsg)
+	}
+
+	bits := make([]uint64, len(fields))
+	for i, part := range parts {
+		var err error
+		if bits[i], err = parseField(part, fields[i]); err != nil {
+			return Schedule{}, fmt.Errorf("invalid schedule %q: %w", spec, err)
+		}
+	}
+	weekdays := bits[4]
+	if weekdays&(1<<7) != 0 {
+		weekdays |= 1
+	}
+	return Schedule{
+		spec:       spec,
+		minutes:    bits[0],
+		hours:      bits[1],
+		days:       bits[2],
+		months:     bits[3],
+		weekdays:   weekdays,
+		anyDay:     strings.HasPrefix(parts[2], "*"),
+		anyWeekday: strings.HasPrefix(parts[4], "*"),
+	}, nil
+}
+
+func parseField(part string, f field) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(part, ",") {
+		rangePart, stepPart, stepped := strings.Cut(item, "/")
+		step := 1
+		if stepped {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step < 1 {
+				errMsg := fmt.Sprintf("invalid step %q in %s", stepPart, f.name)
+				return 0, errors.New(errMsg)
+			}
+		}
+
+		low, high := f.min, f.max
+		if rangePart != "*" {
+			lowPart, highPart, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = parseValue(lowPart, f); err != nil {
+				return 0, err
+			}
+			high = low
+			if isRange {
+				if high, err = parseValue(highPart, f); err != nil {
+					return 0, err
+				}
+			} else if stepped {
+				// 5/15 steps from 5 to the end of the field
+				high = f.max
+			}
+			if low > high {
+				errMsg := fmt.Sprintf("invalid range %q in %s", rangePart, f.name)
+				return 0, errors.New(errMsg)
+			}
+		}
+		for value := low; value <= high; value += step {
+			bits |= 1 << value
+		}
+	}
+	return bits, nil
+}
+
+func parseValue(value string, f field) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < f.min || n > f.max {
+		errMsg := fmt.Sprintf("%s must be between %d and %d, got %q", f.name, f.min, f.max, value)
+		return 0, errors.New(errMsg)
+	}
+	return n, nil
+}
+
+func (s Schedule) String() string {
+	return s.spec
+}
+
+// Next is the first time the schedule matches after the given time, in the time's location
+func (s Schedule) Next(after time.Time) time.Time {
+	if s.every > 0 {
+		return after.Add(s.every).Truncate(time.Second)
+	}
+
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	// every valid expression matches within a few years, even Feb 29th
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.months&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hours&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minutes&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (s Schedule) dayMatches(t time.Time) bool {
+	day := s.days&(1<<uint(t.Day())) != 0
+	weekday := s.weekdays&(1<<uint(t.Weekday())) != 0
+	if s.anyDay || s.anyWeekday {
+		return day && weekday
+	}
+	return day || weekday
+}
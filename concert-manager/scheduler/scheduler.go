@@ -0,0 +1,181 @@
+// Package scheduler starts refresh jobs on cron-like schedules, so the data is refreshed ahead of
+// the requests that read it rather than when the first request after it expires comes in.
+package scheduler
+
+import (
+	"concert-manager/domain"
+	"concert-manager/jobs"
+	"concert-manager/log"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"sync"
+	"time"
+)
+
+type jobStarter interface {
+	Start(string, jobs.Run) jobs.Job
+	List() []jobs.Job
+}
+
+// Task starts a job of its kind on its schedule, the kind also names the task
+type Task struct {
+	Kind string
+	// a schedule for Parse, or Off
+	Spec string
+	Run  jobs.Run
+}
+
+type TaskStatus struct {
+	Kind     string `json:"kind"`
+	Schedule string `json:"schedule"`
+	// when the task runs next with its jitter, unset when it is off or the scheduler isn't running
+	NextRun *time.Time `json:"nextRun,omitempty"`
+	// the latest job of the kind, whether the schedule or a request started it
+	LastRun *jobs.Job `json:"lastRun,omitempty"`
+	// the last time the task was due while its previous job was still running, so it was skipped
+	LastSkipped *time.Time `json:"lastSkipped,omitempty"`
+}
+
+// Scheduler runs its tasks from Start until the context it was started with is done. Only one job
+// of a kind runs at a time, so a task that comes due while its last job is still running is
+// skipped until the next time it is due.
+type Scheduler struct {
+	Jobs jobStarter
+	// each run is delayed by a random amount up to Jitter, so servers and users with the same
+	// schedule don't all call the upstream APIs at once
+	Jitter  time.Duration
+	mutex   sync.Mutex
+	tasks   []*task
+	started bool
+}
+
+type task struct {
+	Task
+	schedule    Schedule
+	off         bool
+	next        time.Time
+	lastSkipped *time.Time
+}
+
+func (s *Scheduler) Add(t Task) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.started {
+		return errors.New("tasks must be added before the scheduler starts")
+	}
+	if slices.ContainsFunc(s.tasks, func(existing *task) bool { return existing.Kind == t.Kind }) {
+		errMsg := fmt.Sprintf("task %s is already scheduled", t.Kind)
+		return errors.New(errMsg)
+	}
+
+	added := &task{Task: t, off: t.Spec == Off}
+	if !added.off {
+		schedule, err := Parse(t.Spec)
+		if err != nil {
+			return fmt.Errorf("failed to schedule %s: %w", t.Kind, err)
+		}
+		added.schedule = schedule
+		added.Spec = schedule.String()
+	}
+	s.tasks = append(s.tasks, added)
+	return nil
+}
+
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.started {
+		return
+	}
+	s.started = true
+	for _, t := range s.tasks {
+		if t.off {
+			log.Infof("Scheduled %s is off", t.Kind)
+			continue
+		}
+		t.next = s.nextRun(t, time.Now())
+		log.Infof("Scheduled %s for %q, next at %v", t.Kind, t.Spec, t.next)
+		go s.loop(ctx, t)
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, t *task) {
+	for {
+		s.mutex.Lock()
+		next := t.next
+		s.mutex.Unlock()
+		if next.IsZero() {
+			log.Infof("Schedule %q of %s never comes due again", t.Spec, t.Kind)
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		s.run(t, next)
+	}
+}
+
+func (s *Scheduler) run(t *task, due time.Time) {
+	job := s.Jobs.Start(t.Kind, t.Run)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if job.StartedAt.Before(due) {
+		log.Infof("Skipping scheduled %s, job %s is still running", t.Kind, job.ID)
+		t.lastSkipped = &due
+	} else {
+		log.Infof("Started scheduled %s as job %s", t.Kind, job.ID)
+	}
+	t.next = s.nextRun(t, time.Now())
+}
+
+// requires the lock to be held
+func (s *Scheduler) nextRun(t *task, after time.Time) time.Time {
+	next := t.schedule.Next(after)
+	if next.IsZero() || s.Jitter <= 0 {
+		return next
+	}
+	return next.Add(time.Duration(rand.Int63n(int64(s.Jitter))))
+}
+
+// Statuses are the tasks in the order they were added
+func (s *Scheduler) Statuses() []TaskStatus {
+	var history []jobs.Job
+	if s.Jobs != nil {
+		history = s.Jobs.List()
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	statuses := []TaskStatus{}
+	for _, t := range s.tasks {
+		status := TaskStatus{Kind: t.Kind, Schedule: t.Spec, LastSkipped: t.lastSkipped}
+		if !t.off && !t.next.IsZero() {
+			next := t.next
+			status.NextRun = &next
+		}
+		// the history is newest first
+		if idx := slices.IndexFunc(history, func(j jobs.Job) bool { return j.Kind == t.Kind }); idx != -1 {
+			status.LastRun = &history[idx]
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+func (s *Scheduler) Status(kind string) (TaskStatus, error) {
+	for _, status := range s.Statuses() {
+		if status.Kind == kind {
+			return status, nil
+		}
+	}
+	return TaskStatus{}, domain.NotFound("no scheduled task %s", kind)
+}
@@ -0,0 +1,106 @@
+package scheduler
+
+import (
+	"concert-manager/jobs"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	// a Wednesday
+	from := time.Date(2024, time.January, 10, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2024, time.January, 10, 10, 45, 0, 0, time.UTC)},
+		{"0 5 * * *", time.Date(2024, time.January, 11, 5, 0, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2024, time.January, 11, 10, 30, 0, 0, time.UTC)},
+		{"0 3 * * 1", time.Date(2024, time.January, 15, 3, 0, 0, 0, time.UTC)},
+		{"0 3 * * 7", time.Date(2024, time.January, 14, 3, 0, 0, 0, time.UTC)},
+		{"0 9-17/4 * * 1-5", time.Date(2024, time.January, 10, 13, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * *", time.Date(2024, time.January, 15, 0, 0, 0, 0, time.UTC)},
+		// either day field matches when both are restricted
+		{"0 0 31 * 5", time.Date(2024, time.January, 12, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, time.January, 10, 11, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2024, time.January, 14, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 90m", time.Date(2024, time.January, 10, 12, 0, 0, 0, time.UTC)},
+		// never matches
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, test := range tests {
+		schedule, err := Parse(test.spec)
+		if err != nil {
+			t.Errorf("failed to parse %q: %v", test.spec, err)
+			continue
+		}
+		if got := schedule.Next(from); !got.Equal(test.want) {
+			t.Errorf("expected %q to run next at %v, got %v", test.spec, test.want, got)
+		}
+	}
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *",
+		"5-1 * * * *", "x * * * *", "@often", "@every 10s", "@every soon"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("expected %q to be invalid", spec)
+		}
+	}
+}
+
+func TestSchedulerSkipsWhileRunning(t *testing.T) {
+	manager := &jobs.Manager{}
+	release := make(chan struct{})
+	s := &Scheduler{Jobs: manager}
+	run := func(ctx context.Context) (any, error) {
+		<-release
+		return nil, nil
+	}
+	if err := s.Add(Task{Kind: "refresh", Spec: "@every 1m", Run: run}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add(Task{Kind: "reload", Spec: Off}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add(Task{Kind: "refresh", Spec: "@daily"}); err == nil {
+		t.Error("expected an error scheduling a kind twice")
+	}
+	// much faster than Parse allows, to see it come due more than once
+	s.tasks[0].schedule = Schedule{spec: "@every 1m", every: 20 * time.Millisecond}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.Start(ctx)
+	if err := s.Add(Task{Kind: "late", Spec: "@daily"}); err == nil {
+		t.Error("expected an error adding a task once started")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status, _ := s.Status("refresh")
+		if status.LastSkipped != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected a run to be skipped while the job is running, got %+v", status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(release)
+
+	if history := manager.List(); len(history) != 1 {
+		t.Errorf("expected a single job while it was running, got %+v", history)
+	}
+	statuses := s.Statuses()
+	if len(statuses) != 2 || statuses[0].NextRun == nil || statuses[0].LastRun == nil || statuses[0].LastRun.Kind != "refresh" {
+		t.Errorf("unexpected refresh status %+v", statuses[0])
+	}
+	if statuses[1].Schedule != Off || statuses[1].NextRun != nil || statuses[1].LastRun != nil {
+		t.Errorf("unexpected status of a task that is off %+v", statuses[1])
+	}
+	if _, err := s.Status("missing"); err == nil {
+		t.Error("expected an error for a task that isn't scheduled")
+	}
+}
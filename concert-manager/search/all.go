@@ -0,0 +1,119 @@
+package search
+
+import (
+	"concert-manager/domain"
+	"sort"
+	"sync"
+)
+
+type ResultType string
+
+const (
+	SavedEventResult    ResultType = "savedEvent"
+	ArtistResult        ResultType = "artist"
+	VenueResult         ResultType = "venue"
+	AlbumResult         ResultType = "album"
+	UpcomingEventResult ResultType = "upcomingEvent"
+)
+
+var ResultTypes = []ResultType{SavedEventResult, ArtistResult, VenueResult, AlbumResult, UpcomingEventResult}
+
+// Result is one match of a search across every kind of data, only the field of its type is set.
+// Score is 1 when a whole name matches and between 0 and 1 otherwise.
+type Result struct {
+	Type          ResultType           `json:"type"`
+	Score         float64              `json:"score"`
+	SavedEvent    *domain.Event        `json:"savedEvent,omitempty"`
+	Artist        *domain.Artist       `json:"artist,omitempty"`
+	Venue         *domain.Venue        `json:"venue,omitempty"`
+	Album         *domain.Album        `json:"album,omitempty"`
+	UpcomingEvent *domain.EventDetails `json:"upcomingEvent,omitempty"`
+}
+
+// Sources is the data to search, nil slices are skipped
+type Sources struct {
+	SavedEvents    []domain.Event
+	Artists        []domain.Artist
+	Venues         []domain.Venue
+	Albums         []domain.Album
+	UpcomingEvents []domain.EventDetails
+}
+
+// Engine keeps an index of each source between searches and only rebuilds one when the
+// searchable fields of its source change. The zero value is ready to use.
+type Engine struct {
+	mutex          sync.Mutex
+	savedEvents    indexCache[domain.Event]
+	artists        indexCache[domain.Artist]
+	venues         indexCache[domain.Venue]
+	albums         indexCache[domain.Album]
+	upcomingEvents indexCache[domain.EventDetails]
+}
+
+type indexCache[T any] struct {
+	index *Index[T]
+}
+
+// requires the engine lock to be held
+func (c *indexCache[T]) get(options []T, fields func(T) []string) *Index[T] {
+	current := fingerprint(options, fields)
+	if c.index == nil || c.index.fingerprint != current || c.index.Len() != len(options) {
+		c.index = newIndex(options, fields, current)
+	}
+	return c.index
+}
+
+// searchCached runs the term against the cached index, returning the matching options from the source
+// rather than the index so fields that aren't searched are never stale
+func searchCached[T any](e *Engine, cache *indexCache[T], options []T, fields func(T) []string, term string, maxResults int, tolerance float64) []Match[T] {
+	e.mutex.Lock()
+	index := cache.get(options, fields)
+	e.mutex.Unlock()
+	matches := []Match[T]{}
+	for _, scored := range index.search(term, maxResults, tolerance) {
+		matches = append(matches, Match[T]{Option: options[scored.option], Score: scored.score})
+	}
+	return matches
+}
+
+// SearchAll searches every source at once, returning the best matches of any type first.
+// Ties keep the order of ResultTypes.
+func (e *Engine) SearchAll(term string, sources Sources, maxResults int, tolerance float64) []Result {
+	results := []Result{}
+	for _, match := range searchCached(e, &e.savedEvents, sources.SavedEvents, eventFields, term, maxResults, tolerance) {
+		event := match.Option
+		results = append(results, Result{Type: SavedEventResult, Score: match.Score, SavedEvent: &event})
+	}
+	for _, match := range searchCached(e, &e.artists, sources.Artists, artistFields, term, maxResults, tolerance) {
+		artist := match.Option
+		results = append(results, Result{Type: ArtistResult, Score: match.Score, Artist: &artist})
+	}
+	for _, match := range searchCached(e, &e.venues, sources.Venues, venueFields, term, maxResults, tolerance) {
+		venue := match.Option
+		results = append(results, Result{Type: VenueResult, Score: match.Score, Venue: &venue})
+	}
+	for _, match := range searchCached(e, &e.albums, sources.Albums, albumFields, term, maxResults, tolerance) {
+		album := match.Option
+		results = append(results, Result{Type: AlbumResult, Score: match.Score, Album: &album})
+	}
+	upcomingFields := func(option domain.EventDetails) []string {
+		return eventFields(option.Event)
+	}
+	for _, match := range searchCached(e, &e.upcomingEvents, sources.UpcomingEvents, upcomingFields, term, maxResults, tolerance) {
+		details := match.Option
+		results = append(results, Result{Type: UpcomingEventResult, Score: match.Score, UpcomingEvent: &details})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if maxResults != NoMaxResults && len(results) > maxResults {
+		results = results[:maxResults]
+	}
+	return results
+}
+
+// SearchAll is Engine.SearchAll without keeping the indexes
+func SearchAll(term string, sources Sources, maxResults int, tolerance float64) []Result {
+	return (&Engine{}).SearchAll(term, sources, maxResults, tolerance)
+}
@@ -0,0 +1,222 @@
+package search
+
+import (
+	"hash/fnv"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Match is an option found by a search. Score is 1 when a whole field matches the term and
+// between 0 and 1 otherwise, see fieldScore.
+type Match[T any] struct {
+	Option T
+	Score  float64
+}
+
+// substrings shorter than this match too much of everything to be useful
+const minSubstringLen = 3
+
+// fieldRef is a field of an option, fields are searched separately so a term has to match
+// within one name rather than across e.g. an artist and a venue
+type fieldRef struct {
+	option int
+	field  int
+}
+
+// Index holds the normalized tokens of a list of options, so repeated searches only
+// normalize the term. Options are searched by the text fields returned for them.
+type Index[T any] struct {
+	options []T
+	// the normalized tokens of each field of each option
+	fields [][][]string
+	// every distinct token, the lookups scan these rather than the options
+	tokens   []string
+	postings map[string][]fieldRef
+	// whole fields by their normalized text, for exact matches
+	phrases     map[string][]fieldRef
+	fingerprint uint64
+}
+
+func NewIndex[T any](options []T, fields func(T) []string) *Index[T] {
+	return newIndex(options, fields, fingerprint(options, fields))
+}
+
+func newIndex[T any](options []T, fields func(T) []string, fingerprint uint64) *Index[T] {
+	ix := &Index[T]{
+		options:     options,
+		fields:      make([][][]string, len(options)),
+		postings:    map[string][]fieldRef{},
+		phrases:     map[string][]fieldRef{},
+		fingerprint: fingerprint,
+	}
+	for i, option := range options {
+		for _, field := range fields(option) {
+			tokens := Tokens(field)
+			if len(tokens) == 0 {
+				continue
+			}
+			ref := fieldRef{option: i, field: len(ix.fields[i])}
+			ix.fields[i] = append(ix.fields[i], tokens)
+			phrase := strings.Join(tokens, " ")
+			ix.phrases[phrase] = append(ix.phrases[phrase], ref)
+			seen := map[string]bool{}
+			for _, token := range tokens {
+				if seen[token] {
+					continue
+				}
+				seen[token] = true
+				if _, exists := ix.postings[token]; !exists {
+					ix.tokens = append(ix.tokens, token)
+				}
+				ix.postings[token] = append(ix.postings[token], ref)
+			}
+		}
+	}
+	sort.Strings(ix.tokens)
+	return ix
+}
+
+// fingerprint changes whenever the searchable fields of the options do
+func fingerprint[T any](options []T, fields func(T) []string) uint64 {
+	hash := fnv.New64a()
+	for _, option := range options {
+		for _, field := range fields(option) {
+			hash.Write([]byte(field))
+			hash.Write([]byte{0})
+		}
+		hash.Write([]byte{1})
+	}
+	return hash.Sum64()
+}
+
+func (ix *Index[T]) Len() int {
+	return len(ix.options)
+}
+
+// Search finds the options with a field matching every word of the term, best matches first
+// and ties in the order of the options. Words match a word of the field exactly, as its prefix,
+// as a substring or with typos, allowing len(word) * tolerance edits. With ExactTolerance only
+// whole fields equal to the term match, ignoring case, accents and punctuation.
+func (ix *Index[T]) Search(term string, maxResults int, tolerance float64) []Match[T] {
+	matches := []Match[T]{}
+	for _, scored := range ix.search(term, maxResults, tolerance) {
+		matches = append(matches, Match[T]{Option: ix.options[scored.option], Score: scored.score})
+	}
+	return matches
+}
+
+type scoredOption struct {
+	option int
+	score  float64
+}
+
+func (ix *Index[T]) search(term string, maxResults int, tolerance float64) []scoredOption {
+	terms := Tokens(term)
+	if len(terms) == 0 {
+		return nil
+	}
+	phrase := strings.Join(terms, " ")
+
+	best := map[int]float64{}
+	for _, ref := range ix.phrases[phrase] {
+		best[ref.option] = 1
+	}
+	if tolerance > ExactTolerance {
+		// the best score of each term within each field, a field only matches if every term does
+		termScores := map[fieldRef][]float64{}
+		for i, t := range terms {
+			for _, token := range ix.tokens {
+				score := tokenScore(t, token, tolerance)
+				if score == 0 {
+					continue
+				}
+				for _, ref := range ix.postings[token] {
+					scores, ok := termScores[ref]
+					if !ok {
+						scores = make([]float64, len(terms))
+						termScores[ref] = scores
+					}
+					scores[i] = max(scores[i], score)
+				}
+			}
+		}
+		for ref, scores := range termScores {
+			if score := fieldScore(scores, len(ix.fields[ref.option][ref.field])); score > best[ref.option] {
+				best[ref.option] = score
+			}
+		}
+	}
+
+	results := make([]scoredOption, 0, len(best))
+	for option, score := range best {
+		if score > 0 {
+			results = append(results, scoredOption{option: option, score: score})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		return results[i].option < results[j].option
+	})
+	if maxResults != NoMaxResults && len(results) > maxResults {
+		results = results[:maxResults]
+	}
+	return results
+}
+
+// fieldScore averages how well each term matched, scaled down when the field has words the term
+// doesn't cover. Anything short of the whole field matching stays below 1.
+func fieldScore(termScores []float64, fieldTokens int) float64 {
+	total := 0.0
+	for _, score := range termScores {
+		if score == 0 {
+			return 0
+		}
+		total += score
+	}
+	coverage := min(1, float64(len(termScores))/float64(fieldTokens))
+	return min(0.99, total/float64(len(termScores))*(0.8+0.2*coverage))
+}
+
+// tokenScore is how well a word of the term matches a word of a field, from 1 for the same
+// word down to 0 for no match. Prefixes score above substrings, which score above typos.
+func tokenScore(term, token string, tolerance float64) float64 {
+	if term == token {
+		return 1
+	}
+	termLen, tokenLen := utf8.RuneCountInString(term), utf8.RuneCountInString(token)
+	coverage := float64(termLen) / float64(tokenLen)
+	score := 0.0
+	if strings.HasPrefix(token, term) {
+		score = 0.75 + 0.2*coverage
+	} else if termLen >= minSubstringLen && strings.Contains(token, term) {
+		score = 0.5 + 0.2*coverage
+	}
+
+	allowed := int(float64(termLen) * tolerance)
+	if allowed == 0 || score >= 0.7 {
+		return score
+	}
+	if abs(termLen-tokenLen) <= allowed {
+		if distance := getTypoDistance(term, token); distance <= allowed {
+			score = max(score, 0.7*(1-float64(distance)/float64(termLen)))
+		}
+	}
+	// typos in a prefix, like "radoi" for "radiohead"
+	if tokenLen > termLen {
+		prefix := string([]rune(token)[:termLen])
+		if distance := getTypoDistance(term, prefix); distance <= allowed {
+			score = max(score, 0.6*(1-float64(distance)/float64(termLen)))
+		}
+	}
+	return score
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -1,38 +1,33 @@
 package search
 
-import (
-	"math"
-	"strings"
-)
-
-// Two-row optimized Levenshtein distance algorithm to find the minimum number of
-// inserts, deletions, or substitutions required to make two case-insensitive strings match
-func getLevenshteinDistance(x string, y string) int {
+// Optimal string alignment distance, which is the Levenshtein distance where swapping two
+// adjacent characters also counts as a single edit, as in "theatre" and "theater". Both strings
+// are expected to be normalized already.
+func getTypoDistance(x string, y string) int {
 	xRunes := []rune(x)
 	yRunes := []rune(y)
 	xLen, yLen := len(xRunes), len(yRunes)
 
-	currRow := make([]int, yLen+1)
-	prevRow := make([]int, yLen+1)
+	rows := make([][]int, xLen+1)
+	for i := range rows {
+		rows[i] = make([]int, yLen+1)
+		rows[i][0] = i
+	}
 	for j := 0; j <= yLen; j++ {
-		prevRow[j] = j
+		rows[0][j] = j
 	}
 
-	for i := 0; i < xLen; i++ {
-		currRow[0] = i + 1
-		for j := 0; j < yLen; j++ {
-			ind := 0
-			if !strings.EqualFold(string(xRunes[i]), string(yRunes[j])) {
-				ind = 1
+	for i := 1; i <= xLen; i++ {
+		for j := 1; j <= yLen; j++ {
+			cost := 1
+			if xRunes[i-1] == yRunes[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && xRunes[i-1] == yRunes[j-2] && xRunes[i-2] == yRunes[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
 			}
-			delDist := prevRow[j+1] + 1
-			insDist := currRow[j] + 1
-			subDist := prevRow[j] + ind
-
-			levDist := int(math.Min(math.Min(float64(delDist), float64(insDist)), float64(subDist)))
-			currRow[j+1] = levDist
 		}
-		prevRow, currRow = currRow, prevRow
 	}
-	return prevRow[yLen]
+	return rows[xLen][yLen]
 }
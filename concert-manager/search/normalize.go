@@ -0,0 +1,67 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalize folds case and diacritics, spells "&" as "and" and turns punctuation into spaces,
+// so "Beyoncé & the Hot-Sauce" becomes "beyonce and the hot sauce". Apostrophes are dropped
+// rather than split on, keeping "Guns N' Roses" and "Guns N Roses" the same.
+func Normalize(s string) string {
+	var b strings.Builder
+	space := true
+	write := func(r rune) {
+		if r == ' ' {
+			if !space {
+				b.WriteRune(' ')
+			}
+			space = true
+			return
+		}
+		b.WriteRune(r)
+		space = false
+	}
+	// decomposing separates letters from their accents, which are then dropped as marks
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case unicode.Is(unicode.Mn, r), r == '\'', r == '’':
+			continue
+		case r == '&':
+			write(' ')
+			for _, c := range "and" {
+				write(c)
+			}
+			write(' ')
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			write(foldLetter(unicode.ToLower(r)))
+		default:
+			write(' ')
+		}
+	}
+	return strings.TrimSuffix(b.String(), " ")
+}
+
+// letters without a decomposed form that people type without the accent
+var letterFolds = map[rune]rune{
+	'ø': 'o', 'ł': 'l', 'đ': 'd', 'ð': 'd', 'ı': 'i', 'ß': 's', 'æ': 'a', 'œ': 'o', 'þ': 't',
+}
+
+func foldLetter(r rune) rune {
+	if folded, ok := letterFolds[r]; ok {
+		return folded
+	}
+	return r
+}
+
+// Tokens is the words of the normalized text, leaving out a leading "the" so "The National"
+// is found by "national". A name that is only "the" keeps it.
+func Tokens(s string) []string {
+	tokens := strings.Fields(Normalize(s))
+	if len(tokens) > 1 && tokens[0] == "the" {
+		return tokens[1:]
+	}
+	return tokens
+}
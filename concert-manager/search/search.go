@@ -1,10 +1,8 @@
 package search
 
-import (
-	"concert-manager/domain"
-	"sort"
-)
+import "concert-manager/domain"
 
+// Tolerance is the share of each word of a term that can be typos, see Index.Search
 const (
 	ExactTolerance    = 0.0
 	StrictTolerance   = 0.1
@@ -14,83 +12,82 @@ const (
 
 const NoMaxResults = 0
 
-type optionDistance[T any] struct {
-	Option   T
-	Distance int
-}
-
-func SearchOptions[T any](term string, options []T, maxResults int, tolerance float64, computeDistance func(string, T) int) []T {
-	threshold := int(float64(len(term)) * tolerance)
-	var optionDistances []optionDistance[T]
-
-	for _, option := range options {
-		distance := computeDistance(term, option)
-		if distance <= threshold {
-			optionDistances = append(optionDistances, optionDistance[T]{Option: option, Distance: distance})
-		}
-	}
-
-	sort.Slice(optionDistances, func(i, j int) bool {
-		return optionDistances[i].Distance < optionDistances[j].Distance
-	})
-
+// SearchOptions finds the options with a field matching the term, best matches first. It indexes
+// the options for a single search, use an Index or Engine to search the same options repeatedly.
+func SearchOptions[T any](term string, options []T, maxResults int, tolerance float64, fields func(T) []string) []T {
 	var results []T
-	for i := 0; i < len(optionDistances) && (maxResults == NoMaxResults || i < maxResults); i++ {
-		results = append(results, optionDistances[i].Option)
+	for _, match := range NewIndex(options, fields).Search(term, maxResults, tolerance) {
+		results = append(results, match.Option)
 	}
-
 	return results
 }
 
 func SearchArtists(term string, options []domain.Artist, maxResults int, tolerance float64) []domain.Artist {
-	return SearchOptions(term, options, maxResults, tolerance, computeArtistDistance)
+	return SearchOptions(term, options, maxResults, tolerance, artistFields)
 }
 
 func SearchVenues(term string, options []domain.Venue, maxResults int, tolerance float64) []domain.Venue {
-	return SearchOptions(term, options, maxResults, tolerance, computeVenueDistance)
+	return SearchOptions(term, options, maxResults, tolerance, venueFields)
 }
 
 func SearchEventsByArtists(term string, options []domain.Event, maxResults int, tolerance float64) []domain.Event {
-	return SearchOptions(term, options, maxResults, tolerance, computeEventDistanceByArtists)
+	return SearchOptions(term, options, maxResults, tolerance, eventArtistFields)
 }
 
 func SearchEventsByVenue(term string, options []domain.Event, maxResults int, tolerance float64) []domain.Event {
-	return SearchOptions(term, options, maxResults, tolerance, func(term string, option domain.Event) int {
-		return computeVenueDistance(term, option.Venue)
+	return SearchOptions(term, options, maxResults, tolerance, func(option domain.Event) []string {
+		return venueFields(option.Venue)
 	})
 }
 
 func SearchEventDetailsByArtist(term string, options []domain.EventDetails, maxResults int, tolerance float64) []domain.EventDetails {
-	return SearchOptions(term, options, maxResults, tolerance, func(term string, option domain.EventDetails) int {
-		return computeEventDistanceByArtists(term, option.Event)
+	return SearchOptions(term, options, maxResults, tolerance, func(option domain.EventDetails) []string {
+		return eventArtistFields(option.Event)
 	})
 }
 
 func SearchEventDetailsByVenue(term string, options []domain.EventDetails, maxResults int, tolerance float64) []domain.EventDetails {
-	return SearchOptions(term, options, maxResults, tolerance, func(term string, option domain.EventDetails) int {
-		return computeVenueDistance(term, option.Event.Venue)
+	return SearchOptions(term, options, maxResults, tolerance, func(option domain.EventDetails) []string {
+		return venueFields(option.Event.Venue)
 	})
 }
 
 func SearchStrings(term string, options []string, maxResults int, tolerance float64) []string {
-	return SearchOptions(term, options, maxResults, tolerance, getLevenshteinDistance)
+	return SearchOptions(term, options, maxResults, tolerance, func(option string) []string {
+		return []string{option}
+	})
+}
+
+func venueFields(option domain.Venue) []string {
+	return []string{option.Name}
 }
 
-func computeVenueDistance(term string, option domain.Venue) int {
-	return getLevenshteinDistance(term, option.Name)
+func artistFields(option domain.Artist) []string {
+	return []string{option.Name}
 }
 
-func computeArtistDistance(term string, option domain.Artist) int {
-	return getLevenshteinDistance(term, option.Name)
+func eventArtistFields(option domain.Event) []string {
+	fields := []string{}
+	for _, artist := range option.Artists() {
+		fields = append(fields, artist.Name)
+	}
+	return fields
+}
+
+// eventFields matches an event by its artists, venue or festival name
+func eventFields(option domain.Event) []string {
+	fields := append(eventArtistFields(option), option.Venue.Name)
+	if option.Festival != nil {
+		fields = append(fields, option.Festival.Name)
+	}
+	return fields
 }
 
-func computeEventDistanceByArtists(term string, option domain.Event) int {
-	minDistance := getLevenshteinDistance(term, option.MainAct.Name)
-	for _, opener := range option.Openers {
-		d := getLevenshteinDistance(term, opener.Name)
-		if d < minDistance {
-			minDistance = d
-		}
+// albumFields matches an album by its name or artists
+func albumFields(option domain.Album) []string {
+	fields := []string{option.Name}
+	for _, artist := range option.Artists {
+		fields = append(fields, artist.Name)
 	}
-	return minDistance
+	return fields
 }
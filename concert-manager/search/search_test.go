@@ -1,24 +1,12 @@
 package search
 
 import (
-	"concert-manager/data"
+	"concert-manager/domain"
+	"slices"
 	"testing"
 )
 
-type MockCache struct {
-	artists []data.Artist
-	venues  []data.Venue
-}
-
-func (c MockCache) GetArtists() []data.Artist {
-	return c.artists
-}
-
-func (c MockCache) GetVenues() []data.Venue {
-	return c.venues
-}
-
-var artists = []data.Artist{
+var artists = []domain.Artist{
 	{Name: "cat"},
 	{Name: "hat"},
 	{Name: "mat"},
@@ -32,41 +20,95 @@ var artists = []data.Artist{
 	{Name: "dt"},
 }
 
-func TestMaxCountArtistsReturned(t *testing.T) {
-	cache := MockCache{}
-	cache.artists = artists
-	search := Search{cache}
+func names(artists []domain.Artist) []string {
+	result := []string{}
+	for _, artist := range artists {
+		result = append(result, artist.Name)
+	}
+	return result
+}
 
-	resp := search.FindFuzzyArtistMatchesByName("dat")
-	expectedLen := maxCount
+func TestMaxCountArtistsReturned(t *testing.T) {
+	resp := SearchArtists("dat", artists, 5, LenientTolerance)
+	expectedLen := 5
 	if len(resp) != expectedLen {
 		t.Errorf("Incorrect number of returned artists, expected: %v, actual: %v", expectedLen, len(resp))
 	}
 }
 
 func TestLessThanMaxCountArtistsReturned(t *testing.T) {
-	cache := MockCache{}
-	cache.artists = artists
-	search := Search{cache}
+	options := []domain.Artist{{Name: "Masquerade"}, {Name: "The Masquerade - Altar"}, {Name: "Mastodon"}, {Name: "Tabernacle"}}
+	resp := SearchArtists("masq", options, 5, ModerateTolerance)
+	expected := []string{"Masquerade", "The Masquerade - Altar", "Mastodon"}
+	if !slices.Equal(names(resp), expected) {
+		t.Errorf("Incorrect artists returned, expected: %v, actual: %v", expected, names(resp))
+	}
+}
 
-	resp := search.FindFuzzyArtistMatchesByName("dt")
-	expectedLen := 2
+func TestNoArtistsReturned(t *testing.T) {
+	resp := SearchArtists("dat", nil, 5, LenientTolerance)
+	expectedLen := 0
 	if len(resp) != expectedLen {
 		t.Errorf("Incorrect number of returned artists, expected: %v, actual: %v", expectedLen, len(resp))
 	}
-	expectedResp := []data.Artist{{Name: "dt"}, {Name: "at"}}
-	if resp[0] != expectedResp[0] || resp[1] != expectedResp[1] {
-		t.Errorf("Incorrect artists returned, expected: %v, actual: %v", expectedResp, resp)
+}
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"Beyoncé & the Hot-Sauce": "beyonce and the hot sauce",
+		"Guns N' Roses":           "guns n roses",
+		"  Sigur Rós!! ":          "sigur ros",
+		"Mø":                      "mo",
+	}
+	for input, expected := range tests {
+		if actual := Normalize(input); actual != expected {
+			t.Errorf("Incorrect normalization of %q, expected: %q, actual: %q", input, expected, actual)
+		}
+	}
+	if tokens := Tokens("The National"); !slices.Equal(tokens, []string{"national"}) {
+		t.Errorf("Expected leading the to be dropped, actual: %v", tokens)
+	}
+	if tokens := Tokens("The"); !slices.Equal(tokens, []string{"the"}) {
+		t.Errorf("Expected a name of only the to be kept, actual: %v", tokens)
 	}
 }
 
-func TestNoArtistsReturned(t *testing.T) {
-	cache := MockCache{}
-	search := Search{cache}
+func TestIndexRanking(t *testing.T) {
+	options := []string{"Radiohead", "Radio Moscow", "Simon & Garfunkel", "Talk Talk", "Florence + the Machine"}
+	index := NewIndex(options, func(option string) []string { return []string{option} })
 
-	resp := search.FindFuzzyArtistMatchesByName("dat")
-	expectedLen := 0
-	if len(resp) != expectedLen {
-		t.Errorf("Incorrect number of returned artists, expected: %v, actual: %v", expectedLen, len(resp))
+	tests := []struct {
+		term      string
+		tolerance float64
+		expected  []string
+	}{
+		{term: "radio", tolerance: ModerateTolerance, expected: []string{"Radio Moscow", "Radiohead"}},
+		{term: "radoihead", tolerance: ModerateTolerance, expected: []string{"Radiohead"}},
+		{term: "simon and garfunkel", tolerance: ExactTolerance, expected: []string{"Simon & Garfunkel"}},
+		{term: "garf", tolerance: ExactTolerance, expected: []string{}},
+		{term: "machine florence", tolerance: StrictTolerance, expected: []string{"Florence + the Machine"}},
+		{term: "moscow radiohead", tolerance: LenientTolerance, expected: []string{}},
+	}
+	for _, test := range tests {
+		actual := []string{}
+		for _, match := range index.Search(test.term, NoMaxResults, test.tolerance) {
+			actual = append(actual, match.Option)
+		}
+		if !slices.Equal(actual, test.expected) {
+			t.Errorf("Incorrect results for %q, expected: %v, actual: %v", test.term, test.expected, actual)
+		}
+	}
+
+	if matches := index.Search("Talk Talk", NoMaxResults, ModerateTolerance); len(matches) != 1 || matches[0].Score != 1 {
+		t.Errorf("Expected a single exact match, actual: %v", matches)
+	}
+}
+
+func TestTypoDistance(t *testing.T) {
+	if dist := getTypoDistance("theater", "theatre"); dist != 1 {
+		t.Errorf("Incorrect distance, expected: 1, actual: %v", dist)
+	}
+	if dist := getTypoDistance("cat", "dog"); dist != 3 {
+		t.Errorf("Incorrect distance, expected: 3, actual: %v", dist)
 	}
 }
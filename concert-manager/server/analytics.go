@@ -4,8 +4,10 @@ import (
 	"concert-manager/analytics"
 	"concert-manager/domain"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 )
 
@@ -100,6 +102,82 @@ func (s *Server) handleAnalyticsGenres(w http.ResponseWriter, r *http.Request) (
 	return analytics.EventsResponse{Count: len(filtered), Events: filtered}, 0, nil
 }
 
+// spending includes tickets already bought for upcoming events
+func (s *Server) getAnalyticsSpending(w http.ResponseWriter, r *http.Request) (any, int, error) {
+	if r.Method != http.MethodGet {
+		return nil, http.StatusMethodNotAllowed, errors.New("unsupported method")
+	}
+	return analytics.BuildSpendingSummary(s.SavedEventCache.GetSavedEvents()), 0, nil
+}
+
+func (s *Server) handleAnalyticsCompanions(w http.ResponseWriter, r *http.Request) (any, int, error) {
+	if r.Method != http.MethodGet {
+		return nil, http.StatusMethodNotAllowed, errors.New("unsupported method")
+	}
+	events := s.pastEvents()
+	key, err := analyticsPathKey(r.URL.Path, "companions")
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if key == "" {
+		return analytics.CountByCompanion(events), 0, nil
+	}
+	filtered := analytics.FilterEventsByCompanion(events, key)
+	return analytics.EventsResponse{Count: len(filtered), Events: filtered}, 0, nil
+}
+
+// handleAnalyticsAlbums summarizes the albums acquired at shows at /v1/analytics/albums,
+// or lists the albums acquired at an event at /v1/analytics/albums/{eventId}
+func (s *Server) handleAnalyticsAlbums(w http.ResponseWriter, r *http.Request) (any, int, error) {
+	if r.Method != http.MethodGet {
+		return nil, http.StatusMethodNotAllowed, errors.New("unsupported method")
+	}
+	albums := s.AlbumCache.GetAlbums()
+	key, err := analyticsPathKey(r.URL.Path, "albums")
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if key == "" {
+		return analytics.BuildAlbumSummary(s.pastEvents(), albums), 0, nil
+	}
+	filtered := analytics.FilterAlbumsByEvent(albums, key)
+	return analytics.AlbumsResponse{Count: len(filtered), Albums: filtered}, 0, nil
+}
+
+// handleAnalyticsRatings lists the average ratings by venue, artist or year at /v1/analytics/ratings/{dim},
+// or the events rated at least ?min= stars at /v1/analytics/ratings
+func (s *Server) handleAnalyticsRatings(w http.ResponseWriter, r *http.Request) (any, int, error) {
+	if r.Method != http.MethodGet {
+		return nil, http.StatusMethodNotAllowed, errors.New("unsupported method")
+	}
+	events := s.pastEvents()
+	dim, err := analyticsPathKey(r.URL.Path, "ratings")
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	switch dim {
+	case "":
+		minRating := 1
+		if minParam := r.URL.Query().Get("min"); minParam != "" {
+			minRating, err = strconv.Atoi(minParam)
+			if err != nil || minRating < 1 || minRating > domain.MaxRating {
+				errMsg := fmt.Sprintf("min must be a rating between 1 and %d", domain.MaxRating)
+				return nil, http.StatusBadRequest, errors.New(errMsg)
+			}
+		}
+		filtered := analytics.FilterEventsByMinRating(events, minRating)
+		return analytics.EventsResponse{Count: len(filtered), Events: filtered}, 0, nil
+	case "venues":
+		return analytics.RatingByVenue(events), 0, nil
+	case "artists":
+		return analytics.RatingByArtist(events), 0, nil
+	case "years":
+		return analytics.RatingByYear(events), 0, nil
+	}
+	errMsg := fmt.Sprintf("unsupported ratings dimension %s", dim)
+	return nil, http.StatusNotFound, errors.New(errMsg)
+}
+
 // analyticsPathKey returns the optional final segment of /v1/analytics/{dim}/{key}.
 // Returns ("", nil) when no key segment is present (the listing route).
 func analyticsPathKey(path, dim string) (string, error) {
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"concert-manager/backup"
+	"concert-manager/log"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+func (s *Server) getBackup(w http.ResponseWriter, r *http.Request) (any, int, error) {
+	if r.Method != http.MethodGet {
+		return nil, http.StatusMethodNotAllowed, errors.New("unsupported method")
+	}
+
+	archive, err := s.BackupService.Export(r.Context())
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to export backup: %w", err)
+	}
+	filename := fmt.Sprintf("beacon-backup-%s.json", archive.CreatedAt.Format("2006-01-02"))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	return archive, 0, nil
+}
+
+func (s *Server) restoreBackup(w http.ResponseWriter, r *http.Request) (any, int, error) {
+	if r.Method != http.MethodPost {
+		return nil, http.StatusMethodNotAllowed, errors.New("unsupported method")
+	}
+
+	mode, err := backup.ParseMode(r.URL.Query().Get("mode"))
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	var archive backup.Archive
+	if err := decodeBody(r, &archive); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	summary, restoreErr := s.BackupService.Restore(r.Context(), archive, mode)
+	// a failed restore may have partially written data, so the caches are reloaded either way
+	if err := s.refreshSavedData(); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to refresh saved data after restore: %w", err)
+	}
+	if restoreErr != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to restore backup: %w", restoreErr)
+	}
+	return summary, 0, nil
+}
+
+func (s *Server) refreshSavedData() error {
+	log.Info("Refreshing all saved data")
+	if err := s.VenueCache.RefreshVenues(); err != nil {
+		return err
+	}
+	if err := s.ArtistCache.RefreshArtists(); err != nil {
+		return err
+	}
+	if err := s.SavedEventCache.RefreshSavedEvents(); err != nil {
+		return err
+	}
+	return s.AlbumCache.RefreshAlbums()
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"concert-manager/backup"
+	"concert-manager/db"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRestoreBackup(t *testing.T) {
+	s := newSearchServer(t)
+	s.BackupService = &backup.Service{Repo: s.VenueCache.(*db.Cache).Database.(*db.EventRepository)}
+	routes := s.Routes()
+
+	request := func(method, url, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		routes.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := request(http.MethodGet, "/v1/backup", "")
+	var archive backup.Archive
+	if rec.Code != http.StatusOK || json.NewDecoder(rec.Body).Decode(&archive) != nil {
+		t.Fatalf("unexpected backup response %d %s", rec.Code, rec.Body)
+	}
+	exported, _ := json.Marshal(archive)
+	if rec := request(http.MethodPost, "/v1/restore?mode=replace", string(exported)); rec.Code != http.StatusOK {
+		t.Errorf("expected the exported archive to be restored, got %d %s", rec.Code, rec.Body)
+	}
+
+	wrongVersion := archive
+	wrongVersion.Version = "0.1"
+	dangling := archive
+	dangling.Events = append(dangling.Events[:0:0], dangling.Events...)
+	dangling.Events[0].Venue.ID.Primary = "missing"
+	tests := []struct {
+		archive backup.Archive
+		field   string
+	}{
+		{archive: wrongVersion, field: "version"},
+		{archive: dangling, field: "events[0].venue"},
+	}
+	for _, test := range tests {
+		body, _ := json.Marshal(test.archive)
+		rec := request(http.MethodPost, "/v1/restore", string(body))
+		var response errorResponse
+		if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+			t.Fatalf("invalid error body: %v", err)
+		}
+		if rec.Code != http.StatusBadRequest || response.Error.Code != codeInvalid ||
+			len(response.Error.Fields) != 1 || response.Error.Fields[0].Field != test.field {
+			t.Errorf("expected an invalid %s, got %d %+v", test.field, rec.Code, response.Error)
+		}
+	}
+	if events := s.SavedEventCache.GetSavedEvents(); len(events) != 1 || events[0].Venue.ID.Primary == "missing" {
+		t.Errorf("expected a rejected archive to leave the data unchanged, got %+v", events)
+	}
+}
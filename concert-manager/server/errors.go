@@ -0,0 +1,116 @@
+package server
+
+import (
+	"concert-manager/domain"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// requestIDHeader carries the ID of a request, taken from the client when it sends one
+const requestIDHeader = "X-Request-ID"
+
+// stable codes clients can act on, the message is only meant for people
+const (
+	codeInvalid          = "invalid_request"
+	codeUnauthorized     = "unauthorized"
+	codeNotFound         = "not_found"
+	codeMethodNotAllowed = "method_not_allowed"
+	codeConflict         = "conflict"
+	codeInternal         = "internal"
+	codeNotImplemented   = "not_implemented"
+	codeUnavailable      = "upstream_unavailable"
+)
+
+var errorCodes = map[int]string{
+	http.StatusBadRequest:          codeInvalid,
+	http.StatusUnauthorized:        codeUnauthorized,
+	http.StatusNotFound:            codeNotFound,
+	http.StatusMethodNotAllowed:    codeMethodNotAllowed,
+	http.StatusConflict:            codeConflict,
+	http.StatusInternalServerError: codeInternal,
+	http.StatusNotImplemented:      codeNotImplemented,
+	http.StatusBadGateway:          codeUnavailable,
+}
+
+var kindStatuses = map[domain.ErrorKind]int{
+	domain.KindNotFound:    http.StatusNotFound,
+	domain.KindConflict:    http.StatusConflict,
+	domain.KindInvalid:     http.StatusBadRequest,
+	domain.KindUnavailable: http.StatusBadGateway,
+}
+
+// errorResponse is the body of every error response
+type errorResponse struct {
+	Error errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"requestId"`
+	// the problems with each field of an invalid body
+	Fields []domain.FieldError `json:"fields,omitempty"`
+	// what still references an artist or venue that couldn't be deleted
+	References *domain.References `json:"references,omitempty"`
+}
+
+// requestID is the ID the client sent, or a new one when it didn't send any
+func requestID(r *http.Request) string {
+	if id := r.Header.Get(requestIDHeader); id != "" {
+		return id
+	}
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// errorStatus is the status for the kind of the error, or the one the handler chose when the
+// error has no kind
+func errorStatus(err error, status int) int {
+	if kindStatus, ok := kindStatuses[domain.KindOf(err)]; ok {
+		return kindStatus
+	}
+	if status < http.StatusBadRequest {
+		return http.StatusInternalServerError
+	}
+	return status
+}
+
+func writeError(w http.ResponseWriter, id string, status int, err error) {
+	status = errorStatus(err, status)
+	code, ok := errorCodes[status]
+	if !ok {
+		code = codeInvalid
+		if status >= http.StatusInternalServerError {
+			code = codeInternal
+		}
+	}
+	body := errorBody{Code: code, Message: err.Error(), RequestID: id, Fields: domain.FieldsOf(err)}
+	var referencedErr domain.ReferencedError
+	if errors.As(err, &referencedErr) {
+		body.References = &referencedErr.References
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(requestIDHeader, id)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorResponse{Error: body})
+}
+
+// decodeBody decodes a JSON body, naming the field when a value has the wrong type
+func decodeBody(r *http.Request, v any) error {
+	err := json.NewDecoder(r.Body).Decode(v)
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		message := fmt.Sprintf("%s cannot be a %s", typeErr.Field, typeErr.Value)
+		return domain.InvalidFields(domain.FieldError{Field: typeErr.Field, Message: message})
+	default:
+		return domain.Invalid("invalid body")
+	}
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"concert-manager/domain"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestErrorResponses(t *testing.T) {
+	s := newSearchServer(t)
+	venueID := s.VenueCache.GetVenues()[0].ID.Primary
+	routes := s.Routes()
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		status int
+		code   string
+		fields []string
+	}{
+		{method: http.MethodPost, path: "/v1/events/saved", body: `{"rating": 9, "ticket": {"priceCents": -1}}`,
+			status: http.StatusBadRequest, code: codeInvalid, fields: []string{"rating", "ticket"}},
+		{method: http.MethodPost, path: "/v1/venues", body: `{"name": 5}`,
+			status: http.StatusBadRequest, code: codeInvalid, fields: []string{"name"}},
+		{method: http.MethodPost, path: "/v1/venues", body: `{`, status: http.StatusBadRequest, code: codeInvalid},
+		{method: http.MethodPost, path: "/v1/venues/" + venueID + "/merge", body: `{}`,
+			status: http.StatusBadRequest, code: codeInvalid, fields: []string{"sourceId"}},
+		{method: http.MethodPost, path: "/v1/venues/" + venueID + "/merge", body: `{"sourceId": "missing"}`,
+			status: http.StatusNotFound, code: codeNotFound},
+		{method: http.MethodDelete, path: "/v1/venues/" + venueID, status: http.StatusConflict, code: codeConflict},
+		{method: http.MethodPost, path: "/v1/albums", body: `{"name": "Live", "eventId": "missing"}`,
+			status: http.StatusBadRequest, code: codeInvalid, fields: []string{"eventId"}},
+		{method: http.MethodPatch, path: "/v1/venues", status: http.StatusMethodNotAllowed, code: codeMethodNotAllowed},
+		{method: http.MethodGet, path: "/v1/sync", status: http.StatusNotImplemented, code: codeNotImplemented},
+		{method: http.MethodPost, path: "/v1/albums/images", status: http.StatusNotImplemented, code: codeNotImplemented},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+		req.Header.Set(requestIDHeader, "test-id")
+		rec := httptest.NewRecorder()
+		routes.ServeHTTP(rec, req)
+
+		var response errorResponse
+		if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+			t.Errorf("%s %s: invalid error body: %v", test.method, test.path, err)
+			continue
+		}
+		fields := []string{}
+		for _, field := range response.Error.Fields {
+			fields = append(fields, field.Field)
+		}
+		if rec.Code != test.status || response.Error.Code != test.code || !slices.Equal(fields, append([]string{}, test.fields...)) {
+			t.Errorf("%s %s: expected %d %s %v, got %d %+v", test.method, test.path, test.status, test.code, test.fields, rec.Code, response.Error)
+		}
+		if response.Error.RequestID != "test-id" || rec.Header().Get(requestIDHeader) != "test-id" {
+			t.Errorf("%s %s: expected the request ID to be echoed, got %+v", test.method, test.path, response.Error)
+		}
+		if test.code == codeConflict && (response.Error.References == nil || len(response.Error.References.Events) != 1) {
+			t.Errorf("expected the references of the venue, got %+v", response.Error.References)
+		}
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		want   int
+	}{
+		{err: errors.New("failed"), status: http.StatusBadRequest, want: http.StatusBadRequest},
+		{err: errors.New("failed"), status: 0, want: http.StatusInternalServerError},
+		{err: fmt.Errorf("failed to update venue: %w", domain.NotFound("venue is not cached")), status: http.StatusInternalServerError, want: http.StatusNotFound},
+		{err: domain.Unavailable(errors.New("timeout"), "failed to upload image"), status: http.StatusInternalServerError, want: http.StatusBadGateway},
+		{err: fmt.Errorf("failed to delete artist: %w", domain.ReferencedError{Kind: "artist"}), status: http.StatusInternalServerError, want: http.StatusConflict},
+	}
+	for _, test := range tests {
+		if got := errorStatus(test.err, test.status); got != test.want {
+			t.Errorf("%v: expected status %d, got %d", test.err, test.want, got)
+		}
+	}
+}
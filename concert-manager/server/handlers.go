@@ -2,34 +2,46 @@ package server
 
 import (
 	"concert-manager/domain"
+	"concert-manager/loader"
 	"concert-manager/log"
 	"concert-manager/ranker"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 )
 
 func (s *Server) handleVenues(w http.ResponseWriter, r *http.Request) (any, int, error) {
 	switch r.Method {
 	case http.MethodGet:
+		if s.notModified(w, r) {
+			return nil, 0, nil
+		}
 		venues := s.VenueCache.GetVenues()
-		return venues, 0, nil
+		if !isListRequest(r.URL.Query()) {
+			return venues, 0, nil
+		}
+		page, err := listItems(venues, r.URL.Query(), func(v domain.Venue) string { return v.ID.Primary }, venueSortFields, "name")
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		return page, 0, nil
 	case http.MethodPost:
+		if strings.HasSuffix(r.URL.Path, "/merge") {
+			return s.mergeVenues(r)
+		}
 		var venue domain.Venue
-		if err := json.NewDecoder(r.Body).Decode(&venue); err != nil {
-			return nil, http.StatusBadRequest, errors.New("invalid body")
+		if err := decodeBody(r, &venue); err != nil {
+			return nil, http.StatusBadRequest, err
 		}
 		savedVenue, err := s.VenueCache.AddVenue(venue)
 		if err != nil {
-			errMsg := fmt.Sprintf("failed to save venue: %v", err)
-			return nil, http.StatusInternalServerError, errors.New(errMsg)
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to save venue: %w", err)
 		}
 		err = s.SyncService.SyncVenueAdd(savedVenue.ID.Primary)
 		if err != nil {
-			errMsg := fmt.Sprintf("failed to sync change for venue: %v", err)
-			return nil, http.StatusInternalServerError, errors.New(errMsg)
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to sync change for venue: %w", err)
 		}
 		return savedVenue, 0, nil
 	case http.MethodPut:
@@ -42,18 +54,16 @@ func (s *Server) handleVenues(w http.ResponseWriter, r *http.Request) (any, int,
 			return nil, http.StatusBadRequest, errors.New("missing venue ID in path")
 		}
 		var venue domain.Venue
-		if err := json.NewDecoder(r.Body).Decode(&venue); err != nil {
-			return nil, http.StatusBadRequest, errors.New("invalid body")
+		if err := decodeBody(r, &venue); err != nil {
+			return nil, http.StatusBadRequest, err
 		}
 		err := s.VenueCache.UpdateVenue(id, venue)
 		if err != nil {
-			errMsg := fmt.Sprintf("failed to update venue: %v", err)
-			return nil, http.StatusInternalServerError, errors.New(errMsg)
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to update venue: %w", err)
 		}
 		err = s.SyncService.SyncVenueUpdate(id)
 		if err != nil {
-			errMsg := fmt.Sprintf("failed to sync change for venue: %v", err)
-			return nil, http.StatusInternalServerError, errors.New(errMsg)
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to sync change for venue: %w", err)
 		}
 		return nil, 0, nil
 	case http.MethodDelete:
@@ -65,9 +75,24 @@ func (s *Server) handleVenues(w http.ResponseWriter, r *http.Request) (any, int,
 		if len(id) == 0 {
 			return nil, http.StatusBadRequest, errors.New("missing venue ID in path")
 		}
-		if err := s.VenueCache.DeleteVenue(id); err != nil {
-			errMsg := fmt.Sprintf("failed to delete venue: %v", err)
-			return nil, http.StatusInternalServerError, errors.New(errMsg)
+		cascade, reassignTo, err := deleteOptions(r)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		var refs domain.References
+		switch {
+		case cascade:
+			refs, err = s.VenueCache.DeleteVenueCascade(id)
+		case reassignTo != "":
+			refs, err = s.VenueCache.DeleteVenueReassign(id, reassignTo)
+		default:
+			err = s.VenueCache.DeleteVenue(id)
+		}
+		if err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to delete venue: %w", err)
+		}
+		if err := s.syncReferences(refs); err != nil {
+			return nil, http.StatusInternalServerError, err
 		}
 		s.SyncService.SyncVenueDelete(id)
 		return nil, 0, nil
@@ -78,22 +103,33 @@ func (s *Server) handleVenues(w http.ResponseWriter, r *http.Request) (any, int,
 func (s *Server) handleArtists(w http.ResponseWriter, r *http.Request) (any, int, error) {
 	switch r.Method {
 	case http.MethodGet:
+		if s.notModified(w, r) {
+			return nil, 0, nil
+		}
 		artists := s.ArtistCache.GetArtists()
-		return artists, 0, nil
+		if !isListRequest(r.URL.Query()) {
+			return artists, 0, nil
+		}
+		page, err := listItems(artists, r.URL.Query(), func(a domain.Artist) string { return a.ID.Primary }, artistSortFields, "name")
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		return page, 0, nil
 	case http.MethodPost:
+		if strings.HasSuffix(r.URL.Path, "/merge") {
+			return s.mergeArtists(r)
+		}
 		var artist domain.Artist
-		if err := json.NewDecoder(r.Body).Decode(&artist); err != nil {
-			return nil, http.StatusBadRequest, errors.New("invalid body")
+		if err := decodeBody(r, &artist); err != nil {
+			return nil, http.StatusBadRequest, err
 		}
 		savedArtist, err := s.ArtistCache.AddArtist(artist)
 		if err != nil {
-			errMsg := fmt.Sprintf("failed to save artist: %v", err)
-			return nil, http.StatusInternalServerError, errors.New(errMsg)
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to save artist: %w", err)
 		}
 		err = s.SyncService.SyncArtistAdd(savedArtist.ID.Primary)
 		if err != nil {
-			errMsg := fmt.Sprintf("failed to sync change for artist: %v", err)
-			return nil, http.StatusInternalServerError, errors.New(errMsg)
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to sync change for artist: %w", err)
 		}
 		return savedArtist, 0, nil
 	case http.MethodPut:
@@ -106,18 +142,16 @@ func (s *Server) handleArtists(w http.ResponseWriter, r *http.Request) (any, int
 			return nil, http.StatusBadRequest, errors.New("missing artist ID in path")
 		}
 		var artist domain.Artist
-		if err := json.NewDecoder(r.Body).Decode(&artist); err != nil {
-			return nil, http.StatusBadRequest, errors.New("invalid body")
+		if err := decodeBody(r, &artist); err != nil {
+			return nil, http.StatusBadRequest, err
 		}
 		err := s.ArtistCache.UpdateArtist(id, artist)
 		if err != nil {
-			errMsg := fmt.Sprintf("failed to update artist: %v", err)
-			return nil, http.StatusInternalServerError, errors.New(errMsg)
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to update artist: %w", err)
 		}
 		err = s.SyncService.SyncArtistUpdate(id)
 		if err != nil {
-			errMsg := fmt.Sprintf("failed to sync change for artist: %v", err)
-			return nil, http.StatusInternalServerError, errors.New(errMsg)
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to sync change for artist: %w", err)
 		}
 		return nil, 0, nil
 	case http.MethodDelete:
@@ -129,9 +163,24 @@ func (s *Server) handleArtists(w http.ResponseWriter, r *http.Request) (any, int
 		if len(id) == 0 {
 			return nil, http.StatusBadRequest, errors.New("missing artist ID in path")
 		}
-		if err := s.ArtistCache.DeleteArtist(id); err != nil {
-			errMsg := fmt.Sprintf("failed to delete artist: %v", err)
-			return nil, http.StatusInternalServerError, errors.New(errMsg)
+		cascade, reassignTo, err := deleteOptions(r)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		var refs domain.References
+		switch {
+		case cascade:
+			refs, err = s.ArtistCache.DeleteArtistCascade(id)
+		case reassignTo != "":
+			refs, err = s.ArtistCache.DeleteArtistReassign(id, reassignTo)
+		default:
+			err = s.ArtistCache.DeleteArtist(id)
+		}
+		if err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to delete artist: %w", err)
+		}
+		if err := s.syncReferences(refs); err != nil {
+			return nil, http.StatusInternalServerError, err
 		}
 		s.SyncService.SyncArtistDelete(id)
 		return nil, 0, nil
@@ -139,37 +188,185 @@ func (s *Server) handleArtists(w http.ResponseWriter, r *http.Request) (any, int
 	return nil, http.StatusMethodNotAllowed, errors.New("unsupported method")
 }
 
+type mergeRequest struct {
+	SourceID string `json:"sourceId"`
+}
+
+// reads the surviving ID from /v1/{artists|venues}/{id}/merge and the source ID from the body
+func parseMerge(r *http.Request, kind string) (string, string, error) {
+	pathParts := strings.Split(r.URL.Path, "/")
+	if len(pathParts) != 5 || len(pathParts[3]) == 0 {
+		errMsg := fmt.Sprintf("missing %s ID in path", kind)
+		return "", "", errors.New(errMsg)
+	}
+	var request mergeRequest
+	if err := decodeBody(r, &request); err != nil {
+		return "", "", err
+	}
+	if request.SourceID == "" {
+		return "", "", domain.InvalidFields(domain.FieldError{Field: "sourceId", Message: "sourceId is required"})
+	}
+	return pathParts[3], request.SourceID, nil
+}
+
+func (s *Server) mergeArtists(r *http.Request) (any, int, error) {
+	survivorID, sourceID, err := parseMerge(r, "artist")
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	merged, refs, err := s.ArtistCache.MergeArtists(survivorID, sourceID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to merge artists: %w", err)
+	}
+	if err := s.SyncService.SyncArtistMerge(survivorID, sourceID); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to sync change for artist: %w", err)
+	}
+	if err := s.syncReferences(refs); err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return merged, 0, nil
+}
+
+func (s *Server) mergeVenues(r *http.Request) (any, int, error) {
+	survivorID, sourceID, err := parseMerge(r, "venue")
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	merged, refs, err := s.VenueCache.MergeVenues(survivorID, sourceID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to merge venues: %w", err)
+	}
+	if err := s.SyncService.SyncVenueMerge(survivorID, sourceID); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to sync change for venue: %w", err)
+	}
+	if err := s.syncReferences(refs); err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return merged, 0, nil
+}
+
+// reads the ?cascade=true or ?reassignTo={id} options for deleting an artist or venue that is still referenced
+func deleteOptions(r *http.Request) (bool, string, error) {
+	query := r.URL.Query()
+	cascade := strings.ToLower(query.Get("cascade")) == "true"
+	reassignTo := query.Get("reassignTo")
+	if cascade && reassignTo != "" {
+		return false, "", errors.New("cascade and reassignTo cannot be used together")
+	}
+	return cascade, reassignTo, nil
+}
+
+// keeps the upcoming events in line with saved events changed or deleted by a cascading or
+// reassigning delete, an event that is no longer saved was deleted
+func (s *Server) syncReferences(refs domain.References) error {
+	saved := s.SavedEventCache.GetSavedEvents()
+	for _, id := range refs.Events {
+		if !slices.ContainsFunc(saved, func(e domain.Event) bool { return e.ID.Primary == id }) {
+			s.SyncService.SyncEventDelete(id)
+			continue
+		}
+		if err := s.SyncService.SyncEventUpdate(id); err != nil {
+			return fmt.Errorf("failed to sync change for event: %w", err)
+		}
+	}
+	return nil
+}
+
+// savedEventResponse is a saved event with the albums bought or signed at it
+type savedEventResponse struct {
+	domain.Event
+	Albums []domain.Album `json:"albums"`
+}
+
+func (s *Server) withAlbums(events []domain.Event) []savedEventResponse {
+	albumsByEvent := map[string][]domain.Album{}
+	for _, album := range s.AlbumCache.GetAlbums() {
+		if album.EventID != "" {
+			albumsByEvent[album.EventID] = append(albumsByEvent[album.EventID], album)
+		}
+	}
+	responses := make([]savedEventResponse, 0, len(events))
+	for _, event := range events {
+		albums := albumsByEvent[event.ID.Primary]
+		if albums == nil {
+			albums = []domain.Album{}
+		}
+		responses = append(responses, savedEventResponse{Event: event, Albums: albums})
+	}
+	return responses
+}
+
 func (s *Server) handleSavedEvents(w http.ResponseWriter, r *http.Request) (any, int, error) {
 	switch r.Method {
 	case http.MethodGet:
-		events := s.SavedEventCache.GetSavedEvents()
-		id := r.URL.Query().Get("id")
-		if id == "" {
-			return events, 0, nil
+		if s.notModified(w, r) {
+			return nil, 0, nil
 		}
-		for _, event := range events {
-			if event.ID.Primary == id {
-				return []domain.Event{event}, 0, nil
+		events := s.SavedEventCache.GetSavedEvents()
+		params := r.URL.Query()
+		id := params.Get("id")
+		if id != "" {
+			for _, event := range events {
+				if event.ID.Primary == id {
+					return s.withAlbums([]domain.Event{event}), 0, nil
+				}
 			}
+			errMsg := fmt.Sprintf("event with ID %s not found", id)
+			return nil, http.StatusNotFound, errors.New(errMsg)
+		}
+		if !isListRequest(params, eventFilterParams...) {
+			return s.withAlbums(events), 0, nil
+		}
+		filtered, err := filterEvents(events, params)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		page, err := listItems(filtered, params, func(e domain.Event) string { return e.ID.Primary }, eventSortFields, "date")
+		if err != nil {
+			return nil, http.StatusBadRequest, err
 		}
-		errMsg := fmt.Sprintf("event with ID %s not found", id)
-		return nil, http.StatusNotFound, errors.New(errMsg)
+		return mapPage(page, s.withAlbums(page.Items)), 0, nil
 	case http.MethodPost:
 		var event domain.Event
-		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
-			return nil, http.StatusBadRequest, errors.New("invalid body")
+		if err := decodeBody(r, &event); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		if err := event.ValidateDetails(); err != nil {
+			return nil, http.StatusBadRequest, err
 		}
 		savedEvent, err := s.SavedEventCache.AddSavedEvent(event)
 		if err != nil {
-			errMsg := fmt.Sprintf("failed to save event: %v", err)
-			return nil, http.StatusInternalServerError, errors.New(errMsg)
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to save event: %w", err)
 		}
 		err = s.SyncService.SyncEventAdd(savedEvent.ID.Primary)
 		if err != nil {
-			errMsg := fmt.Sprintf("failed to sync change for event: %v", err)
-			return nil, http.StatusInternalServerError, errors.New(errMsg)
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to sync change for event: %w", err)
 		}
 		return savedEvent, 0, nil
+	case http.MethodPut:
+		pathParts := strings.Split(r.URL.Path, "/")
+		if len(pathParts) != 5 {
+			return nil, http.StatusBadRequest, errors.New("missing event ID in path")
+		}
+		id := pathParts[4]
+		if len(id) == 0 {
+			return nil, http.StatusBadRequest, errors.New("missing event ID in path")
+		}
+		var event domain.Event
+		if err := decodeBody(r, &event); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		if err := event.ValidateDetails(); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		if err := s.SavedEventCache.UpdateSavedEvent(id, event); err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to update event: %w", err)
+		}
+		err := s.SyncService.SyncEventUpdate(id)
+		if err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to sync change for event: %w", err)
+		}
+		return nil, 0, nil
 	case http.MethodDelete:
 		pathParts := strings.Split(r.URL.Path, "/")
 		if len(pathParts) != 5 {
@@ -180,8 +377,7 @@ func (s *Server) handleSavedEvents(w http.ResponseWriter, r *http.Request) (any,
 			return nil, http.StatusBadRequest, errors.New("missing event ID in path")
 		}
 		if err := s.SavedEventCache.DeleteSavedEvent(id); err != nil {
-			errMsg := fmt.Sprintf("failed to delete event: %v", err)
-			return nil, http.StatusInternalServerError, errors.New(errMsg)
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to delete event: %w", err)
 		}
 		s.SyncService.SyncEventDelete(id)
 		return nil, 0, nil
@@ -235,14 +431,12 @@ func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request) (any, int,
 
 	file, _, err := r.FormFile("file")
 	if err != nil {
-		errMsg := fmt.Sprintf("unable to parse request file: %v", err)
-		return nil, http.StatusBadRequest, errors.New(errMsg)
+		return nil, http.StatusBadRequest, fmt.Errorf("unable to parse request file: %w", err)
 	}
 
 	rows, err := s.EventLoader.Upload(r.Context(), file)
 	if err != nil {
-		errMsg := fmt.Sprintf("error occurred during upload processing: %v", err)
-		return nil, http.StatusBadRequest, errors.New(errMsg)
+		return nil, http.StatusBadRequest, fmt.Errorf("error occurred during upload processing: %w", err)
 	}
 	return fmt.Sprintf("Successfully uploaded %d rows", rows), 0, nil
 }
@@ -252,15 +446,12 @@ func (s *Server) reloadGenres(w http.ResponseWriter, r *http.Request) (any, int,
 		return nil, http.StatusMethodNotAllowed, errors.New("unsupported method")
 	}
 
-	queryParams := r.URL.Query()
-	artists := queryParams["artists"]
+	// the artists are compared with those of a running reload, so the order they are given in doesn't matter
+	artists := slices.Clone(r.URL.Query()["artists"])
+	slices.Sort(artists)
+	artists = slices.Compact(artists)
 
-	updateCount, err := s.ArtistInfoLoader.ReloadGenres(r.Context(), artists)
-	if err != nil {
-		errMsg := fmt.Sprintf("some artist genres failed to refresh, %s", err)
-		return nil, http.StatusInternalServerError, errors.New(errMsg)
-	}
-	return fmt.Sprintf("Successfully refreshed genres for %d artists", updateCount), 0, nil
+	return s.startJobWithArgs(w, GenresJob, artists, s.reloadGenresJob(artists))
 }
 
 var thresholdOpts = map[string]ranker.RecLevel{
@@ -301,12 +492,7 @@ func (s *Server) refreshUpcomingEvents(w http.ResponseWriter, r *http.Request) (
 		return nil, http.StatusMethodNotAllowed, errors.New("unsupported method")
 	}
 
-	err := s.UpcomingEventsCache.RefreshUpcomingEvents()
-	if err != nil {
-		log.Errorf("Failed to refresh upcoming events %v", err)
-		return nil, http.StatusInternalServerError, errors.New("failed to refresh upcoming event cache")
-	}
-	return nil, 0, nil
+	return s.startJob(w, UpcomingEventsJob, s.refreshUpcomingEventsJob)
 }
 
 func (s *Server) refreshRanks(w http.ResponseWriter, r *http.Request) (any, int, error) {
@@ -315,24 +501,37 @@ func (s *Server) refreshRanks(w http.ResponseWriter, r *http.Request) (any, int,
 	}
 
 	log.Info("Manual ranks refresh triggered via API")
-	go s.RanksCache.DoRefresh()
-	return map[string]string{"status": "refresh started"}, http.StatusOK, nil
+	return s.startJob(w, RanksJob, s.refreshRanksJob)
 }
 
 func (s *Server) handleAlbums(w http.ResponseWriter, r *http.Request) (any, int, error) {
 	switch r.Method {
 	case http.MethodGet:
+		if s.notModified(w, r) {
+			return nil, 0, nil
+		}
 		albums := s.AlbumCache.GetAlbums()
-		return albums, 0, nil
+		params := r.URL.Query()
+		if !isListRequest(params, albumFilterParams...) {
+			return albums, 0, nil
+		}
+		filtered, err := filterAlbums(albums, params)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		page, err := listItems(filtered, params, func(a domain.Album) string { return a.ID }, albumSortFields, "name")
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		return page, 0, nil
 	case http.MethodPost:
 		var album domain.Album
-		if err := json.NewDecoder(r.Body).Decode(&album); err != nil {
-			return nil, http.StatusBadRequest, errors.New("invalid body")
+		if err := decodeBody(r, &album); err != nil {
+			return nil, http.StatusBadRequest, err
 		}
 		savedAlbum, err := s.AlbumCache.AddAlbum(album)
 		if err != nil {
-			errMsg := fmt.Sprintf("failed to save album: %v", err)
-			return nil, http.StatusInternalServerError, errors.New(errMsg)
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to save album: %w", err)
 		}
 		return savedAlbum, 0, nil
 	case http.MethodPut:
@@ -345,12 +544,11 @@ func (s *Server) handleAlbums(w http.ResponseWriter, r *http.Request) (any, int,
 			return nil, http.StatusBadRequest, errors.New("missing album ID in path")
 		}
 		var album domain.Album
-		if err := json.NewDecoder(r.Body).Decode(&album); err != nil {
-			return nil, http.StatusBadRequest, errors.New("invalid body")
+		if err := decodeBody(r, &album); err != nil {
+			return nil, http.StatusBadRequest, err
 		}
 		if err := s.AlbumCache.UpdateAlbum(id, album); err != nil {
-			errMsg := fmt.Sprintf("failed to update album: %v", err)
-			return nil, http.StatusInternalServerError, errors.New(errMsg)
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to update album: %w", err)
 		}
 		return nil, 0, nil
 	case http.MethodDelete:
@@ -363,8 +561,7 @@ func (s *Server) handleAlbums(w http.ResponseWriter, r *http.Request) (any, int,
 			return nil, http.StatusBadRequest, errors.New("missing album ID in path")
 		}
 		if err := s.AlbumCache.DeleteAlbum(id); err != nil {
-			errMsg := fmt.Sprintf("failed to delete album: %v", err)
-			return nil, http.StatusInternalServerError, errors.New(errMsg)
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to delete album: %w", err)
 		}
 		return nil, 0, nil
 	}
@@ -375,6 +572,9 @@ func (s *Server) handleAlbumImages(w http.ResponseWriter, r *http.Request) (any,
 	if r.Method != http.MethodPost {
 		return nil, http.StatusMethodNotAllowed, errors.New("unsupported method")
 	}
+	if s.ImageUploader == nil {
+		return nil, http.StatusNotImplemented, errors.New("album image uploads are not supported")
+	}
 
 	if err := r.ParseMultipartForm(10 << 20); err != nil {
 		return nil, http.StatusBadRequest, errors.New("failed to parse multipart form")
@@ -393,22 +593,39 @@ func (s *Server) handleAlbumImages(w http.ResponseWriter, r *http.Request) (any,
 
 	url, err := s.ImageUploader.UploadImage(r.Context(), file, contentType)
 	if err != nil {
-		errMsg := fmt.Sprintf("failed to upload image: %v", err)
-		return nil, http.StatusInternalServerError, errors.New(errMsg)
+		return nil, http.StatusBadGateway, domain.Unavailable(err, "failed to upload image")
 	}
 	return map[string]string{"url": url}, 0, nil
 }
 
-func (s *Server) refreshAlbums(w http.ResponseWriter, r *http.Request) (any, int, error) {
+// importAlbums adds the albums in the uploaded CSV file, a generic or Discogs export detected
+// from the header unless ?format= is set. ?dryRun=true reports what would be imported.
+func (s *Server) importAlbums(w http.ResponseWriter, r *http.Request) (any, int, error) {
 	if r.Method != http.MethodPost {
 		return nil, http.StatusMethodNotAllowed, errors.New("unsupported method")
 	}
 
-	if err := s.AlbumCache.RefreshAlbums(); err != nil {
-		log.Errorf("Failed to refresh albums %v", err)
-		return nil, http.StatusInternalServerError, errors.New("failed to refresh albums cache")
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("unable to parse request file: %w", err)
 	}
-	return nil, 0, nil
+	defer file.Close()
+
+	format := loader.AlbumFormat(r.URL.Query().Get("format"))
+	dryRun := r.URL.Query().Get("dryRun") == "true"
+	report, err := s.AlbumLoader.Import(r.Context(), file, format, dryRun)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("error occurred during album import: %w", err)
+	}
+	return report, 0, nil
+}
+
+func (s *Server) refreshAlbums(w http.ResponseWriter, r *http.Request) (any, int, error) {
+	if r.Method != http.MethodPost {
+		return nil, http.StatusMethodNotAllowed, errors.New("unsupported method")
+	}
+
+	return s.startJob(w, AlbumsJob, s.refreshAlbumsJob)
 }
 
 func (s *Server) handleGenres(w http.ResponseWriter, r *http.Request) (any, int, error) {
@@ -0,0 +1,163 @@
+package server
+
+import (
+	"concert-manager/domain"
+	"concert-manager/jobs"
+	"concert-manager/scheduler"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+type jobRunner interface {
+	StartWithArgs(string, []string, jobs.Run) (jobs.Job, error)
+	Get(string) (jobs.Job, error)
+	List() []jobs.Job
+	Cancel(string) (jobs.Job, error)
+}
+
+// kinds of the jobs started by the refresh endpoints and the scheduler, only one of each runs at a time
+const (
+	UpcomingEventsJob = "upcoming-events-refresh"
+	GenresJob         = "genres-reload"
+	RanksJob          = "ranks-refresh"
+	AlbumsJob         = "albums-refresh"
+	SavedDataJob      = "saved-data-refresh"
+)
+
+var jobKinds = []string{UpcomingEventsJob, GenresJob, RanksJob, AlbumsJob, SavedDataJob}
+
+var jobStatuses = []string{string(jobs.Running), string(jobs.Succeeded), string(jobs.Failed), string(jobs.Cancelled)}
+
+// countResult is the result of a refresh job, how many entities it loaded or updated
+type countResult struct {
+	Count int `json:"count"`
+}
+
+// RefreshTasks are the refreshes that can run on a schedule, each with the spec given for its kind.
+// A kind without a spec is off.
+func (s *Server) RefreshTasks(specs map[string]string) []scheduler.Task {
+	runs := []struct {
+		kind string
+		run  jobs.Run
+	}{
+		{UpcomingEventsJob, s.refreshUpcomingEventsJob},
+		{RanksJob, s.refreshRanksJob},
+		{GenresJob, s.reloadGenresJob(nil)},
+		{SavedDataJob, s.refreshSavedDataJob},
+	}
+	tasks := []scheduler.Task{}
+	for _, r := range runs {
+		spec := specs[r.kind]
+		if spec == "" {
+			spec = scheduler.Off
+		}
+		tasks = append(tasks, scheduler.Task{Kind: r.kind, Spec: spec, Run: r.run})
+	}
+	return tasks
+}
+
+func (s *Server) refreshUpcomingEventsJob(ctx context.Context) (any, error) {
+	if err := s.UpcomingEventsCache.RefreshUpcomingEvents(ctx); err != nil {
+		return nil, domain.Unavailable(err, "failed to refresh upcoming event cache")
+	}
+	return countResult{Count: len(s.UpcomingEventsCache.GetUpcomingEvents())}, nil
+}
+
+func (s *Server) refreshRanksJob(ctx context.Context) (any, error) {
+	return nil, s.RanksCache.Refresh(ctx)
+}
+
+// reloads the genres of the artists by name, or of every artist when none are given
+func (s *Server) reloadGenresJob(artists []string) jobs.Run {
+	return func(ctx context.Context) (any, error) {
+		updateCount, err := s.ArtistInfoLoader.ReloadGenres(ctx, artists)
+		if err != nil {
+			return nil, domain.Unavailable(err, "some artist genres failed to refresh")
+		}
+		return countResult{Count: updateCount}, nil
+	}
+}
+
+func (s *Server) refreshAlbumsJob(ctx context.Context) (any, error) {
+	if err := s.AlbumCache.RefreshAlbums(); err != nil {
+		return nil, fmt.Errorf("failed to refresh albums cache: %w", err)
+	}
+	return countResult{Count: len(s.AlbumCache.GetAlbums())}, nil
+}
+
+// reloads the venues, artists, saved events and albums from the database, picking up changes made
+// to it without going through this server
+func (s *Server) refreshSavedDataJob(ctx context.Context) (any, error) {
+	return nil, s.refreshSavedData()
+}
+
+// startJob runs work in the background, responding with 202 and the job to follow at
+// /v1/jobs/{id}. A job of the same kind that is already running is returned instead.
+func (s *Server) startJob(w http.ResponseWriter, kind string, run jobs.Run) (any, int, error) {
+	return s.startJobWithArgs(w, kind, nil, run)
+}
+
+// startJobWithArgs is startJob for work that depends on its arguments, responding with 409 while
+// a job of the kind is running with other arguments
+func (s *Server) startJobWithArgs(w http.ResponseWriter, kind string, args []string, run jobs.Run) (any, int, error) {
+	if s.Jobs == nil {
+		return nil, http.StatusNotImplemented, errors.New("jobs are not supported")
+	}
+	job, err := s.Jobs.StartWithArgs(kind, args, run)
+	if err != nil {
+		return nil, http.StatusConflict, err
+	}
+	w.Header().Set("Location", "/v1/jobs/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	return job, 0, nil
+}
+
+// handleJobs lists the recent jobs, newest first and optionally filtered by ?kind= and ?status=,
+// gets one at /v1/jobs/{id} and cancels one with POST /v1/jobs/{id}/cancel
+func (s *Server) handleJobs(w http.ResponseWriter, r *http.Request) (any, int, error) {
+	if s.Jobs == nil {
+		return nil, http.StatusNotImplemented, errors.New("jobs are not supported")
+	}
+	pathParts := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+	switch {
+	case r.Method == http.MethodGet && len(pathParts) == 3:
+		return s.listJobs(r)
+	case r.Method == http.MethodGet && len(pathParts) == 4:
+		job, err := s.Jobs.Get(pathParts[3])
+		if err != nil {
+			return nil, http.StatusNotFound, err
+		}
+		return job, 0, nil
+	case r.Method == http.MethodPost && len(pathParts) == 5 && pathParts[4] == "cancel":
+		job, err := s.Jobs.Cancel(pathParts[3])
+		if err != nil {
+			return nil, http.StatusNotFound, err
+		}
+		return job, 0, nil
+	}
+	return nil, http.StatusMethodNotAllowed, errors.New("unsupported method")
+}
+
+func (s *Server) listJobs(r *http.Request) (any, int, error) {
+	params := r.URL.Query()
+	kind, status := params.Get("kind"), params.Get("status")
+	if kind != "" && !slices.Contains(jobKinds, kind) {
+		errMsg := fmt.Sprintf("kind must be one of %s", strings.Join(jobKinds, ", "))
+		return nil, http.StatusBadRequest, errors.New(errMsg)
+	}
+	if status != "" && !slices.Contains(jobStatuses, status) {
+		errMsg := fmt.Sprintf("status must be one of %s", strings.Join(jobStatuses, ", "))
+		return nil, http.StatusBadRequest, errors.New(errMsg)
+	}
+	filtered := []jobs.Job{}
+	for _, job := range s.Jobs.List() {
+		if (kind == "" || job.Kind == kind) && (status == "" || string(job.Status) == status) {
+			filtered = append(filtered, job)
+		}
+	}
+	return filtered, 0, nil
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"concert-manager/jobs"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRefreshJobs(t *testing.T) {
+	s := newSearchServer(t)
+	manager := &jobs.Manager{}
+	s.Jobs = manager
+	routes := s.Routes()
+	request := func(method, url string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		routes.ServeHTTP(rec, httptest.NewRequest(method, url, nil))
+		return rec
+	}
+
+	rec := request(http.MethodPost, "/v1/albums/refresh")
+	var started jobs.Job
+	if rec.Code != http.StatusAccepted || json.NewDecoder(rec.Body).Decode(&started) != nil || started.Kind != AlbumsJob {
+		t.Fatalf("expected an accepted albums job, got %d %s", rec.Code, rec.Body)
+	}
+	if location := rec.Header().Get("Location"); location != "/v1/jobs/"+started.ID {
+		t.Errorf("expected the job location, got %q", location)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := manager.Wait(ctx, started.ID); err != nil {
+		t.Fatalf("failed to wait for job: %v", err)
+	}
+
+	var job jobs.Job
+	rec = request(http.MethodGet, "/v1/jobs/"+started.ID)
+	if json.NewDecoder(rec.Body).Decode(&job) != nil || job.Status != jobs.Succeeded {
+		t.Errorf("expected the job to succeed, got %d %+v", rec.Code, job)
+	}
+	if result, ok := job.Result.(map[string]any); !ok || result["count"] != float64(1) {
+		t.Errorf("expected the album count as the result, got %+v", job.Result)
+	}
+
+	var list []jobs.Job
+	rec = request(http.MethodGet, "/v1/jobs?kind="+AlbumsJob+"&status=succeeded")
+	if json.NewDecoder(rec.Body).Decode(&list) != nil || len(list) != 1 {
+		t.Errorf("expected the albums job, got %d %s", rec.Code, rec.Body)
+	}
+	if rec := request(http.MethodGet, "/v1/jobs?kind=other"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected bad request for an unknown kind, got %d", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/v1/jobs/"+started.ID+"/cancel"); rec.Code != http.StatusConflict {
+		t.Errorf("expected conflict cancelling a finished job, got %d", rec.Code)
+	}
+	if rec := request(http.MethodGet, "/v1/jobs/missing"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected not found, got %d", rec.Code)
+	}
+	if rec := request(http.MethodPost, "/v1/jobs/missing/cancel"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected not found cancelling a missing job, got %d", rec.Code)
+	}
+}
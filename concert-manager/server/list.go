@@ -0,0 +1,312 @@
+package server
+
+import (
+	"cmp"
+	"concert-manager/domain"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const maxPageSize = 500
+
+// list parameters shared by every list endpoint, any of them switches the response to a listPage
+var pagingParams = []string{"sort", "limit", "cursor"}
+
+// listPage is the response of a list endpoint once it is filtered, sorted or paginated.
+// Total is the number of items matching the filters across every page.
+type listPage[T any] struct {
+	Items      []T    `json:"items"`
+	Count      int    `json:"count"`
+	Total      int    `json:"total"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// sortField orders items by a string key, so keys of other types are formatted to sort
+// the same way as strings, e.g. dates as yyyy-mm-dd
+type sortField[T any] func(T) string
+
+// listQuery is the parsed sort, limit and cursor of a list request
+type listQuery struct {
+	sort       string
+	descending bool
+	limit      int
+	after      *listCursor
+}
+
+func (q listQuery) sortParam() string {
+	if q.descending {
+		return "-" + q.sort
+	}
+	return q.sort
+}
+
+// listCursor is the position after the last item of a page. It holds the sort key rather than
+// an offset so pages don't skip or repeat items when earlier items are added or deleted.
+type listCursor struct {
+	Sort string `json:"s"`
+	Key  string `json:"k"`
+	ID   string `json:"id"`
+}
+
+func (c listCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (*listCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	var cursor listCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	return &cursor, nil
+}
+
+// isListRequest is whether the request uses any list parameters, plain requests keep getting
+// the whole list as an array for clients that don't know about listPage
+func isListRequest(params url.Values, filters ...string) bool {
+	for _, param := range append(slices.Clone(pagingParams), filters...) {
+		if params.Has(param) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseListQuery reads ?sort=, ?limit= and ?cursor=. A sort field prefixed with "-" is descending.
+func parseListQuery[T any](params url.Values, fields map[string]sortField[T], defaultSort string) (listQuery, error) {
+	query := listQuery{sort: defaultSort}
+	if sort := params.Get("sort"); sort != "" {
+		query.descending = strings.HasPrefix(sort, "-")
+		query.sort = strings.TrimPrefix(sort, "-")
+	}
+	if _, ok := fields[query.sort]; !ok {
+		names := []string{}
+		for name := range fields {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		errMsg := fmt.Sprintf("unsupported sort %s, expected one of %s", query.sort, strings.Join(names, ", "))
+		return listQuery{}, errors.New(errMsg)
+	}
+	if limit := params.Get("limit"); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil || parsed < 1 || parsed > maxPageSize {
+			errMsg := fmt.Sprintf("limit must be between 1 and %d", maxPageSize)
+			return listQuery{}, errors.New(errMsg)
+		}
+		query.limit = parsed
+	}
+	if cursor := params.Get("cursor"); cursor != "" {
+		after, err := decodeCursor(cursor)
+		if err != nil {
+			return listQuery{}, err
+		}
+		if after.Sort != query.sortParam() {
+			return listQuery{}, errors.New("cursor is from a list with a different sort")
+		}
+		query.after = after
+	}
+	return query, nil
+}
+
+// paginate sorts the filtered items and returns the page after the cursor, with IDs breaking
+// ties between equal keys so every item has a single position
+func paginate[T any](items []T, id func(T) string, fields map[string]sortField[T], query listQuery) listPage[T] {
+	key := fields[query.sort]
+	compare := func(aKey, aID, bKey, bID string) int {
+		c := cmp.Compare(aKey, bKey)
+		if c == 0 {
+			c = cmp.Compare(aID, bID)
+		}
+		if query.descending {
+			return -c
+		}
+		return c
+	}
+	sorted := slices.Clone(items)
+	slices.SortStableFunc(sorted, func(a, b T) int {
+		return compare(key(a), id(a), key(b), id(b))
+	})
+
+	start := 0
+	if query.after != nil {
+		start = len(sorted)
+		for i, item := range sorted {
+			if compare(key(item), id(item), query.after.Key, query.after.ID) > 0 {
+				start = i
+				break
+			}
+		}
+	}
+	end := len(sorted)
+	if query.limit > 0 && start+query.limit < end {
+		end = start + query.limit
+	}
+
+	page := listPage[T]{Items: sorted[start:end], Count: end - start, Total: len(sorted)}
+	if end < len(sorted) {
+		last := sorted[end-1]
+		page.NextCursor = listCursor{Sort: query.sortParam(), Key: key(last), ID: id(last)}.encode()
+	}
+	return page
+}
+
+// listItems parses the list parameters and returns the page of items they select
+func listItems[T any](items []T, params url.Values, id func(T) string, fields map[string]sortField[T], defaultSort string) (listPage[T], error) {
+	query, err := parseListQuery(params, fields, defaultSort)
+	if err != nil {
+		return listPage[T]{}, err
+	}
+	return paginate(items, id, fields, query), nil
+}
+
+func mapPage[T, U any](page listPage[T], items []U) listPage[U] {
+	return listPage[U]{Items: items, Count: page.Count, Total: page.Total, NextCursor: page.NextCursor}
+}
+
+func parseOptionalBool(params url.Values, name string) (*bool, error) {
+	value := params.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		errMsg := fmt.Sprintf("%s must be true or false", name)
+		return nil, errors.New(errMsg)
+	}
+	return &parsed, nil
+}
+
+func sortableDate(date domain.Date) string {
+	return fmt.Sprintf("%04d-%02d-%02d", date.Year, date.Month, date.Day)
+}
+
+var eventSortFields = map[string]sortField[domain.Event]{
+	"date": func(e domain.Event) string { return sortableDate(e.Date) },
+	"artist": func(e domain.Event) string {
+		if e.MainAct == nil {
+			return ""
+		}
+		return strings.ToLower(e.MainAct.Name)
+	},
+	"venue":  func(e domain.Event) string { return strings.ToLower(e.Venue.Name) },
+	"rating": func(e domain.Event) string { return fmt.Sprintf("%02d", e.Rating) },
+}
+
+var eventFilterParams = []string{"from", "to", "purchased", "venueId", "artistId", "genre"}
+
+// filterEvents applies ?from= and ?to= (m/d/yyyy, inclusive, matching festivals overlapping
+// the range), ?purchased=, ?venueId=, ?artistId= and ?genre=, which matches the genres of
+// any artist in the lineup from any source
+func filterEvents(events []domain.Event, params url.Values) ([]domain.Event, error) {
+	from, err := domain.ParseOptionalDate(params.Get("from"))
+	if err != nil {
+		return nil, err
+	}
+	to, err := domain.ParseOptionalDate(params.Get("to"))
+	if err != nil {
+		return nil, err
+	}
+	purchased, err := parseOptionalBool(params, "purchased")
+	if err != nil {
+		return nil, err
+	}
+	venueID := params.Get("venueId")
+	artistID := params.Get("artistId")
+	genre := strings.TrimSpace(params.Get("genre"))
+
+	filtered := []domain.Event{}
+	for _, e := range events {
+		if !from.IsZero() && e.LastDate().Before(from) {
+			continue
+		}
+		if !to.IsZero() && e.Date.After(to) {
+			continue
+		}
+		if purchased != nil && e.Purchased != *purchased {
+			continue
+		}
+		if venueID != "" && e.Venue.ID.Primary != venueID {
+			continue
+		}
+		if artistID != "" && !slices.ContainsFunc(e.Artists(), func(a domain.Artist) bool { return a.ID.Primary == artistID }) {
+			continue
+		}
+		if genre != "" && !slices.ContainsFunc(e.Artists(), func(a domain.Artist) bool { return hasGenre(a, genre) }) {
+			continue
+		}
+		filtered = append(filtered, e)
+	}
+	return filtered, nil
+}
+
+func hasGenre(artist domain.Artist, genre string) bool {
+	g := artist.Genres
+	for _, genres := range [][]string{g.User, g.Spotify, g.LastFm, g.Ticketmaster} {
+		if slices.ContainsFunc(genres, func(other string) bool { return strings.EqualFold(strings.TrimSpace(other), genre) }) {
+			return true
+		}
+	}
+	return false
+}
+
+var artistSortFields = map[string]sortField[domain.Artist]{
+	"name": func(a domain.Artist) string { return strings.ToLower(a.Name) },
+}
+
+var venueSortFields = map[string]sortField[domain.Venue]{
+	"name":  func(v domain.Venue) string { return strings.ToLower(v.Name) },
+	"city":  func(v domain.Venue) string { return strings.ToLower(v.City) },
+	"state": func(v domain.Venue) string { return strings.ToLower(v.State) },
+}
+
+var albumSortFields = map[string]sortField[domain.Album]{
+	"name": func(a domain.Album) string { return strings.ToLower(a.Name) },
+	"year": func(a domain.Album) string { return fmt.Sprintf("%04d", a.Year) },
+	"artist": func(a domain.Album) string {
+		if len(a.Artists) == 0 {
+			return ""
+		}
+		return strings.ToLower(a.Artists[0].Name)
+	},
+}
+
+var albumFilterParams = []string{"format", "wishlisted", "signed"}
+
+// filterAlbums applies ?format=, which ignores case, ?wishlisted= and ?signed=
+func filterAlbums(albums []domain.Album, params url.Values) ([]domain.Album, error) {
+	wishlisted, err := parseOptionalBool(params, "wishlisted")
+	if err != nil {
+		return nil, err
+	}
+	signed, err := parseOptionalBool(params, "signed")
+	if err != nil {
+		return nil, err
+	}
+	format := strings.TrimSpace(params.Get("format"))
+
+	filtered := []domain.Album{}
+	for _, album := range albums {
+		if format != "" && !strings.EqualFold(album.Format, format) {
+			continue
+		}
+		if wishlisted != nil && album.Wishlisted != *wishlisted {
+			continue
+		}
+		if signed != nil && album.Signed != *signed {
+			continue
+		}
+		filtered = append(filtered, album)
+	}
+	return filtered, nil
+}
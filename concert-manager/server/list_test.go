@@ -0,0 +1,109 @@
+package server
+
+import (
+	"concert-manager/domain"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+func albumIDs(albums []domain.Album) []string {
+	ids := []string{}
+	for _, album := range albums {
+		ids = append(ids, album.ID)
+	}
+	return ids
+}
+
+func albumPage(t *testing.T, albums []domain.Album, query string) listPage[domain.Album] {
+	t.Helper()
+	params, _ := url.ParseQuery(query)
+	page, err := listItems(albums, params, func(a domain.Album) string { return a.ID }, albumSortFields, "name")
+	if err != nil {
+		t.Fatalf("failed to list %s: %v", query, err)
+	}
+	return page
+}
+
+func TestListPagesWithCursor(t *testing.T) {
+	albums := []domain.Album{
+		{ID: "1", Name: "Bravo", Year: 2001},
+		{ID: "2", Name: "alpha", Year: 2003},
+		{ID: "3", Name: "Charlie", Year: 2001},
+		{ID: "4", Name: "Delta", Year: 2002},
+	}
+
+	first := albumPage(t, albums, "limit=2")
+	if !slices.Equal(albumIDs(first.Items), []string{"2", "1"}) || first.Total != 4 || first.Count != 2 || first.NextCursor == "" {
+		t.Fatalf("unexpected first page %+v", first)
+	}
+	// deleting an item already listed doesn't shift the next page
+	second := albumPage(t, albums[1:], "limit=2&cursor="+first.NextCursor)
+	if !slices.Equal(albumIDs(second.Items), []string{"3", "4"}) || second.NextCursor != "" {
+		t.Errorf("unexpected second page %+v", second)
+	}
+
+	byYear := albumPage(t, albums, "sort=-year&limit=3")
+	if !slices.Equal(albumIDs(byYear.Items), []string{"2", "4", "3"}) {
+		t.Errorf("expected descending years with ties by ID, got %+v", byYear.Items)
+	}
+	rest := albumPage(t, albums, "sort=-year&cursor="+byYear.NextCursor)
+	if !slices.Equal(albumIDs(rest.Items), []string{"1"}) {
+		t.Errorf("unexpected last page %+v", rest.Items)
+	}
+
+	params, _ := url.ParseQuery("sort=year&cursor=" + byYear.NextCursor)
+	if _, err := listItems(albums, params, func(a domain.Album) string { return a.ID }, albumSortFields, "name"); err == nil {
+		t.Error("expected error for a cursor from a different sort")
+	}
+	params, _ = url.ParseQuery("sort=color")
+	if _, err := listItems(albums, params, func(a domain.Album) string { return a.ID }, albumSortFields, "name"); err == nil {
+		t.Error("expected error for an unknown sort")
+	}
+}
+
+func TestFilterEvents(t *testing.T) {
+	rock := domain.Artist{Name: "Rock", ID: domain.ID{Primary: "a1"}, Genres: domain.GenreInfo{Spotify: []string{"Indie Rock"}}}
+	jazz := domain.Artist{Name: "Jazz", ID: domain.ID{Primary: "a2"}, Genres: domain.GenreInfo{User: []string{"jazz"}}}
+	festival := domain.Event{
+		ID:       domain.ID{Primary: "fest"},
+		MainAct:  &jazz,
+		Date:     domain.MustParseDate("6/28/2024"),
+		Festival: &domain.Festival{Name: "Fest", EndDate: domain.MustParseDate("7/2/2024")},
+		Venue:    domain.Venue{ID: domain.ID{Primary: "v2"}},
+	}
+	events := []domain.Event{
+		{ID: domain.ID{Primary: "e1"}, MainAct: &rock, Date: domain.MustParseDate("5/1/2024"), Purchased: true, Venue: domain.Venue{ID: domain.ID{Primary: "v1"}}},
+		{ID: domain.ID{Primary: "e2"}, MainAct: &jazz, Openers: []domain.Artist{rock}, Date: domain.MustParseDate("8/1/2024"), Venue: domain.Venue{ID: domain.ID{Primary: "v1"}}},
+		festival,
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "from=7/1/2024&to=7/31/2024", want: []string{"fest"}},
+		{query: "purchased=false", want: []string{"e2", "fest"}},
+		{query: "venueId=v1&artistId=a2", want: []string{"e2"}},
+		{query: "genre=indie%20rock", want: []string{"e1", "e2"}},
+	}
+	for _, test := range tests {
+		params, _ := url.ParseQuery(test.query)
+		filtered, err := filterEvents(events, params)
+		if err != nil {
+			t.Fatalf("%s: failed to filter: %v", test.query, err)
+		}
+		ids := []string{}
+		for _, e := range filtered {
+			ids = append(ids, e.ID.Primary)
+		}
+		if !slices.Equal(ids, test.want) {
+			t.Errorf("%s: expected %v, got %v", test.query, test.want, ids)
+		}
+	}
+
+	params, _ := url.ParseQuery("from=2/30/2024")
+	if _, err := filterEvents(events, params); err == nil {
+		t.Error("expected error for an invalid date")
+	}
+}
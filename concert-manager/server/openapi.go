@@ -0,0 +1,555 @@
+package server
+
+import (
+	"concert-manager/analytics"
+	"concert-manager/backup"
+	"concert-manager/domain"
+	"concert-manager/jobs"
+	"concert-manager/loader"
+	"concert-manager/scheduler"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const apiVersion = "1.0.0"
+
+// operation documents one method of a route. The OpenAPI document is built from these, and the
+// schemas from the Go types the handlers decode and return, so it can't drift from the payloads.
+type operation struct {
+	ID      string
+	Method  string
+	Path    string
+	Route   string
+	Summary string
+	Params  []parameter
+	// request body type, nil when the operation has no JSON body
+	Body reflect.Type
+	// multipart form fields holding uploaded files
+	Files []string
+	// response body type, nil when the operation returns no content
+	Response reflect.Type
+	// the listPage returned instead of Response when list parameters are used
+	ListResponse reflect.Type
+	// public operations don't need an API key
+	Public bool
+	// the work runs as a job, responding with 202 and the job instead of 200
+	Accepted bool
+	// the response has an ETag and If-None-Match is answered with 304, see notModified
+	Cached bool
+}
+
+type parameter struct {
+	Name        string
+	In          string
+	Description string
+	Type        string
+	Enum        []string
+	Required    bool
+	// the parameter can be repeated, e.g. ?artists=a&artists=b
+	Repeated bool
+}
+
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func pathParam(name, description string) parameter {
+	return parameter{Name: name, In: "path", Description: description, Type: "string", Required: true}
+}
+
+func queryParam(name, paramType, description string) parameter {
+	return parameter{Name: name, In: "query", Description: description, Type: paramType}
+}
+
+func enumParam(name, description string, values ...string) parameter {
+	return parameter{Name: name, In: "query", Description: description, Type: "string", Enum: values}
+}
+
+// listParams are the parameters of a list endpoint, see listItems
+func listParams(sortFields []string, filters ...parameter) []parameter {
+	sorts := []string{}
+	for _, field := range sortFields {
+		sorts = append(sorts, field, "-"+field)
+	}
+	params := []parameter{
+		enumParam("sort", "sort field, descending when prefixed with -", sorts...),
+		queryParam("limit", "integer", fmt.Sprintf("page size, up to %d", maxPageSize)),
+		queryParam("cursor", "string", "nextCursor of the previous page, with the same sort"),
+	}
+	return append(params, filters...)
+}
+
+func sortFieldNames[T any](fields map[string]sortField[T]) []string {
+	names := []string{}
+	for name := range fields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// deleteParams are the options for deleting an artist or venue that is still referenced
+var deleteParams = []parameter{
+	queryParam("cascade", "boolean", "also delete the events referencing it, an artist is only taken out of the events it opens"),
+	queryParam("reassignTo", "string", "ID to move the events referencing it to"),
+}
+
+// analyticsOperations documents /v1/analytics/{dim}, counting past events, and
+// /v1/analytics/{dim}/{key}, listing the past events of one key
+func analyticsOperations(dim, name, key, keyDescription string) []operation {
+	return []operation{
+		{
+			ID:       "Get" + name + "Counts",
+			Method:   http.MethodGet,
+			Path:     "/v1/analytics/" + dim,
+			Route:    "/v1/analytics/" + dim,
+			Summary:  fmt.Sprintf("Count past events by %s", strings.TrimSuffix(dim, "s")),
+			Response: typeOf[[]analytics.Count](),
+		},
+		{
+			ID:       "GetEventsBy" + strings.TrimSuffix(name, "s"),
+			Method:   http.MethodGet,
+			Path:     fmt.Sprintf("/v1/analytics/%s/{%s}", dim, key),
+			Route:    "/v1/analytics/" + dim + "/",
+			Summary:  fmt.Sprintf("List the past events of a %s", strings.TrimSuffix(dim, "s")),
+			Params:   []parameter{pathParam(key, keyDescription)},
+			Response: typeOf[analytics.EventsResponse](),
+		},
+	}
+}
+
+var operations = sync.OnceValue(func() []operation {
+	eventFilters := []parameter{
+		queryParam("from", "string", "first date, m/d/yyyy"),
+		queryParam("to", "string", "last date, m/d/yyyy"),
+		queryParam("purchased", "boolean", "whether tickets were bought"),
+		queryParam("venueId", "string", "primary ID of the venue"),
+		queryParam("artistId", "string", "primary ID of an artist in the lineup"),
+		queryParam("genre", "string", "genre of an artist in the lineup"),
+	}
+	albumFilters := []parameter{
+		queryParam("format", "string", "format like LP, ignoring case"),
+		queryParam("wishlisted", "boolean", "whether the album is wishlisted"),
+		queryParam("signed", "boolean", "whether the album is signed"),
+	}
+
+	ops := []operation{
+		{ID: "UploadEvents", Method: http.MethodPost, Path: "/v1/upload", Route: "/v1/upload",
+			Summary: "Add saved events from a CSV file", Files: []string{"file"}, Response: typeOf[string]()},
+		{ID: "GetUpcomingEvents", Method: http.MethodGet, Path: "/v1/events/upcoming", Route: "/v1/events/upcoming",
+			Summary: "List upcoming events near the current location", Response: typeOf[[]domain.EventDetails]()},
+		{ID: "RefreshUpcomingEvents", Method: http.MethodPost, Path: "/v1/events/upcoming/refresh", Route: "/v1/events/upcoming/refresh",
+			Summary: "Start finding the upcoming events again", Response: typeOf[jobs.Job](), Accepted: true},
+		{ID: "GetRecommendedEvents", Method: http.MethodGet, Path: "/v1/events/recommended", Route: "/v1/events/recommended",
+			Summary: "List upcoming events recommended at least at a threshold",
+			Params: []parameter{func() parameter {
+				p := enumParam("threshold", "minimum recommendation", "low", "medium", "high")
+				p.Required = true
+				return p
+			}()},
+			Response: typeOf[[]domain.EventDetails]()},
+
+		{ID: "GetSavedEvents", Method: http.MethodGet, Path: "/v1/events/saved", Route: "/v1/events/saved",
+			Summary:      "List saved events with the albums acquired at them",
+			Params:       append([]parameter{queryParam("id", "string", "only the event with this primary ID")}, listParams(sortFieldNames(eventSortFields), eventFilters...)...),
+			Response:     typeOf[[]savedEventResponse](),
+			ListResponse: typeOf[listPage[savedEventResponse]](),
+			Cached:       true},
+		{ID: "AddSavedEvent", Method: http.MethodPost, Path: "/v1/events/saved", Route: "/v1/events/saved",
+			Summary: "Save an event", Body: typeOf[domain.Event](), Response: typeOf[domain.Event]()},
+		{ID: "UpdateSavedEvent", Method: http.MethodPut, Path: "/v1/events/saved/{id}", Route: "/v1/events/saved/",
+			Summary: "Update a saved event", Params: []parameter{pathParam("id", "primary ID of the event")}, Body: typeOf[domain.Event]()},
+		{ID: "DeleteSavedEvent", Method: http.MethodDelete, Path: "/v1/events/saved/{id}", Route: "/v1/events/saved/",
+			Summary: "Delete a saved event, unlinking its albums", Params: []parameter{pathParam("id", "primary ID of the event")}},
+		{ID: "RefreshSavedEvents", Method: http.MethodPost, Path: "/v1/events/saved/refresh", Route: "/v1/events/saved/refresh",
+			Summary: "Reload the saved events from the database"},
+
+		{ID: "GetVenues", Method: http.MethodGet, Path: "/v1/venues", Route: "/v1/venues",
+			Summary: "List venues", Params: listParams(sortFieldNames(venueSortFields)),
+			Response: typeOf[[]domain.Venue](), ListResponse: typeOf[listPage[domain.Venue]](), Cached: true},
+		{ID: "AddVenue", Method: http.MethodPost, Path: "/v1/venues", Route: "/v1/venues",
+			Summary: "Add a venue", Body: typeOf[domain.Venue](), Response: typeOf[domain.Venue]()},
+		{ID: "UpdateVenue", Method: http.MethodPut, Path: "/v1/venues/{id}", Route: "/v1/venues/",
+			Summary: "Update a venue", Params: []parameter{pathParam("id", "primary ID of the venue")}, Body: typeOf[domain.Venue]()},
+		{ID: "DeleteVenue", Method: http.MethodDelete, Path: "/v1/venues/{id}", Route: "/v1/venues/",
+			Summary: "Delete a venue, failing with 409 while events reference it unless cascading or reassigning",
+			Params:  append([]parameter{pathParam("id", "primary ID of the venue")}, deleteParams...)},
+		{ID: "MergeVenues", Method: http.MethodPost, Path: "/v1/venues/{id}/merge", Route: "/v1/venues/",
+			Summary: "Merge the source venue into this one", Params: []parameter{pathParam("id", "primary ID of the surviving venue")},
+			Body: typeOf[mergeRequest](), Response: typeOf[domain.Venue]()},
+		{ID: "RefreshVenues", Method: http.MethodPost, Path: "/v1/venues/refresh", Route: "/v1/venues/refresh",
+			Summary: "Reload the venues from the database"},
+
+		{ID: "GetAlbums", Method: http.MethodGet, Path: "/v1/albums", Route: "/v1/albums",
+			Summary: "List albums", Params: listParams(sortFieldNames(albumSortFields), albumFilters...),
+			Response: typeOf[[]domain.Album](), ListResponse: typeOf[listPage[domain.Album]](), Cached: true},
+		{ID: "AddAlbum", Method: http.MethodPost, Path: "/v1/albums", Route: "/v1/albums",
+			Summary: "Add an album", Body: typeOf[domain.Album](), Response: typeOf[domain.Album]()},
+		{ID: "UpdateAlbum", Method: http.MethodPut, Path: "/v1/albums/{id}", Route: "/v1/albums/",
+			Summary: "Update an album", Params: []parameter{pathParam("id", "ID of the album")}, Body: typeOf[domain.Album]()},
+		{ID: "DeleteAlbum", Method: http.MethodDelete, Path: "/v1/albums/{id}", Route: "/v1/albums/",
+			Summary: "Delete an album", Params: []parameter{pathParam("id", "ID of the album")}},
+		{ID: "RefreshAlbums", Method: http.MethodPost, Path: "/v1/albums/refresh", Route: "/v1/albums/refresh",
+			Summary: "Start reloading the albums from the database", Response: typeOf[jobs.Job](), Accepted: true},
+		{ID: "UploadAlbumImage", Method: http.MethodPost, Path: "/v1/albums/images", Route: "/v1/albums/images",
+			Summary: "Upload a cover image, returning its url", Files: []string{"image"}, Response: typeOf[map[string]string]()},
+		{ID: "ImportAlbums", Method: http.MethodPost, Path: "/v1/albums/import", Route: "/v1/albums/import",
+			Summary: "Add the albums in a generic or Discogs CSV file",
+			Params: []parameter{
+				enumParam("format", "format of the file, detected from the header when unset", string(loader.GenericFormat), string(loader.DiscogsFormat)),
+				queryParam("dryRun", "boolean", "report what would be imported without saving"),
+			},
+			Files: []string{"file"}, Response: typeOf[loader.AlbumImportReport]()},
+
+		{ID: "GetArtists", Method: http.MethodGet, Path: "/v1/artists", Route: "/v1/artists",
+			Summary: "List artists", Params: listParams(sortFieldNames(artistSortFields)),
+			Response: typeOf[[]domain.Artist](), ListResponse: typeOf[listPage[domain.Artist]](), Cached: true},
+		{ID: "AddArtist", Method: http.MethodPost, Path: "/v1/artists", Route: "/v1/artists",
+			Summary: "Add an artist", Body: typeOf[domain.Artist](), Response: typeOf[domain.Artist]()},
+		{ID: "UpdateArtist", Method: http.MethodPut, Path: "/v1/artists/{id}", Route: "/v1/artists/",
+			Summary: "Update an artist", Params: []parameter{pathParam("id", "primary ID of the artist")}, Body: typeOf[domain.Artist]()},
+		{ID: "DeleteArtist", Method: http.MethodDelete, Path: "/v1/artists/{id}", Route: "/v1/artists/",
+			Summary: "Delete an artist, failing with 409 while events reference it unless cascading or reassigning",
+			Params:  append([]parameter{pathParam("id", "primary ID of the artist")}, deleteParams...)},
+		{ID: "MergeArtists", Method: http.MethodPost, Path: "/v1/artists/{id}/merge", Route: "/v1/artists/",
+			Summary: "Merge the source artist into this one", Params: []parameter{pathParam("id", "primary ID of the surviving artist")},
+			Body: typeOf[mergeRequest](), Response: typeOf[domain.Artist]()},
+		{ID: "RefreshArtists", Method: http.MethodPost, Path: "/v1/artists/refresh", Route: "/v1/artists/refresh",
+			Summary: "Reload the artists from the database"},
+
+		{ID: "RefreshRanks", Method: http.MethodPost, Path: "/v1/ranks/refresh", Route: "/v1/ranks/refresh",
+			Summary: "Start recalculating the ranks of upcoming events", Response: typeOf[jobs.Job](), Accepted: true},
+		{ID: "GetGenres", Method: http.MethodGet, Path: "/v1/genres", Route: "/v1/genres",
+			Summary: "List the genres of every artist by source", Response: typeOf[domain.GenreResponse]()},
+		{ID: "ReloadGenres", Method: http.MethodGet, Path: "/v1/genres/refresh", Route: "/v1/genres/refresh",
+			Summary:  "Start looking up the genres of artists again, failing with 409 while a reload of other artists runs",
+			Params:   []parameter{{Name: "artists", In: "query", Description: "names of the artists, every artist when unset", Type: "string", Repeated: true}},
+			Response: typeOf[jobs.Job](), Accepted: true},
+		{ID: "Search", Method: http.MethodGet, Path: "/v1/search", Route: "/v1/search",
+			Summary: "Search saved events, artists, venues, albums and upcoming events",
+			Params: []parameter{
+				{Name: "q", In: "query", Description: "search terms", Type: "string", Required: true},
+				queryParam("tolerance", "string", "exact, strict, moderate, lenient or a share of each word between 0 and 1"),
+				queryParam("types", "string", "comma separated result types"),
+				queryParam("limit", "integer", fmt.Sprintf("maximum results, %d by default", defaultSearchLimit)),
+			},
+			Response: typeOf[searchResponse]()},
+		{ID: "Sync", Method: http.MethodGet, Path: "/v1/sync", Route: "/v1/sync",
+			Summary:  "List the saved data created, updated and deleted since a previous sync",
+			Params:   []parameter{queryParam("since", "string", "token of the previous sync, everything is listed with reset set when unset or expired")},
+			Response: typeOf[domain.Changes]()},
+		{ID: "GetJobs", Method: http.MethodGet, Path: "/v1/jobs", Route: "/v1/jobs",
+			Summary: "List the running and recent jobs, newest first",
+			Params: []parameter{
+				enumParam("kind", "only jobs of this kind", jobKinds...),
+				enumParam("status", "only jobs with this status", jobStatuses...),
+			},
+			Response: typeOf[[]jobs.Job]()},
+		{ID: "GetJob", Method: http.MethodGet, Path: "/v1/jobs/{id}", Route: "/v1/jobs/",
+			Summary: "Get a job", Params: []parameter{pathParam("id", "ID of the job")}, Response: typeOf[jobs.Job]()},
+		{ID: "CancelJob", Method: http.MethodPost, Path: "/v1/jobs/{id}/cancel", Route: "/v1/jobs/",
+			Summary:  "Ask a running job to stop, failing with 409 once it finished",
+			Params:   []parameter{pathParam("id", "ID of the job")},
+			Response: typeOf[jobs.Job]()},
+		{ID: "GetSchedules", Method: http.MethodGet, Path: "/v1/schedules", Route: "/v1/schedules",
+			Summary:  "List the scheduled refreshes with their next and last runs",
+			Response: typeOf[[]scheduler.TaskStatus]()},
+		{ID: "GetSchedule", Method: http.MethodGet, Path: "/v1/schedules/{kind}", Route: "/v1/schedules/",
+			Summary:  "Get the scheduled refresh of a job kind",
+			Params:   []parameter{pathParam("kind", "job kind of the refresh")},
+			Response: typeOf[scheduler.TaskStatus]()},
+		{ID: "GetOpenAPI", Method: http.MethodGet, Path: "/v1/openapi.json", Route: "/v1/openapi.json",
+			Summary: "This document", Response: typeOf[map[string]any]()},
+
+		{ID: "GetAnalyticsSummary", Method: http.MethodGet, Path: "/v1/analytics/summary", Route: "/v1/analytics/summary",
+			Summary: "Summarize past events", Response: typeOf[analytics.Summary]()},
+		{ID: "GetSpending", Method: http.MethodGet, Path: "/v1/analytics/spending", Route: "/v1/analytics/spending",
+			Summary: "Total ticket spending, including upcoming events", Response: typeOf[analytics.SpendingSummary]()},
+		{ID: "GetRatedEvents", Method: http.MethodGet, Path: "/v1/analytics/ratings", Route: "/v1/analytics/ratings",
+			Summary:  "List past events rated at least min stars",
+			Params:   []parameter{queryParam("min", "integer", fmt.Sprintf("minimum rating from 1 to %d", domain.MaxRating))},
+			Response: typeOf[analytics.EventsResponse]()},
+		{ID: "GetRatings", Method: http.MethodGet, Path: "/v1/analytics/ratings/{dimension}", Route: "/v1/analytics/ratings/",
+			Summary: "List average ratings, highest first",
+			Params: []parameter{func() parameter {
+				p := pathParam("dimension", "what to average the ratings by")
+				p.Enum = []string{"venues", "artists", "years"}
+				return p
+			}()},
+			Response: typeOf[[]analytics.Rating]()},
+		{ID: "GetAlbumSummary", Method: http.MethodGet, Path: "/v1/analytics/albums", Route: "/v1/analytics/albums",
+			Summary: "Count the albums acquired at past shows", Response: typeOf[analytics.AlbumSummary]()},
+		{ID: "GetAlbumsByEvent", Method: http.MethodGet, Path: "/v1/analytics/albums/{eventId}", Route: "/v1/analytics/albums/",
+			Summary: "List the albums acquired at an event", Params: []parameter{pathParam("eventId", "primary ID of the event")},
+			Response: typeOf[analytics.AlbumsResponse]()},
+
+		{ID: "GetBackup", Method: http.MethodGet, Path: "/v1/backup", Route: "/v1/backup",
+			Summary: "Export every saved venue, artist, event and album", Response: typeOf[backup.Archive]()},
+		{ID: "RestoreBackup", Method: http.MethodPost, Path: "/v1/restore", Route: "/v1/restore",
+			Summary: "Restore an exported backup",
+			Params:  []parameter{enumParam("mode", "replace also deletes the data missing from the archive once it is written, merge is the default", string(backup.ReplaceMode), string(backup.MergeMode))},
+			Body:    typeOf[backup.Archive](), Response: typeOf[backup.Summary]()},
+
+		{ID: "StartSpotifyAuth", Method: http.MethodGet, Path: "/v1/spotify/auth/start", Route: "/v1/spotify/auth/start",
+			Summary: "Start authorizing Spotify, returning the url to open", Response: typeOf[spotifyReauthResponse]()},
+		{ID: "GetSpotifyAuthStatus", Method: http.MethodGet, Path: "/v1/spotify/auth/status", Route: "/v1/spotify/auth/status",
+			Summary: "Whether Spotify is authorized", Response: typeOf[spotifyAuthStatusResponse]()},
+		{ID: "SpotifyAuthCallback", Method: http.MethodGet, Path: "/v1/spotify/auth/callback", Route: "/v1/spotify/auth/callback",
+			Summary: "Redirect from Spotify, which redirects on to the app", Public: true,
+			Params: []parameter{
+				queryParam("code", "string", "authorization code"),
+				queryParam("state", "string", "state from StartSpotifyAuth"),
+				queryParam("error", "string", "set when authorization failed"),
+			}},
+	}
+	ops = append(ops, analyticsOperations("years", "Year", "year", "year like 2024")...)
+	ops = append(ops, analyticsOperations("months", "Month", "month", "month like 2024-03")...)
+	ops = append(ops, analyticsOperations("artists", "Artist", "artistId", "primary ID of the artist")...)
+	ops = append(ops, analyticsOperations("venues", "Venue", "venueId", "primary ID of the venue")...)
+	ops = append(ops, analyticsOperations("genres", "Genre", "genre", "genre, ignoring case")...)
+	ops = append(ops, analyticsOperations("companions", "Companion", "companion", "name of the companion, ignoring case")...)
+	return ops
+})
+
+func (s *Server) getOpenAPI(w http.ResponseWriter, r *http.Request) (any, int, error) {
+	if r.Method != http.MethodGet {
+		return nil, http.StatusMethodNotAllowed, errors.New("unsupported method")
+	}
+	return openAPIDocument(), 0, nil
+}
+
+var openAPIDocument = sync.OnceValue(func() map[string]any {
+	return buildOpenAPI(operations())
+})
+
+// buildOpenAPI describes the operations as an OpenAPI 3.0 document
+func buildOpenAPI(ops []operation) map[string]any {
+	schemas := schemaBuilder{components: map[string]any{}}
+	paths := map[string]map[string]any{}
+	for _, op := range ops {
+		doc := map[string]any{
+			"operationId": op.ID,
+			"summary":     op.Summary,
+			"responses":   schemas.responses(op),
+		}
+		if op.Public {
+			doc["security"] = []any{}
+		}
+		params := op.Params
+		if op.Cached {
+			params = append(slices.Clip(params), parameter{Name: "If-None-Match", In: "header", Type: "string",
+				Description: "ETag of a previous response, answered with 304 while nothing changed"})
+		}
+		if len(params) > 0 {
+			docParams := []any{}
+			for _, p := range params {
+				docParams = append(docParams, p.document())
+			}
+			doc["parameters"] = docParams
+		}
+		if op.Body != nil {
+			doc["requestBody"] = map[string]any{
+				"required": true,
+				"content":  map[string]any{"application/json": map[string]any{"schema": schemas.schema(op.Body)}},
+			}
+		} else if len(op.Files) > 0 {
+			properties := map[string]any{}
+			for _, file := range op.Files {
+				properties[file] = map[string]any{"type": "string", "format": "binary"}
+			}
+			form := map[string]any{"type": "object", "properties": properties, "required": op.Files}
+			doc["requestBody"] = map[string]any{
+				"required": true,
+				"content":  map[string]any{"multipart/form-data": map[string]any{"schema": form}},
+			}
+		}
+		if paths[op.Path] == nil {
+			paths[op.Path] = map[string]any{}
+		}
+		paths[op.Path][strings.ToLower(op.Method)] = doc
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":       "Concert Manager API",
+			"version":     apiVersion,
+			"description": "Every request needs the API key of a user as a bearer token. Errors are returned as an error object with a stable code, a message and the request ID.",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas.components,
+			"securitySchemes": map[string]any{
+				"apiKey": map[string]any{"type": "http", "scheme": "bearer"},
+			},
+		},
+		"security": []any{map[string]any{"apiKey": []any{}}},
+	}
+}
+
+func (p parameter) document() map[string]any {
+	schema := map[string]any{"type": p.Type}
+	if len(p.Enum) > 0 {
+		schema["enum"] = p.Enum
+	}
+	if p.Repeated {
+		schema = map[string]any{"type": "array", "items": schema}
+	}
+	doc := map[string]any{"name": p.Name, "in": p.In, "schema": schema}
+	if p.Description != "" {
+		doc["description"] = p.Description
+	}
+	if p.Required {
+		doc["required"] = true
+	}
+	return doc
+}
+
+func (b *schemaBuilder) responses(op operation) map[string]any {
+	responses := map[string]any{
+		"default": map[string]any{
+			"description": "error",
+			"content":     map[string]any{"application/json": map[string]any{"schema": b.schema(typeOf[errorResponse]())}},
+		},
+	}
+	switch {
+	case op.Public:
+		responses["302"] = map[string]any{"description": "redirect to the app"}
+	case op.Response == nil:
+		responses["200"] = map[string]any{"description": "no content"}
+	default:
+		schema := b.schema(op.Response)
+		description := "success"
+		if op.ListResponse != nil {
+			schema = map[string]any{"oneOf": []any{schema, b.schema(op.ListResponse)}}
+			description = "the whole list, or a page of it when any list parameter is set"
+		}
+		status := "200"
+		if op.Accepted {
+			status, description = "202", "the started job, or the one of the same kind already running"
+		}
+		responses[status] = map[string]any{
+			"description": description,
+			"content":     map[string]any{"application/json": map[string]any{"schema": schema}},
+		}
+	}
+	if op.Cached {
+		responses["200"].(map[string]any)["headers"] = map[string]any{
+			"ETag": map[string]any{"schema": map[string]any{"type": "string"}},
+		}
+		responses["304"] = map[string]any{"description": "not modified since the If-None-Match ETag"}
+	}
+	return responses
+}
+
+// schemaBuilder converts Go types to schemas the way encoding/json would encode them,
+// adding named structs to the components
+type schemaBuilder struct {
+	components map[string]any
+}
+
+var (
+	dateType      = typeOf[domain.Date]()
+	timeOfDayType = typeOf[domain.TimeOfDay]()
+	timeType      = typeOf[time.Time]()
+)
+
+func (b *schemaBuilder) schema(t reflect.Type) map[string]any {
+	switch t {
+	case dateType:
+		return map[string]any{"type": "string", "description": "m/d/yyyy, empty when unset", "example": "7/12/2024"}
+	case timeOfDayType:
+		return map[string]any{"type": "string", "description": "HH:MM", "example": "19:30"}
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := b.schema(t.Elem())
+		if _, ok := schema["$ref"]; ok {
+			return map[string]any{"allOf": []any{schema}, "nullable": true}
+		}
+		schema["nullable"] = true
+		return schema
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Struct:
+		name := schemaName(t)
+		if name == "" {
+			return b.object(t)
+		}
+		if _, exists := b.components[name]; !exists {
+			// reserved first so recursive types refer to themselves
+			b.components[name] = nil
+			b.components[name] = b.object(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	}
+	return map[string]any{}
+}
+
+// object lists the JSON fields of a struct, including those of embedded structs
+func (b *schemaBuilder) object(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	var addFields func(reflect.Type)
+	addFields = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag := field.Tag.Get("json")
+			name, _, _ := strings.Cut(tag, ",")
+			if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+				continue
+			}
+			if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+				addFields(field.Type)
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			properties[name] = b.schema(field.Type)
+		}
+	}
+	addFields(t)
+	return map[string]any{"type": "object", "properties": properties}
+}
+
+// schemaName names domain and server types by their own name and other types with their package
+// prefixed, like AnalyticsSummary. Generic types are left inline.
+func schemaName(t reflect.Type) string {
+	name := t.Name()
+	if name == "" || strings.Contains(name, "[") {
+		return ""
+	}
+	name = upperFirst(name)
+	pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+	if pkg == "domain" || pkg == "server" {
+		return name
+	}
+	return upperFirst(pkg) + name
+}
+
+func upperFirst(s string) string {
+	runes := []rune(s)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+var pathParamPattern = regexp.MustCompile(`\{[^}]+\}`)
+
+func TestOperationsCoverRoutes(t *testing.T) {
+	s := &Server{}
+	mux := s.Routes()
+	documented := map[string]bool{}
+	ids := map[string]bool{}
+	for _, op := range operations() {
+		if ids[op.ID] {
+			t.Errorf("duplicate operation ID %s", op.ID)
+		}
+		ids[op.ID] = true
+		documented[op.Route] = true
+
+		// the documented path has to reach the route it claims to be served by
+		path := pathParamPattern.ReplaceAllString(op.Path, "x")
+		if _, pattern := mux.Handler(httptest.NewRequest(op.Method, path, nil)); pattern != op.Route {
+			t.Errorf("%s: %s is served by %q, not %q", op.ID, op.Path, pattern, op.Route)
+		}
+	}
+	for _, rt := range s.routes() {
+		if !documented[rt.pattern] {
+			t.Errorf("route %s has no documented operation", rt.pattern)
+		}
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	s := &Server{}
+	rec := httptest.NewRecorder()
+	s.handleRequest(s.getOpenAPI)(rec, httptest.NewRequest(http.MethodGet, "/v1/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+
+	var doc struct {
+		Paths      map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]any `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid document: %v", err)
+	}
+	if _, ok := doc.Paths["/v1/venues/{id}"]["delete"]; !ok {
+		t.Errorf("expected DELETE /v1/venues/{id}, got %v", doc.Paths["/v1/venues/{id}"])
+	}
+	// the embedded event is flattened into the saved event response like encoding/json does
+	saved := doc.Components.Schemas["SavedEventResponse"].Properties
+	for _, field := range []string{"mainAct", "date", "albums"} {
+		if _, ok := saved[field]; !ok {
+			t.Errorf("expected %s in SavedEventResponse, got %v", field, saved)
+		}
+	}
+	if _, ok := doc.Components.Schemas["BackupSummary"]; !ok {
+		t.Error("expected BackupSummary to be named apart from AnalyticsSummary")
+	}
+}
@@ -0,0 +1,36 @@
+package server
+
+import (
+	"concert-manager/scheduler"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+type scheduleReporter interface {
+	Statuses() []scheduler.TaskStatus
+	Status(string) (scheduler.TaskStatus, error)
+}
+
+// handleSchedules lists the scheduled refreshes with their next and last runs, or gets the one of
+// a job kind at /v1/schedules/{kind}
+func (s *Server) handleSchedules(w http.ResponseWriter, r *http.Request) (any, int, error) {
+	if s.Scheduler == nil {
+		return nil, http.StatusNotImplemented, errors.New("scheduled refreshes are not supported")
+	}
+	if r.Method != http.MethodGet {
+		return nil, http.StatusMethodNotAllowed, errors.New("unsupported method")
+	}
+	pathParts := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+	switch len(pathParts) {
+	case 3:
+		return s.Scheduler.Statuses(), 0, nil
+	case 4:
+		status, err := s.Scheduler.Status(pathParts[3])
+		if err != nil {
+			return nil, http.StatusNotFound, err
+		}
+		return status, 0, nil
+	}
+	return nil, http.StatusNotFound, errors.New("unknown schedule path")
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"concert-manager/jobs"
+	"concert-manager/scheduler"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSchedules(t *testing.T) {
+	s := newSearchServer(t)
+	request := func(url string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.Routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		return rec
+	}
+	if rec := request("/v1/schedules"); rec.Code != http.StatusNotImplemented {
+		t.Errorf("expected not implemented without a scheduler, got %d", rec.Code)
+	}
+
+	manager := &jobs.Manager{}
+	s.Jobs = manager
+	sched := &scheduler.Scheduler{Jobs: manager, Jitter: time.Minute}
+	for _, task := range s.RefreshTasks(map[string]string{SavedDataJob: "@daily"}) {
+		if err := sched.Add(task); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sched.Start(ctx)
+	s.Scheduler = sched
+	// a refresh started by hand is the last run of its schedule too
+	manual := manager.Start(SavedDataJob, func(context.Context) (any, error) { return nil, nil })
+
+	var statuses []scheduler.TaskStatus
+	rec := request("/v1/schedules")
+	if json.NewDecoder(rec.Body).Decode(&statuses) != nil || len(statuses) != 4 {
+		t.Fatalf("expected a schedule for each refresh, got %d %s", rec.Code, rec.Body)
+	}
+	for _, status := range statuses {
+		if status.Kind != SavedDataJob && (status.Schedule != scheduler.Off || status.NextRun != nil) {
+			t.Errorf("expected %s to be off, got %+v", status.Kind, status)
+		}
+	}
+
+	var status scheduler.TaskStatus
+	rec = request("/v1/schedules/" + SavedDataJob)
+	if json.NewDecoder(rec.Body).Decode(&status) != nil || status.Schedule != "@daily" {
+		t.Fatalf("expected the saved data schedule, got %d %s", rec.Code, rec.Body)
+	}
+	if status.NextRun == nil || status.NextRun.Before(time.Now()) || time.Until(*status.NextRun) > 25*time.Hour {
+		t.Errorf("expected the next run within a day, got %v", status.NextRun)
+	}
+	if status.LastRun == nil || status.LastRun.ID != manual.ID {
+		t.Errorf("expected the manual refresh as the last run, got %+v", status.LastRun)
+	}
+	if rec := request("/v1/schedules/missing"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected not found, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"concert-manager/search"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const defaultSearchLimit = 20
+
+var searchTolerances = map[string]float64{
+	"exact":    search.ExactTolerance,
+	"strict":   search.StrictTolerance,
+	"moderate": search.ModerateTolerance,
+	"lenient":  search.LenientTolerance,
+}
+
+type searchResponse struct {
+	Query   string          `json:"query"`
+	Count   int             `json:"count"`
+	Results []search.Result `json:"results"`
+}
+
+// handleSearch searches saved events, artists, venues, albums and upcoming events for ?q=.
+// ?tolerance= is exact, strict, moderate (the default), lenient or a fraction of the query length,
+// ?types= limits the search to a comma separated list of result types and ?limit= caps the results.
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) (any, int, error) {
+	if r.Method != http.MethodGet {
+		return nil, http.StatusMethodNotAllowed, errors.New("unsupported method")
+	}
+	params := r.URL.Query()
+	query := strings.TrimSpace(params.Get("q"))
+	if query == "" {
+		return nil, http.StatusBadRequest, errors.New("missing search query q")
+	}
+
+	tolerance := search.ModerateTolerance
+	if value := params.Get("tolerance"); value != "" {
+		named, ok := searchTolerances[value]
+		parsed, err := strconv.ParseFloat(value, 64)
+		switch {
+		case ok:
+			tolerance = named
+		case err == nil && parsed >= 0 && parsed <= 1:
+			tolerance = parsed
+		default:
+			return nil, http.StatusBadRequest, errors.New("tolerance must be exact, strict, moderate, lenient or between 0 and 1")
+		}
+	}
+
+	limit := defaultSearchLimit
+	if value := params.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxPageSize {
+			errMsg := fmt.Sprintf("limit must be between 1 and %d", maxPageSize)
+			return nil, http.StatusBadRequest, errors.New(errMsg)
+		}
+		limit = parsed
+	}
+
+	types := search.ResultTypes
+	if value := params.Get("types"); value != "" {
+		types = []search.ResultType{}
+		for _, name := range strings.Split(value, ",") {
+			resultType := search.ResultType(strings.TrimSpace(name))
+			if !slices.Contains(search.ResultTypes, resultType) {
+				errMsg := fmt.Sprintf("unsupported search type %s", name)
+				return nil, http.StatusBadRequest, errors.New(errMsg)
+			}
+			types = append(types, resultType)
+		}
+	}
+
+	sources := search.Sources{}
+	if slices.Contains(types, search.SavedEventResult) {
+		sources.SavedEvents = s.SavedEventCache.GetSavedEvents()
+	}
+	if slices.Contains(types, search.ArtistResult) {
+		sources.Artists = s.ArtistCache.GetArtists()
+	}
+	if slices.Contains(types, search.VenueResult) {
+		sources.Venues = s.VenueCache.GetVenues()
+	}
+	if slices.Contains(types, search.AlbumResult) {
+		sources.Albums = s.AlbumCache.GetAlbums()
+	}
+	if slices.Contains(types, search.UpcomingEventResult) {
+		// searching never waits on a Ticketmaster refresh, the source is skipped until events are loaded
+		sources.UpcomingEvents = s.UpcomingEventsCache.CachedUpcomingEvents()
+	}
+
+	results := s.searchEngine.SearchAll(query, sources, limit, tolerance)
+	return searchResponse{Query: query, Count: len(results), Results: results}, 0, nil
+}
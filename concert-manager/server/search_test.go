@@ -0,0 +1,100 @@
+package server
+
+import (
+	"concert-manager/db"
+	"concert-manager/domain"
+	"concert-manager/search"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func newSearchServer(t *testing.T) *Server {
+	cache := &db.Cache{Database: db.NewMemoryRepository()}
+	cache.LoadCaches()
+	event := domain.Event{
+		MainAct: &domain.Artist{Name: "Radiohead"},
+		Venue:   domain.Venue{Name: "Fox Theatre", City: "Atlanta", State: "GA"},
+		Date:    domain.MustParseDate("3/14/2024"),
+	}
+	saved, err := cache.AddSavedEvent(event)
+	if err != nil {
+		t.Fatalf("failed to add event: %v", err)
+	}
+	if _, err := cache.AddAlbum(domain.Album{Name: "Kid A", Artists: []domain.Artist{*saved.MainAct}}); err != nil {
+		t.Fatalf("failed to add album: %v", err)
+	}
+	return &Server{
+		SavedEventCache:     cache,
+		ArtistCache:         cache,
+		VenueCache:          cache,
+		AlbumCache:          cache,
+		UpcomingEventsCache: &stubUpcomingEvents{city: "Atlanta"},
+	}
+}
+
+func TestSearch(t *testing.T) {
+	s := newSearchServer(t)
+
+	tests := []struct {
+		query  string
+		status int
+		types  []search.ResultType
+	}{
+		{query: "q=radiohed", status: http.StatusOK, types: []search.ResultType{search.SavedEventResult, search.ArtistResult, search.AlbumResult}},
+		{query: "q=kid%20a&tolerance=exact", status: http.StatusOK, types: []search.ResultType{search.AlbumResult}},
+		{query: "q=fox%20theater&types=venue", status: http.StatusOK, types: []search.ResultType{search.VenueResult}},
+		{query: "q=", status: http.StatusBadRequest},
+		{query: "q=fox&tolerance=2", status: http.StatusBadRequest},
+		{query: "q=fox&types=song", status: http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		s.handleRequest(s.handleSearch)(rec, httptest.NewRequest(http.MethodGet, "/v1/search?"+test.query, nil))
+		if rec.Code != test.status {
+			t.Errorf("%s: expected status %d, got %d, %s", test.query, test.status, rec.Code, rec.Body.String())
+			continue
+		}
+		if test.status != http.StatusOK {
+			continue
+		}
+		var response searchResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("%s: invalid response %v", test.query, err)
+		}
+		types := []search.ResultType{}
+		for _, result := range response.Results {
+			types = append(types, result.Type)
+			if result.Score <= 0 || result.Score > 1 {
+				t.Errorf("%s: unexpected score %v", test.query, result.Score)
+			}
+		}
+		if !slices.Equal(types, test.types) {
+			t.Errorf("%s: expected %v, got %v", test.query, test.types, types)
+		}
+	}
+}
+
+func TestSearchUpcomingEventsOnlyCached(t *testing.T) {
+	s := newSearchServer(t)
+	upcoming := s.UpcomingEventsCache.(*stubUpcomingEvents)
+	search := func() searchResponse {
+		rec := httptest.NewRecorder()
+		s.handleRequest(s.handleSearch)(rec, httptest.NewRequest(http.MethodGet, "/v1/search?q=radiohead&types=upcomingEvent", nil))
+		var response searchResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); rec.Code != http.StatusOK || err != nil {
+			t.Fatalf("unexpected search response %d %s", rec.Code, rec.Body)
+		}
+		return response
+	}
+
+	if response := search(); response.Count != 0 || upcoming.loads != 0 {
+		t.Errorf("expected nothing found without loading upcoming events, got %+v after %d loads", response, upcoming.loads)
+	}
+	upcoming.cached = []domain.EventDetails{{Event: domain.Event{MainAct: &domain.Artist{Name: "Radiohead"}}}}
+	if response := search(); response.Count != 1 || upcoming.loads != 0 {
+		t.Errorf("expected the cached upcoming event, got %+v after %d loads", response, upcoming.loads)
+	}
+}
@@ -1,21 +1,23 @@
 package server
 
 import (
+	"concert-manager/backup"
 	"concert-manager/domain"
 	"concert-manager/finder"
+	"concert-manager/loader"
 	"concert-manager/log"
 	"concert-manager/ranker"
+	"concert-manager/search"
 	"context"
 	"encoding/json"
 	"io"
 	"net/http"
-	"slices"
-	"strings"
 	"time"
 )
 
 type Server struct {
 	EventLoader         eventLoader
+	AlbumLoader         albumLoader
 	ArtistInfoLoader    artistInfoLoader
 	SavedEventCache     savedEventStore
 	ArtistCache         artistStore
@@ -26,13 +28,22 @@ type Server struct {
 	SyncService         dataSyncService
 	ImageUploader       imageUploader
 	SpotifyAuthHandler  spotifyOAuthHandler
-	ApiKey              string
+	BackupService       backupService
+	ChangeTracker       changeTracker
+	Jobs                jobRunner
+	Scheduler           scheduleReporter
+	// keeps the search indexes between requests
+	searchEngine search.Engine
 }
 
 type eventLoader interface {
 	Upload(context.Context, io.ReadCloser) (int, error)
 }
 
+type albumLoader interface {
+	Import(context.Context, io.Reader, loader.AlbumFormat, bool) (*loader.AlbumImportReport, error)
+}
+
 type artistInfoLoader interface {
 	ReloadGenres(context.Context, []string) (int, error)
 }
@@ -41,6 +52,7 @@ type savedEventStore interface {
 	GetSavedEvents() []domain.Event
 	GetPassedSavedEvents() []domain.Event
 	AddSavedEvent(domain.Event) (*domain.Event, error)
+	UpdateSavedEvent(string, domain.Event) error
 	DeleteSavedEvent(string) error
 	RefreshSavedEvents() error
 }
@@ -50,6 +62,9 @@ type artistStore interface {
 	AddArtist(domain.Artist) (*domain.Artist, error)
 	UpdateArtist(string, domain.Artist) error
 	DeleteArtist(string) error
+	DeleteArtistCascade(string) (domain.References, error)
+	DeleteArtistReassign(string, string) (domain.References, error)
+	MergeArtists(string, string) (*domain.Artist, domain.References, error)
 	RefreshArtists() error
 	GetUniqueGenres() domain.GenreResponse
 }
@@ -59,6 +74,9 @@ type venueStore interface {
 	AddVenue(domain.Venue) (*domain.Venue, error)
 	UpdateVenue(string, domain.Venue) error
 	DeleteVenue(string) error
+	DeleteVenueCascade(string) (domain.References, error)
+	DeleteVenueReassign(string, string) (domain.References, error)
+	MergeVenues(string, string) (*domain.Venue, domain.References, error)
 	RefreshVenues() error
 }
 
@@ -72,9 +90,10 @@ type albumStore interface {
 
 type upcomingEventsStore interface {
 	GetUpcomingEvents() []domain.EventDetails
+	CachedUpcomingEvents() []domain.EventDetails
 	ChangeLocation(string, string)
 	GetLocation() finder.Location
-	RefreshUpcomingEvents() error
+	RefreshUpcomingEvents(context.Context) error
 	GetRecommendedEvents(ranker.RecLevel) []domain.EventDetails
 }
 
@@ -82,108 +101,116 @@ type dataSyncService interface {
 	SyncArtistAdd(string) error
 	SyncArtistUpdate(string) error
 	SyncArtistDelete(string)
+	SyncArtistMerge(string, string) error
 	SyncVenueAdd(string) error
 	SyncVenueUpdate(string) error
 	SyncVenueDelete(string)
+	SyncVenueMerge(string, string) error
 	SyncEventAdd(string) error
 	SyncEventUpdate(string) error
 	SyncEventDelete(string)
 }
 
 type ranksRefresher interface {
-	DoRefresh()
+	Refresh(context.Context) error
+}
+
+type backupService interface {
+	Export(context.Context) (*backup.Archive, error)
+	Restore(context.Context, backup.Archive, backup.Mode) (*backup.Summary, error)
 }
 
 type imageUploader interface {
 	UploadImage(context.Context, io.Reader, string) (string, error)
 }
 
-const port = ":3001"
-
-func (s *Server) StartServer() {
-	http.HandleFunc("/v1/upload", s.handleRequest(s.handleUpload))
-	http.HandleFunc("/v1/events/upcoming", s.handleRequest(s.getUpcomingEvents))
-	http.HandleFunc("/v1/events/upcoming/refresh", s.handleRequest(s.refreshUpcomingEvents))
-	http.HandleFunc("/v1/events/recommended", s.handleRequest(s.getRecommendations))
-	http.HandleFunc("/v1/events/saved", s.handleRequest(s.handleSavedEvents))
-	http.HandleFunc("/v1/events/saved/", s.handleRequest(s.handleSavedEvents))
-	http.HandleFunc("/v1/events/saved/refresh", s.handleRequest(s.refreshSavedEvents))
-	http.HandleFunc("/v1/venues", s.handleRequest(s.handleVenues))
-	http.HandleFunc("/v1/venues/", s.handleRequest(s.handleVenues))
-	http.HandleFunc("/v1/venues/refresh", s.handleRequest(s.refreshVenues))
-	http.HandleFunc("/v1/albums", s.handleRequest(s.handleAlbums))
-	http.HandleFunc("/v1/albums/", s.handleRequest(s.handleAlbums))
-	http.HandleFunc("/v1/albums/refresh", s.handleRequest(s.refreshAlbums))
-	http.HandleFunc("/v1/albums/images", s.handleRequest(s.handleAlbumImages))
-	http.HandleFunc("/v1/artists", s.handleRequest(s.handleArtists))
-	http.HandleFunc("/v1/artists/", s.handleRequest(s.handleArtists))
-	http.HandleFunc("/v1/artists/refresh", s.handleRequest(s.refreshArtists))
-	http.HandleFunc("/v1/ranks/refresh", s.handleRequest(s.refreshRanks))
-	http.HandleFunc("/v1/genres", s.handleRequest(s.handleGenres))
-	http.HandleFunc("/v1/genres/refresh", s.handleRequest(s.reloadGenres))
-	http.HandleFunc("/v1/analytics/summary", s.handleRequest(s.getAnalyticsSummary))
-	http.HandleFunc("/v1/analytics/years", s.handleRequest(s.handleAnalyticsYears))
-	http.HandleFunc("/v1/analytics/years/", s.handleRequest(s.handleAnalyticsYears))
-	http.HandleFunc("/v1/analytics/months", s.handleRequest(s.handleAnalyticsMonths))
-	http.HandleFunc("/v1/analytics/months/", s.handleRequest(s.handleAnalyticsMonths))
-	http.HandleFunc("/v1/analytics/artists", s.handleRequest(s.handleAnalyticsArtists))
-	http.HandleFunc("/v1/analytics/artists/", s.handleRequest(s.handleAnalyticsArtists))
-	http.HandleFunc("/v1/analytics/venues", s.handleRequest(s.handleAnalyticsVenues))
-	http.HandleFunc("/v1/analytics/venues/", s.handleRequest(s.handleAnalyticsVenues))
-	http.HandleFunc("/v1/analytics/genres", s.handleRequest(s.handleAnalyticsGenres))
-	http.HandleFunc("/v1/analytics/genres/", s.handleRequest(s.handleAnalyticsGenres))
-	http.HandleFunc("/v1/spotify/auth/start", s.handleRequest(s.startSpotifyAuth))
-	http.HandleFunc("/v1/spotify/auth/status", s.handleRequest(s.getSpotifyAuthStatus))
-	// doesn't use handleRequest for custom deep-link response
-	http.HandleFunc("/v1/spotify/auth/callback", s.handleSpotifyAuthCallback)
-
-	log.Info("Starting server on port", port)
-	log.Fatal(http.ListenAndServe(port, s.authMiddleware(http.DefaultServeMux)))
-}
-
-// unauthenticated for OAuth callback
-var publicPaths = map[string]bool{
-	"/v1/spotify/auth/callback": true,
-}
-
-func (s *Server) authMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if publicPaths[r.URL.Path] {
-			next.ServeHTTP(w, r)
-			return
-		}
-		const prefix = "Bearer "
-		header := r.Header.Get("Authorization")
-		if !strings.HasPrefix(header, prefix) {
-			log.Infof("Unauthorized request to %s from %s: missing bearer token", r.URL.Path, r.RemoteAddr)
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
-			return
-		}
-		got := strings.TrimPrefix(header, prefix)
-		if slices.Compare([]byte(got), []byte(s.ApiKey)) != 0 {
-			log.Infof("Unauthorized request to %s from %s: invalid bearer token", r.URL.Path, r.RemoteAddr)
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
-			return
-		}
-		next.ServeHTTP(w, r)
-	})
+// route is an endpoint pattern and the handler serving it, every route is documented in operations
+type route struct {
+	pattern string
+	handler http.HandlerFunc
+}
+
+func (s *Server) routes() []route {
+	return []route{
+		{"/v1/upload", s.handleRequest(s.handleUpload)},
+		{"/v1/events/upcoming", s.handleRequest(s.getUpcomingEvents)},
+		{"/v1/events/upcoming/refresh", s.handleRequest(s.refreshUpcomingEvents)},
+		{"/v1/events/recommended", s.handleRequest(s.getRecommendations)},
+		{"/v1/events/saved", s.handleRequest(s.handleSavedEvents)},
+		{"/v1/events/saved/", s.handleRequest(s.handleSavedEvents)},
+		{"/v1/events/saved/refresh", s.handleRequest(s.refreshSavedEvents)},
+		{"/v1/venues", s.handleRequest(s.handleVenues)},
+		{"/v1/venues/", s.handleRequest(s.handleVenues)},
+		{"/v1/venues/refresh", s.handleRequest(s.refreshVenues)},
+		{"/v1/albums", s.handleRequest(s.handleAlbums)},
+		{"/v1/albums/", s.handleRequest(s.handleAlbums)},
+		{"/v1/albums/refresh", s.handleRequest(s.refreshAlbums)},
+		{"/v1/albums/images", s.handleRequest(s.handleAlbumImages)},
+		{"/v1/albums/import", s.handleRequest(s.importAlbums)},
+		{"/v1/artists", s.handleRequest(s.handleArtists)},
+		{"/v1/artists/", s.handleRequest(s.handleArtists)},
+		{"/v1/artists/refresh", s.handleRequest(s.refreshArtists)},
+		{"/v1/ranks/refresh", s.handleRequest(s.refreshRanks)},
+		{"/v1/genres", s.handleRequest(s.handleGenres)},
+		{"/v1/genres/refresh", s.handleRequest(s.reloadGenres)},
+		{"/v1/search", s.handleRequest(s.handleSearch)},
+		{"/v1/sync", s.handleRequest(s.handleSync)},
+		{"/v1/openapi.json", s.handleRequest(s.getOpenAPI)},
+		{"/v1/jobs", s.handleRequest(s.handleJobs)},
+		{"/v1/jobs/", s.handleRequest(s.handleJobs)},
+		{"/v1/schedules", s.handleRequest(s.handleSchedules)},
+		{"/v1/schedules/", s.handleRequest(s.handleSchedules)},
+		{"/v1/analytics/summary", s.handleRequest(s.getAnalyticsSummary)},
+		{"/v1/analytics/years", s.handleRequest(s.handleAnalyticsYears)},
+		{"/v1/analytics/years/", s.handleRequest(s.handleAnalyticsYears)},
+		{"/v1/analytics/months", s.handleRequest(s.handleAnalyticsMonths)},
+		{"/v1/analytics/months/", s.handleRequest(s.handleAnalyticsMonths)},
+		{"/v1/analytics/artists", s.handleRequest(s.handleAnalyticsArtists)},
+		{"/v1/analytics/artists/", s.handleRequest(s.handleAnalyticsArtists)},
+		{"/v1/analytics/venues", s.handleRequest(s.handleAnalyticsVenues)},
+		{"/v1/analytics/venues/", s.handleRequest(s.handleAnalyticsVenues)},
+		{"/v1/analytics/genres", s.handleRequest(s.handleAnalyticsGenres)},
+		{"/v1/analytics/genres/", s.handleRequest(s.handleAnalyticsGenres)},
+		{"/v1/analytics/spending", s.handleRequest(s.getAnalyticsSpending)},
+		{"/v1/analytics/companions", s.handleRequest(s.handleAnalyticsCompanions)},
+		{"/v1/analytics/companions/", s.handleRequest(s.handleAnalyticsCompanions)},
+		{"/v1/analytics/ratings", s.handleRequest(s.handleAnalyticsRatings)},
+		{"/v1/analytics/ratings/", s.handleRequest(s.handleAnalyticsRatings)},
+		{"/v1/analytics/albums", s.handleRequest(s.handleAnalyticsAlbums)},
+		{"/v1/analytics/albums/", s.handleRequest(s.handleAnalyticsAlbums)},
+		{"/v1/backup", s.handleRequest(s.getBackup)},
+		{"/v1/restore", s.handleRequest(s.restoreBackup)},
+		{"/v1/spotify/auth/start", s.handleRequest(s.startSpotifyAuth)},
+		{"/v1/spotify/auth/status", s.handleRequest(s.getSpotifyAuthStatus)},
+		// doesn't use handleRequest for custom deep-link response
+		{"/v1/spotify/auth/callback", s.handleSpotifyAuthCallback},
+	}
+}
+
+// Routes registers every endpoint for the user the server was set up for
+func (s *Server) Routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	for _, rt := range s.routes() {
+		mux.HandleFunc(rt.pattern, rt.handler)
+	}
+	return mux
 }
 
 type handlerFunc func(http.ResponseWriter, *http.Request) (any, int, error)
 
 func (s *Server) handleRequest(f handlerFunc) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		id := time.Now().Nanosecond()
-		log.Infof("Received request (%s) %s, assigned ID: %d", r.Method, r.URL, id)
+		id := requestID(r)
+		w.Header().Set(requestIDHeader, id)
+		log.Infof("Received request (%s) %s, assigned ID: %s", r.Method, r.URL, id)
 		startTs := time.Now()
 		body, status, err := f(w, r)
 		if err != nil {
-			log.Errorf("Error processing request ID %d: %v", id, err)
-			http.Error(w, err.Error(), status)
-		}
-		if body != nil {
+			log.Errorf("Error processing request ID %s: %v", id, err)
+			writeError(w, id, status, err)
+		} else if body != nil {
 			json.NewEncoder(w).Encode(body)
 		}
-		log.Infof("Finished processing request ID %d in %v ms", id, time.Since(startTs).Milliseconds())
+		log.Infof("Finished processing request ID %s in %v ms", id, time.Since(startTs).Milliseconds())
 	}
 }
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"concert-manager/domain"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strings"
+)
+
+type changeTracker interface {
+	ChangeToken() string
+	ChangesSince(string) (*domain.Changes, error)
+}
+
+// handleSync lists the saved events, artists, venues and albums created, updated and deleted since
+// ?since=, a token from a previous sync. Without a token, or with one the server can no longer
+// answer for, everything is listed as created and reset is set so the client starts over.
+func (s *Server) handleSync(w http.ResponseWriter, r *http.Request) (any, int, error) {
+	if r.Method != http.MethodGet {
+		return nil, http.StatusMethodNotAllowed, errors.New("unsupported method")
+	}
+	if s.ChangeTracker == nil {
+		return nil, http.StatusNotImplemented, errors.New("sync is not supported")
+	}
+	changes, err := s.ChangeTracker.ChangesSince(r.URL.Query().Get("since"))
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return changes, 0, nil
+}
+
+// notModified sets the ETag of a GET on saved data and reports whether it matches If-None-Match,
+// in which case the response is already written. The tag is the change token plus the request,
+// so it is read before the data and a change made in between only makes the next request miss.
+func (s *Server) notModified(w http.ResponseWriter, r *http.Request) bool {
+	if s.ChangeTracker == nil {
+		return false
+	}
+	hash := fnv.New64a()
+	hash.Write([]byte(r.URL.Path + "?" + r.URL.RawQuery))
+	etag := fmt.Sprintf(`"%s-%x"`, s.ChangeTracker.ChangeToken(), hash.Sum64())
+	w.Header().Set("ETag", etag)
+	for _, match := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		match = strings.TrimSpace(match)
+		if match == etag || match == "*" {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
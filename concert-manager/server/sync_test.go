@@ -0,0 +1,65 @@
+package server
+
+import (
+	"concert-manager/db"
+	"concert-manager/domain"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSyncAndETags(t *testing.T) {
+	s := newSearchServer(t)
+	s.ChangeTracker = s.VenueCache.(*db.Cache)
+	routes := s.Routes()
+
+	get := func(url string, etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		rec := httptest.NewRecorder()
+		routes.ServeHTTP(rec, req)
+		return rec
+	}
+	sync := func(url string) domain.Changes {
+		rec := get(url, "")
+		var changes domain.Changes
+		if rec.Code != http.StatusOK || json.NewDecoder(rec.Body).Decode(&changes) != nil {
+			t.Fatalf("unexpected sync response %d %s", rec.Code, rec.Body)
+		}
+		return changes
+	}
+
+	full := sync("/v1/sync")
+	if !full.Reset || len(full.Events.Created) != 1 || len(full.Albums.Created) != 1 {
+		t.Errorf("expected everything as created, got %+v", full)
+	}
+
+	etag := get("/v1/venues", "").Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected an ETag on the venue list")
+	}
+	if rec := get("/v1/venues", etag); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("expected not modified, got %d %s", rec.Code, rec.Body)
+	}
+	if rec := get("/v1/venues?limit=1", etag); rec.Code != http.StatusOK {
+		t.Errorf("expected a different query to have its own ETag, got %d", rec.Code)
+	}
+
+	venue, err := s.VenueCache.AddVenue(domain.Venue{Name: "Tabernacle", City: "Atlanta", State: "GA"})
+	if err != nil {
+		t.Fatalf("failed to add venue: %v", err)
+	}
+	if rec := get("/v1/venues", etag); rec.Code != http.StatusOK {
+		t.Errorf("expected the venue list to change, got %d", rec.Code)
+	}
+	changes := sync("/v1/sync?since=" + full.Token)
+	if changes.Reset || len(changes.Venues.Created) != 1 || changes.Venues.Created[0].ID.Primary != venue.ID.Primary {
+		t.Errorf("expected only the new venue, got %+v", changes)
+	}
+	if rec := get("/v1/sync?since=bad", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected bad request for an invalid token, got %d", rec.Code)
+	}
+}
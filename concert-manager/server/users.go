@@ -0,0 +1,88 @@
+package server
+
+import (
+	"concert-manager/external/spotify"
+	"concert-manager/log"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+const port = ":3001"
+
+// unauthenticated for OAuth callback
+var publicPaths = map[string]bool{
+	"/v1/spotify/auth/callback": true,
+}
+
+// Router authenticates each request and sends it to the server for the user its API key belongs to.
+// Every user has their own Server, so their data and caches are never shared.
+type Router struct {
+	Authenticator userAuthenticator
+	Servers       map[string]*Server
+	DefaultUserID string
+	handlers      map[string]http.Handler
+}
+
+type userAuthenticator interface {
+	Authenticate(string) (string, bool)
+}
+
+func (rt *Router) StartServer() {
+	log.Infof("Starting server on port %s for %d users", port, len(rt.Servers))
+	log.Fatal(http.ListenAndServe(port, rt.Handler()))
+}
+
+func (rt *Router) Handler() http.Handler {
+	rt.handlers = map[string]http.Handler{}
+	for userID, server := range rt.Servers {
+		rt.handlers[userID] = server.Routes()
+	}
+	return http.HandlerFunc(rt.route)
+}
+
+func (rt *Router) route(w http.ResponseWriter, r *http.Request) {
+	// the user's server answers with the same ID
+	id := requestID(r)
+	r.Header.Set(requestIDHeader, id)
+	if publicPaths[r.URL.Path] {
+		rt.routePublic(w, r)
+		return
+	}
+	const prefix = "Bearer "
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, prefix) {
+		log.Infof("Unauthorized request to %s from %s: missing bearer token", r.URL.Path, r.RemoteAddr)
+		writeError(w, id, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+	userID, ok := rt.Authenticator.Authenticate(strings.TrimPrefix(header, prefix))
+	if !ok {
+		log.Infof("Unauthorized request to %s from %s: invalid bearer token", r.URL.Path, r.RemoteAddr)
+		writeError(w, id, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+	handler, ok := rt.handlers[userID]
+	if !ok {
+		log.Errorf("No server is set up for authenticated user %q", userID)
+		writeError(w, id, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+	log.Debugf("Routing request to %s for user %q", r.URL.Path, userID)
+	handler.ServeHTTP(w, r)
+}
+
+// the Spotify callback can't carry an API key, so the user is found from the prefix of the OAuth state
+func (rt *Router) routePublic(w http.ResponseWriter, r *http.Request) {
+	userID := rt.DefaultUserID
+	if stateUser, found := spotify.StateUser(r.URL.Query().Get("state")); found {
+		userID = stateUser
+	}
+	handler, ok := rt.handlers[userID]
+	if !ok {
+		log.Infof("Rejected request to %s from %s for unknown user %q", r.URL.Path, r.RemoteAddr, userID)
+		writeError(w, requestID(r), http.StatusNotFound, errors.New("unknown user"))
+		return
+	}
+	handler.ServeHTTP(w, r)
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"concert-manager/domain"
+	"concert-manager/finder"
+	"concert-manager/ranker"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type stubAuthenticator map[string]string
+
+func (a stubAuthenticator) Authenticate(apiKey string) (string, bool) {
+	id, ok := a[apiKey]
+	return id, ok
+}
+
+type stubUpcomingEvents struct {
+	city string
+	// the events already loaded, nil until they are
+	cached []domain.EventDetails
+	loads  int
+}
+
+func (s *stubUpcomingEvents) GetUpcomingEvents() []domain.EventDetails {
+	s.loads++
+	return []domain.EventDetails{{Event: domain.Event{Venue: domain.Venue{City: s.city}}}}
+}
+func (s *stubUpcomingEvents) CachedUpcomingEvents() []domain.EventDetails { return s.cached }
+func (s *stubUpcomingEvents) ChangeLocation(string, string)               {}
+func (s *stubUpcomingEvents) GetLocation() finder.Location                { return finder.Location{City: s.city} }
+func (s *stubUpcomingEvents) RefreshUpcomingEvents(context.Context) error { return nil }
+func (s *stubUpcomingEvents) GetRecommendedEvents(ranker.RecLevel) []domain.EventDetails {
+	return []domain.EventDetails{}
+}
+
+type stubSpotifyAuth struct {
+	completed bool
+}
+
+func (s *stubSpotifyAuth) StartReauth() (string, error) { return "", nil }
+func (s *stubSpotifyAuth) CompleteReauth(string, string) error {
+	s.completed = true
+	return nil
+}
+func (s *stubSpotifyAuth) GetAuthStatus() (bool, time.Time) { return s.completed, time.Time{} }
+
+func TestRouterSendsRequestsToUserServer(t *testing.T) {
+	defaultAuth, alexAuth := &stubSpotifyAuth{}, &stubSpotifyAuth{}
+	router := Router{
+		Authenticator: stubAuthenticator{"default-key": "", "alex-key": "alex"},
+		Servers: map[string]*Server{
+			"":     {UpcomingEventsCache: &stubUpcomingEvents{city: "Atlanta"}, SpotifyAuthHandler: defaultAuth},
+			"alex": {UpcomingEventsCache: &stubUpcomingEvents{city: "Chicago"}, SpotifyAuthHandler: alexAuth},
+		},
+	}
+	handler := router.Handler()
+
+	tests := []struct {
+		apiKey string
+		status int
+		city   string
+	}{
+		{apiKey: "default-key", status: http.StatusOK, city: "Atlanta"},
+		{apiKey: "alex-key", status: http.StatusOK, city: "Chicago"},
+		{apiKey: "wrong", status: http.StatusUnauthorized},
+		{apiKey: "", status: http.StatusUnauthorized},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/v1/events/upcoming", nil)
+		if test.apiKey != "" {
+			req.Header.Set("Authorization", "Bearer "+test.apiKey)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != test.status {
+			t.Errorf("key %q: expected status %d, got %d", test.apiKey, test.status, rec.Code)
+		}
+		if test.city != "" && !strings.Contains(rec.Body.String(), test.city) {
+			t.Errorf("key %q: expected events in %s, got %s", test.apiKey, test.city, rec.Body.String())
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/spotify/auth/callback?code=c&state=alex.random", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if !alexAuth.completed || defaultAuth.completed {
+		t.Errorf("expected the callback to complete reauth for alex only")
+	}
+}
@@ -1,7 +1,7 @@
 package input
 
 import (
-	"concert-manager/util"
+	"concert-manager/domain"
 	"errors"
 	"unicode"
 )
@@ -36,7 +36,7 @@ func StateValidation(in string) error {
 }
 
 func DateValidation(date string) error {
-	if !util.ValidDate(date) {
+	if _, err := domain.ParseDate(date); err != nil {
 		return errors.New("expected date format is mm/dd/yyyy")
 	}
 	return nil
@@ -4,11 +4,11 @@ import (
 	"concert-manager/domain"
 	"concert-manager/log"
 	"concert-manager/search"
-	"concert-manager/util"
 	"fmt"
 	"math"
 	"slices"
 	"strings"
+	"time"
 )
 
 func FormatArtist(artist domain.Artist) string {
@@ -50,7 +50,7 @@ func FormatVenueExpanded(venue domain.Venue) string {
 func FormatEvent(e domain.Event) string {
 	fmtParts := []any{}
 
-	date := util.FormatDate(e.Date)
+	date := e.Date.Padded()
 	fmtParts = append(fmtParts, date)
 
 	location := fmt.Sprintf("%s, %s, %s", e.Venue.Name, e.Venue.City, e.Venue.State)
@@ -79,7 +79,7 @@ func FormatEvent(e domain.Event) string {
 	fmtParts = append(fmtParts, artistStr, genreStr)
 
 	format := "%v @ %s\n\tArtists: %s\n\tGenres: %s\n"
-	if util.FutureDate(e.Date) {
+	if !e.Passed(time.Now()) {
 		format += "\tPurchased: %v\n"
 		fmtParts = append(fmtParts, e.Purchased)
 	}
@@ -104,7 +104,7 @@ func FormatEventsShort(events []domain.Event) []string {
 	formattedEvents := []string{}
 	for i, event := range events {
 		artist := artistNames[i]
-		date := util.FormatDate(event.Date)
+		date := event.Date.Padded()
 		venue := event.Venue.Name
 
 		var spacing strings.Builder
@@ -156,8 +156,11 @@ func FormatEventExpanded(e domain.Event) string {
 	}
 
 	date := dateNaFmt
-	if util.ValidDate(e.Date) {
-		date = fmt.Sprintf(dateFmt, util.FormatDate(e.Date))
+	if !e.Date.IsZero() {
+		date = fmt.Sprintf(dateFmt, e.Date.Padded())
+		if e.StartTime != nil {
+			date += " " + e.StartTime.String()
+		}
 	}
 
 	purchased := fmt.Sprintf(purchasedFmt, e.Purchased)
@@ -169,6 +172,44 @@ func FormatEventExpanded(e domain.Event) string {
 	fmtParts = append(fmtParts, date)
 	fmtParts = append(fmtParts, purchased)
 
+	// the details are only shown once they have been filled in
+	if e.Rating > 0 {
+		eventFmt += "Rating: %d/%d\n"
+		fmtParts = append(fmtParts, e.Rating, domain.MaxRating)
+	}
+	for _, artist := range e.Artists() {
+		if performance, ok := e.Performance(artist.ID.Primary); ok && performance.Rating > 0 {
+			eventFmt += "  %s: %d/%d\n"
+			fmtParts = append(fmtParts, artist.Name, performance.Rating, domain.MaxRating)
+		}
+	}
+	if e.Festival != nil {
+		eventFmt += "Festival: %s, through %s\n"
+		fmtParts = append(fmtParts, e.Festival.Name, e.Festival.EndDate.Padded())
+		for _, artist := range e.SeenArtists() {
+			if e.Festival.Seen(artist.ID.Primary) {
+				eventFmt += "  Saw %s\n"
+				fmtParts = append(fmtParts, artist.Name)
+			}
+		}
+	}
+	if e.Seat != "" {
+		eventFmt += "Seat: %s\n"
+		fmtParts = append(fmtParts, e.Seat)
+	}
+	if len(e.Companions) > 0 {
+		eventFmt += "With: %s\n"
+		fmtParts = append(fmtParts, strings.Join(e.Companions, ", "))
+	}
+	if e.Ticket.Populated() {
+		eventFmt += "Cost: $%.2f (%d tickets)\n"
+		fmtParts = append(fmtParts, float64(e.Ticket.TotalCents())/100, e.Ticket.Quantity)
+	}
+	if e.Notes != "" {
+		eventFmt += "Notes: %s\n"
+		fmtParts = append(fmtParts, e.Notes)
+	}
+
 	return fmt.Sprintf(eventFmt, fmtParts...)
 }
 
@@ -176,7 +217,7 @@ func FormatEventDetails(d domain.EventDetails) string {
 	fmtParts := []any{}
 	event := d.Event
 
-	date := util.FormatDate(event.Date)
+	date := event.Date.Padded()
 	fmtParts = append(fmtParts, date)
 
 	fmtParts = append(fmtParts, event.Venue.Name)
@@ -237,7 +278,7 @@ func FormatEventDetailsShort(details []domain.EventDetails) []string {
 	formattedEvents := []string{}
 	for i, detail := range details {
 		eventName := eventNames[i]
-		date := util.FormatDate(detail.Event.Date)
+		date := detail.Event.Date.Padded()
 		venue := detail.Event.Venue.Name
 
 		var spacing strings.Builder
@@ -5,8 +5,8 @@ import (
 	"concert-manager/log"
 	"concert-manager/tui/input"
 	"concert-manager/tui/output"
-	"concert-manager/util"
 	"slices"
+	"time"
 )
 
 type eventAddCache interface {
@@ -122,8 +122,9 @@ func (a *EventAdder) NextScreen(i int) Screen {
 		a.VenueEditor.SetVenue(&a.newEvent.Venue)
 		return a.VenueEditor
 	case editDate:
-		a.newEvent.Date = input.PromptAndGetInput("event date (mm/dd/yyyy)", input.DateValidation)
-		if util.FutureDate(a.newEvent.Date) {
+		// the input is already validated
+		a.newEvent.Date, _ = domain.ParseDate(input.PromptAndGetInput("event date (mm/dd/yyyy)", input.DateValidation))
+		if !a.newEvent.Passed(time.Now()) {
 			a.dateType = future
 		} else {
 			a.dateType = past
@@ -7,7 +7,6 @@ import (
 	"concert-manager/ranker"
 	"concert-manager/tui/input"
 	"concert-manager/tui/output"
-	"concert-manager/util"
 	"fmt"
 	"strings"
 	"time"
@@ -29,10 +28,10 @@ type RecommendationViewer struct {
 	RecommendationCache recommendationCache
 	SavedCache          savedEventCache
 	actions             []string
-	date                time.Time
-	recs                map[string][]domain.EventDetails
-	firstRecDate        time.Time
-	lastRecDate         time.Time
+	date                domain.Date
+	recs                map[domain.Date][]domain.EventDetails
+	firstRecDate        domain.Date
+	lastRecDate         domain.Date
 	threshold           ranker.RecLevel
 }
 
@@ -62,10 +61,9 @@ func (v *RecommendationViewer) Refresh() {
 	output.Displayf("Retrieving recommendations for %s...", v.RecommendationCache.GetLocation())
 	events := v.RecommendationCache.GetRecommendedEvents(v.threshold)
 	log.Debugf("Found %v recommendations for threshold %s\n", len(events), v.threshold)
-	v.recs = map[string][]domain.EventDetails{}
+	v.recs = map[domain.Date][]domain.EventDetails{}
 	for _, e := range events {
-		date := util.Timestamp(e.Event.Date)
-		key := getRecKey(date)
+		key := e.Event.Date
 		eventsForDate := v.recs[key]
 		if eventsForDate == nil {
 			eventsForDate = []domain.EventDetails{}
@@ -74,9 +72,9 @@ func (v *RecommendationViewer) Refresh() {
 		v.recs[key] = eventsForDate
 	}
 
-	firstDate, lastDate := time.Time{}.AddDate(9999, 0, 0), time.Time{}
+	firstDate, lastDate := domain.NewDate(9999, time.December, 31), domain.Date{}
 	for _, e := range events {
-		eventDate := util.Timestamp(e.Event.Date)
+		eventDate := e.Event.Date
 		if eventDate.Before(firstDate) {
 			firstDate = eventDate
 		}
@@ -101,8 +99,8 @@ func (v RecommendationViewer) DisplayData() {
 	var eventData strings.Builder
 	eventData.WriteString(fmt.Sprintf("Filter Threshold: %s\n", v.threshold))
 
-	weekday := v.date.Weekday().String()
-	formattedDate := util.Date(v.date)
+	weekday := v.date.Time(time.UTC).Weekday().String()
+	formattedDate := v.date.String()
 	dateInd := fmt.Sprintf("Date - %s, %s\n", weekday, formattedDate)
 	eventData.WriteString(dateInd)
 
@@ -117,7 +115,7 @@ func (v RecommendationViewer) DisplayData() {
 	eventData.WriteString("\n")
 
 	eventData.WriteString("--Recommended Events--\n")
-	recs := v.recs[getRecKey(v.date)]
+	recs := v.recs[v.date]
 	if recs == nil {
 		recs = []domain.EventDetails{}
 	}
@@ -158,37 +156,37 @@ func (v *RecommendationViewer) NextScreen(i int) Screen {
 	switch i {
 	case nextDate:
 		for {
-			v.date = v.date.AddDate(0, 0, 1)
+			v.date = v.date.AddDays(1)
 			log.Debug("Next date: ", v.date)
 			if v.date.After(v.lastRecDate) {
 				log.Debug("Date is after lastRec date, setting date to lastRec ", v.lastRecDate)
 				v.date = v.lastRecDate
 			}
-			if len(v.recs[getRecKey(v.date)]) > 0 {
+			if len(v.recs[v.date]) > 0 {
 				log.Debug("Found recommended events for date")
 				break
 			}
-			log.Debugf("No recommended events for %s, trying next date\n", util.Date(v.date))
+			log.Debugf("No recommended events for %s, trying next date\n", v.date)
 		}
 	case prevDate:
 		for {
-			v.date = v.date.AddDate(0, 0, -1)
+			v.date = v.date.AddDays(-1)
 			log.Debug("Prev date: ", v.date)
 			if v.date.Before(v.firstRecDate) {
 				log.Debug("Date is before firstRec date, setting date to firstRec ", v.firstRecDate)
 				v.date = v.firstRecDate
 			}
-			if len(v.recs[getRecKey(v.date)]) > 0 {
+			if len(v.recs[v.date]) > 0 {
 				log.Debug("Found recommended events for date")
 				break
 			}
-			log.Debugf("No recommended events for %s, trying prev date\n", util.Date(v.date))
+			log.Debugf("No recommended events for %s, trying prev date\n", v.date)
 		}
 	case gotoDate:
-		newDate := input.PromptAndGetInput("date", input.DateValidation)
-		v.date = util.Timestamp(newDate)
+		// the input is already validated
+		v.date, _ = domain.ParseDate(input.PromptAndGetInput("date", input.DateValidation))
 	case saveRecEvent:
-		events := v.recs[getRecKey(v.date)]
+		events := v.recs[v.date]
 		if events == nil {
 			events = []domain.EventDetails{}
 		}
@@ -230,21 +228,21 @@ func (v *RecommendationViewer) NextScreen(i int) Screen {
 		v.RecommendationCache.Invalidate()
 		v.recs = nil
 	case recToDiscoveryMenu:
-		v.date = time.Time{}
+		v.date = domain.Date{}
 		return nil
 	}
 	return v
 }
 
-func (v RecommendationViewer) getSavedEventsForDate(date time.Time) []domain.Event {
-	log.Debug("Requesting saved events for date ", util.Date(date))
+func (v RecommendationViewer) getSavedEventsForDate(date domain.Date) []domain.Event {
+	log.Debug("Requesting saved events for date ", date)
 	events := []domain.Event{}
 	for _, event := range v.SavedCache.GetSavedEvents() {
-		if date.Equal(util.Timestamp(event.Date)) {
+		if date == event.Date {
 			events = append(events, event)
 		}
 	}
-	log.Debugf("Found %v saved events for date %s\n", len(events), util.Date(date))
+	log.Debugf("Found %v saved events for date %s\n", len(events), date)
 	return events
 }
 
@@ -254,7 +252,3 @@ func (v *RecommendationViewer) changeLocation() {
 	v.RecommendationCache.ChangeLocation(city, stateCode)
 	v.recs = nil
 }
-
-func getRecKey(date time.Time) string {
-	return util.Date(date.Round(0))
-}
@@ -0,0 +1,137 @@
+package user
+
+import (
+	"concert-manager/file"
+	"concert-manager/log"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+)
+
+const (
+	usersFileEnv     = "CM_USERS_FILE"
+	defaultUsersFile = "users.json"
+	apiKeyByteLength = 32
+)
+
+// DefaultID is the user that owns the data saved before there were multiple users.
+// It is authenticated with the legacy CM_API_KEY and keeps its data in the original locations.
+const DefaultID = ""
+
+var validID = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// User only stores hashes of its API keys, the keys themselves are shown once when issued
+type User struct {
+	ID           string   `json:"id"`
+	ApiKeyHashes []string `json:"apiKeyHashes"`
+}
+
+type Store struct {
+	Path         string
+	Users        []User
+	LegacyApiKey string
+}
+
+type usersFile struct {
+	Users []User `json:"users"`
+}
+
+// Load reads the users from CM_USERS_FILE, or users.json next to the executable
+func Load() (*Store, error) {
+	path := os.Getenv(usersFileEnv)
+	if path == "" {
+		defaultPath, err := file.GetCacheFilePath(defaultUsersFile)
+		if err != nil {
+			return nil, err
+		}
+		path = defaultPath
+	}
+
+	store := &Store{Path: path, Users: []User{}}
+	if !file.FileExists(path) {
+		log.Debug("Users file does not exist at", path)
+		return store, nil
+	}
+	var contents usersFile
+	if err := file.ReadJSONFile(path, &contents); err != nil {
+		return nil, fmt.Errorf("failed to read users file: %w", err)
+	}
+	if contents.Users != nil {
+		store.Users = contents.Users
+	}
+	log.Infof("Loaded %d users", len(store.Users))
+	return store, nil
+}
+
+func (s *Store) save() error {
+	return file.WriteJSONFile(s.Path, usersFile{Users: s.Users})
+}
+
+// IDs lists every user that can sign in, including the default user when CM_API_KEY is set
+func (s *Store) IDs() []string {
+	ids := []string{}
+	if s.LegacyApiKey != "" {
+		ids = append(ids, DefaultID)
+	}
+	for _, user := range s.Users {
+		ids = append(ids, user.ID)
+	}
+	return ids
+}
+
+// Authenticate returns the ID of the user the API key belongs to
+func (s *Store) Authenticate(apiKey string) (string, bool) {
+	if apiKey == "" {
+		return "", false
+	}
+	if s.LegacyApiKey != "" && subtle.ConstantTimeCompare([]byte(apiKey), []byte(s.LegacyApiKey)) == 1 {
+		return DefaultID, true
+	}
+	hash := hashApiKey(apiKey)
+	for _, user := range s.Users {
+		for _, userHash := range user.ApiKeyHashes {
+			if subtle.ConstantTimeCompare([]byte(hash), []byte(userHash)) == 1 {
+				return user.ID, true
+			}
+		}
+	}
+	return "", false
+}
+
+// IssueApiKey creates the user if it doesn't exist yet and returns a new API key for it
+func (s *Store) IssueApiKey(id string) (string, error) {
+	if !validID.MatchString(id) {
+		return "", errors.New("user ID must be 1-32 lowercase letters, numbers, dashes or underscores")
+	}
+
+	buf := make([]byte, apiKeyByteLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	apiKey := base64.RawURLEncoding.EncodeToString(buf)
+
+	userIdx := slices.IndexFunc(s.Users, func(u User) bool { return u.ID == id })
+	if userIdx == -1 {
+		log.Info("Creating new user", id)
+		s.Users = append(s.Users, User{ID: id, ApiKeyHashes: []string{}})
+		userIdx = len(s.Users) - 1
+	}
+	s.Users[userIdx].ApiKeyHashes = append(s.Users[userIdx].ApiKeyHashes, hashApiKey(apiKey))
+	if err := s.save(); err != nil {
+		return "", fmt.Errorf("failed to save users file: %w", err)
+	}
+	log.Info("Issued new API key for user", id)
+	return apiKey, nil
+}
+
+func hashApiKey(apiKey string) string {
+	hash := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,53 @@
+package user
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestIssueApiKeyAndAuthenticate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	t.Setenv(usersFileEnv, path)
+
+	store, err := Load()
+	if err != nil {
+		t.Fatalf("failed to load users: %v", err)
+	}
+	store.LegacyApiKey = "legacy"
+	alexKey, err := store.IssueApiKey("alex")
+	if err != nil {
+		t.Fatalf("failed to issue API key: %v", err)
+	}
+	secondKey, err := store.IssueApiKey("alex")
+	if err != nil {
+		t.Fatalf("failed to issue second API key: %v", err)
+	}
+	if _, err := store.IssueApiKey("Not Valid"); err == nil {
+		t.Error("expected an invalid user ID to be rejected")
+	}
+
+	reloaded, err := Load()
+	if err != nil {
+		t.Fatalf("failed to reload users: %v", err)
+	}
+	reloaded.LegacyApiKey = "legacy"
+	if ids := reloaded.IDs(); !slices.Equal(ids, []string{DefaultID, "alex"}) {
+		t.Errorf("expected the default user and alex, got %v", ids)
+	}
+
+	for _, key := range []string{alexKey, secondKey} {
+		if id, ok := reloaded.Authenticate(key); !ok || id != "alex" {
+			t.Errorf("expected key to authenticate as alex, got %q, %v", id, ok)
+		}
+	}
+	if id, ok := reloaded.Authenticate("legacy"); !ok || id != DefaultID {
+		t.Errorf("expected legacy key to authenticate as the default user, got %q, %v", id, ok)
+	}
+	if _, ok := reloaded.Authenticate("wrong"); ok {
+		t.Error("expected an unknown key to be rejected")
+	}
+	if _, ok := reloaded.Authenticate(""); ok {
+		t.Error("expected an empty key to be rejected")
+	}
+}
@@ -0,0 +1,26 @@
+package util
+
+import (
+	"crypto/rand"
+	"math/big"
+)
+
+const (
+	idChars  = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+	idLength = 20
+)
+
+// generates a random alphanumeric ID in the same format Firestore uses for
+// auto-generated document IDs, so IDs stay interchangeable between databases
+func NewID() string {
+	id := make([]byte, idLength)
+	max := big.NewInt(int64(len(idChars)))
+	for i := range id {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			panic(err)
+		}
+		id[i] = idChars[n.Int64()]
+	}
+	return string(id)
+}